@@ -0,0 +1,222 @@
+// Command graphbench times graph.Runner on synthetic graphs of
+// different shapes and sizes, with different numbers of workers, and
+// writes the results as JSON so they can be compared between commits:
+//
+//	graphbench -o before.json
+//	... change the scheduler ...
+//	graphbench -base before.json -o after.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/graph/graphbench"
+)
+
+func main() {
+	var (
+		shapes  = flag.String("shapes", shapeNames(), "Comma-separated graph shapes to run")
+		nodes   = flag.String("nodes", "16,64,256", "Comma-separated graph sizes, in nodes")
+		workers = flag.String("workers", defaultWorkers(), "Comma-separated numbers of workers (as MUSCRAT_WORKERS)")
+		blocks  = flag.Int("blocks", 2000, "Number of blocks to time for each run")
+		warmup  = flag.Int("warmup", 100, "Number of blocks to run before timing")
+		outFile = flag.String("o", "", "Write the results as JSON to this file (- for stdout)")
+		base    = flag.String("base", "", "Compare the results with a JSON file written by an earlier run")
+		list    = flag.Bool("list", false, "List the graph shapes and exit")
+	)
+	flag.Parse()
+
+	if *list {
+		for _, s := range graphbench.Shapes {
+			fmt.Printf("%-10s %s\n", s.Name, s.Doc)
+		}
+		return
+	}
+
+	var baseRep *graphbench.Report
+	if *base != "" {
+		f, err := os.Open(*base)
+		if err != nil {
+			log.Fatal(err)
+		}
+		baseRep, err = graphbench.ReadReport(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	sizes, err := parseInts(*nodes)
+	if err != nil {
+		log.Fatalf("-nodes: %v", err)
+	}
+	workerCounts, err := parseInts(*workers)
+	if err != nil {
+		log.Fatalf("-workers: %v", err)
+	}
+
+	rep := &graphbench.Report{
+		Commit:     commit(),
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		SampleRate: conf.SampleRate,
+		Time:       time.Now().UTC(),
+	}
+
+	// progress and the table go to stderr when the JSON goes to
+	// stdout.
+	textOut := os.Stdout
+	if *outFile == "-" {
+		textOut = os.Stderr
+	}
+
+	ctx := context.Background()
+	for _, name := range strings.Split(*shapes, ",") {
+		shape, ok := graphbench.ShapeByName(strings.TrimSpace(name))
+		if !ok {
+			log.Fatalf("unknown shape %q; run with -list to see the shapes", name)
+		}
+		for _, n := range sizes {
+			for _, w := range workerCounts {
+				res, err := graphbench.Run(ctx, graphbench.Config{
+					Shape:   shape,
+					Nodes:   n,
+					Workers: w,
+					Blocks:  *blocks,
+					Warmup:  *warmup,
+				})
+				if err != nil {
+					log.Fatal(err)
+				}
+				rep.Results = append(rep.Results, res)
+				fmt.Fprintf(textOut, ".")
+			}
+		}
+	}
+	fmt.Fprintln(textOut)
+
+	printResults(textOut, rep)
+	if baseRep != nil {
+		printComparison(textOut, baseRep, rep)
+	}
+
+	if *outFile != "" {
+		w := os.Stdout
+		if *outFile != "-" {
+			f, err := os.Create(*outFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := graphbench.WriteReport(w, rep); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// printResults prints a table of block times, with the speedup of
+// each run over the run of the same graph with the fewest workers.
+func printResults(w *os.File, rep *graphbench.Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "shape\tnodes\tworkers\tmean µs\tp50 µs\tp99 µs\tmax µs\trealtime\tspeedup\t")
+	var first graphbench.Result
+	for _, r := range rep.Results {
+		if r.Shape != first.Shape || r.Nodes != first.Nodes {
+			first = r
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1fx\t%.2fx\t\n",
+			r.Shape, r.Nodes, r.Workers, r.Mean/1e3, r.P50/1e3, r.P99/1e3, r.Max/1e3, r.Realtime, first.Mean/r.Mean)
+	}
+	tw.Flush()
+}
+
+func printComparison(w *os.File, base, rep *graphbench.Report) {
+	cmp := graphbench.Compare(base, rep)
+	if len(cmp) == 0 {
+		fmt.Fprintln(w, "\nno results in common with the baseline")
+		return
+	}
+	label := base.Commit
+	if label == "" {
+		label = "baseline"
+	}
+	fmt.Fprintf(w, "\ncompared with %s:\n", label)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "shape\tnodes\tworkers\tbase µs\tmean µs\tchange\t")
+	for _, c := range cmp {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%+.1f%%\t\n",
+			c.Shape, c.Nodes, c.Workers, c.Base.Mean/1e3, c.Mean/1e3, 100*c.Change)
+	}
+	tw.Flush()
+}
+
+func shapeNames() string {
+	names := make([]string, len(graphbench.Shapes))
+	for i, s := range graphbench.Shapes {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// defaultWorkers returns powers of two up to the number of CPUs.
+func defaultWorkers() string {
+	var ws []string
+	for w := 1; w <= runtime.NumCPU(); w *= 2 {
+		ws = append(ws, strconv.Itoa(w))
+	}
+	return strings.Join(ws, ",")
+}
+
+func parseInts(s string) ([]int, error) {
+	var res []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, fmt.Errorf("%d is less than 1", n)
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+// commit returns the VCS revision the binary was built from, if it's
+// known.
+func commit() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var rev, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = "-dirty"
+			}
+		}
+	}
+	if rev == "" {
+		return ""
+	}
+	return rev + modified
+}
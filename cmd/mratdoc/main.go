@@ -0,0 +1,45 @@
+// Command mratdoc generates the Markdown and HTML reference
+// documentation for mrat.core.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+	"github.com/jfhamlin/muscrat/pkg/refdoc"
+)
+
+func main() {
+	out := flag.String("out", "docs/reference", "Directory to write the reference to")
+	flag.Parse()
+
+	ref := refdoc.New(mrat.GetNSPublics())
+
+	var md, html bytes.Buffer
+	if err := ref.WriteMarkdown(&md); err != nil {
+		log.Fatal(err)
+	}
+	if err := ref.WriteHTML(&html); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatal(err)
+	}
+	for name, buf := range map[string][]byte{
+		refdoc.Namespace + ".md":   md.Bytes(),
+		refdoc.Namespace + ".html": html.Bytes(),
+	} {
+		if err := os.WriteFile(filepath.Join(*out, name), buf, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "documented %d symbols; %d undocumented, %d ugens with undocumented arguments\n",
+		ref.NumEntries(), len(ref.Undocumented), len(ref.UndocumentedArgs))
+}
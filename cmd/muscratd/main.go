@@ -0,0 +1,85 @@
+// Command muscratd runs muscrat without a user interface, controlled
+// through its HTTP and WebSocket API and over OSC.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/jfhamlin/muscrat/pkg/api"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+)
+
+func main() {
+	defaultPort := conf.APIPort
+	if defaultPort == 0 {
+		defaultPort = 8710
+	}
+
+	var (
+		addr        = flag.String("addr", fmt.Sprintf("localhost:%d", defaultPort), "Address to serve the API on")
+		oscPort     = flag.Int("osc", conf.OSCPort, "UDP port to listen for OSC messages on (0 to disable)")
+		oscEval     = flag.Bool("osc-eval", conf.OSCEval, "Allow OSC clients to evaluate code (unauthenticated)")
+		noAudio     = flag.Bool("no-audio", false, "Don't play audio to the system output")
+		origins     = flag.String("origins", "", "Comma-separated origins of web pages allowed to use the API")
+		evalTimeout = flag.Duration("eval-timeout", conf.EvalTimeout, "Abort script evaluation after this long (0 for no limit)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] [script.glj]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	srv := mrat.NewServer()
+	srv.SetEvalTimeout(*evalTimeout)
+	if err := srv.Start(ctx, *noAudio); err != nil {
+		log.Fatalf("error starting server: %v", err)
+	}
+
+	if *oscPort != 0 {
+		var opts []mrat.OSCOption
+		if *oscEval {
+			opts = append(opts, mrat.WithOSCEval())
+		}
+		go func() {
+			if err := srv.ListenOSC(ctx, fmt.Sprintf(":%d", *oscPort), opts...); err != nil {
+				log.Printf("error serving OSC: %v", err)
+			}
+		}()
+	}
+
+	if flag.NArg() > 0 {
+		go func() {
+			if err := mrat.WatchScriptFile(ctx, flag.Arg(0), srv); err != nil {
+				log.Printf("error watching script file: %v", err)
+			}
+		}()
+	}
+
+	var opts []api.Option
+	if *origins != "" {
+		opts = append(opts, api.WithAllowedOrigins(strings.Split(*origins, ",")...))
+	}
+	httpServer := &http.Server{
+		Addr:    *addr,
+		Handler: api.New(ctx, srv, opts...),
+	}
+	context.AfterFunc(ctx, func() {
+		httpServer.Shutdown(context.Background())
+	})
+
+	log.Printf("serving the muscrat API on http://%s", *addr)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
@@ -21,6 +21,7 @@ func main() {
 	// ---------------------------------------------------------------- CLI ----
 	var durSec = flag.Int("duration", 10, "How long to run the script (seconds)")
 	flag.IntVar(durSec, "d", 10, "Alias for -duration")
+	var evalTimeout = flag.Duration("eval-timeout", conf.EvalTimeout, "Abort script evaluation after this long (0 for no limit)")
 	flag.Parse()
 
 	if flag.NArg() < 1 {
@@ -32,6 +33,7 @@ func main() {
 
 	// -------------------------------------------------------- start server ----
 	srv := mrat.NewServer()
+	srv.SetEvalTimeout(*evalTimeout)
 	srv.Start(context.Background(), false)
 
 	// --------------------------------------------------- counters & latency ---
@@ -17,13 +17,57 @@ import * as ugen$0 from "./pkg/ugen/models.js";
 // @ts-ignore: Unused imports
 import * as $models from "./models.js";
 
+/**
+ * CancelEval aborts any evaluation in progress, leaving the playing
+ * graph untouched.
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function CancelEval() {
+    let $resultPromise = /** @type {any} */($Call.ByID(272800870));
+    return $resultPromise;
+}
+
+/**
+ * EvalRegion evaluates the forms between the byte offsets start and
+ * end of content.
+ * @param {string} content
+ * @param {number} start
+ * @param {number} end
+ * @param {string} ns
+ * @returns {Promise<mrat$0.EvalResult | null> & { cancel(): void }}
+ */
+export function EvalRegion(content, start, end, ns) {
+    let $resultPromise = /** @type {any} */($Call.ByID(2892436688, content, start, end, ns));
+    let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
+        return $$createType1($result);
+    }));
+    $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
+    return $typingPromise;
+}
+
+/**
+ * EvalString evaluates source text in the given namespace. If ns is
+ * empty, the namespace declared in the source is used.
+ * @param {string} content
+ * @param {string} ns
+ * @returns {Promise<mrat$0.EvalResult | null> & { cancel(): void }}
+ */
+export function EvalString(content, ns) {
+    let $resultPromise = /** @type {any} */($Call.ByID(1890502685, content, ns));
+    let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
+        return $$createType1($result);
+    }));
+    $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
+    return $typingPromise;
+}
+
 /**
  * @returns {Promise<(ugen$0.Knob | null)[]> & { cancel(): void }}
  */
 export function GetKnobs() {
     let $resultPromise = /** @type {any} */($Call.ByID(2507447091));
     let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
-        return $$createType2($result);
+        return $$createType4($result);
     }));
     $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
     return $typingPromise;
@@ -35,7 +79,7 @@ export function GetKnobs() {
 export function GetNSPublics() {
     let $resultPromise = /** @type {any} */($Call.ByID(3036817141));
     let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
-        return $$createType3($result);
+        return $$createType5($result);
     }));
     $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
     return $typingPromise;
@@ -49,6 +93,28 @@ export function GetSampleRate() {
     return $resultPromise;
 }
 
+/**
+ * GraphHistory returns the graphs played, most recent first.
+ * @returns {Promise<mrat$0.GraphHistoryEntry[]> & { cancel(): void }}
+ */
+export function GraphHistory() {
+    let $resultPromise = /** @type {any} */($Call.ByID(2548441610));
+    let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
+        return $$createType7($result);
+    }));
+    $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
+    return $typingPromise;
+}
+
+/**
+ * NextCue plays the next scene in the cue list.
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function NextCue() {
+    let $resultPromise = /** @type {any} */($Call.ByID(2925343810));
+    return $resultPromise;
+}
+
 /**
  * OpenFileDialog opens a file dialog.
  * @returns {Promise<$models.OpenFileDialogResponse | null> & { cancel(): void }}
@@ -56,7 +122,7 @@ export function GetSampleRate() {
 export function OpenFileDialog() {
     let $resultPromise = /** @type {any} */($Call.ByID(3758918700));
     let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
-        return $$createType5($result);
+        return $$createType9($result);
     }));
     $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
     return $typingPromise;
@@ -73,6 +139,41 @@ export function PlayFile(fileName) {
     return $resultPromise;
 }
 
+/**
+ * PrevCue plays the previous scene in the cue list.
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function PrevCue() {
+    let $resultPromise = /** @type {any} */($Call.ByID(4234755194));
+    return $resultPromise;
+}
+
+/**
+ * RegisterScene registers a scene, replacing any scene with the same
+ * name.
+ * @param {mrat$0.Scene} scene
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function RegisterScene(scene) {
+    let $resultPromise = /** @type {any} */($Call.ByID(665028793, scene));
+    return $resultPromise;
+}
+
+/**
+ * Revert plays the graph n entries before the one playing. Revert(1)
+ * restores the last graph that played before the current one.
+ * @param {number} n
+ * @returns {Promise<mrat$0.GraphHistoryEntry | null> & { cancel(): void }}
+ */
+export function Revert(n) {
+    let $resultPromise = /** @type {any} */($Call.ByID(2164675748, n));
+    let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
+        return $$createType10($result);
+    }));
+    $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
+    return $typingPromise;
+}
+
 /**
  * SaveFile saves a file. If the fileName is empty, a file dialog is
  * shown. Returns the filename and an error.
@@ -95,6 +196,50 @@ export function SaveToTemp(content) {
     return $resultPromise;
 }
 
+/**
+ * SceneKey plays the scene bound to a keystroke.
+ * @param {string} key
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function SceneKey(key) {
+    let $resultPromise = /** @type {any} */($Call.ByID(457888089, key));
+    return $resultPromise;
+}
+
+/**
+ * Scenes returns the registered scenes.
+ * @returns {Promise<mrat$0.Scene[]> & { cancel(): void }}
+ */
+export function Scenes() {
+    let $resultPromise = /** @type {any} */($Call.ByID(530177523));
+    let $typingPromise = /** @type {any} */($resultPromise.then(($result) => {
+        return $$createType12($result);
+    }));
+    $typingPromise.cancel = $resultPromise.cancel.bind($resultPromise);
+    return $typingPromise;
+}
+
+/**
+ * SetCueList sets the scenes stepped through by NextCue and PrevCue.
+ * @param {string[]} names
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function SetCueList(names) {
+    let $resultPromise = /** @type {any} */($Call.ByID(1943209569, names));
+    return $resultPromise;
+}
+
+/**
+ * SetCueTrigger sets a MIDI trigger that plays the next cue, or
+ * removes it if trig is nil.
+ * @param {mrat$0.MIDITrigger | null} trig
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function SetCueTrigger(trig) {
+    let $resultPromise = /** @type {any} */($Call.ByID(88132195, trig));
+    return $resultPromise;
+}
+
 /**
  * @returns {Promise<void> & { cancel(): void }}
  */
@@ -111,10 +256,27 @@ export function ToggleHydraWindow() {
     return $resultPromise;
 }
 
+/**
+ * TriggerScene plays the named scene with its own transition.
+ * @param {string} name
+ * @returns {Promise<void> & { cancel(): void }}
+ */
+export function TriggerScene(name) {
+    let $resultPromise = /** @type {any} */($Call.ByID(3722553778, name));
+    return $resultPromise;
+}
+
 // Private type creation functions
-const $$createType0 = ugen$0.Knob.createFrom;
+const $$createType0 = mrat$0.EvalResult.createFrom;
 const $$createType1 = $Create.Nullable($$createType0);
-const $$createType2 = $Create.Array($$createType1);
-const $$createType3 = $Create.Array($Create.Any);
-const $$createType4 = $models.OpenFileDialogResponse.createFrom;
-const $$createType5 = $Create.Nullable($$createType4);
+const $$createType2 = ugen$0.Knob.createFrom;
+const $$createType3 = $Create.Nullable($$createType2);
+const $$createType4 = $Create.Array($$createType3);
+const $$createType5 = $Create.Array($Create.Any);
+const $$createType6 = mrat$0.GraphHistoryEntry.createFrom;
+const $$createType7 = $Create.Array($$createType6);
+const $$createType8 = $models.OpenFileDialogResponse.createFrom;
+const $$createType9 = $Create.Nullable($$createType8);
+const $$createType10 = $Create.Nullable($$createType6);
+const $$createType11 = mrat$0.Scene.createFrom;
+const $$createType12 = $Create.Array($$createType11);
@@ -2,6 +2,19 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export {
+    Diagnostic,
+    DiagnosticKind,
+    EvalResult,
+    GraphHistoryEntry,
+    MIDITrigger,
+    MIDITriggerType,
+    Scene,
+    StackFrame,
+    Transition,
+    TransitionKind
+} from "./models.js";
+
 import * as $models from "./models.js";
 
 /**
@@ -6,6 +6,549 @@
 // @ts-ignore: Unused imports
 import {Create as $Create} from "@wailsio/runtime";
 
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as time$0 from "../../../../../time/models.js";
+
+/**
+ * Diagnostic describes a failure to evaluate a script or source
+ * text. It implements error, and is the error returned by
+ * EvalScript, EvalString and EvalRegion when a form fails to read
+ * or evaluate, or when the resulting graph is invalid.
+ * 
+ * File, Line and Column locate the offending form in the
+ * evaluated source when its location is known; Line and Column
+ * are 1-based and zero if unknown.
+ */
+export class Diagnostic {
+    /**
+     * Creates a new Diagnostic instance.
+     * @param {Partial<Diagnostic>} [$$source = {}] - The source object to create the Diagnostic.
+     */
+    constructor($$source = {}) {
+        if (!("kind" in $$source)) {
+            /**
+             * @member
+             * @type {DiagnosticKind}
+             */
+            this["kind"] = DiagnosticKind.$zero;
+        }
+        if (!("message" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["message"] = "";
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {string | undefined}
+             */
+            this["file"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {number | undefined}
+             */
+            this["line"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {number | undefined}
+             */
+            this["column"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {string | undefined}
+             */
+            this["form"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Stack is the glojure stack at the point of failure, most
+             * recent call first.
+             * @member
+             * @type {StackFrame[] | undefined}
+             */
+            this["stack"] = undefined;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Diagnostic instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Diagnostic}
+     */
+    static createFrom($$source = {}) {
+        const $$createField6_0 = $$createType1;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("stack" in $$parsedSource) {
+            $$parsedSource["stack"] = $$createField6_0($$parsedSource["stack"]);
+        }
+        return new Diagnostic(/** @type {Partial<Diagnostic>} */($$parsedSource));
+    }
+}
+
+/**
+ * DiagnosticKind classifies the stage at which evaluation failed.
+ * @readonly
+ * @enum {string}
+ */
+export const DiagnosticKind = {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero: "",
+
+    /**
+     * DiagnosticRead indicates that the source could not be read.
+     */
+    DiagnosticRead: "read",
+
+    /**
+     * DiagnosticCompile indicates that a form could not be analyzed
+     * or macroexpanded, e.g. because of an unresolved symbol.
+     */
+    DiagnosticCompile: "compile",
+
+    /**
+     * DiagnosticRuntime indicates that evaluating a form failed.
+     */
+    DiagnosticRuntime: "runtime",
+
+    /**
+     * DiagnosticGraph indicates that the graph built by the script
+     * is invalid.
+     */
+    DiagnosticGraph: "graph",
+
+    /**
+     * DiagnosticCanceled indicates that evaluation was canceled or
+     * timed out.
+     */
+    DiagnosticCanceled: "canceled",
+};
+
+/**
+ * EvalResult is the result of evaluating source text.
+ */
+export class EvalResult {
+    /**
+     * Creates a new EvalResult instance.
+     * @param {Partial<EvalResult>} [$$source = {}] - The source object to create the EvalResult.
+     */
+    constructor($$source = {}) {
+        if (!("value" in $$source)) {
+            /**
+             * Value is the printed representation of the value of the last
+             * form evaluated.
+             * @member
+             * @type {string}
+             */
+            this["value"] = "";
+        }
+        if (!("namespace" in $$source)) {
+            /**
+             * Namespace is the namespace that was current after evaluation.
+             * @member
+             * @type {string}
+             */
+            this["namespace"] = "";
+        }
+        if (!("stdout" in $$source)) {
+            /**
+             * Stdout is everything printed during evaluation.
+             * @member
+             * @type {string}
+             */
+            this["stdout"] = "";
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Error is the error message if evaluation failed.
+             * @member
+             * @type {string | undefined}
+             */
+            this["error"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Diagnostic describes the failure if evaluation failed.
+             * @member
+             * @type {Diagnostic | null | undefined}
+             */
+            this["diagnostic"] = undefined;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new EvalResult instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {EvalResult}
+     */
+    static createFrom($$source = {}) {
+        const $$createField4_0 = $$createType3;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("diagnostic" in $$parsedSource) {
+            $$parsedSource["diagnostic"] = $$createField4_0($$parsedSource["diagnostic"]);
+        }
+        return new EvalResult(/** @type {Partial<EvalResult>} */($$parsedSource));
+    }
+}
+
+/**
+ * GraphHistoryEntry is a graph played by the server.
+ */
+export class GraphHistoryEntry {
+    /**
+     * Creates a new GraphHistoryEntry instance.
+     * @param {Partial<GraphHistoryEntry>} [$$source = {}] - The source object to create the GraphHistoryEntry.
+     */
+    constructor($$source = {}) {
+        if (!("time" in $$source)) {
+            /**
+             * Time is when the graph was first played.
+             * @member
+             * @type {time$0.Time}
+             */
+            this["time"] = null;
+        }
+        if (!("sourceHash" in $$source)) {
+            /**
+             * SourceHash is a hex-encoded SHA-256 hash of the source that
+             * built the graph.
+             * @member
+             * @type {string}
+             */
+            this["sourceHash"] = "";
+        }
+        if (!("description" in $$source)) {
+            /**
+             * Description describes where the graph came from.
+             * @member
+             * @type {string}
+             */
+            this["description"] = "";
+        }
+        if (!("playing" in $$source)) {
+            /**
+             * Playing is true if the graph is the one currently playing.
+             * @member
+             * @type {boolean}
+             */
+            this["playing"] = false;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new GraphHistoryEntry instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {GraphHistoryEntry}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new GraphHistoryEntry(/** @type {Partial<GraphHistoryEntry>} */($$parsedSource));
+    }
+}
+
+/**
+ * MIDITrigger maps a MIDI note or controller to a scene or to the
+ * next cue.
+ */
+export class MIDITrigger {
+    /**
+     * Creates a new MIDITrigger instance.
+     * @param {Partial<MIDITrigger>} [$$source = {}] - The source object to create the MIDITrigger.
+     */
+    constructor($$source = {}) {
+        if (/** @type {any} */(false)) {
+            /**
+             * Device is a regular expression matching the name of the
+             * MIDI input port to listen to. If empty, messages from any
+             * port already in use, e.g. by a script, match.
+             * @member
+             * @type {string | undefined}
+             */
+            this["device"] = undefined;
+        }
+        if (!("channel" in $$source)) {
+            /**
+             * Channel is the 0-based MIDI channel. Negative values match
+             * any channel.
+             * @member
+             * @type {number}
+             */
+            this["channel"] = 0;
+        }
+        if (!("type" in $$source)) {
+            /**
+             * @member
+             * @type {MIDITriggerType}
+             */
+            this["type"] = MIDITriggerType.$zero;
+        }
+        if (!("number" in $$source)) {
+            /**
+             * Number is the note or controller number.
+             * @member
+             * @type {number}
+             */
+            this["number"] = 0;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new MIDITrigger instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {MIDITrigger}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new MIDITrigger(/** @type {Partial<MIDITrigger>} */($$parsedSource));
+    }
+}
+
+/**
+ * MIDITriggerType is the kind of MIDI message that triggers a scene.
+ * @readonly
+ * @enum {string}
+ */
+export const MIDITriggerType = {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero: "",
+
+    /**
+     * MIDINote triggers on a note on message.
+     */
+    MIDINote: "note",
+
+    /**
+     * MIDIControl triggers when a controller's value rises to 64 or
+     * more, as when a button mapped to the controller is pressed.
+     */
+    MIDIControl: "control",
+};
+
+/**
+ * Scene is a named graph, or a script producing one, that can be
+ * played on demand.
+ */
+export class Scene {
+    /**
+     * Creates a new Scene instance.
+     * @param {Partial<Scene>} [$$source = {}] - The source object to create the Scene.
+     */
+    constructor($$source = {}) {
+        if (!("name" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["name"] = "";
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Script is the path of a script evaluated each time the
+             * scene is triggered, so that the scene reflects the latest
+             * edits to it.
+             * @member
+             * @type {string | undefined}
+             */
+            this["script"] = undefined;
+        }
+        if (!("transition" in $$source)) {
+            /**
+             * Transition is used when the scene is triggered without
+             * one. The zero value plays the scene immediately.
+             * @member
+             * @type {Transition}
+             */
+            this["transition"] = (new Transition());
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Key is a keystroke that triggers the scene.
+             * @member
+             * @type {string | undefined}
+             */
+            this["key"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * MIDI, if set, triggers the scene.
+             * @member
+             * @type {MIDITrigger | null | undefined}
+             */
+            this["midi"] = undefined;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Scene instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Scene}
+     */
+    static createFrom($$source = {}) {
+        const $$createField2_0 = $$createType4;
+        const $$createField4_0 = $$createType6;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("transition" in $$parsedSource) {
+            $$parsedSource["transition"] = $$createField2_0($$parsedSource["transition"]);
+        }
+        if ("midi" in $$parsedSource) {
+            $$parsedSource["midi"] = $$createField4_0($$parsedSource["midi"]);
+        }
+        return new Scene(/** @type {Partial<Scene>} */($$parsedSource));
+    }
+}
+
+/**
+ * StackFrame is a frame of a glojure-level stack trace.
+ */
+export class StackFrame {
+    /**
+     * Creates a new StackFrame instance.
+     * @param {Partial<StackFrame>} [$$source = {}] - The source object to create the StackFrame.
+     */
+    constructor($$source = {}) {
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {string | undefined}
+             */
+            this["file"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {number | undefined}
+             */
+            this["line"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {number | undefined}
+             */
+            this["column"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {string | undefined}
+             */
+            this["form"] = undefined;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new StackFrame instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {StackFrame}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new StackFrame(/** @type {Partial<StackFrame>} */($$parsedSource));
+    }
+}
+
 /**
  * @typedef {any} Symbol
  */
+
+/**
+ * Transition describes how to move to a scene.
+ */
+export class Transition {
+    /**
+     * Creates a new Transition instance.
+     * @param {Partial<Transition>} [$$source = {}] - The source object to create the Transition.
+     */
+    constructor($$source = {}) {
+        if (!("kind" in $$source)) {
+            /**
+             * @member
+             * @type {TransitionKind}
+             */
+            this["kind"] = TransitionKind.$zero;
+        }
+        if (!("duration" in $$source)) {
+            /**
+             * Duration is the length of a crossfade. A quantized
+             * transition with a non-zero Duration crossfades from the
+             * start of the bar.
+             * @member
+             * @type {time$0.Duration}
+             */
+            this["duration"] = time$0.Duration.$zero;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Transition instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Transition}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Transition(/** @type {Partial<Transition>} */($$parsedSource));
+    }
+}
+
+/**
+ * TransitionKind is how a scene replaces the playing graph.
+ * @readonly
+ * @enum {string}
+ */
+export const TransitionKind = {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero: "",
+
+    /**
+     * TransitionImmediate plays the scene as soon as it's ready.
+     */
+    TransitionImmediate: "immediate",
+
+    /**
+     * TransitionCrossfade fades from the playing graph into the scene.
+     */
+    TransitionCrossfade: "crossfade",
+
+    /**
+     * TransitionQuantized plays the scene at the start of the next
+     * bar.
+     */
+    TransitionQuantized: "quantized",
+};
+
+// Private type creation functions
+const $$createType0 = StackFrame.createFrom;
+const $$createType1 = $Create.Array($$createType0);
+const $$createType2 = Diagnostic.createFrom;
+const $$createType3 = $Create.Nullable($$createType2);
+const $$createType4 = Transition.createFrom;
+const $$createType5 = MIDITrigger.createFrom;
+const $$createType6 = $Create.Nullable($$createType5);
@@ -12,6 +12,7 @@ import {
 
 import {
   ConsoleEvent,
+  Diagnostic,
   EventWithCount,
   EventProps,
   ClearButtonProps,
@@ -39,6 +40,27 @@ const COLORS: Record<string, string> = {
   error: 'bg-red-500',
 };
 
+const formatLocation = (loc: { file?: string, line?: number, column?: number }): string => {
+  return [loc.file || '<unknown>', loc.line, loc.column].filter((x) => x).join(':');
+};
+
+// formatData formats event data for display. Evaluation errors carry
+// a diagnostic with the location of the error and a glojure stack.
+const formatData = (data: any): string => {
+  if (typeof data === 'string') {
+    return data;
+  }
+  if (data.kind && data.message !== undefined) {
+    const diag = data as Diagnostic;
+    const lines = [`${formatLocation(diag)}: ${diag.kind} error: ${diag.message}`];
+    for (const frame of diag.stack || []) {
+      lines.push(`  ${formatLocation(frame)}\t${frame.form || ''}`);
+    }
+    return lines.join('\n');
+  }
+  return JSON.stringify(data, null, 2);
+};
+
 const Event: React.FC<EventProps> = ({ event, count }) => {
   const level = event.level; // debug, info, warn, error
   const message = event.message;
@@ -48,7 +70,7 @@ const Event: React.FC<EventProps> = ({ event, count }) => {
   const toggleData = (): void => setDataVisible((prev) => !prev);
   const dataElement = data && (
     <div className="text-gray-100 text-xs p-1">
-      <pre>{data}</pre>
+      <pre>{formatData(data)}</pre>
     </div>
   );
 
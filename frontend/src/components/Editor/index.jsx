@@ -6,9 +6,13 @@ import {
 } from 'react';
 
 import {
+  CancelEval,
   OpenFileDialog,
   SaveFile,
   GetNSPublics,
+  NextCue,
+  PrevCue,
+  SceneKey,
 } from "../../../bindings/github.com/jfhamlin/muscrat/muscratservice";
 
 import Editor, { loader } from '@monaco-editor/react';
@@ -96,6 +100,32 @@ export default (props) => {
     editor.addCommand(monaco.KeyMod.CtrlCmd | monaco.KeyCode.KeyR, () => {
       buffersStore.playBuffer(selectedBufferNameRef.current);
     });
+
+    // add a key binding for cmd+. (cancel evaluation)
+    editor.addCommand(monaco.KeyMod.CtrlCmd | monaco.KeyCode.Period, () => {
+      CancelEval();
+    });
+
+    // cmd+alt+1 through cmd+alt+9 trigger the scenes bound to the
+    // keys 1 through 9; cmd+alt+right and cmd+alt+left step through
+    // the cue list. errors are reported in the console.
+    for (let i = 1; i <= 9; i++) {
+      editor.addCommand(monaco.KeyMod.CtrlCmd | monaco.KeyMod.Alt | monaco.KeyCode[`Digit${i}`], () => {
+        SceneKey(`${i}`).catch((err) => {
+          console.log(err);
+        });
+      });
+    }
+    editor.addCommand(monaco.KeyMod.CtrlCmd | monaco.KeyMod.Alt | monaco.KeyCode.RightArrow, () => {
+      NextCue().catch((err) => {
+        console.log(err);
+      });
+    });
+    editor.addCommand(monaco.KeyMod.CtrlCmd | monaco.KeyMod.Alt | monaco.KeyCode.LeftArrow, () => {
+      PrevCue().catch((err) => {
+        console.log(err);
+      });
+    });
   };
 
   const handleEditorChange = (value, event) => {
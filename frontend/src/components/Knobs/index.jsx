@@ -125,6 +125,16 @@ const Knob = ({ knob, color }) => {
     }
   }, [midiSub, midiControllerValue, midiCaught, value]);
 
+  // reflect values set remotely, e.g. over OSC
+  useEffect(() => {
+    return Events.On('knob-value-set', (evt) => {
+      const update = evt.data[0];
+      if (update.id === knob.id) {
+        setValue(update.value);
+      }
+    });
+  }, [knob.id]);
+
   // component should flash if waiting for midi
 
   // Calculate MIDI controller position for visual indicator
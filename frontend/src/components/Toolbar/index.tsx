@@ -1,7 +1,9 @@
 import React from 'react';
 // @ts-ignore - Wails generated bindings
 import {
+  CancelEval,
   OpenFileDialog,
+  Revert,
   SaveFile,
   Silence,
   ToggleHydraWindow,
@@ -69,6 +71,20 @@ const Toolbar: React.FC = () => {
     Silence();
   };
 
+  // abort an evaluation that's taking too long, e.g. a runaway loop,
+  // leaving the playing graph untouched.
+  const handleCancelClick = (): void => {
+    CancelEval();
+  };
+
+  // restore the graph that played before the current one, e.g. when
+  // an edit breaks the mix.
+  const handleRevertClick = (): void => {
+    Revert(1).catch((err: unknown) => {
+      console.log(err);
+    });
+  };
+
   const handleNewClick = (): void => {
     const DEFAULT_CONTENT = `(ns user
   (:use [mrat.core]))`;
@@ -109,6 +125,18 @@ const Toolbar: React.FC = () => {
           <path strokeLinecap="round" strokeLinejoin="round" d="M5.25 7.5A2.25 2.25 0 0 1 7.5 5.25h9a2.25 2.25 0 0 1 2.25 2.25v9a2.25 2.25 0 0 1-2.25 2.25h-9a2.25 2.25 0 0 1-2.25-2.25v-9Z" />
         </Svg>
       </Button>
+      <Button onClick={handleCancelClick}
+              title="Cancel evaluation">
+        <Svg>
+          <path strokeLinecap="round" strokeLinejoin="round" d="m9.75 9.75 4.5 4.5m0-4.5-4.5 4.5M21 12a9 9 0 1 1-18 0 9 9 0 0 1 18 0Z" />
+        </Svg>
+      </Button>
+      <Button onClick={handleRevertClick}
+              title="Revert to previous graph">
+        <Svg>
+          <path strokeLinecap="round" strokeLinejoin="round" d="M9 15 3 9m0 0 6-6M3 9h12a6 6 0 0 1 0 12h-3" />
+        </Svg>
+      </Button>
       <Button onClick={ToggleHydraWindow}
               title="Toggle Hydra">
         <Svg>
@@ -118,6 +118,23 @@ export interface ConsoleEvent {
   data?: any;
 }
 
+export interface StackFrame {
+  file?: string;
+  line?: number;
+  column?: number;
+  form?: string;
+}
+
+export interface Diagnostic {
+  kind: 'read' | 'compile' | 'runtime' | 'graph' | 'canceled';
+  message: string;
+  file?: string;
+  line?: number;
+  column?: number;
+  form?: string;
+  stack?: StackFrame[];
+}
+
 export interface EventWithCount {
   event: ConsoleEvent;
   count: number;
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"embed"
 	_ "embed"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"github.com/wailsapp/wails/v3/pkg/events"
 
+	"github.com/jfhamlin/muscrat/pkg/lsp"
+
 	// pprof
 	"net/http"
 	_ "net/http/pprof"
@@ -29,6 +33,15 @@ func init() {
 var assets embed.FS
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		// serve the Language Server Protocol on stdin and stdout for
+		// editors, without starting the app.
+		if err := lsp.ServeStdio(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	fmt.Println("Starting muscrat")
 
 	muscratService := NewMuscratService()
@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
-	"math"
+	"net/http"
 	"os"
 	"sync"
 
+	"github.com/jfhamlin/muscrat/pkg/api"
 	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/meter"
 	"github.com/jfhamlin/muscrat/pkg/mrat"
 	"github.com/jfhamlin/muscrat/pkg/pubsub"
 	"github.com/jfhamlin/muscrat/pkg/ugen"
@@ -23,22 +25,11 @@ type (
 		cancelPlayFile func()
 		playFileStop   chan struct{}
 
-		channelBuffers [][]float64
-
 		hydraWindow *application.WebviewWindow
 
 		windowMtx sync.Mutex
 
 		playMtx sync.Mutex
-
-		// Volume metering state
-		volumeMeterMtx      sync.Mutex
-		fastRMSBuffers      [][]float64
-		slowRMSBuffers      [][]float64
-		currentRMS          []float64
-		currentPeak         []float64
-		smoothedRMS         []float64
-		smoothedPeak        []float64
 	}
 
 	OpenFileDialogResponse struct {
@@ -62,150 +53,33 @@ func (a *MuscratService) startup(app *application.App) {
 
 	a.srv.Start(context.Background(), false)
 
-	// send at ~15 times per second, in multiples of conf.BufferSize
-	maxBuffersSamples := conf.SampleRate / 15
-	// round to nearest multiple of conf.BufferSize
-	maxBuffersSamples = (maxBuffersSamples/conf.BufferSize + 1) * conf.BufferSize
-
-	// Time constants for dual RMS windows
-	fastWindowSamples := int(float64(conf.SampleRate) * 0.020) // 20ms fast window
-	slowWindowSamples := int(float64(conf.SampleRate) * 0.300) // 300ms slow window
-
-	// Ballistics constants (at ~15Hz update rate)
-	// Attack: 0.95 means ~3 updates to reach 95% (200ms at 15Hz)
-	// Release: 0.25 means ~12 updates to decay to 5% (800ms at 15Hz)
-	attackRate := 0.95  // Fast attack
-	releaseRate := 0.25 // Moderate release for better responsiveness
-
-	pubsub.Subscribe("samples", func(event string, data any) {
-		samples, ok := data.([][]float64)
-		if !ok {
-			return
+	if conf.OSCPort != 0 {
+		var opts []mrat.OSCOption
+		if conf.OSCEval {
+			opts = append(opts, mrat.WithOSCEval())
 		}
-
-		a.volumeMeterMtx.Lock()
-		defer a.volumeMeterMtx.Unlock()
-
-		// Initialize buffers if needed
-		if len(a.channelBuffers) != len(samples) {
-			a.channelBuffers = make([][]float64, len(samples))
-			a.fastRMSBuffers = make([][]float64, len(samples))
-			a.slowRMSBuffers = make([][]float64, len(samples))
-			a.currentRMS = make([]float64, len(samples))
-			a.currentPeak = make([]float64, len(samples))
-			a.smoothedRMS = make([]float64, len(samples))
-			a.smoothedPeak = make([]float64, len(samples))
-		}
-
-		// Append new samples to all buffers
-		for i := range samples {
-			a.channelBuffers[i] = append(a.channelBuffers[i], samples[i]...)
-			a.fastRMSBuffers[i] = append(a.fastRMSBuffers[i], samples[i]...)
-			a.slowRMSBuffers[i] = append(a.slowRMSBuffers[i], samples[i]...)
-
-			// Trim fast buffer to window size
-			if len(a.fastRMSBuffers[i]) > fastWindowSamples {
-				a.fastRMSBuffers[i] = a.fastRMSBuffers[i][len(a.fastRMSBuffers[i])-fastWindowSamples:]
-			}
-
-			// Trim slow buffer to window size
-			if len(a.slowRMSBuffers[i]) > slowWindowSamples {
-				a.slowRMSBuffers[i] = a.slowRMSBuffers[i][len(a.slowRMSBuffers[i])-slowWindowSamples:]
+		go func() {
+			if err := a.srv.ListenOSC(context.Background(), fmt.Sprintf(":%d", conf.OSCPort), opts...); err != nil {
+				fmt.Printf("error serving OSC: %v\n", err)
 			}
-		}
+		}()
+	}
 
-		// Process when we have enough samples
-		if len(a.channelBuffers[0]) >= maxBuffersSamples {
-			cpy := make([][]float64, len(a.channelBuffers))
-			for i := range a.channelBuffers {
-				cpy[i] = make([]float64, len(a.channelBuffers[i]))
-				copy(cpy[i], a.channelBuffers[i])
-			}
-			go app.EmitEvent("samples", cpy)
-
-			// Calculate RMS and peak values with improvements
-			rms := make([]float64, len(a.channelBuffers))
-			peak := make([]float64, len(a.channelBuffers))
-			rmsDB := make([]float64, len(a.channelBuffers))
-			peakDB := make([]float64, len(a.channelBuffers))
-
-			for i := range a.channelBuffers {
-				// Calculate fast RMS
-				fastSum := 0.0
-				for _, v := range a.fastRMSBuffers[i] {
-					fastSum += v * v
-				}
-				fastRMS := math.Sqrt(fastSum / float64(len(a.fastRMSBuffers[i])))
-
-				// Calculate slow RMS
-				slowSum := 0.0
-				for _, v := range a.slowRMSBuffers[i] {
-					slowSum += v * v
-				}
-				slowRMS := math.Sqrt(slowSum / float64(len(a.slowRMSBuffers[i])))
-
-				// Use the maximum of fast and slow RMS
-				a.currentRMS[i] = math.Max(fastRMS, slowRMS)
-
-				// Find peak in current buffer
-				maxVal := 0.0
-				for _, v := range a.channelBuffers[i] {
-					absV := math.Abs(v)
-					if absV > maxVal {
-						maxVal = absV
-					}
-				}
-				a.currentPeak[i] = maxVal
-
-				// Apply ballistics
-				if a.currentRMS[i] > a.smoothedRMS[i] {
-					// Fast attack
-					a.smoothedRMS[i] += (a.currentRMS[i] - a.smoothedRMS[i]) * attackRate
-				} else {
-					// Slow release
-					a.smoothedRMS[i] += (a.currentRMS[i] - a.smoothedRMS[i]) * releaseRate
-				}
-
-				if a.currentPeak[i] > a.smoothedPeak[i] {
-					// Instant attack for peaks
-					a.smoothedPeak[i] = a.currentPeak[i]
-				} else {
-					// Slow decay for peaks
-					a.smoothedPeak[i] += (a.currentPeak[i] - a.smoothedPeak[i]) * releaseRate
-				}
-
-				// Store linear values for compatibility
-				rms[i] = a.smoothedRMS[i]
-				peak[i] = a.smoothedPeak[i]
-
-				// Convert to dB (with -60dB floor)
-				const minDB = -60.0
-				if a.smoothedRMS[i] > 0 {
-					rmsDB[i] = 20 * math.Log10(a.smoothedRMS[i])
-					rmsDB[i] = math.Max(rmsDB[i], minDB)
-				} else {
-					rmsDB[i] = minDB
-				}
-
-				if a.smoothedPeak[i] > 0 {
-					peakDB[i] = 20 * math.Log10(a.smoothedPeak[i])
-					peakDB[i] = math.Max(peakDB[i], minDB)
-				} else {
-					peakDB[i] = minDB
-				}
+	if conf.APIPort != 0 {
+		go func() {
+			addr := fmt.Sprintf("localhost:%d", conf.APIPort)
+			if err := http.ListenAndServe(addr, api.New(context.Background(), a.srv)); err != nil {
+				fmt.Printf("error serving API: %v\n", err)
 			}
+		}()
+	}
 
-			go app.EmitEvent("volume", map[string]any{
-				"rms":    rms,
-				"peak":   peak,
-				"rmsDB":  rmsDB,
-				"peakDB": peakDB,
-			})
-
-			// Clear main buffer
-			for i := range a.channelBuffers {
-				a.channelBuffers[i] = a.channelBuffers[i][:0]
-			}
+	volumeMeter := meter.New(conf.SampleRate, conf.BufferSize, func(event string, data any) {
+		go app.EmitEvent(event, data)
+	})
+	pubsub.Subscribe("samples", func(event string, data any) {
+		if samples, ok := data.([][]float64); ok {
+			volumeMeter.Write(samples)
 		}
 	})
 
@@ -355,6 +229,77 @@ func (a *MuscratService) PlayFile(fileName string) error {
 	return nil
 }
 
+// EvalString evaluates source text in the given namespace. If ns is
+// empty, the namespace declared in the source is used.
+func (a *MuscratService) EvalString(content, ns string) (*mrat.EvalResult, error) {
+	return a.srv.EvalString(context.Background(), content, ns)
+}
+
+// EvalRegion evaluates the forms between the byte offsets start and
+// end of content.
+func (a *MuscratService) EvalRegion(content string, start, end int, ns string) (*mrat.EvalResult, error) {
+	return a.srv.EvalRegion(context.Background(), content, mrat.Region{Start: start, End: end}, ns)
+}
+
+// CancelEval aborts any evaluation in progress, leaving the playing
+// graph untouched.
+func (a *MuscratService) CancelEval() {
+	a.srv.CancelEval()
+}
+
+// GraphHistory returns the graphs played, most recent first.
+func (a *MuscratService) GraphHistory() []mrat.GraphHistoryEntry {
+	return a.srv.History()
+}
+
+// Revert plays the graph n entries before the one playing. Revert(1)
+// restores the last graph that played before the current one.
+func (a *MuscratService) Revert(n int) (*mrat.GraphHistoryEntry, error) {
+	return a.srv.Revert(n)
+}
+
+// RegisterScene registers a scene, replacing any scene with the same
+// name.
+func (a *MuscratService) RegisterScene(scene mrat.Scene) error {
+	return a.srv.RegisterScene(scene)
+}
+
+// Scenes returns the registered scenes.
+func (a *MuscratService) Scenes() []mrat.Scene {
+	return a.srv.Scenes()
+}
+
+// TriggerScene plays the named scene with its own transition.
+func (a *MuscratService) TriggerScene(name string) error {
+	return a.srv.TriggerScene(context.Background(), name, mrat.Transition{})
+}
+
+// SceneKey plays the scene bound to a keystroke.
+func (a *MuscratService) SceneKey(key string) error {
+	return a.srv.TriggerSceneKey(context.Background(), key)
+}
+
+// SetCueList sets the scenes stepped through by NextCue and PrevCue.
+func (a *MuscratService) SetCueList(names []string) error {
+	return a.srv.SetCueList(names)
+}
+
+// SetCueTrigger sets a MIDI trigger that plays the next cue, or
+// removes it if trig is nil.
+func (a *MuscratService) SetCueTrigger(trig *mrat.MIDITrigger) error {
+	return a.srv.SetCueTrigger(trig)
+}
+
+// NextCue plays the next scene in the cue list.
+func (a *MuscratService) NextCue() error {
+	return a.srv.NextCue(context.Background())
+}
+
+// PrevCue plays the previous scene in the cue list.
+func (a *MuscratService) PrevCue() error {
+	return a.srv.PrevCue(context.Background())
+}
+
 func (a *MuscratService) Silence() {
 	a.playMtx.Lock()
 	defer a.playMtx.Unlock()
@@ -154,6 +154,17 @@ func findAndListenToMIDIPort(ctx context.Context, id int, namePattern *regexp.Re
 	return found.Number(), nil
 }
 
+// ListenMIDI starts publishing the messages received from the first
+// MIDI input port whose name matches namePattern on the "midi" topic,
+// and returns the port's ID.
+func ListenMIDI(ctx context.Context, namePattern string) (int, error) {
+	re, err := regexp.Compile(namePattern)
+	if err != nil {
+		return 0, err
+	}
+	return findAndListenToMIDIPort(ctx, -1, re)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type (
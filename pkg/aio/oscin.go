@@ -0,0 +1,72 @@
+package aio
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// OSCIn is a ugen emitting the latest value of an OSC control
+	// signal: one numeric argument of the messages received at an
+	// address.
+	OSCIn struct {
+		Address string
+		Index   int
+
+		def     float64
+		control *oscControl
+	}
+
+	// oscControl holds the latest arguments received at an address.
+	oscControl struct {
+		values atomic.Pointer[[]float64]
+	}
+)
+
+var (
+	oscControls    = map[string]*oscControl{}
+	oscControlsMtx sync.Mutex
+)
+
+// NewOSCIn returns a ugen emitting argument index of the latest
+// message received at address, or def until one is received.
+func NewOSCIn(address string, index int, def float64) *OSCIn {
+	return &OSCIn{
+		Address: address,
+		Index:   index,
+		def:     def,
+		control: getOSCControl(address),
+	}
+}
+
+// SetOSCControl records the numeric arguments of a message received
+// at address, for the OSCIn ugens reading it.
+func SetOSCControl(address string, values []float64) {
+	values = append([]float64(nil), values...)
+	getOSCControl(address).values.Store(&values)
+}
+
+func getOSCControl(address string) *oscControl {
+	oscControlsMtx.Lock()
+	defer oscControlsMtx.Unlock()
+
+	c, ok := oscControls[address]
+	if !ok {
+		c = &oscControl{}
+		oscControls[address] = c
+	}
+	return c
+}
+
+func (o *OSCIn) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	value := o.def
+	if values := o.control.values.Load(); values != nil && o.Index < len(*values) {
+		value = (*values)[o.Index]
+	}
+	for i := range out {
+		out[i] = value
+	}
+}
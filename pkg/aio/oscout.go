@@ -0,0 +1,178 @@
+package aio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jfhamlin/muscrat/pkg/oscproto"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// OSCOut is a sink ugen that samples its inputs and sends them
+	// as the float arguments of OSC messages to a UDP address. Inputs
+	// are read from the ports "$0", "$1", etc., in order.
+	//
+	// If the "trig" port is connected, the inputs are sampled on
+	// each rising edge of the trigger signal; otherwise, they're
+	// sampled at a fixed rate.
+	OSCOut struct {
+		Host    string
+		Address string
+		Rate    float64
+
+		client *oscproto.Client
+		cancel context.CancelFunc
+
+		// frames holds the input values to send, and free the
+		// frames available to fill, so that Gen doesn't
+		// allocate.
+		frames chan []float32
+		free   chan []float32
+
+		// initialized is set once the ports and frames have been
+		// set up, on the first block.
+		initialized bool
+		ports       []string
+		countdown   float64
+		lastTrig    float64
+	}
+)
+
+var (
+	_ ugen.UGen = (*OSCOut)(nil)
+)
+
+// oscOutQueueSize is the number of messages an OSCOut buffers before
+// dropping new ones, if sending can't keep up.
+const oscOutQueueSize = 64
+
+// NewOSCOut returns an OSCOut sending messages to address at the UDP
+// address host, e.g. "localhost:9000", rate times per second unless
+// triggered.
+func NewOSCOut(host, address string, rate float64) *OSCOut {
+	return &OSCOut{
+		Host:    host,
+		Address: address,
+		Rate:    rate,
+	}
+}
+
+func (o *OSCOut) Start(ctx context.Context) error {
+	if !strings.HasPrefix(o.Address, "/") {
+		return fmt.Errorf("osc-out: invalid address %q", o.Address)
+	}
+	client, err := oscproto.Dial(o.Host)
+	if err != nil {
+		return fmt.Errorf("osc-out: %w", err)
+	}
+	o.client = client
+	o.frames = make(chan []float32, oscOutQueueSize)
+	o.free = make(chan []float32, oscOutQueueSize)
+
+	ctx, o.cancel = context.WithCancel(ctx)
+	go func() {
+		defer client.Close()
+		msg := oscproto.NewMessage(o.Address)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case frame := <-o.frames:
+				msg.Args = msg.Args[:0]
+				for _, v := range frame {
+					msg.Args = append(msg.Args, v)
+				}
+				o.free <- frame
+				// sends fail while nothing is listening on the
+				// other end; keep going, in case something starts
+				// to.
+				client.Send(msg)
+			}
+		}
+	}()
+	return nil
+}
+
+func (o *OSCOut) Stop(ctx context.Context) error {
+	if o.cancel != nil {
+		o.cancel()
+	}
+	return nil
+}
+
+func (o *OSCOut) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	if o.client == nil {
+		return
+	}
+	if !o.initialized {
+		o.initialized = true
+		o.ports = inputPorts(cfg.InputSamples)
+		for range oscOutQueueSize {
+			o.free <- make([]float32, len(o.ports))
+		}
+	}
+
+	if trig, ok := cfg.InputSamples["trig"]; ok {
+		for i, t := range trig {
+			if t > 0 && o.lastTrig <= 0 {
+				o.send(cfg, i)
+			}
+			o.lastTrig = t
+		}
+		return
+	}
+
+	if o.Rate <= 0 {
+		return
+	}
+	period := float64(cfg.SampleRateHz) / o.Rate
+	for i := range out {
+		o.countdown--
+		if o.countdown <= 0 {
+			o.send(cfg, i)
+			o.countdown += period
+			if o.countdown <= 0 {
+				// faster than the sample rate.
+				o.countdown = period
+			}
+		}
+	}
+}
+
+// send queues a message with the inputs' values at sample i. The
+// message is dropped if the queue is full.
+func (o *OSCOut) send(cfg ugen.SampleConfig, i int) {
+	var frame []float32
+	select {
+	case frame = <-o.free:
+	default:
+		return
+	}
+	for j, port := range o.ports {
+		frame[j] = float32(cfg.InputSamples[port][i])
+	}
+	o.frames <- frame
+}
+
+// inputPorts returns the names of the numbered ports "$0", "$1",
+// etc. in inputs, in order.
+func inputPorts(inputs map[string][]float64) []string {
+	var ports []string
+	for port := range inputs {
+		if n, ok := strings.CutPrefix(port, "$"); ok {
+			if _, err := strconv.Atoi(n); err == nil {
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		a, _ := strconv.Atoi(ports[i][1:])
+		b, _ := strconv.Atoi(ports[j][1:])
+		return a < b
+	})
+	return ports
+}
@@ -0,0 +1,631 @@
+// Package api serves an HTTP and WebSocket API for controlling a
+// mrat.Server, so that a muscrat instance can be driven by scripts or
+// custom user interfaces, including on a headless machine.
+//
+// Requests and responses are JSON. Failed requests respond with an
+// object whose "error" field describes the failure. The endpoints are:
+//
+//   - GET /api/info: the sample rate and current scene.
+//   - POST /api/eval {"source", "ns", "region"}: evaluate source, or
+//     the region of it given as {"start", "end"} byte offsets, in the
+//     namespace ns (see mrat.Server.EvalString). Responds with the
+//     mrat.EvalResult, with status 422 if evaluation failed.
+//   - POST /api/eval/cancel: cancel evaluations in progress.
+//   - POST /api/play {"file"}: play a script file, re-evaluating it
+//     whenever it changes.
+//   - POST /api/silence: stop playing the file and play silence.
+//   - POST /api/revert {"n"}: revert n graphs, 1 by default.
+//   - GET /api/history: the graphs played, most recent first.
+//   - GET /api/knobs: the knobs, with their current values.
+//   - POST /api/knobs/{id} {"value"}: set a knob's value.
+//   - GET /api/symbols, GET /api/symbols/{name}: symbol docs.
+//   - GET /api/scenes, POST /api/scenes/{name}: list and trigger
+//     scenes.
+//   - PUT /api/scenes/{name} {"script", "transition", "key", "midi"}:
+//     register a scene playing a script (see mrat.Scene).
+//   - PUT /api/cues {"scenes", "trigger"}: set the cue list, and the
+//     MIDI trigger that plays the next cue if given.
+//   - POST /api/cues/next, POST /api/cues/prev: step through the cue
+//     list.
+//   - GET /api/events?events=a,b: a WebSocket streaming Events.
+//
+// The events streamed are those published on the pubsub broker, except
+// that "samples" is sent in batches about 15 times per second, each
+// followed by a "volume" event with a meter.Volume, and that
+// "knobs-changed" carries the knobs. If the events parameter is given,
+// only the listed events are sent.
+//
+// Requests from web pages are refused unless they come from one of
+// the origins allowed with WithAllowedOrigins or from the origin the
+// API is served from, provided that it's a loopback address, so that
+// arbitrary pages can't evaluate code, even by rebinding a domain
+// name to the machine.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/meter"
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+	"github.com/jfhamlin/muscrat/pkg/pubsub"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+const (
+	// clientBufferSize is the number of events queued for a WebSocket
+	// client. Events are dropped for clients that fall further behind.
+	clientBufferSize = 64
+
+	// eventQueueSize is the number of published events queued to be
+	// broadcast. Events are dropped if broadcasting falls further
+	// behind, so that publishers, such as the audio thread, never
+	// wait for clients.
+	eventQueueSize = 256
+
+	// writeTimeout bounds the time taken to write an event to a
+	// WebSocket client.
+	writeTimeout = 5 * time.Second
+
+	// maxRequestSize bounds the size of request bodies.
+	maxRequestSize = 16 << 20
+)
+
+type (
+	// Handler serves the API for a server.
+	Handler struct {
+		ctx context.Context
+		srv *mrat.Server
+		mux *http.ServeMux
+
+		upgrader       websocket.Upgrader
+		allowedOrigins map[string]bool
+
+		clients    map[*client]struct{}
+		clientsMtx sync.Mutex
+		numClients atomic.Int32
+		events     chan Event
+
+		cancelPlayFile func()
+		playFileStop   chan struct{}
+		playMtx        sync.Mutex
+	}
+
+	// Option configures a Handler.
+	Option func(*Handler)
+
+	// Event is a message sent to WebSocket clients.
+	Event struct {
+		Event string `json:"event"`
+		Data  any    `json:"data"`
+	}
+
+	// Knob is a knob and its current value.
+	Knob struct {
+		*ugen.Knob
+		Value float64 `json:"value"`
+	}
+
+	client struct {
+		// events the client is interested in, or nil for all.
+		events map[string]bool
+		send   chan []byte
+	}
+
+	evalRequest struct {
+		Source    string       `json:"source"`
+		Namespace string       `json:"ns"`
+		Region    *mrat.Region `json:"region"`
+	}
+
+	playRequest struct {
+		File string `json:"file"`
+	}
+
+	revertRequest struct {
+		N int `json:"n"`
+	}
+
+	knobRequest struct {
+		Value *float64 `json:"value"`
+	}
+
+	cuesRequest struct {
+		Scenes  []string          `json:"scenes"`
+		Trigger *mrat.MIDITrigger `json:"trigger"`
+	}
+)
+
+// WithAllowedOrigins allows requests from web pages served from the
+// given origins, e.g. "http://localhost:3000", including cross-origin
+// requests from browsers. "*" allows all origins.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(h *Handler) {
+		for _, o := range origins {
+			h.allowedOrigins[o] = true
+		}
+	}
+}
+
+// New returns a Handler serving the API for srv. Events are streamed
+// to clients until ctx is done.
+func New(ctx context.Context, srv *mrat.Server, opts ...Option) *Handler {
+	h := &Handler{
+		ctx:            ctx,
+		srv:            srv,
+		mux:            http.NewServeMux(),
+		allowedOrigins: map[string]bool{},
+		clients:        map[*client]struct{}{},
+		events:         make(chan Event, eventQueueSize),
+		playFileStop:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.upgrader.CheckOrigin = h.checkOrigin
+
+	h.mux.HandleFunc("GET /api/info", h.handleInfo)
+	h.mux.HandleFunc("POST /api/eval", h.handleEval)
+	h.mux.HandleFunc("POST /api/eval/cancel", h.handleCancelEval)
+	h.mux.HandleFunc("POST /api/play", h.handlePlay)
+	h.mux.HandleFunc("POST /api/silence", h.handleSilence)
+	h.mux.HandleFunc("POST /api/revert", h.handleRevert)
+	h.mux.HandleFunc("GET /api/history", h.handleHistory)
+	h.mux.HandleFunc("GET /api/knobs", h.handleKnobs)
+	h.mux.HandleFunc("POST /api/knobs/{id}", h.handleSetKnob)
+	h.mux.HandleFunc("GET /api/symbols", h.handleSymbols)
+	h.mux.HandleFunc("GET /api/symbols/{name}", h.handleSymbol)
+	h.mux.HandleFunc("GET /api/scenes", h.handleScenes)
+	h.mux.HandleFunc("POST /api/scenes/{name}", h.handleTriggerScene)
+	h.mux.HandleFunc("PUT /api/scenes/{name}", h.handleRegisterScene)
+	h.mux.HandleFunc("PUT /api/cues", h.handleSetCues)
+	h.mux.HandleFunc("POST /api/cues/next", h.handleNextCue)
+	h.mux.HandleFunc("POST /api/cues/prev", h.handlePrevCue)
+	h.mux.HandleFunc("GET /api/events", h.handleEvents)
+
+	// listeners are called synchronously by publishers, so events are
+	// queued, to be encoded and sent by broadcastEvents.
+	volumeMeter := meter.New(conf.SampleRate, conf.BufferSize, h.queueEvent)
+	unsubscribe := pubsub.Subscribe("", func(event string, data any) {
+		if h.numClients.Load() == 0 {
+			return
+		}
+		if event == "samples" {
+			// the samples are recycled once published, so they're
+			// batched, and copied, synchronously.
+			if samples, ok := data.([][]float64); ok {
+				volumeMeter.Write(samples)
+			}
+			return
+		}
+		h.queueEvent(event, data)
+	})
+	go h.broadcastEvents()
+	context.AfterFunc(ctx, func() {
+		unsubscribe()
+
+		h.playMtx.Lock()
+		defer h.playMtx.Unlock()
+		h.stopFile()
+	})
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.checkOrigin(r) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("origin %s not allowed", r.Header.Get("Origin")))
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	}
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// checkOrigin reports whether a request may be served: requests
+// without an Origin header don't come from web pages, and are always
+// allowed. A page from the same origin as the API is only allowed if
+// the API is served on a loopback address, as a page from any domain
+// that resolves to the machine has a matching origin.
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.allowedOrigins["*"] || h.allowedOrigins[origin] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host) && isLoopback(r.Host)
+}
+
+// isLoopback reports whether host, with an optional port, is a
+// loopback name or IP address.
+func isLoopback(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Handlers
+
+func (h *Handler) handleInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"sampleRate": conf.SampleRate,
+		"scene":      h.srv.CurrentScene(),
+	})
+}
+
+func (h *Handler) handleEval(w http.ResponseWriter, r *http.Request) {
+	var req evalRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	var res *mrat.EvalResult
+	var err error
+	if req.Region != nil {
+		res, err = h.srv.EvalRegion(r.Context(), req.Source, *req.Region, req.Namespace)
+	} else {
+		res, err = h.srv.EvalString(r.Context(), req.Source, req.Namespace)
+	}
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, res)
+}
+
+func (h *Handler) handleCancelEval(w http.ResponseWriter, r *http.Request) {
+	h.srv.CancelEval()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handlePlay(w http.ResponseWriter, r *http.Request) {
+	var req playRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if _, err := os.Stat(req.File); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.playMtx.Lock()
+	defer h.playMtx.Unlock()
+
+	h.stopFile()
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.cancelPlayFile = cancel
+
+	go func() {
+		defer func() {
+			h.playFileStop <- struct{}{}
+		}()
+		if err := mrat.WatchScriptFile(ctx, req.File, h.srv); err != nil {
+			fmt.Printf("error watching script file: %v\n", err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleSilence(w http.ResponseWriter, r *http.Request) {
+	h.playMtx.Lock()
+	defer h.playMtx.Unlock()
+
+	h.stopFile()
+	h.srv.PlayGraph(mrat.ZeroGraph())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleRevert(w http.ResponseWriter, r *http.Request) {
+	req := revertRequest{N: 1}
+	if r.ContentLength != 0 && !readJSON(w, r, &req) {
+		return
+	}
+	entry, err := h.srv.Revert(req.N)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (h *Handler) handleHistory(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.srv.History())
+}
+
+func (h *Handler) handleKnobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, knobs())
+}
+
+func (h *Handler) handleSetKnob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid knob id %q", r.PathValue("id")))
+		return
+	}
+	var req knobRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Value == nil {
+		writeError(w, http.StatusBadRequest, errors.New("missing value"))
+		return
+	}
+
+	for _, k := range ugen.GetKnobs() {
+		if k.ID != id {
+			continue
+		}
+		update := ugen.KnobUpdate{ID: k.ID, Value: *req.Value}
+		pubsub.Publish(ugen.KnobValueChangeEvent, update)
+		pubsub.Publish(ugen.KnobValueSetEvent, update)
+		writeJSON(w, http.StatusOK, Knob{Knob: k, Value: k.Value()})
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("no knob with id %d", id))
+}
+
+func (h *Handler) handleSymbols(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, mrat.GetNSPublics())
+}
+
+func (h *Handler) handleSymbol(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	for _, sym := range mrat.GetNSPublics() {
+		if sym.Name == name {
+			writeJSON(w, http.StatusOK, sym)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("no symbol named %s", name))
+}
+
+func (h *Handler) handleScenes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"scenes":  h.srv.Scenes(),
+		"cues":    h.srv.Cues(),
+		"current": h.srv.CurrentScene(),
+	})
+}
+
+func (h *Handler) handleTriggerScene(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, h.srv.TriggerScene(h.ctx, r.PathValue("name"), mrat.Transition{}))
+}
+
+func (h *Handler) handleNextCue(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, h.srv.NextCue(h.ctx))
+}
+
+func (h *Handler) handlePrevCue(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, h.srv.PrevCue(h.ctx))
+}
+
+func (h *Handler) handleRegisterScene(w http.ResponseWriter, r *http.Request) {
+	var scene mrat.Scene
+	if !readJSON(w, r, &scene) {
+		return
+	}
+	scene.Name = r.PathValue("name")
+	if err := h.srv.RegisterScene(scene); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleSetCues(w http.ResponseWriter, r *http.Request) {
+	var req cuesRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if err := h.srv.SetCueList(req.Scenes); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Trigger != nil {
+		if err := h.srv.SetCueTrigger(req.Trigger); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respond responds to a request with no result.
+func (h *Handler) respond(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stopFile stops the file being played, if any. playMtx must be held.
+func (h *Handler) stopFile() {
+	if h.cancelPlayFile == nil {
+		return
+	}
+
+	h.cancelPlayFile()
+	h.cancelPlayFile = nil
+	<-h.playFileStop
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Events
+
+func (h *Handler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already responded.
+		return
+	}
+	defer conn.Close()
+
+	c := &client{send: make(chan []byte, clientBufferSize)}
+	if events := r.URL.Query().Get("events"); events != "" {
+		c.events = map[string]bool{}
+		for _, e := range strings.Split(events, ",") {
+			c.events[strings.TrimSpace(e)] = true
+		}
+	}
+
+	h.clientsMtx.Lock()
+	h.clients[c] = struct{}{}
+	h.numClients.Add(1)
+	h.clientsMtx.Unlock()
+	defer func() {
+		h.clientsMtx.Lock()
+		delete(h.clients, c)
+		h.numClients.Add(-1)
+		h.clientsMtx.Unlock()
+	}()
+
+	// clients don't send anything, but reading is needed to process
+	// control messages and to notice when the connection is closed.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case msg := <-c.send:
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-h.ctx.Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(writeTimeout))
+			return
+		}
+	}
+}
+
+// queueEvent queues an event to be broadcast, dropping it if the
+// queue is full.
+func (h *Handler) queueEvent(event string, data any) {
+	select {
+	case h.events <- Event{Event: event, Data: data}:
+	default:
+	}
+}
+
+// broadcastEvents broadcasts the queued events until h.ctx is done.
+func (h *Handler) broadcastEvents() {
+	for {
+		select {
+		case e := <-h.events:
+			if e.Event == ugen.KnobsChangedEvent {
+				e.Data = knobs()
+			}
+			h.broadcast(e.Event, e.Data)
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// broadcast sends an event to the clients interested in it, dropping
+// it for clients whose queue is full.
+func (h *Handler) broadcast(event string, data any) {
+	h.clientsMtx.Lock()
+	defer h.clientsMtx.Unlock()
+
+	var msg []byte
+	for c := range h.clients {
+		if c.events != nil && !c.events[event] {
+			continue
+		}
+		if msg == nil {
+			var err error
+			msg, err = json.Marshal(Event{Event: event, Data: data})
+			if err != nil {
+				return
+			}
+		}
+		select {
+		case c.send <- msg:
+		default:
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func knobs() []Knob {
+	ks := ugen.GetKnobs()
+	res := make([]Knob, len(ks))
+	for i, k := range ks {
+		res[i] = Knob{Knob: k, Value: k.Value()}
+	}
+	return res
+}
+
+// readJSON decodes the JSON body of r into v, responding with an error
+// and returning false if it can't. JSON bodies must be declared as
+// such, so that browsers won't send them cross-origin without asking.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("expected a Content-Type of application/json"))
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
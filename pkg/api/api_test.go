@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+func TestAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := mrat.NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(New(ctx, srv))
+	defer ts.Close()
+
+	request := func(method, path, body string, header http.Header) (int, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res map[string]any
+		json.NewDecoder(resp.Body).Decode(&res)
+		return resp.StatusCode, res
+	}
+	post := func(path, body string, header http.Header) (int, map[string]any) {
+		t.Helper()
+		return request(http.MethodPost, path, body, header)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/events?events=volume,knob-value-set"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	nextEvent := func(name string) Event {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var evt Event
+			if err := conn.ReadJSON(&evt); err != nil {
+				t.Fatalf("waiting for %s event: %v", name, err)
+			}
+			if evt.Event == name {
+				return evt
+			}
+		}
+	}
+
+	// evaluation.
+	status, res := post("/api/eval", `{"source": "(play (sin 440))", "ns": "api-test"}`, nil)
+	if status != http.StatusOK {
+		t.Fatalf("eval: got status %d (%v), want 200", status, res)
+	}
+	if res["namespace"] != "api-test" {
+		t.Errorf("eval: got namespace %v, want api-test", res["namespace"])
+	}
+	if h := srv.History(); len(h) != 1 {
+		t.Errorf("got %d history entries after eval, want 1", len(h))
+	}
+	vol := nextEvent("volume").Data.(map[string]any)
+	if rms := vol["rms"].([]any); len(rms) != 2 {
+		t.Errorf("got volume for %d channels, want 2", len(rms))
+	}
+
+	status, res = post("/api/eval", `{"source": "(play (sin"}`, nil)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("eval of invalid source: got status %d, want 422", status)
+	}
+	if res["error"] == nil || res["diagnostic"] == nil {
+		t.Errorf("eval of invalid source: expected an error and diagnostic, got %v", res)
+	}
+
+	// knobs.
+	knob := ugen.NewKnob("api-test-knob", 0, 0, 10, 0.1, "")
+	knob.Start(ctx)
+	defer knob.Stop(ctx)
+
+	status, res = post(fmt.Sprintf("/api/knobs/%d", knob.ID), `{"value": 7}`, nil)
+	if status != http.StatusOK || res["value"] != 7.0 {
+		t.Errorf("set knob: got status %d (%v), want 200 with value 7", status, res)
+	}
+	if v := nextEvent("knob-value-set").Data.(map[string]any)["value"]; v != 7.0 {
+		t.Errorf("got knob-value-set event with value %v, want 7", v)
+	}
+	resp, err := http.Get(ts.URL + "/api/knobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var knobs []map[string]any
+	json.NewDecoder(resp.Body).Decode(&knobs)
+	resp.Body.Close()
+	var found bool
+	for _, k := range knobs {
+		if k["name"] == "api-test-knob" {
+			found = true
+			if k["value"] != 7.0 {
+				t.Errorf("got knob value %v, want 7", k["value"])
+			}
+		}
+	}
+	if !found {
+		t.Error("expected api-test-knob in the knobs")
+	}
+	if status, _ := post("/api/knobs/999999", `{"value": 1}`, nil); status != http.StatusNotFound {
+		t.Errorf("set unknown knob: got status %d, want 404", status)
+	}
+
+	// symbols.
+	resp, err = http.Get(ts.URL + "/api/symbols/sin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("get symbol sin: got status %d, want 200", resp.StatusCode)
+	}
+
+	// silence.
+	if status, _ := post("/api/silence", "", nil); status != http.StatusNoContent {
+		t.Errorf("silence: got status %d, want 204", status)
+	}
+
+	// scenes.
+	script := filepath.Join(t.TempDir(), "api_test_scene.glj")
+	if err := os.WriteFile(script, []byte(`(ns api-test-scene (:use [mrat.core])) (play (saw 220))`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scene, _ := json.Marshal(map[string]any{"script": script, "key": "1"})
+	if status, res := request(http.MethodPut, "/api/scenes/api-test", string(scene), nil); status != http.StatusNoContent {
+		t.Fatalf("register scene: got status %d (%v), want 204", status, res)
+	}
+	if status, _ := request(http.MethodPut, "/api/scenes/api-test-bad", `{"transition": {"kind": "crossfade"}}`, nil); status != http.StatusBadRequest {
+		t.Errorf("register scene without a script: got status %d, want 400", status)
+	}
+	if status, res := request(http.MethodPut, "/api/cues", `{"scenes": ["api-test"], "trigger": {"type": "note", "number": 60, "channel": -1}}`, nil); status != http.StatusNoContent {
+		t.Fatalf("set cues: got status %d (%v), want 204", status, res)
+	}
+	if status, _ := request(http.MethodPut, "/api/cues", `{"scenes": ["api-test-missing"]}`, nil); status != http.StatusBadRequest {
+		t.Errorf("set cues with an unknown scene: got status %d, want 400", status)
+	}
+	if status, res := post("/api/cues/next", "", nil); status != http.StatusNoContent {
+		t.Fatalf("next cue: got status %d (%v), want 204", status, res)
+	}
+	if cur := srv.CurrentScene(); cur != "api-test" {
+		t.Errorf("got current scene %q, want api-test", cur)
+	}
+
+	// requests from other web pages are refused.
+	status, _ = post("/api/eval", `{"source": "(play (sin 220))"}`, http.Header{"Origin": {"http://example.com"}})
+	if status != http.StatusForbidden {
+		t.Errorf("cross-origin eval: got status %d, want 403", status)
+	}
+	// as are pages from a domain rebound to the machine.
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/eval", strings.NewReader(`{"source": "1"}`))
+	req.Host = "rebound.example.com"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://rebound.example.com")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("eval from a rebound domain: got status %d, want 403", resp.StatusCode)
+	}
+	status, _ = post("/api/eval", `{"source": "1"}`, http.Header{"Origin": {ts.URL}})
+	if status != http.StatusOK {
+		t.Errorf("same-origin eval: got status %d, want 200", status)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, ts.URL+"/api/eval", strings.NewReader(`{"source": "1"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("eval with text/plain body: got status %d, want 415", resp.StatusCode)
+	}
+}
@@ -0,0 +1,280 @@
+// Package audiotest renders graphs offline and measures the audio they
+// produce, for tests that make assertions about how scripts and ugens
+// sound. It backs the mrat.test namespace, whose tests are run under
+// go test by package mrattest.
+package audiotest
+
+import (
+	"context"
+	"math"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/graph"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+const (
+	// SilenceThreshold is the peak amplitude below which audio is
+	// considered silent, about -80 dBFS.
+	SilenceThreshold = 1e-4
+
+	// maxFFTSize bounds the number of samples analyzed by
+	// DominantFrequency.
+	maxFFTSize = 1 << 17
+
+	// onsetHigh and onsetLow are the fractions of the peak amplitude
+	// that the envelope must rise above, after having fallen below,
+	// for an onset to be detected.
+	onsetHigh = 0.25
+	onsetLow  = 0.1
+
+	// onsetFrameRate is the number of envelope frames per second
+	// used for onset detection.
+	onsetFrameRate = 400
+)
+
+type (
+	// Audio is audio rendered offline.
+	Audio struct {
+		SampleRate int
+		// Channels holds the samples of each channel, all of the
+		// same length.
+		Channels [][]float64
+	}
+)
+
+// Render renders seconds of g's output at conf.SampleRate.
+func Render(g *graph.Graph, seconds float64) (*Audio, error) {
+	return RenderContext(context.Background(), g, seconds)
+}
+
+// RenderContext is like Render, but returns an error as soon as ctx is
+// done.
+func RenderContext(ctx context.Context, g *graph.Graph, seconds float64) (*Audio, error) {
+	numSamples := int(math.Round(seconds * float64(conf.SampleRate)))
+	chans, err := graph.Render(ctx, g, ugen.SampleConfig{SampleRateHz: conf.SampleRate}, numSamples)
+	if err != nil {
+		return nil, err
+	}
+	return &Audio{SampleRate: conf.SampleRate, Channels: chans}, nil
+}
+
+// Len returns the number of samples in each channel.
+func (a *Audio) Len() int {
+	if len(a.Channels) == 0 {
+		return 0
+	}
+	return len(a.Channels[0])
+}
+
+// Duration returns the duration of the audio in seconds.
+func (a *Audio) Duration() float64 {
+	return float64(a.Len()) / float64(a.SampleRate)
+}
+
+// Channel returns the ith channel as mono audio.
+func (a *Audio) Channel(i int) *Audio {
+	return &Audio{SampleRate: a.SampleRate, Channels: [][]float64{a.Channels[i]}}
+}
+
+// Slice returns the audio between start and end, in seconds. The
+// bounds are clamped to the audio's duration.
+func (a *Audio) Slice(start, end float64) *Audio {
+	from := min(max(int(math.Round(start*float64(a.SampleRate))), 0), a.Len())
+	to := min(max(int(math.Round(end*float64(a.SampleRate))), from), a.Len())
+	res := &Audio{SampleRate: a.SampleRate}
+	for _, ch := range a.Channels {
+		res.Channels = append(res.Channels, ch[from:to])
+	}
+	return res
+}
+
+// Mono returns the average of the channels.
+func (a *Audio) Mono() []float64 {
+	res := make([]float64, a.Len())
+	for _, ch := range a.Channels {
+		for i, x := range ch {
+			res[i] += x / float64(len(a.Channels))
+		}
+	}
+	return res
+}
+
+// Peak returns the largest absolute sample value in any channel.
+func (a *Audio) Peak() float64 {
+	var peak float64
+	for _, ch := range a.Channels {
+		for _, x := range ch {
+			peak = max(peak, math.Abs(x))
+		}
+	}
+	return peak
+}
+
+// RMS returns the root mean square of the samples of all channels.
+func (a *Audio) RMS() float64 {
+	var sum float64
+	var n int
+	for _, ch := range a.Channels {
+		for _, x := range ch {
+			sum += x * x
+		}
+		n += len(ch)
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// DC returns the mean of the samples of all channels.
+func (a *Audio) DC() float64 {
+	var sum float64
+	var n int
+	for _, ch := range a.Channels {
+		for _, x := range ch {
+			sum += x
+		}
+		n += len(ch)
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// IsSilent reports whether the peak amplitude is below
+// SilenceThreshold.
+func (a *Audio) IsSilent() bool {
+	return a.Peak() < SilenceThreshold
+}
+
+// DominantFrequency returns the frequency in Hz with the most energy
+// in the mono mix, or 0 if the mix is silent. The estimate is
+// interpolated between FFT bins, so it's accurate to well within a
+// bin for steady tones.
+func (a *Audio) DominantFrequency() float64 {
+	x := a.Mono()
+	if len(x) > maxFFTSize {
+		// analyze the middle, away from any attack or release.
+		start := (len(x) - maxFFTSize) / 2
+		x = x[start : start+maxFFTSize]
+	}
+	mono := &Audio{SampleRate: a.SampleRate, Channels: [][]float64{x}}
+	if len(x) < 4 || mono.IsSilent() {
+		return 0
+	}
+
+	var mean float64
+	for _, v := range x {
+		mean += v / float64(len(x))
+	}
+	windowed := make([]float64, len(x))
+	for i, v := range x {
+		// Hann window.
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(len(x)-1))
+		windowed[i] = (v - mean) * w
+	}
+	coeffs := fourier.NewFFT(len(x)).Coefficients(nil, windowed)
+
+	mags := make([]float64, len(coeffs))
+	best := 1
+	for i := range coeffs {
+		re, im := real(coeffs[i]), imag(coeffs[i])
+		mags[i] = math.Sqrt(re*re + im*im)
+		if i > 0 && mags[i] > mags[best] {
+			best = i
+		}
+	}
+
+	// refine with a parabola through the log magnitudes around the
+	// peak.
+	bin := float64(best)
+	if best > 0 && best < len(mags)-1 && mags[best-1] > 0 && mags[best+1] > 0 {
+		l, c, r := math.Log(mags[best-1]), math.Log(mags[best]), math.Log(mags[best+1])
+		if d := l - 2*c + r; d != 0 {
+			bin += 0.5 * (l - r) / d
+		}
+	}
+	return bin * float64(a.SampleRate) / float64(len(x))
+}
+
+// Onsets returns the times in seconds at which sounds start. An onset
+// is detected where the envelope of the mono mix rises above a quarter
+// of the peak amplitude after having fallen below a tenth of it; its
+// time is the first sample in the rise above a tenth of the peak.
+func (a *Audio) Onsets() []float64 {
+	x := a.Mono()
+	var peak float64
+	for _, v := range x {
+		peak = max(peak, math.Abs(v))
+	}
+	if peak < SilenceThreshold {
+		return nil
+	}
+	high, low := peak*onsetHigh, peak*onsetLow
+
+	frame := max(a.SampleRate/onsetFrameRate, 1)
+	var res []float64
+	armed := true
+	quietEnd := 0 // the end of the last frame below low.
+	for start := 0; start < len(x); start += frame {
+		end := min(start+frame, len(x))
+		var env float64
+		for _, v := range x[start:end] {
+			env = max(env, math.Abs(v))
+		}
+		switch {
+		case env < low:
+			armed = true
+			quietEnd = end
+		case armed && env >= high:
+			armed = false
+			onset := quietEnd
+			for onset < end && math.Abs(x[onset]) < low {
+				onset++
+			}
+			res = append(res, float64(onset)/float64(a.SampleRate))
+		}
+	}
+	return res
+}
+
+// Correlation returns the correlation between the first two channels,
+// from -1 for channels out of phase to 1 for identical channels. Mono
+// audio, and channels that are equal, have a correlation of 1; a
+// constant channel is uncorrelated with a varying one.
+func (a *Audio) Correlation() float64 {
+	if len(a.Channels) < 2 {
+		return 1
+	}
+	l, r := a.Channels[0], a.Channels[1]
+	n := float64(len(l))
+	if n == 0 {
+		return 1
+	}
+
+	var meanL, meanR float64
+	for i := range l {
+		meanL += l[i] / n
+		meanR += r[i] / n
+	}
+	var cov, varL, varR float64
+	equal := true
+	for i := range l {
+		dl, dr := l[i]-meanL, r[i]-meanR
+		cov += dl * dr
+		varL += dl * dl
+		varR += dr * dr
+		equal = equal && l[i] == r[i]
+	}
+	switch {
+	case equal:
+		return 1
+	case varL == 0 || varR == 0:
+		return 0
+	}
+	return cov / math.Sqrt(varL*varR)
+}
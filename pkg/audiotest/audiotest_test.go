@@ -0,0 +1,88 @@
+package audiotest
+
+import (
+	"math"
+	"testing"
+)
+
+const sampleRate = 44100
+
+func sine(freq, amp, seconds float64) []float64 {
+	res := make([]float64, int(seconds*sampleRate))
+	for i := range res {
+		res[i] = amp * math.Sin(2*math.Pi*freq*float64(i)/sampleRate)
+	}
+	return res
+}
+
+func TestMeasures(t *testing.T) {
+	s := sine(440, 0.5, 1)
+	a := &Audio{SampleRate: sampleRate, Channels: [][]float64{s, s}}
+
+	if got := a.Duration(); got != 1 {
+		t.Errorf("got duration %v, want 1", got)
+	}
+	if got := a.Peak(); math.Abs(got-0.5) > 1e-3 {
+		t.Errorf("got peak %v, want 0.5", got)
+	}
+	if got, want := a.RMS(), 0.5/math.Sqrt2; math.Abs(got-want) > 1e-3 {
+		t.Errorf("got RMS %v, want %v", got, want)
+	}
+	if got := a.DC(); math.Abs(got) > 1e-3 {
+		t.Errorf("got DC %v, want 0", got)
+	}
+	if a.IsSilent() {
+		t.Error("sine is silent")
+	}
+	if got := a.DominantFrequency(); math.Abs(got-440) > 1 {
+		t.Errorf("got dominant frequency %v, want 440", got)
+	}
+	if got := a.Correlation(); got != 1 {
+		t.Errorf("got correlation %v, want 1", got)
+	}
+
+	inverted := make([]float64, len(s))
+	for i, x := range s {
+		inverted[i] = -x + 0.25
+	}
+	b := &Audio{SampleRate: sampleRate, Channels: [][]float64{s, inverted}}
+	if got := b.Correlation(); math.Abs(got+1) > 1e-9 {
+		t.Errorf("got correlation %v, want -1", got)
+	}
+	if got := b.Channel(1).DC(); math.Abs(got-0.25) > 1e-3 {
+		t.Errorf("got DC %v, want 0.25", got)
+	}
+
+	silent := &Audio{SampleRate: sampleRate, Channels: [][]float64{make([]float64, 100)}}
+	if !silent.IsSilent() || silent.DominantFrequency() != 0 || silent.Onsets() != nil {
+		t.Error("expected silence to be silent, without frequency or onsets")
+	}
+}
+
+func TestOnsets(t *testing.T) {
+	// decaying bursts of a tone at 0.1s, 0.35s and 0.8s.
+	x := make([]float64, sampleRate)
+	want := []float64{0.1, 0.35, 0.8}
+	for _, start := range want {
+		burst := sine(1000, 0.8, 0.15)
+		offset := int(start * sampleRate)
+		for i, v := range burst {
+			x[offset+i] += v * math.Exp(-float64(i)/sampleRate/0.02)
+		}
+	}
+	a := &Audio{SampleRate: sampleRate, Channels: [][]float64{x}}
+
+	got := a.Onsets()
+	if len(got) != len(want) {
+		t.Fatalf("got onsets %v, want %v", got, want)
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 0.002 {
+			t.Errorf("got onset %v, want %v", got[i], want[i])
+		}
+	}
+
+	if got := a.Slice(0.3, 2).Onsets(); len(got) != 2 || math.Abs(got[0]-0.05) > 0.002 {
+		t.Errorf("got onsets %v in slice from 0.3s, want 2 starting at 0.05", got)
+	}
+}
@@ -0,0 +1,319 @@
+// Package golden compares audio rendered in tests against reference
+// files checked in under testdata/golden, so that changes to DSP code
+// can't silently change how ugens and scripts sound:
+//
+//	func TestLPF(t *testing.T) {
+//		golden.UGen(t, "lpf", NewLPF(), map[string][]float64{
+//			"in":   audiotest.Noise(1, 2048),
+//			"freq": {1000},
+//		})
+//	}
+//
+// Audio matches its reference if no sample differs by more than a
+// tolerance and the log-spectral distance between the two is within a
+// tolerance in dB. Run the tests with -update to write the reference
+// files from the current output.
+package golden
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+var update = flag.Bool("update", false, "update golden audio files")
+
+const (
+	// DefaultSamples is the number of samples rendered by UGen.
+	DefaultSamples = 2048
+
+	// DefaultTolerance is the largest difference allowed between a
+	// sample and its reference.
+	DefaultTolerance = 1e-6
+
+	// DefaultSpectralTolerance is the largest log-spectral distance
+	// allowed between audio and its reference, in dB.
+	DefaultSpectralTolerance = 0.5
+
+	// spectralFloor is the level, in dB relative to the loudest bin of
+	// the reference, below which spectral differences are ignored.
+	spectralFloor = -90
+)
+
+type (
+	// Option configures a comparison.
+	Option func(*options)
+
+	options struct {
+		samples           int
+		tolerance         float64
+		spectralTolerance float64
+	}
+
+	// Diff describes how audio differs from its reference.
+	Diff struct {
+		// MaxSample is the largest absolute difference between a
+		// sample and its reference, found at Index in Channel.
+		MaxSample float64
+		Channel   int
+		Index     int
+
+		// Spectral is the log-spectral distance between the audio
+		// and its reference in dB: the root mean square difference
+		// of their magnitude spectra, averaged over channels.
+		Spectral float64
+	}
+)
+
+// WithSamples sets the number of samples rendered by UGen.
+func WithSamples(n int) Option {
+	return func(o *options) {
+		o.samples = n
+	}
+}
+
+// WithTolerance sets the largest difference allowed between a sample
+// and its reference. A tolerance of math.Inf(1) compares only spectra,
+// for audio whose phase isn't expected to be stable.
+func WithTolerance(tol float64) Option {
+	return func(o *options) {
+		o.tolerance = tol
+	}
+}
+
+// WithSpectralTolerance sets the largest log-spectral distance allowed
+// between audio and its reference, in dB.
+func WithSpectralTolerance(db float64) Option {
+	return func(o *options) {
+		o.spectralTolerance = db
+	}
+}
+
+// UGen renders u with the given inputs, as audiotest.RenderUGen does,
+// and checks its output against the reference file for name.
+func UGen(t testing.TB, name string, u ugen.UGen, inputs map[string][]float64, opts ...Option) {
+	t.Helper()
+	o := makeOptions(opts)
+	got, err := audiotest.RenderUGen(t.Context(), u, inputs, o.samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Check(t, name, got, opts...)
+}
+
+// Check checks audio against the reference file for name,
+// testdata/golden/<name>.golden, or writes the file if the test is run
+// with -update.
+func Check(t testing.TB, name string, got *audiotest.Audio, opts ...Option) {
+	t.Helper()
+	o := makeOptions(opts)
+	path := filepath.Join("testdata", "golden", name+".golden")
+
+	if *update {
+		if err := write(path, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("no golden file for %s; run the test with -update to create it", name)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Compare(want, got)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if d.MaxSample > o.tolerance {
+		t.Errorf("%s: sample %d of channel %d differs from golden by %g (tolerance %g)",
+			name, d.Index, d.Channel, d.MaxSample, o.tolerance)
+	}
+	if d.Spectral > o.spectralTolerance {
+		t.Errorf("%s: spectrum differs from golden by %.3g dB (tolerance %g dB)",
+			name, d.Spectral, o.spectralTolerance)
+	}
+}
+
+// Compare returns how got differs from want. It returns an error if
+// they differ in sample rate, number of channels or length.
+func Compare(want, got *audiotest.Audio) (Diff, error) {
+	switch {
+	case want.SampleRate != got.SampleRate:
+		return Diff{}, fmt.Errorf("got sample rate %d, want %d", got.SampleRate, want.SampleRate)
+	case len(want.Channels) != len(got.Channels):
+		return Diff{}, fmt.Errorf("got %d channels, want %d", len(got.Channels), len(want.Channels))
+	case want.Len() != got.Len():
+		return Diff{}, fmt.Errorf("got %d samples, want %d", got.Len(), want.Len())
+	}
+
+	var d Diff
+	for c := range want.Channels {
+		w, g := want.Channels[c], got.Channels[c]
+		for i := range w {
+			if diff := math.Abs(w[i] - g[i]); diff > d.MaxSample || math.IsNaN(diff) {
+				d.MaxSample, d.Channel, d.Index = diff, c, i
+				if math.IsNaN(diff) {
+					d.MaxSample = math.Inf(1)
+				}
+			}
+		}
+		d.Spectral += spectralDistance(w, g) / float64(len(want.Channels))
+	}
+	return d, nil
+}
+
+// spectralDistance returns the root mean square difference in dB
+// between the Hann-windowed magnitude spectra of want and got, ignoring
+// levels below spectralFloor.
+func spectralDistance(want, got []float64) float64 {
+	if len(want) < 2 {
+		return 0
+	}
+	w, g := spectrum(want), spectrum(got)
+
+	var peak float64
+	for _, m := range w {
+		peak = max(peak, m)
+	}
+	floor := peak * math.Pow(10, spectralFloor/20)
+	if floor == 0 {
+		// silent reference; any sound at all is a difference.
+		floor = audiotest.SilenceThreshold
+	}
+
+	var sum float64
+	for i := range w {
+		db := 20 * math.Log10(max(g[i], floor)/max(w[i], floor))
+		sum += db * db
+	}
+	res := math.Sqrt(sum / float64(len(w)))
+	if math.IsNaN(res) {
+		return math.Inf(1)
+	}
+	return res
+}
+
+func spectrum(x []float64) []float64 {
+	windowed := make([]float64, len(x))
+	for i, v := range x {
+		windowed[i] = v * (0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(len(x)-1)))
+	}
+	coeffs := fourier.NewFFT(len(x)).Coefficients(nil, windowed)
+	res := make([]float64, len(coeffs))
+	for i, c := range coeffs {
+		res[i] = math.Hypot(real(c), imag(c))
+	}
+	return res
+}
+
+func makeOptions(opts []Option) options {
+	o := options{
+		samples:           DefaultSamples,
+		tolerance:         DefaultTolerance,
+		spectralTolerance: DefaultSpectralTolerance,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// File format
+//
+// Golden files are text, so changes show up in diffs: a header giving
+// the sample rate and number of channels, then one line per sample
+// with a value for each channel.
+
+func write(path string, a *audiotest.Audio) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# golden audio; regenerate with go test -update\n")
+	fmt.Fprintf(&b, "sample-rate %d\n", a.SampleRate)
+	fmt.Fprintf(&b, "channels %d\n", len(a.Channels))
+	for i := 0; i < a.Len(); i++ {
+		for c, ch := range a.Channels {
+			if c > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strconv.FormatFloat(ch[i], 'g', 9, 64))
+		}
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func read(path string) (*audiotest.Audio, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a := &audiotest.Audio{}
+	numChannels := -1
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+
+		if numChannels < 0 {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("%s:%d: malformed header", path, lineNum)
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+			}
+			switch fields[0] {
+			case "sample-rate":
+				a.SampleRate = n
+			case "channels":
+				numChannels = n
+				a.Channels = make([][]float64, n)
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown header %q", path, lineNum, fields[0])
+			}
+			continue
+		}
+
+		if len(fields) != numChannels {
+			return nil, fmt.Errorf("%s:%d: got %d samples, want one for each of %d channels", path, lineNum, len(fields), numChannels)
+		}
+		for c, field := range fields {
+			x, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+			}
+			a.Channels[c] = append(a.Channels[c], x)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if numChannels < 0 {
+		return nil, fmt.Errorf("%s: missing channels header", path)
+	}
+	return a, nil
+}
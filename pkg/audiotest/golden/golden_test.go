@@ -0,0 +1,63 @@
+package golden
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+)
+
+func TestFileRoundTrip(t *testing.T) {
+	a := &audiotest.Audio{
+		SampleRate: 48000,
+		Channels: [][]float64{
+			audiotest.Sine(1000, 256),
+			audiotest.Noise(1, 256),
+		},
+	}
+	path := filepath.Join(t.TempDir(), "sub", "a.golden")
+	if err := write(path, a); err != nil {
+		t.Fatal(err)
+	}
+	b, err := read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.MaxSample > 1e-8 || d.Spectral > 1e-6 {
+		t.Errorf("got diff %+v after a round trip", d)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want := &audiotest.Audio{SampleRate: 44100, Channels: [][]float64{audiotest.Sine(440, 4096)}}
+
+	louder := &audiotest.Audio{SampleRate: 44100, Channels: [][]float64{make([]float64, 4096)}}
+	for i, x := range want.Channels[0] {
+		louder.Channels[0][i] = 2 * x
+	}
+	d, err := Compare(want, louder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(d.MaxSample-1) > 1e-3 || d.Spectral < DefaultSpectralTolerance {
+		t.Errorf("got diff %+v for a doubled signal", d)
+	}
+
+	shifted := &audiotest.Audio{SampleRate: 44100, Channels: [][]float64{audiotest.Sine(440, 4097)[1:]}}
+	d, err = Compare(want, shifted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.MaxSample < 1e-3 || d.Spectral > DefaultSpectralTolerance {
+		t.Errorf("got diff %+v for a signal shifted by a sample, want only samples to differ", d)
+	}
+
+	if _, err := Compare(want, want.Slice(0, 0.01)); err == nil {
+		t.Error("expected an error comparing audio of different lengths")
+	}
+}
@@ -0,0 +1,89 @@
+package audiotest
+
+import (
+	"context"
+	"math"
+	"math/rand"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// RenderUGen renders numSamples of u's output at conf.SampleRate, in
+// blocks of conf.BufferSize. Each input is a signal for the port of
+// that name; an input of a single sample is held for the whole render,
+// and a shorter input is padded with its last sample. u is started
+// and stopped if it implements ugen.Starter or ugen.Stopper.
+func RenderUGen(ctx context.Context, u ugen.UGen, inputs map[string][]float64, numSamples int) (*Audio, error) {
+	if s, ok := u.(ugen.Starter); ok {
+		if err := s.Start(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := u.(ugen.Stopper); ok {
+		defer s.Stop(ctx)
+	}
+
+	padded := make(map[string][]float64, len(inputs))
+	for name, in := range inputs {
+		padded[name] = hold(in, numSamples)
+	}
+
+	out := make([]float64, numSamples)
+	blockInputs := make(map[string][]float64, len(padded))
+	for start := 0; start < numSamples; start += conf.BufferSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(start+conf.BufferSize, numSamples)
+		for name, in := range padded {
+			blockInputs[name] = in[start:end]
+		}
+		u.Gen(ctx, ugen.SampleConfig{
+			SampleRateHz: conf.SampleRate,
+			InputSamples: blockInputs,
+		}, out[start:end])
+	}
+	return &Audio{SampleRate: conf.SampleRate, Channels: [][]float64{out}}, nil
+}
+
+func hold(in []float64, n int) []float64 {
+	res := make([]float64, n)
+	copy(res, in)
+	if len(in) > 0 {
+		for i := len(in); i < n; i++ {
+			res[i] = in[len(in)-1]
+		}
+	}
+	return res
+}
+
+// Sine returns numSamples of a unit sine wave at freq Hz, at
+// conf.SampleRate.
+func Sine(freq float64, numSamples int) []float64 {
+	res := make([]float64, numSamples)
+	for i := range res {
+		res[i] = math.Sin(2 * math.Pi * freq * float64(i) / float64(conf.SampleRate))
+	}
+	return res
+}
+
+// Noise returns numSamples of uniform white noise in [-1, 1). The
+// noise is the same for the same seed.
+func Noise(seed int64, numSamples int) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	res := make([]float64, numSamples)
+	for i := range res {
+		res[i] = 2*r.Float64() - 1
+	}
+	return res
+}
+
+// Impulse returns numSamples of a unit impulse followed by silence.
+func Impulse(numSamples int) []float64 {
+	res := make([]float64, numSamples)
+	if numSamples > 0 {
+		res[0] = 1
+	}
+	return res
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jfhamlin/muscrat/internal/pkg/platform"
 	"github.com/jfhamlin/muscrat/pkg/bufferpool"
@@ -44,6 +45,24 @@ var (
 
 		return []string{filepath.Join(resourcesPath, "samples")}
 	}()
+
+	// EvalTimeout is the maximum time a script may take to evaluate
+	// before it is aborted, set in seconds. Zero means no limit.
+	EvalTimeout = time.Duration(max(0, getValueInt("MUSCRAT_EVAL_TIMEOUT", 30))) * time.Second
+
+	// OSCPort is the UDP port on which the app listens for Open Sound
+	// Control messages. Zero disables OSC control.
+	OSCPort = clamp(0, 65535, getValueInt("MUSCRAT_OSC_PORT", 0))
+
+	// OSCEval enables evaluation of code sent over OSC. OSC has no
+	// authentication, so this lets anyone who can reach OSCPort run
+	// arbitrary code.
+	OSCEval = getValueInt("MUSCRAT_OSC_EVAL", 0) != 0
+
+	// APIPort is the TCP port on which the app serves its HTTP and
+	// WebSocket control API on the loopback interface. Zero disables
+	// the API.
+	APIPort = clamp(0, 65535, getValueInt("MUSCRAT_API_PORT", 0))
 )
 
 func clamp(min, max, value int) int {
@@ -11,17 +11,15 @@ import (
 func NewAllPass(maxDelayTime float64) ugen.UGen {
 	delayLine := NewDelayLine(conf.SampleRate, maxDelayTime)
 
+	inInput := ugen.Input{Name: "in"}
+	delayTimeInput := ugen.Input{Name: "delaytime", Default: 0.2}
+	decayTimeInput := ugen.Input{Name: "decaytime", Default: 1}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
+		in := inInput.Samples(cfg, len(out))
 
 		// For per-sample parameters, we can cache references to the input slices
-		delayTimeSamplesInput := cfg.InputSamples["delaytime"]
-		decayTimeSamplesInput := cfg.InputSamples["decaytime"]
-
-		lastIdx := len(out) - 1
-		_ = in[lastIdx]
-		_ = delayTimeSamplesInput[lastIdx]
-		_ = decayTimeSamplesInput[lastIdx]
+		delayTimeSamplesInput := delayTimeInput.Samples(cfg, len(out))
+		decayTimeSamplesInput := decayTimeInput.Samples(cfg, len(out))
 
 		for i := range out {
 			xn := in[i]
@@ -51,8 +49,8 @@ func NewAllPass(maxDelayTime float64) ugen.UGen {
 			// All-pass filter equation
 			yn := -g*xn + delayedSample
 
-			// Update delay line
-			delayLine.WriteSample(xn + g*yn)
+			// Update delay line, keeping NaNs out of the feedback path
+			delayLine.WriteSample(zapgremlins(xn + g*yn))
 
 			// Output sample
 			out[i] = yn
@@ -10,8 +10,6 @@ import (
 
 var (
 	log1 = math.Log(0.1)
-
-	sampleRate = float64(conf.SampleRate)
 )
 
 func NewAmplitude(attackTime, releaseTime float64, opts ...ugen.Option) ugen.UGen {
@@ -20,23 +18,24 @@ func NewAmplitude(attackTime, releaseTime float64, opts ...ugen.Option) ugen.UGe
 		opt(&o)
 	}
 
+	sampleRate := float64(conf.SampleRate)
 	clampCoef := 0.0
 	if attackTime != 0 {
-		math.Exp(log1 / (attackTime * sampleRate))
+		clampCoef = math.Exp(log1 / (attackTime * sampleRate))
 	}
 	relaxCoef := 0.0
 	if releaseTime != 0 {
-		math.Exp(log1 / (releaseTime * sampleRate))
+		relaxCoef = math.Exp(log1 / (releaseTime * sampleRate))
 	}
 
 	prevIn := 0.0
 
+	inInput := ugen.Input{Name: "in"}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 		// code ported from Supercollider's Amplitdue ugen
 		// https://doc.sccode.org/Classes/Amplitude.html
 
-		in := cfg.InputSamples["in"]
-		_ = in[len(out)-1]
+		in := inInput.Samples(cfg, len(out))
 		for i := range out {
 			val := math.Abs(in[i])
 			if val < prevIn {
@@ -44,7 +43,7 @@ func NewAmplitude(attackTime, releaseTime float64, opts ...ugen.Option) ugen.UGe
 			} else {
 				val = val + (prevIn-val)*clampCoef
 			}
-			prevIn = val
+			prevIn = zapgremlins(val)
 			out[i] = val
 		}
 	})
@@ -12,33 +12,17 @@ func NewBitcrusher() ugen.UGen {
 		defaultDepth = 24
 		defaultRate  = 44100
 	)
-	var defaultRates, defaultDepths []float64
-
 	var count, lastOut float64
 
+	inInput := ugen.Input{Name: "in"}
+	rateInput := ugen.Input{Name: "rate", Default: defaultRate}
+	bitsInput := ugen.Input{Name: "bits", Default: defaultDepth}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 		n := len(out)
 
-		in := cfg.InputSamples["in"]
-		rate := cfg.InputSamples["rate"]
-		bits := cfg.InputSamples["bits"]
-
-		if len(rate) == 0 {
-			if len(defaultRates) < n {
-				for i := len(defaultRates); i < n; i++ {
-					defaultRates = append(defaultRates, defaultRate)
-				}
-			}
-			rate = defaultRates
-		}
-		if len(bits) == 0 {
-			if len(defaultDepths) < n {
-				for i := len(defaultDepths); i < n; i++ {
-					defaultDepths = append(defaultDepths, defaultDepth)
-				}
-			}
-			bits = defaultDepths
-		}
+		in := inInput.Samples(cfg, n)
+		rate := rateInput.Samples(cfg, n)
+		bits := bitsInput.Samples(cfg, n)
 
 		for i := range out {
 			var step, stepr, ratio float64
@@ -51,7 +35,7 @@ func NewBitcrusher() ugen.UGen {
 			}
 			if rate[i] >= float64(cfg.SampleRateHz) {
 				ratio = 1
-			} else {
+			} else if rate[i] > 0 {
 				ratio = rate[i] / float64(cfg.SampleRateHz)
 			}
 
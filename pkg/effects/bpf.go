@@ -13,10 +13,13 @@ func NewBPF() ugen.UGen {
 	var freq, bw float64
 	var y1, y2, a0, b1, b2 float64
 
+	inInput := ugen.Input{Name: "in"}
+	wInput := ugen.Input{Name: "w", Default: 440}
+	bwInput := ugen.Input{Name: "bw", Default: 1}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		ws := cfg.InputSamples["w"]
-		bws := cfg.InputSamples["bw"]
+		in := inInput.Samples(cfg, len(out))
+		ws := wInput.Samples(cfg, len(out))
+		bws := bwInput.Samples(cfg, len(out))
 
 		for i := range out {
 			if ws[i] != freq || bws[i] != bw {
@@ -41,10 +44,12 @@ func NewBPF() ugen.UGen {
 	})
 }
 
+// zapgremlins returns 0 for NaNs, infinities and denormals, and x
+// otherwise.
 func zapgremlins(x float64) float64 {
 	absx := math.Abs(x)
-	if absx < math.SmallestNonzeroFloat64 || absx > math.MaxFloat64 {
-		return 0
+	if absx >= math.SmallestNonzeroFloat64 && absx <= math.MaxFloat64 {
+		return x
 	}
-	return x
+	return 0
 }
@@ -0,0 +1,56 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// NewChorus returns a chorus ugen: voices copies of its input, each
+// delayed by a time swept by an LFO, their LFOs spread evenly through
+// a period, and mixed with the input. phase offsets the LFOs, in
+// periods, so that the channels of a stereo chorus sweep apart.
+//
+// Its "rate" input is the LFO rate in Hz; "delay" the delay, in
+// seconds, around which the voices sweep; "depth" how far they sweep
+// either way, in seconds; and "mix" the dry/wet mix, from 0 (dry) to
+// 1 (wet).
+func NewChorus(voices int, phase float64) ugen.UGen {
+	if voices < 1 {
+		panic(fmt.Errorf("chorus: voices must be positive, got %d", voices))
+	}
+
+	delayLine := NewDelayLine(conf.SampleRate, maxModDelay)
+	var osc lfo
+
+	inInput := ugen.Input{Name: "in"}
+	rateInput := ugen.Input{Name: "rate", Default: 0.5}
+	delayInput := ugen.Input{Name: "delay", Default: 0.015}
+	depthInput := ugen.Input{Name: "depth", Default: 0.003}
+	mixInput := ugen.Input{Name: "mix", Default: 0.5}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		rates := rateInput.Samples(cfg, len(out))
+		delays := delayInput.Samples(cfg, len(out))
+		depths := depthInput.Samples(cfg, len(out))
+		mixes := mixInput.Samples(cfg, len(out))
+		sampleRate := float64(cfg.SampleRateHz)
+
+		for i := range out {
+			x := zapgremlins(in[i])
+			delayLine.WriteSample(x)
+
+			var wet float64
+			for v := 0; v < voices; v++ {
+				sweep := osc.at(phase + float64(v)/float64(voices))
+				wet += delayLine.TapC(delays[i] + depths[i]*sweep)
+			}
+			wet /= float64(voices)
+			osc.advance(rates[i], sampleRate)
+
+			out[i] = (1-mixes[i])*x + mixes[i]*wet
+		}
+	})
+}
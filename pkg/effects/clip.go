@@ -7,10 +7,13 @@ import (
 )
 
 func NewClip() ugen.UGen {
+	inInput := ugen.Input{Name: "in"}
+	loInput := ugen.Input{Name: "lo", Default: -1}
+	hiInput := ugen.Input{Name: "hi", Default: 1}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		los := cfg.InputSamples["lo"]
-		his := cfg.InputSamples["hi"]
+		in := inInput.Samples(cfg, len(out))
+		los := loInput.Samples(cfg, len(out))
+		his := hiInput.Samples(cfg, len(out))
 
 		for i := range out {
 			x := in[i]
@@ -0,0 +1,324 @@
+package effects
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/freeverb-go"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+)
+
+func TestConformance(t *testing.T) {
+	sine := ugentest.Sine(440)
+	ugentest.Run(t,
+		ugentest.Spec{
+			Name: "allpass",
+			New:  func() ugen.UGen { return NewAllPass(0.1) },
+			Inputs: map[string]ugentest.Signal{
+				"in":        sine,
+				"delaytime": ugentest.Const(0.01),
+				"decaytime": ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name:   "amplitude",
+			New:    func() ugen.UGen { return NewAmplitude(0.01, 0.1) },
+			Inputs: map[string]ugentest.Signal{"in": sine},
+		},
+		ugentest.Spec{
+			Name: "apply-gain",
+			New:  func() ugen.UGen { return NewApplyGain(0.005) },
+			Inputs: map[string]ugentest.Signal{
+				"in":             sine,
+				"gain-reduction": ugentest.Const(6),
+				"makeup":         ugentest.Const(3),
+			},
+		},
+		ugentest.Spec{
+			Name: "bitcrusher",
+			New:  NewBitcrusher,
+			Inputs: map[string]ugentest.Signal{
+				"in":   sine,
+				"rate": ugentest.Const(8000),
+				"bits": ugentest.Const(4),
+			},
+		},
+		ugentest.Spec{
+			Name: "bpf",
+			New:  NewBPF,
+			Inputs: map[string]ugentest.Signal{
+				"in": sine,
+				"w":  ugentest.Const(440),
+				"bw": ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "chorus",
+			New:  func() ugen.UGen { return NewChorus(3, 0) },
+			Inputs: map[string]ugentest.Signal{
+				"in":   sine,
+				"rate": ugentest.Const(1),
+				"mix":  ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "clip",
+			New:  NewClip,
+			Inputs: map[string]ugentest.Signal{
+				"in": sine,
+				"lo": ugentest.Const(-0.5),
+				"hi": ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "compressor",
+			New:  NewCompressor,
+			Inputs: map[string]ugentest.Signal{
+				"$0":        sine,
+				"$1":        ugentest.Gate(2),
+				"threshold": ugentest.Const(-12),
+				"ratio":     ugentest.Const(4),
+				"knee":      ugentest.Const(6),
+				"attack":    ugentest.Const(0.005),
+				"release":   ugentest.Const(0.1),
+			},
+		},
+		ugentest.Spec{
+			Name: "convolve",
+			New:  func() ugen.UGen { return NewConvolver(decayIR(0.05, 0.3), 0.1) },
+			Inputs: map[string]ugentest.Signal{
+				"in":        sine,
+				"mix":       ugentest.Const(0.7),
+				"pre-delay": ugentest.Const(0.02),
+			},
+		},
+		ugentest.Spec{
+			Name:   "delay",
+			New:    func() ugen.UGen { return NewDelay(0.1) },
+			Inputs: map[string]ugentest.Signal{"in": sine, "delay": ugentest.Const(0.01)},
+		},
+		ugentest.Spec{
+			Name: "ensemble",
+			New:  func() ugen.UGen { return NewEnsemble(0) },
+			Inputs: map[string]ugentest.Signal{
+				"in":   sine,
+				"rate": ugentest.Const(0.6),
+				"mix":  ugentest.Const(1),
+			},
+		},
+		ugentest.Spec{
+			Name: "expander",
+			New:  NewExpander,
+			Inputs: map[string]ugentest.Signal{
+				"$0":        ugentest.Gate(2),
+				"threshold": ugentest.Const(-20),
+				"ratio":     ugentest.Const(3),
+				"knee":      ugentest.Const(6),
+				"attack":    ugentest.Const(0.001),
+				"release":   ugentest.Const(0.05),
+				"range":     ugentest.Const(40),
+			},
+		},
+		ugentest.Spec{
+			Name: "fdn",
+			New:  func() ugen.UGen { return NewFDN(8, "hadamard", 0) },
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"decay": ugentest.Const(1),
+				"mix":   ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "flanger",
+			New:  func() ugen.UGen { return NewFlanger(0, true) },
+			Inputs: map[string]ugentest.Signal{
+				"in":       sine,
+				"rate":     ugentest.Const(0.5),
+				"feedback": ugentest.Const(-0.7),
+				"mix":      ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "freeverb",
+			New:  func() ugen.UGen { return NewFreeverb(freeverb.NewRevModel()) },
+			// the model is tuned for 44.1kHz.
+			FixedRate: true,
+			Inputs: map[string]ugentest.Signal{
+				"in":        ugentest.Gate(2),
+				"room-size": ugentest.Const(0.5),
+				"damp":      ugentest.Const(0.5),
+				"mix":       ugentest.Const(0.3),
+			},
+		},
+		ugentest.Spec{
+			Name: "hishelf",
+			New:  NewHiShelf,
+			Inputs: map[string]ugentest.Signal{
+				"in": sine,
+				"w":  ugentest.Const(1000),
+				"rs": ugentest.Const(1),
+				"db": ugentest.Const(6),
+			},
+		},
+		ugentest.Spec{
+			Name:   "hpf",
+			New:    NewHPF,
+			Inputs: map[string]ugentest.Signal{"in": sine, "freq": ugentest.Const(1000)},
+		},
+		ugentest.Spec{
+			Name: "ladder",
+			New:  NewLadder,
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"freq":  ugentest.Const(1000),
+				"res":   ugentest.Const(0.5),
+				"drive": ugentest.Const(2),
+			},
+		},
+		ugentest.Spec{
+			Name:   "limiter",
+			New:    func() ugen.UGen { return NewLimiter(0.01) },
+			Inputs: map[string]ugentest.Signal{"in": sine, "amp": ugentest.Const(0.5)},
+		},
+		ugentest.Spec{
+			Name: "lores",
+			New:  NewLowpassFilter,
+			Inputs: map[string]ugentest.Signal{
+				"in":        sine,
+				"cutoff":    ugentest.Const(1000),
+				"resonance": ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "loshelf",
+			New:  NewLoShelf,
+			Inputs: map[string]ugentest.Signal{
+				"in": sine,
+				"w":  ugentest.Const(1000),
+				"rs": ugentest.Const(1),
+				"db": ugentest.Const(6),
+			},
+		},
+		ugentest.Spec{
+			Name:   "lpf",
+			New:    NewLPF,
+			Inputs: map[string]ugentest.Signal{"in": sine, "freq": ugentest.Const(1000)},
+		},
+		ugentest.Spec{
+			Name: "moogff",
+			New:  NewMoogFF,
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"freq":  ugentest.Const(1000),
+				"gain":  ugentest.Const(2),
+				"reset": ugentest.Const(0),
+			},
+		},
+		ugentest.Spec{
+			Name: "peakeq",
+			New:  NewPeakEQ,
+			Inputs: map[string]ugentest.Signal{
+				"in": sine,
+				"w":  ugentest.Const(1000),
+				"rq": ugentest.Const(1),
+				"db": ugentest.Const(6),
+			},
+		},
+		ugentest.Spec{
+			Name: "phaser",
+			New:  func() ugen.UGen { return NewPhaser(4, 0) },
+			Inputs: map[string]ugentest.Signal{
+				"in":       sine,
+				"rate":     ugentest.Const(0.5),
+				"feedback": ugentest.Const(0.7),
+				"mix":      ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "pitchshift",
+			New:  func() ugen.UGen { return NewPitchShift(ugen.WithSeed(1)) },
+			Inputs: map[string]ugentest.Signal{
+				"in":              sine,
+				"pitchRatio":      ugentest.Const(1.5),
+				"windowSize":      ugentest.Const(0.2),
+				"pitchDispersion": ugentest.Const(0),
+				"timeDispersion":  ugentest.Const(0),
+			},
+		},
+		ugentest.Spec{
+			Name: "plate",
+			New:  func() ugen.UGen { return NewPlate(0) },
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"decay": ugentest.Const(1),
+				"mix":   ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "rhpf",
+			New:  NewRHPF,
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"freq":  ugentest.Const(1000),
+				"reson": ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "rlpf",
+			New:  NewRLPF,
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"freq":  ugentest.Const(1000),
+				"reson": ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name: "sallen-key",
+			New:  func() ugen.UGen { return NewSallenKey("lp") },
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"freq":  ugentest.Const(1000),
+				"res":   ugentest.Const(0.5),
+				"drive": ugentest.Const(2),
+			},
+		},
+		ugentest.Spec{
+			Name: "svf",
+			New:  func() ugen.UGen { return newSVFMode("bp") },
+			Inputs: map[string]ugentest.Signal{
+				"in":   sine,
+				"freq": ugentest.Const(1000),
+				"res":  ugentest.Const(0.5),
+			},
+		},
+		ugentest.Spec{
+			Name:   "tapedelay",
+			New:    NewTapeDelay,
+			Inputs: map[string]ugentest.Signal{"$0": sine, "delay": ugentest.Const(0.01)},
+		},
+		ugentest.Spec{
+			Name: "wavefolder",
+			New:  func() ugen.UGen { return NewWaveFolder() },
+			Inputs: map[string]ugentest.Signal{
+				"in": sine,
+				"lo": ugentest.Const(-0.5),
+				"hi": ugentest.Const(0.5),
+			},
+		},
+	)
+}
+
+// decayIR returns an impulse response of the given length in seconds,
+// at conf.SampleRate, that decays exponentially with the given time
+// constant. It's scaled so that its response doesn't depend on the
+// sample rate.
+func decayIR(tau, seconds float64) []float64 {
+	ir := make([]float64, int(seconds*float64(conf.SampleRate)))
+	for i := range ir {
+		t := float64(i) / float64(conf.SampleRate)
+		ir[i] = math.Exp(-t/tau) / (tau * float64(conf.SampleRate))
+	}
+	return ir
+}
@@ -0,0 +1,170 @@
+package effects
+
+import (
+	"context"
+	"math"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// convPartitionSize is the number of samples in each partition of a
+// convolver's impulse response. The first partition is convolved
+// directly, sample by sample, and the rest with FFTs of twice its
+// size once per partition, so it trades the cost of the one against
+// the other.
+const convPartitionSize = 256
+
+type (
+	// convolver convolves a signal with an impulse response, with no
+	// latency. The first partition of the response is convolved
+	// directly; the rest are convolved a partition at a time by
+	// uniformly partitioned overlap-save, from the spectra of the
+	// blocks of input that precede the partition being output.
+	convolver struct {
+		// head is the first partition of the impulse response,
+		// reversed.
+		head []float64
+		// hist holds the last partition of the input twice over, so
+		// that the samples ending at any position are contiguous.
+		hist    []float64
+		histPos int
+
+		fft *fourier.FFT
+		// parts holds the spectra of the partitions of the impulse
+		// response after the first.
+		parts [][]complex128
+		// spectra holds the spectra of the last len(parts) blocks of
+		// input, the newest at spectraPos.
+		spectra    [][]complex128
+		spectraPos int
+
+		// block holds the last two blocks of input, the second
+		// filled up to blockPos.
+		block    []float64
+		blockPos int
+		// tail is the output of all partitions but the first for the
+		// current block.
+		tail []float64
+
+		acc []complex128
+		seq []float64
+	}
+)
+
+func newConvolver(ir []float64) *convolver {
+	const n = convPartitionSize
+	c := &convolver{
+		head:  make([]float64, n),
+		hist:  make([]float64, 2*n),
+		fft:   fourier.NewFFT(2 * n),
+		block: make([]float64, 2*n),
+		tail:  make([]float64, n),
+		acc:   make([]complex128, n+1),
+		seq:   make([]float64, 2*n),
+	}
+	for i := 0; i < n && i < len(ir); i++ {
+		c.head[n-1-i] = ir[i]
+	}
+	for start := n; start < len(ir); start += n {
+		clear(c.seq)
+		copy(c.seq, ir[start:min(start+n, len(ir))])
+		c.parts = append(c.parts, c.fft.Coefficients(nil, c.seq))
+		c.spectra = append(c.spectra, make([]complex128, n+1))
+	}
+	return c
+}
+
+// process returns the next sample of the convolution of the input
+// with the impulse response, given the next sample of input.
+func (c *convolver) process(x float64) float64 {
+	const n = convPartitionSize
+	c.histPos = (c.histPos + 1) % n
+	c.hist[c.histPos] = x
+	c.hist[c.histPos+n] = x
+	y := c.tail[c.blockPos]
+	for i, h := range c.head {
+		y += h * c.hist[c.histPos+1+i]
+	}
+
+	c.block[n+c.blockPos] = x
+	c.blockPos++
+	if c.blockPos == n {
+		c.nextBlock()
+	}
+	return y
+}
+
+// nextBlock computes the tail of the next block of output, from the
+// block of input that's just been completed and those before it.
+func (c *convolver) nextBlock() {
+	const n = convPartitionSize
+	c.blockPos = 0
+	if len(c.parts) == 0 {
+		return
+	}
+
+	c.spectraPos = (c.spectraPos + 1) % len(c.spectra)
+	c.fft.Coefficients(c.spectra[c.spectraPos], c.block)
+	copy(c.block, c.block[n:])
+
+	// the newest block of input is delayed by one partition, so it's
+	// convolved with the second partition of the response, the block
+	// before it with the third, and so on.
+	clear(c.acc)
+	for p, part := range c.parts {
+		spectrum := c.spectra[(c.spectraPos-p+len(c.spectra))%len(c.spectra)]
+		for k, h := range part {
+			c.acc[k] += h * spectrum[k]
+		}
+	}
+	c.fft.Sequence(c.seq, c.acc)
+	// the first half of the sequence is the circular wrap of the
+	// previous block; the second is the linear convolution.
+	for i := range c.tail {
+		c.tail[i] = c.seq[n+i] / (2 * n)
+	}
+}
+
+// NewConvolver returns a ugen that convolves its "in" input with the
+// impulse response ir, with no latency. Its "mix" input, from 0 to 1,
+// crossfades between the dry input and the convolved signal, and its
+// "pre-delay" input delays the convolved signal by up to maxPreDelay
+// seconds.
+func NewConvolver(ir []float64, maxPreDelay float64) ugen.UGen {
+	conv := newConvolver(ir)
+	delayLine := NewDelayLine(conf.SampleRate, maxPreDelay)
+	var preDelay float64
+
+	inInput := ugen.Input{Name: "in"}
+	mixInput := ugen.Input{Name: "mix", Default: 1}
+	preDelayInput := ugen.Input{Name: "pre-delay"}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		mixes := mixInput.Samples(cfg, len(out))
+		preDelays := preDelayInput.Samples(cfg, len(out))
+
+		for i := range out {
+			// a NaN or infinity would stay in the convolution for the
+			// length of the response.
+			x := zapgremlins(in[i])
+
+			newPreDelay := math.Max(0, math.Min(maxPreDelay, preDelays[i]))
+			if newPreDelay != preDelay && !math.IsNaN(newPreDelay) {
+				delayLine.SetDelaySeconds(newPreDelay)
+				preDelay = newPreDelay
+			}
+			delayLine.WriteSample(x)
+			wet := conv.process(delayLine.ReadSampleN())
+
+			mix := mixes[i]
+			if math.IsNaN(mix) {
+				mix = 1
+			}
+			mix = math.Max(0, math.Min(1, mix))
+			out[i] = (1-mix)*x + mix*wet
+		}
+	})
+}
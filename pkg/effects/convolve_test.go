@@ -0,0 +1,105 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// renderBlocks renders a ugen over the given inputs in blocks of
+// varying sizes, to exercise blocks that don't line up with a
+// convolver's partitions.
+func renderBlocks(u ugen.UGen, inputs map[string][]float64) []float64 {
+	n := len(inputs["in"])
+	out := make([]float64, n)
+	sizes := []int{1, 7, 61, 128, 300}
+	for start, i := 0, 0; start < n; i++ {
+		end := min(start+sizes[i%len(sizes)], n)
+		cfg := ugen.SampleConfig{
+			SampleRateHz: conf.SampleRate,
+			InputSamples: map[string][]float64{},
+		}
+		for port, in := range inputs {
+			cfg.InputSamples[port] = in[start:end]
+		}
+		u.Gen(context.Background(), cfg, out[start:end])
+		start = end
+	}
+	return out
+}
+
+// directConvolution convolves x with h, truncated to the length of x.
+func directConvolution(x, h []float64) []float64 {
+	y := make([]float64, len(x))
+	for i := range y {
+		for j := 0; j < len(h) && j <= i; j++ {
+			y[i] += h[j] * x[i-j]
+		}
+	}
+	return y
+}
+
+func TestConvolver(t *testing.T) {
+	const n = 6000
+	x := audiotest.Noise(1, n)
+	for _, irLen := range []int{1, 100, convPartitionSize, convPartitionSize + 1, 1000, 5000} {
+		t.Run(fmt.Sprint(irLen), func(t *testing.T) {
+			ir := audiotest.Noise(2, irLen)
+			got := renderBlocks(NewConvolver(ir, 0), map[string][]float64{"in": x})
+			want := directConvolution(x, ir)
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-9 {
+					t.Fatalf("sample %d is %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConvolverMixAndPreDelay(t *testing.T) {
+	const n = 2000
+	x := audiotest.Noise(1, n)
+	ir := []float64{0, 0.5}
+	constant := func(v float64) []float64 {
+		res := make([]float64, n)
+		for i := range res {
+			res[i] = v
+		}
+		return res
+	}
+
+	const delaySamples = 100
+	got := renderBlocks(NewConvolver(ir, 0.1), map[string][]float64{
+		"in":        x,
+		"mix":       constant(0.25),
+		"pre-delay": constant(delaySamples / float64(conf.SampleRate)),
+	})
+	for i := range got {
+		// the response delays by one sample, after the pre-delay.
+		wet := 0.0
+		if j := i - delaySamples - 1; j >= 0 {
+			wet = 0.5 * x[j]
+		}
+		if want := 0.75*x[i] + 0.25*wet; math.Abs(got[i]-want) > 1e-9 {
+			t.Fatalf("sample %d is %v, want %v", i, got[i], want)
+		}
+	}
+}
+
+func TestConvolverNonFinite(t *testing.T) {
+	const n = 3000
+	x := audiotest.Impulse(n)
+	x[10] = math.NaN()
+	x[20] = math.Inf(1)
+	got := renderBlocks(NewConvolver(audiotest.Noise(2, 1000), 0), map[string][]float64{"in": x})
+	for i, s := range got {
+		if math.IsNaN(s) || math.IsInf(s, 0) {
+			t.Fatalf("sample %d is %v", i, s)
+		}
+	}
+}
@@ -29,9 +29,11 @@ func NewDelay(maxDelay float64, opts ...ugen.Option) ugen.UGen {
 		panic("unknown interpolation type")
 	}
 
+	inInput := ugen.Input{Name: "in"}
+	delayInput := ugen.Input{Name: "delay", Default: 0.2}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		delays := cfg.InputSamples["delay"]
+		in := inInput.Samples(cfg, len(out))
+		delays := delayInput.Samples(cfg, len(out))
 
 		for i := range out {
 			newDelaySeconds := math.Max(0, delays[i])
@@ -77,6 +77,9 @@ func (dl *DelayLine) ReadSampleC() float64 {
 
 // SetDelaySeconds sets the delay time in seconds.
 func (dl *DelayLine) SetDelaySeconds(delaySec float64) {
+	if math.IsNaN(delaySec) {
+		delaySec = 0
+	}
 	if delaySec > dl.maxDelay {
 		delaySec = dl.maxDelay
 	}
@@ -97,3 +100,24 @@ func (dl *DelayLine) SetDelaySeconds(delaySec float64) {
 	dl.readPosInt = int(math.Floor(readPosFloat)) & dl.idxMask
 	dl.readPosFrac = readPosFloat - math.Floor(readPosFloat)
 }
+
+// TapC reads the sample delaySec seconds before the most recently
+// written one, using cubic interpolation, without moving the read
+// position, so that a line can be read at several modulated delays
+// at once. The delay is clamped to between one sample, the shortest
+// the interpolation can read, and the line's maximum delay.
+func (dl *DelayLine) TapC(delaySec float64) float64 {
+	d := math.Min(delaySec*dl.sampleRateHz, dl.maxDelay*dl.sampleRateHz)
+	d = math.Min(d, float64(len(dl.buf)-3))
+	if !(d > 1) {
+		d = 1
+	}
+	pos := float64(dl.writePos-1) - d
+	posInt := math.Floor(pos)
+	idx := int(posInt)
+	return ugen.CubInterp(pos-posInt,
+		dl.buf[(idx-1)&dl.idxMask],
+		dl.buf[idx&dl.idxMask],
+		dl.buf[(idx+1)&dl.idxMask],
+		dl.buf[(idx+2)&dl.idxMask])
+}
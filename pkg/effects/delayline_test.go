@@ -101,3 +101,33 @@ func TestDelayLine(t *testing.T) {
 		})
 	}
 }
+
+func TestDelayLineTapC(t *testing.T) {
+	const sampleRate = 1000
+	dl := NewDelayLine(sampleRate, 0.1)
+	// a ramp, which cubic interpolation reproduces exactly.
+	for i := 0; i < 500; i++ {
+		dl.WriteSample(float64(i))
+	}
+	for _, tc := range []struct {
+		delay, want float64
+	}{
+		{0.0105, 499 - 10.5},
+		{0.05, 499 - 50},
+		{0.0012, 499 - 1.2},
+		// clamped to the shortest and longest delays.
+		{0, 498},
+		{math.NaN(), 498},
+		{1, 499 - 100},
+	} {
+		if got := dl.TapC(tc.delay); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("TapC(%v) = %v, want %v", tc.delay, got, tc.want)
+		}
+	}
+	// reading a tap doesn't move the read position.
+	dl.SetDelaySeconds(0.002)
+	dl.TapC(0.05)
+	if got := dl.ReadSampleN(); got != 498 {
+		t.Errorf("got %v after a tap, want 498", got)
+	}
+}
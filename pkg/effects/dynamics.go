@@ -0,0 +1,213 @@
+package effects
+
+import (
+	"context"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// gainComputer computes the gain reduction of a compressor or an
+	// expander from the level of its detection inputs.
+	gainComputer struct {
+		// expand is true for downward expansion below the threshold,
+		// false for compression above it.
+		expand bool
+
+		thresholdInput ugen.Input
+		ratioInput     ugen.Input
+		kneeInput      ugen.Input
+		attackInput    ugen.Input
+		releaseInput   ugen.Input
+		rangeInput     ugen.Input
+
+		// held holds the peaks of the inputs since each of the last
+		// two resets, which alternate every detectorHold seconds;
+		// the larger is the peak over at least that long. elapsed
+		// counts the samples since the last reset.
+		held    [2]float64
+		elapsed float64
+
+		// peak is the detected peak level, which falls at the
+		// detector's release once the held peak does.
+		peak float64
+
+		// gr is the smoothed gain reduction in dB.
+		gr  float64
+		ins [][]float64
+	}
+)
+
+const (
+	// detectorHold is the least time, in seconds, for which the level
+	// detector of a compressor or expander holds a peak: a period of
+	// the lowest audible frequency, so that the level doesn't fall
+	// between the peaks of a low note.
+	detectorHold = 0.05
+
+	// detectorRelease is the time constant, in seconds, with which
+	// the level detector falls once a peak is no longer held.
+	detectorRelease = 0.05
+)
+
+// NewCompressor returns a ugen that outputs the gain reduction in dB
+// of a compressor whose level is detected from the peaks of its
+// indexed inputs, "$0", "$1" and so on; several inputs share one gain
+// reduction, from the loudest of them. Peaks are held for at least
+// 50ms, so that the level doesn't fall at each zero crossing of a low
+// note. Levels above the "threshold" input, in dB, are reduced by the
+// "ratio" input, with a soft knee "knee" dB wide around the
+// threshold. The reduction rises over the "attack" input and falls
+// over the "release" input, both in seconds.
+func NewCompressor() ugen.UGen {
+	return newGainComputer(false, -20, 4)
+}
+
+// NewExpander returns a ugen that outputs the gain reduction in dB of
+// a downward expander. It's like NewCompressor, but levels below the
+// threshold are pushed down, by the ratio for each dB below it, up to
+// the "range" input in dB. The reduction falls over the "attack" input,
+// as the expander opens, and rises over the "release" input.
+func NewExpander() ugen.UGen {
+	return newGainComputer(true, -40, 2)
+}
+
+func newGainComputer(expand bool, threshold, ratio float64) *gainComputer {
+	return &gainComputer{
+		expand:         expand,
+		thresholdInput: ugen.Input{Name: "threshold", Default: threshold},
+		ratioInput:     ugen.Input{Name: "ratio", Default: ratio},
+		kneeInput:      ugen.Input{Name: "knee"},
+		attackInput:    ugen.Input{Name: "attack", Default: 0.01},
+		releaseInput:   ugen.Input{Name: "release", Default: 0.1},
+		rangeInput:     ugen.Input{Name: "range", Default: 60},
+	}
+}
+
+func (g *gainComputer) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	g.ins = ugen.AppendIndexedInputs(g.ins[:0], cfg)
+	thresholds := g.thresholdInput.Samples(cfg, len(out))
+	ratios := g.ratioInput.Samples(cfg, len(out))
+	knees := g.kneeInput.Samples(cfg, len(out))
+	attacks := g.attackInput.Samples(cfg, len(out))
+	releases := g.releaseInput.Samples(cfg, len(out))
+	ranges := g.rangeInput.Samples(cfg, len(out))
+	sampleRate := float64(cfg.SampleRateHz)
+	releaseCoef := math.Exp(-1 / (detectorRelease * sampleRate))
+
+	for i := range out {
+		peak := 0.0
+		for _, in := range g.ins {
+			if len(in) > 0 {
+				peak = math.Max(peak, math.Abs(zapgremlins(in[i])))
+			}
+		}
+		if g.elapsed >= detectorHold*sampleRate {
+			g.held[0], g.held[1] = g.held[1], 0
+			g.elapsed = 0
+		}
+		g.elapsed++
+		g.held[0] = math.Max(g.held[0], peak)
+		g.held[1] = math.Max(g.held[1], peak)
+		held := math.Max(g.held[0], g.held[1])
+		if held >= g.peak {
+			g.peak = held
+		} else {
+			g.peak = held + releaseCoef*(g.peak-held)
+		}
+		// -200dB stands in for silence, well below any threshold.
+		level := math.Max(-200, 20*math.Log10(g.peak))
+
+		var target float64
+		if g.expand {
+			target = expansion(level, thresholds[i], ratios[i], knees[i], ranges[i])
+		} else {
+			target = compression(level, thresholds[i], ratios[i], knees[i])
+		}
+		// an infinite threshold would otherwise be held by the
+		// smoothing.
+		if math.IsNaN(target) || math.IsInf(target, 0) {
+			target = 0
+		}
+
+		// the compressor attacks as the reduction rises, and the
+		// expander as it falls.
+		t := releases[i]
+		if (target > g.gr) != g.expand {
+			t = attacks[i]
+		}
+		coef := 0.0
+		if t > 0 {
+			coef = math.Exp(-1 / (t * sampleRate))
+		}
+		g.gr = target + coef*(g.gr-target)
+		out[i] = g.gr
+	}
+}
+
+// compression returns the gain reduction in dB of a compressor for a
+// signal at level dB.
+func compression(level, threshold, ratio, knee float64) float64 {
+	slope := 1 - 1/math.Max(1, ratio)
+	over := level - threshold
+	knee = math.Max(0, knee)
+	switch {
+	case 2*over <= -knee:
+		return 0
+	case 2*over < knee:
+		x := over + knee/2
+		return slope * x * x / (2 * knee)
+	default:
+		return slope * over
+	}
+}
+
+// expansion returns the gain reduction in dB of a downward expander
+// for a signal at level dB.
+func expansion(level, threshold, ratio, knee, rng float64) float64 {
+	slope := math.Max(1, ratio) - 1
+	under := threshold - level
+	knee = math.Max(0, knee)
+	var gr float64
+	switch {
+	case 2*under <= -knee:
+		gr = 0
+	case 2*under < knee:
+		x := under + knee/2
+		gr = slope * x * x / (2 * knee)
+	default:
+		gr = slope * under
+	}
+	return math.Min(gr, math.Max(0, rng))
+}
+
+// NewApplyGain returns a ugen that applies the gain reduction of a
+// compressor or expander, its "gain-reduction" input in dB, to its
+// "in" input, followed by its "makeup" input, a gain in dB. The input
+// is delayed by lookahead seconds, so that the gain reduction, from a
+// detector that isn't delayed, anticipates it.
+func NewApplyGain(lookahead float64) ugen.UGen {
+	var delayLine *DelayLine
+	var sampleRate int
+
+	inInput := ugen.Input{Name: "in"}
+	grInput := ugen.Input{Name: "gain-reduction"}
+	makeupInput := ugen.Input{Name: "makeup"}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		if delayLine == nil || cfg.SampleRateHz != sampleRate {
+			sampleRate = cfg.SampleRateHz
+			delayLine = NewDelayLine(sampleRate, lookahead)
+			delayLine.SetDelaySeconds(lookahead)
+		}
+
+		in := inInput.Samples(cfg, len(out))
+		grs := grInput.Samples(cfg, len(out))
+		makeups := makeupInput.Samples(cfg, len(out))
+
+		for i := range out {
+			delayLine.WriteSample(in[i])
+			out[i] = delayLine.ReadSampleN() * math.Pow(10, (makeups[i]-grs[i])/20)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package effects
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+func TestGainCurves(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"compression below", compression(-30, -20, 4, 0), 0},
+		{"compression above", compression(-10, -20, 4, 0), 7.5},
+		{"compression knee start", compression(-23, -20, 4, 6), 0},
+		{"compression knee middle", compression(-20, -20, 4, 6), 0.5625},
+		{"compression knee end", compression(-17, -20, 4, 6), 2.25},
+		{"compression ratio 1", compression(0, -20, 1, 0), 0},
+		{"expansion above", expansion(-30, -40, 2, 0, 60), 0},
+		{"expansion below", expansion(-50, -40, 2, 0, 60), 10},
+		{"expansion knee end", expansion(-43, -40, 3, 6, 60), 6},
+		{"expansion range", expansion(-200, -40, 2, 0, 60), 60},
+	} {
+		if math.Abs(tc.got-tc.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+// constant returns n samples of v.
+func constant(v float64, n int) []float64 {
+	res := make([]float64, n)
+	for i := range res {
+		res[i] = v
+	}
+	return res
+}
+
+// scale returns x scaled by a.
+func scale(a float64, x []float64) []float64 {
+	res := make([]float64, len(x))
+	for i := range x {
+		res[i] = a * x[i]
+	}
+	return res
+}
+
+func TestCompressor(t *testing.T) {
+	n := conf.SampleRate / 2
+	for _, tc := range []struct {
+		amp, knee float64
+		wantGR    float64
+	}{
+		{0.01, 0, 0}, // -40dB, below the threshold
+		{1, 0, 15},   // 0dB, 20dB over
+		{0.1, 6, 0.5625},
+	} {
+		out, err := audiotest.RenderUGen(context.Background(), NewCompressor(), map[string][]float64{
+			"$0":        constant(tc.amp, n),
+			"threshold": {-20},
+			"ratio":     {4},
+			"knee":      {tc.knee},
+			"attack":    {0.01},
+		}, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := out.Slice(0.4, 0.5).DC(); math.Abs(got-tc.wantGR) > 0.01 {
+			t.Errorf("amplitude %v: got gain reduction %v, want %v", tc.amp, got, tc.wantGR)
+		}
+	}
+}
+
+func TestExpander(t *testing.T) {
+	n := conf.SampleRate
+	for _, tc := range []struct {
+		amp    float64
+		wantGR float64
+	}{
+		{0.1, 0},    // -20dB, above the threshold
+		{0.001, 20}, // -60dB, 20dB below
+		{1e-6, 60},  // held at the range
+	} {
+		out, err := audiotest.RenderUGen(context.Background(), NewExpander(), map[string][]float64{
+			"$0":        constant(tc.amp, n),
+			"threshold": {-40},
+			"ratio":     {2},
+			"range":     {60},
+		}, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := out.Slice(0.9, 1).DC(); math.Abs(got-tc.wantGR) > 0.01 {
+			t.Errorf("amplitude %v: got gain reduction %v, want %v", tc.amp, got, tc.wantGR)
+		}
+	}
+}
+
+func TestCompressorLowFrequency(t *testing.T) {
+	// the level of a low note doesn't fall at its zero crossings, so
+	// the gain reduction holds steady despite a fast release.
+	n := conf.SampleRate
+	out, err := audiotest.RenderUGen(context.Background(), NewCompressor(), map[string][]float64{
+		"$0":        audiotest.Sine(40, n),
+		"threshold": {-20},
+		"ratio":     {4},
+		"attack":    {0.001},
+		"release":   {0.01},
+	}, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, gr := range out.Slice(0.5, 1).Mono() {
+		lo, hi = math.Min(lo, gr), math.Max(hi, gr)
+	}
+	if lo < 14.9 || hi > 15.01 {
+		t.Errorf("got gain reduction from %v to %v, want a steady 15", lo, hi)
+	}
+}
+
+func TestCompressorLinked(t *testing.T) {
+	// the loudest of the inputs sets the reduction.
+	n := conf.SampleRate / 2
+	out, err := audiotest.RenderUGen(context.Background(), NewCompressor(), map[string][]float64{
+		"$0":        constant(0.01, n),
+		"$1":        constant(-1, n),
+		"threshold": {-20},
+		"ratio":     {2},
+	}, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Slice(0.4, 0.5).DC(); math.Abs(got-10) > 0.01 {
+		t.Errorf("got gain reduction %v, want 10", got)
+	}
+}
+
+func TestSidechainDucking(t *testing.T) {
+	// a pad ducked by a kick every half second.
+	n := conf.SampleRate
+	pad := scale(0.5, audiotest.Sine(220, n))
+	kick := make([]float64, n)
+	for i := range kick {
+		if t := float64(i%(n/2)) / float64(conf.SampleRate); t < 0.05 {
+			kick[i] = math.Sin(2 * math.Pi * 60 * t)
+		}
+	}
+	gr, err := audiotest.RenderUGen(context.Background(), NewCompressor(), map[string][]float64{
+		"$0":        kick,
+		"threshold": {-30},
+		"ratio":     {8},
+		"attack":    {0.001},
+		"release":   {0.05},
+	}, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := audiotest.RenderUGen(context.Background(), NewApplyGain(0), map[string][]float64{
+		"in":             pad,
+		"gain-reduction": gr.Mono(),
+	}, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// during a kick, the pad is ducked; before the next, it's back.
+	if rms := out.Slice(0.51, 0.54).RMS(); rms > 0.05 {
+		t.Errorf("got RMS %v during the kick, want the pad ducked", rms)
+	}
+	if rms := out.Slice(0.4, 0.5).RMS(); math.Abs(rms-0.5/math.Sqrt2) > 0.01 {
+		t.Errorf("got RMS %v between kicks, want %v", rms, 0.5/math.Sqrt2)
+	}
+}
+
+func TestApplyGain(t *testing.T) {
+	const n, delay = 1000, 64
+	in := audiotest.Noise(1, n)
+	// the lookahead is in seconds, at whatever rate the graph runs.
+	for _, sampleRate := range []int{conf.SampleRate, 96000} {
+		u := NewApplyGain(float64(delay) / float64(sampleRate))
+		cfg := ugen.SampleConfig{
+			SampleRateHz: sampleRate,
+			InputSamples: map[string][]float64{
+				"in":             in,
+				"gain-reduction": constant(12, n),
+				"makeup":         constant(6, n),
+			},
+		}
+		out := make([]float64, n)
+		u.Gen(context.Background(), cfg, out)
+		g := math.Pow(10, -6.0/20)
+		for i := range out {
+			want := 0.0
+			if i >= delay {
+				want = g * in[i-delay]
+			}
+			if math.Abs(out[i]-want) > 1e-12 {
+				t.Fatalf("%vHz: sample %d is %v, want %v", sampleRate, i, out[i], want)
+			}
+		}
+	}
+}
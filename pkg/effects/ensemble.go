@@ -0,0 +1,64 @@
+package effects
+
+import (
+	"context"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+const (
+	// ensembleDelay is the delay, in seconds, around which the taps of
+	// the ensemble sweep.
+	ensembleDelay = 0.008
+	// ensembleSlowDepth and ensembleFastDepth are how far, in seconds,
+	// the slow and fast LFOs sweep the taps either way at full depth.
+	ensembleSlowDepth = 0.003
+	ensembleFastDepth = 0.0004
+	// ensembleFastRatio is the rate of the fast LFO over that of the
+	// slow one.
+	ensembleFastRatio = 10
+)
+
+// NewEnsemble returns a string ensemble ugen in the style of the
+// Solina's: three taps of a delay line, each swept by a slow and a
+// fast LFO a third of a period apart from those of the others, for a
+// lush, shimmering chorus. phase offsets the LFOs, in periods, so
+// that the channels of a stereo ensemble sweep apart.
+//
+// Its "rate" input is the rate of the slow LFO in Hz, the fast one
+// running ten times as fast; "depth" scales the sweep, from 0 to 1;
+// and "mix" the dry/wet mix, from 0 (dry) to 1 (wet).
+func NewEnsemble(phase float64) ugen.UGen {
+	delayLine := NewDelayLine(conf.SampleRate, maxModDelay)
+	var slow, fast lfo
+
+	inInput := ugen.Input{Name: "in"}
+	rateInput := ugen.Input{Name: "rate", Default: 0.6}
+	depthInput := ugen.Input{Name: "depth", Default: 1}
+	mixInput := ugen.Input{Name: "mix", Default: 1}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		rates := rateInput.Samples(cfg, len(out))
+		depths := depthInput.Samples(cfg, len(out))
+		mixes := mixInput.Samples(cfg, len(out))
+		sampleRate := float64(cfg.SampleRateHz)
+
+		for i := range out {
+			x := zapgremlins(in[i])
+			delayLine.WriteSample(x)
+
+			var wet float64
+			for tap := 0; tap < 3; tap++ {
+				offset := phase + float64(tap)/3
+				sweep := ensembleSlowDepth*slow.at(offset) + ensembleFastDepth*fast.at(offset)
+				wet += delayLine.TapC(ensembleDelay + depths[i]*sweep)
+			}
+			wet /= 3
+			slow.advance(rates[i], sampleRate)
+			fast.advance(ensembleFastRatio*rates[i], sampleRate)
+
+			out[i] = (1-mixes[i])*x + mixes[i]*wet
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// fdnDelays are the delays, in seconds, of the lines of a feedback
+// delay network at a scale of 1, spread so that their echoes rarely
+// coincide. A network of fewer lines takes an even spread of them.
+var fdnDelays = [...]float64{
+	0.0297, 0.0371, 0.0411, 0.0437, 0.0503, 0.0571, 0.0617, 0.0683,
+	0.0751, 0.0797, 0.0853, 0.0919, 0.0977, 0.1039, 0.1093, 0.1151,
+}
+
+// fdnDiffusers are the delays, in seconds, of the all-pass filters
+// that diffuse the input to a feedback delay network.
+var fdnDiffusers = [...]float64{0.0047, 0.0036, 0.0127, 0.0093}
+
+// fdnEarly are the delays, in seconds at a scale of 1, and gains of the
+// early reflections of each channel.
+var fdnEarly = [2][8]struct{ delay, gain float64 }{
+	{{0.0071, 0.9}, {0.0113, 0.75}, {0.0191, 0.68}, {0.0237, 0.55}, {0.0313, 0.47}, {0.0379, 0.39}, {0.0457, 0.31}, {0.0523, 0.25}},
+	{{0.0083, 0.88}, {0.0127, 0.73}, {0.0173, 0.66}, {0.0251, 0.56}, {0.0293, 0.45}, {0.0401, 0.37}, {0.0439, 0.3}, {0.0547, 0.24}},
+}
+
+const (
+	// fdnMinScale and fdnMaxScale scale the delays of a feedback delay
+	// network at the smallest and largest sizes.
+	fdnMinScale = 0.25
+	fdnMaxScale = 1.75
+
+	// fdnBassFreq is the frequency, in Hz, below which the bass
+	// multiplier scales the decay time, and fdnDampFreq that above
+	// which the damping shortens it.
+	fdnBassFreq = 250
+	fdnDampFreq = 3000
+
+	// fdnModDepth is how far, in seconds, the lines' delays are swept
+	// either way at full modulation, and fdnModRate the rate in Hz of
+	// the slowest of their LFOs.
+	fdnModDepth = 0.0006
+	fdnModRate  = 0.4
+
+	// fdnStereoSpread is added to the delays of the right channel's
+	// network, to decorrelate it from the left's.
+	fdnStereoSpread = 0.0005
+
+	// fdnMaxDiffusion is the coefficient of the diffusers at full
+	// diffusion.
+	fdnMaxDiffusion = 0.75
+)
+
+// NewFDN returns a feedback delay network reverb ugen for one channel
+// of a stereo reverb: lines delay lines, 8 to 16, whose outputs are
+// filtered, mixed by an orthogonal matrix and fed back into them, for
+// a dense, smooth tail. matrix is "hadamard", for 8 or 16 lines, or
+// "householder". channel, 0 or 1, picks the left or right channel,
+// whose networks differ slightly so that they're decorrelated.
+//
+// Its inputs are "in"; "mix", the dry/wet mix from 0 (dry) to 1 (wet);
+// "size", from 0 to 1, which scales the delays; "decay", the time in
+// seconds for the tail to fall by 60 dB; "bass", which multiplies the
+// decay time below 250 Hz; "damping", from 0 to 1, which shortens it
+// above 3 kHz; "diffusion", from 0 to 1, how much the input is smeared
+// before it enters the network; "mod", from 0 to 1, how far the
+// delays are swept by slow LFOs, to keep the tail from ringing; and
+// "early", the level of the early reflections. All but "in" and "mix"
+// are read once a block.
+func NewFDN(lines int, matrix string, channel int) ugen.UGen {
+	if lines < 8 || lines > len(fdnDelays) {
+		panic(fmt.Errorf("fdn: lines must be from 8 to %d, got %d", len(fdnDelays), lines))
+	}
+	var mixMatrix func([]float64)
+	switch matrix {
+	case "hadamard":
+		if lines&(lines-1) != 0 {
+			panic(fmt.Errorf("fdn: a hadamard matrix needs 8 or 16 lines, got %d", lines))
+		}
+		mixMatrix = hadamard
+	case "householder":
+		mixMatrix = householder
+	default:
+		panic(fmt.Errorf("fdn: unknown matrix %q", matrix))
+	}
+	if channel != 0 && channel != 1 {
+		panic(fmt.Errorf("fdn: channel must be 0 or 1, got %d", channel))
+	}
+	spread := float64(channel) * fdnStereoSpread
+
+	delays := make([]float64, lines)
+	for i := range delays {
+		delays[i] = fdnDelays[i*len(fdnDelays)/lines] + spread
+	}
+	maxDelay := fdnDelays[len(fdnDelays)-1]*fdnMaxScale + fdnStereoSpread + fdnModDepth
+	delayLines := make([]*DelayLine, lines)
+	for i := range delayLines {
+		delayLines[i] = NewDelayLine(conf.SampleRate, maxDelay)
+	}
+	lows := make([]onePole, lines)
+	highs := make([]onePole, lines)
+	lfos := make([]lfo, lines)
+	for i := range lfos {
+		lfos[i].phase = float64(i)/float64(lines) + 0.25*float64(channel)
+	}
+
+	var diffusers [len(fdnDiffusers)]diffuser
+	for i := range diffusers {
+		diffusers[i] = newDiffuser(fdnDiffusers[i])
+	}
+	early := fdnEarly[channel]
+	earlyLine := NewDelayLine(conf.SampleRate, early[len(early)-1].delay*fdnMaxScale)
+
+	// the scale of the delays at the end of the last block, from which
+	// that of the next is ramped; 0 before the first.
+	var lastScale float64
+	gains := make([]float64, lines)
+	bassGains := make([]float64, lines)
+	dampGains := make([]float64, lines)
+	taps := make([]float64, lines)
+	feedback := make([]float64, lines)
+	gain := 1 / math.Sqrt(float64(lines))
+
+	inInput := ugen.Input{Name: "in"}
+	mixInput := ugen.Input{Name: "mix", Default: 1.0 / 3}
+	sizeInput := ugen.Input{Name: "size", Default: 0.5}
+	decayInput := ugen.Input{Name: "decay", Default: 2}
+	bassInput := ugen.Input{Name: "bass", Default: 1}
+	dampingInput := ugen.Input{Name: "damping", Default: 0.5}
+	diffusionInput := ugen.Input{Name: "diffusion", Default: 0.7}
+	modInput := ugen.Input{Name: "mod", Default: 0.3}
+	earlyInput := ugen.Input{Name: "early", Default: 0.3}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		n := len(out)
+		in := inInput.Samples(cfg, n)
+		mixes := mixInput.Samples(cfg, n)
+		sampleRate := float64(cfg.SampleRateHz)
+
+		scale := fdnMinScale + (fdnMaxScale-fdnMinScale)*clamp01(sizeInput.Samples(cfg, n)[0], 0.5)
+		decay := clampDecay(decayInput.Samples(cfg, n)[0])
+		bass := bassInput.Samples(cfg, n)[0]
+		if !(bass > 0.1) {
+			bass = 0.1
+		}
+		bass = math.Min(bass, 10)
+		damping := clamp01(dampingInput.Samples(cfg, n)[0], 0.5)
+		diffusion := fdnMaxDiffusion * clamp01(diffusionInput.Samples(cfg, n)[0], 0)
+		mod := fdnModDepth * clamp01(modInput.Samples(cfg, n)[0], 0)
+		earlyLevel := earlyInput.Samples(cfg, n)[0]
+
+		// each line's loop gain is split into bands by two shelving
+		// filters: one that scales the gain below the bass frequency
+		// to that of the bass decay, and one that scales it above the
+		// damping frequency to that of the damped decay. As the
+		// damped decay is never longer, the gain never exceeds the
+		// larger of the other two, so the network stays stable.
+		bassDecay := clampDecay(decay * bass)
+		highDecay := clampDecay(decay * (1 - 0.99*damping))
+		for i := range delays {
+			d := delays[i] * scale
+			gains[i] = decayGain(d, decay)
+			bassGains[i] = decayGain(d, bassDecay)/gains[i] - 1
+			dampGains[i] = decayGain(d, highDecay)/gains[i] - 1
+		}
+		if lastScale == 0 {
+			lastScale = scale
+		}
+		bassG := zdfGain(fdnBassFreq, sampleRate)
+		bassG /= 1 + bassG
+		dampG := zdfGain(fdnDampFreq, sampleRate)
+		dampG /= 1 + dampG
+
+		for i := range out {
+			t := float64(i+1) / float64(n)
+			k := (1-t)*lastScale + t*scale
+			x := zapgremlins(in[i])
+
+			earlyLine.WriteSample(x)
+			var reflections float64
+			for _, tap := range early {
+				reflections += tap.gain * earlyLine.TapC(tap.delay*k)
+			}
+
+			diffused := x
+			for j := range diffusers {
+				diffused = diffusers[j].process(diffused, fdnDiffusers[j], diffusion)
+			}
+
+			for j, line := range delayLines {
+				// read a sample closer than the delay, before the
+				// line is written, so the loop is as long as the delay.
+				delay := delays[j]*k + mod*lfos[j].at(0)
+				lfos[j].advance(fdnModRate*(1+0.17*float64(j)), sampleRate)
+				v := line.TapC(delay - 1/sampleRate)
+				taps[j] = v
+				v += bassGains[j] * lows[j].lowpass(v, bassG)
+				v += dampGains[j] * highs[j].highpass(v, dampG)
+				feedback[j] = gains[j] * v
+			}
+			mixMatrix(feedback)
+
+			var tail float64
+			for j, line := range delayLines {
+				sign := float64(1 - 2*(j&1))
+				line.WriteSample(zapgremlins(feedback[j] + sign*gain*diffused))
+				if (j>>1)&1 == channel {
+					tail += taps[j]
+				} else {
+					tail -= taps[j]
+				}
+			}
+			tail *= gain
+
+			out[i] = (1-mixes[i])*x + mixes[i]*(earlyLevel*reflections+tail)
+		}
+		lastScale = scale
+	})
+}
+
+// hadamard mixes v in place by the normalized Hadamard matrix, with
+// the fast Walsh-Hadamard transform. len(v) must be a power of 2.
+func hadamard(v []float64) {
+	for h := 1; h < len(v); h *= 2 {
+		for i := 0; i < len(v); i += 2 * h {
+			for j := i; j < i+h; j++ {
+				v[j], v[j+h] = v[j]+v[j+h], v[j]-v[j+h]
+			}
+		}
+	}
+	s := 1 / math.Sqrt(float64(len(v)))
+	for i := range v {
+		v[i] *= s
+	}
+}
+
+// householder mixes v in place by the Householder reflection about the
+// vector of ones, I - 2/N 11ᵀ.
+func householder(v []float64) {
+	var sum float64
+	for _, x := range v {
+		sum += x
+	}
+	sum *= 2 / float64(len(v))
+	for i := range v {
+		v[i] -= sum
+	}
+}
@@ -0,0 +1,66 @@
+package effects
+
+import (
+	"context"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// NewFlanger returns a flanger ugen: its input mixed with a copy
+// delayed by a short time swept by an LFO, fed back into the delay.
+// phase offsets the LFO, in periods, so that the channels of a stereo
+// flanger sweep apart.
+//
+// Its "rate" input is the LFO rate in Hz; "delay" the delay, in
+// seconds, around which the copy sweeps; "depth" how far it sweeps
+// either way, in seconds; "feedback" the gain, from -0.95 to 0.95, of
+// the copy fed back, whose sign sets the polarity of the comb's
+// peaks; and "mix" the dry/wet mix, from 0 (dry) to 1 (wet).
+//
+// If throughZero is set, the input is delayed by "delay" too, so the
+// copy sweeps from behind the input to ahead of it and through it,
+// cancelling it as it passes, as a tape flanger does.
+func NewFlanger(phase float64, throughZero bool) ugen.UGen {
+	delayLine := NewDelayLine(conf.SampleRate, maxModDelay)
+	// the input, delayed alone in through-zero mode.
+	var dryLine *DelayLine
+	if throughZero {
+		dryLine = NewDelayLine(conf.SampleRate, maxModDelay)
+	}
+	var osc lfo
+
+	inInput := ugen.Input{Name: "in"}
+	rateInput := ugen.Input{Name: "rate", Default: 0.2}
+	delayInput := ugen.Input{Name: "delay", Default: 0.003}
+	depthInput := ugen.Input{Name: "depth", Default: 0.002}
+	feedbackInput := ugen.Input{Name: "feedback", Default: 0.5}
+	mixInput := ugen.Input{Name: "mix", Default: 0.5}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		rates := rateInput.Samples(cfg, len(out))
+		delays := delayInput.Samples(cfg, len(out))
+		depths := depthInput.Samples(cfg, len(out))
+		feedbacks := feedbackInput.Samples(cfg, len(out))
+		mixes := mixInput.Samples(cfg, len(out))
+		sampleRate := float64(cfg.SampleRateHz)
+
+		for i := range out {
+			x := zapgremlins(in[i])
+
+			// the copy is read before the input is written, a sample
+			// closer, so that the feedback loop is as long as the
+			// delay.
+			wet := delayLine.TapC(delays[i] + depths[i]*osc.at(phase) - 1/sampleRate)
+			osc.advance(rates[i], sampleRate)
+			delayLine.WriteSample(zapgremlins(x + clampFeedback(feedbacks[i])*wet))
+
+			dry := x
+			if dryLine != nil {
+				dryLine.WriteSample(x)
+				dry = dryLine.TapC(delays[i])
+			}
+			out[i] = (1-mixes[i])*dry + mixes[i]*wet
+		}
+	})
+}
@@ -14,6 +14,7 @@ func NewFreeverb(revmod *freeverb.RevModel) ugen.UGen {
 	outputLeft := make([]float32, conf.BufferSize)
 	outputRight := make([]float32, conf.BufferSize)
 
+	inInput := ugen.Input{Name: "in"}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 		n := len(out)
 		if len(input32) != n {
@@ -22,8 +23,11 @@ func NewFreeverb(revmod *freeverb.RevModel) ugen.UGen {
 			outputRight = make([]float32, n)
 		}
 
+		// the reverb's feedback would hold on to a NaN or infinity
+		// forever, so they're silenced on the way in.
+		in := inInput.Samples(cfg, n)
 		for i := range input32 {
-			input32[i] = float32(cfg.InputSamples["in"][i])
+			input32[i] = float32(zapgremlins(in[i]))
 		}
 		if roomSizes, ok := cfg.InputSamples["room-size"]; ok {
 			rs := roomSizes[0]
@@ -0,0 +1,43 @@
+package effects
+
+import (
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/audiotest/golden"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// TestGolden checks the output of the effects against reference files
+// in testdata/golden. After an intended change to how an effect
+// sounds, regenerate them with go test -update.
+func TestGolden(t *testing.T) {
+	const n = golden.DefaultSamples
+	noise := audiotest.Noise(1, n)
+	sweep := make([]float64, n)
+	for i := range sweep {
+		sweep[i] = 200 + 4000*float64(i)/n
+	}
+
+	tests := []struct {
+		name   string
+		ugen   ugen.UGen
+		inputs map[string][]float64
+	}{
+		{"lpf", NewLPF(), map[string][]float64{"in": noise, "freq": {1000}}},
+		{"lpf-sweep", NewLPF(), map[string][]float64{"in": noise, "freq": sweep}},
+		{"hpf", NewHPF(), map[string][]float64{"in": noise, "freq": {1000}}},
+		{"bpf", NewBPF(), map[string][]float64{"in": noise, "w": {1000}, "bw": {0.5}}},
+		{"rlpf", NewRLPF(), map[string][]float64{"in": noise, "freq": sweep, "reson": {0.2}}},
+		{"moogff", NewMoogFF(), map[string][]float64{"in": noise, "freq": sweep, "gain": {3}, "reset": {0}}},
+		{"delay", NewDelay(0.02), map[string][]float64{"in": audiotest.Impulse(n), "delay": {0.01}}},
+		{"bitcrusher", NewBitcrusher(), map[string][]float64{"in": audiotest.Sine(440, n), "rate": {8000}, "bits": {4}}},
+		{"clip", NewClip(), map[string][]float64{"in": audiotest.Sine(440, n), "lo": {-0.5}, "hi": {0.5}}},
+		{"wavefolder", NewWaveFolder(), map[string][]float64{"in": audiotest.Sine(440, n), "lo": {-0.5}, "hi": {0.5}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			golden.UGen(t, test.name, test.ugen, test.inputs)
+		})
+	}
+}
@@ -33,16 +33,20 @@ func NewHiShelf() ugen.UGen {
 		b2 = ((a + 1) - j - k) * -b0rz
 	}
 
+	inInput := ugen.Input{Name: "in"}
+	wInput := ugen.Input{Name: "w", Default: 1200}
+	rsInput := ugen.Input{Name: "rs", Default: 1}
+	dbInput := ugen.Input{Name: "db"}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		w := cfg.InputSamples["w"]
-		rsInput := cfg.InputSamples["rs"]
-		dbInput := cfg.InputSamples["db"]
+		in := inInput.Samples(cfg, len(out))
+		w := wInput.Samples(cfg, len(out))
+		rss := rsInput.Samples(cfg, len(out))
+		dbs := dbInput.Samples(cfg, len(out))
 
 		for i := range out {
 			freq := w[i]
-			rs := rsInput[i]
-			db := dbInput[i]
+			rs := rss[i]
+			db := dbs[i]
 
 			if !coefficientsComputed || freq != prevFreq || rs != prevRS || db != prevDB {
 				computeCoefficients(freq, rs, db)
@@ -0,0 +1,57 @@
+package effects
+
+import (
+	"context"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// ladderFeedback is the feedback of the ladder filter at full
+// resonance, a little over the 4 at which the linear filter starts to
+// oscillate, so that it keeps oscillating through the saturation.
+const ladderFeedback = 4.4
+
+// NewLadder returns a zero-delay-feedback model of a transistor ladder
+// low-pass filter, four one-pole stages in a feedback loop that
+// saturates, for 24 dB per octave. It stays stable while its cutoff
+// and resonance are modulated at audio rate. Its "freq" input is the
+// cutoff in Hz and its "res" input, from 0 to 1, the resonance; near
+// 1, the filter oscillates on its own at the cutoff. Its "drive"
+// input scales the input into the saturation.
+func NewLadder() ugen.UGen {
+	var stages [4]onePole
+
+	inInput := ugen.Input{Name: "in"}
+	freqInput := ugen.Input{Name: "freq", Default: 440}
+	resInput := ugen.Input{Name: "res"}
+	driveInput := ugen.Input{Name: "drive", Default: 1}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		freqs := freqInput.Samples(cfg, len(out))
+		ress := resInput.Samples(cfg, len(out))
+		drives := driveInput.Samples(cfg, len(out))
+		sampleRate := float64(cfg.SampleRateHz)
+
+		for i := range out {
+			x := zapgremlins(in[i] * drives[i])
+			g := zdfGain(freqs[i], sampleRate)
+			G := g / (1 + g)
+			k := ladderFeedback * clampRes(ress[i])
+
+			// each stage outputs G times its input plus its state over
+			// 1+g, so the output of the last is G^4 times the input
+			// to the first plus the sum of the states, each scaled by
+			// the stages after it. The feedback is solved for
+			// linearly, then saturated.
+			S := (G*G*G*stages[0].s + G*G*stages[1].s + G*stages[2].s + stages[3].s) / (1 + g)
+			u := math.Tanh((x - k*S) / (1 + k*G*G*G*G))
+
+			y := u
+			for j := range stages {
+				y = stages[j].lowpass(y, G)
+			}
+			out[i] = y
+		}
+	})
+}
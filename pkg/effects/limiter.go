@@ -35,11 +35,13 @@ func NewLimiter(dur float64) ugen.UGen {
 	xmidBufFull := table[bufsize : 2*bufsize]
 	xoutBufFull := table[2*bufsize : 3*bufsize]
 
+	inInput := ugen.Input{Name: "in"}
+	ampInput := ugen.Input{Name: "amp", Default: 1}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 		// input signal
-		in := cfg.InputSamples["in"]
+		in := inInput.Samples(cfg, len(out))
 		// The peak output amplitude level to which to normalize the input.
-		amps := cfg.InputSamples["amp"]
+		amps := ampInput.Samples(cfg, len(out))
 
 		amp := amps[0]
 
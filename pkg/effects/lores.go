@@ -13,14 +13,14 @@ func NewLowpassFilter() ugen.UGen {
 	// https://github.com/v7b1/vb_UGens/blob/fea1587dd2165457c4a016214d17216987b56f00/projects/vbUtils/vbUtils.cpp
 	var a1, a2, fqterm, resterm, scale, ym1, ym2 float64
 	lastCut, lastRes := -1.0, -1.0
-	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		cuts := cfg.InputSamples["cutoff"]
-		ress := cfg.InputSamples["resonance"]
 
-		_ = in[len(out)-1]
-		_ = cuts[len(out)-1]
-		_ = ress[len(out)-1]
+	inInput := ugen.Input{Name: "in"}
+	cutoffInput := ugen.Input{Name: "cutoff", Default: 1200}
+	resonanceInput := ugen.Input{Name: "resonance"}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		cuts := cutoffInput.Samples(cfg, len(out))
+		ress := resonanceInput.Samples(cfg, len(out))
 
 		for i := range out {
 			cut := cuts[i]
@@ -34,7 +34,10 @@ func NewLowpassFilter() ugen.UGen {
 
 			if cut != lastCut || res != lastRes {
 				if res != lastRes {
-					resterm = math.Exp(res*0.125) * 0.882497
+					// the pole radius is tuned for 44.1kHz, so scale
+					// it to keep the resonance the same at other
+					// rates.
+					resterm = math.Pow(math.Exp(res*0.125)*0.882497, 44100/float64(cfg.SampleRateHz))
 				}
 				if cut != lastCut {
 					fqterm = math.Cos(cut * math.Pi * 2 / float64(cfg.SampleRateHz))
@@ -47,7 +50,7 @@ func NewLowpassFilter() ugen.UGen {
 			}
 			val := in[i]
 			temp := ym1
-			ym1 = scale*val - a1*ym1 - a2*ym2
+			ym1 = zapgremlins(scale*val - a1*ym1 - a2*ym2)
 			ym2 = temp
 			out[i] = ym1
 		}
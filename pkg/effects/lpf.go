@@ -28,12 +28,11 @@ func NewLPF() ugen.UGen {
 		b2 = -(1.0 - sqrt2C + C2) * a0
 	}
 
+	inInput := ugen.Input{Name: "in"}
+	freqInput := ugen.Input{Name: "freq", Default: 440}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		freq := cfg.InputSamples["freq"]
-
-		_ = in[len(out)-1]
-		_ = freq[len(out)-1]
+		in := inInput.Samples(cfg, len(out))
+		freq := freqInput.Samples(cfg, len(out))
 
 		for i := range out {
 			if !coefficientsComputed || freq[i] != prevFreq {
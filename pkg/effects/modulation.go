@@ -0,0 +1,41 @@
+package effects
+
+import "math"
+
+// maxModDelay is the longest delay, in seconds, that the modulation
+// effects read from their delay lines.
+const maxModDelay = 0.05
+
+// maxModFeedback bounds the feedback of the modulation effects, so
+// that they can't run away.
+const maxModFeedback = 0.95
+
+// lfo is the sine low-frequency oscillator that sweeps the modulation
+// effects.
+type lfo struct {
+	phase float64
+}
+
+// at returns the LFO's value, from -1 to 1, offset by offset periods.
+func (l *lfo) at(offset float64) float64 {
+	return math.Sin(2 * math.Pi * (l.phase + offset))
+}
+
+// advance moves the LFO on by a sample at rate Hz. A rate that isn't
+// finite leaves it where it is.
+func (l *lfo) advance(rate, sampleRate float64) {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return
+	}
+	l.phase += rate / sampleRate
+	l.phase -= math.Floor(l.phase)
+}
+
+// clampFeedback clamps the feedback of a modulation effect to
+// maxModFeedback either way; a NaN is 0.
+func clampFeedback(fb float64) float64 {
+	if math.IsNaN(fb) {
+		return 0
+	}
+	return math.Max(-maxModFeedback, math.Min(fb, maxModFeedback))
+}
@@ -0,0 +1,183 @@
+package effects
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// renderModulation renders a modulation effect for a second of a 440Hz
+// sine.
+func renderModulation(t *testing.T, u ugen.UGen, inputs map[string][]float64) *audiotest.Audio {
+	t.Helper()
+	n := conf.SampleRate
+	in := map[string][]float64{"in": audiotest.Sine(440, n)}
+	for port, sig := range inputs {
+		in[port] = sig
+	}
+	out, err := audiotest.RenderUGen(context.Background(), u, in, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// assertDelayed fails unless a is the 440Hz sine delayed by delay
+// seconds.
+func assertDelayed(t *testing.T, a *audiotest.Audio, delay float64) {
+	t.Helper()
+	start := int(delay*float64(conf.SampleRate)) + 4
+	for i, s := range a.Mono()[start:] {
+		i += start
+		want := math.Sin(2 * math.Pi * 440 * (float64(i)/float64(conf.SampleRate) - delay))
+		if math.Abs(s-want) > 1e-4 {
+			t.Fatalf("sample %d is %v, want %v", i, s, want)
+		}
+	}
+}
+
+// maxDiff returns the largest difference between a and b.
+func maxDiff(a, b *audiotest.Audio) float64 {
+	var d float64
+	bs := b.Mono()
+	for i, s := range a.Mono() {
+		d = math.Max(d, math.Abs(s-bs[i]))
+	}
+	return d
+}
+
+func TestChorus(t *testing.T) {
+	t.Run("dry", func(t *testing.T) {
+		assertDelayed(t, renderModulation(t, NewChorus(3, 0), map[string][]float64{"mix": {0}}), 0)
+	})
+	t.Run("unmodulated", func(t *testing.T) {
+		out := renderModulation(t, NewChorus(1, 0), map[string][]float64{
+			"delay": {0.01},
+			"depth": {0},
+			"mix":   {1},
+		})
+		assertDelayed(t, out, 0.01)
+	})
+	t.Run("stereo", func(t *testing.T) {
+		left := renderModulation(t, NewChorus(3, 0), map[string][]float64{"mix": {1}})
+		right := renderModulation(t, NewChorus(3, 0.25), map[string][]float64{"mix": {1}})
+		if d := maxDiff(left, right); d < 0.05 {
+			t.Errorf("got channels at most %v apart, want them to differ", d)
+		}
+	})
+}
+
+func TestFlanger(t *testing.T) {
+	// a fixed 1ms delay mixed evenly with the input is a comb with
+	// notches at odd multiples of 500Hz and peaks at multiples of 1kHz.
+	for _, tc := range []struct {
+		feedback      float64
+		freq          float64
+		want          float64
+		throughZero   bool
+		phase, offset float64
+	}{
+		{feedback: 0, freq: 500, want: 0},
+		{feedback: 0, freq: 1000, want: 1},
+		{feedback: 0.5, freq: 500, want: 1.0 / 6},
+		{feedback: 0.5, freq: 1000, want: 1.5},
+		// negative feedback swaps the peaks and notches.
+		{feedback: -0.5, freq: 500, want: 0.5},
+		{feedback: -0.5, freq: 1000, want: 5.0 / 6},
+	} {
+		g := gainAt(t, NewFlanger(0, false), tc.freq, map[string][]float64{
+			"delay":    {0.001},
+			"depth":    {0},
+			"feedback": {tc.feedback},
+			"mix":      {0.5},
+		})
+		if math.Abs(g-tc.want) > 0.02 {
+			t.Errorf("feedback %v: got gain %v at %vHz, want %v", tc.feedback, g, tc.freq, tc.want)
+		}
+	}
+}
+
+func TestFlangerThroughZero(t *testing.T) {
+	controls := func(depth float64) map[string][]float64 {
+		return map[string][]float64{
+			"rate":     {0},
+			"delay":    {0.003},
+			"depth":    {depth},
+			"feedback": {0},
+			"mix":      {0.5},
+		}
+	}
+	// with no sweep, the copy lines up with the delayed input.
+	for _, freq := range []float64{500, 1000, 5000} {
+		if g := gainAt(t, NewFlanger(0, true), freq, controls(0)); math.Abs(g-1) > 0.01 {
+			t.Errorf("got gain %v at %vHz, want 1", g, freq)
+		}
+	}
+	// at the bottom of its sweep, the copy runs 1ms ahead of the
+	// input, cutting a notch at 500Hz.
+	if g := gainAt(t, NewFlanger(0.75, true), 500, controls(0.001)); g > 0.02 {
+		t.Errorf("got gain %v at 500Hz, want a notch", g)
+	}
+	if g := gainAt(t, NewFlanger(0.75, true), 1000, controls(0.001)); math.Abs(g-1) > 0.02 {
+		t.Errorf("got gain %v at 1kHz, want 1", g)
+	}
+}
+
+func TestPhaser(t *testing.T) {
+	for _, tc := range []struct {
+		stages   int
+		freq     float64
+		min, max float64
+	}{
+		// each stage shifts the phase by 90 degrees at its break
+		// frequency, so two cancel the input there.
+		{2, 1000, 0, 0.02},
+		{2, 50, 0.98, 1.01},
+		{2, 20000, 0.98, 1.01},
+		// four cancel it where each shifts it by 45 or 135 degrees.
+		{4, 1000 * math.Tan(math.Pi/8), 0, 0.02},
+		{4, 1000 * math.Tan(3*math.Pi/8), 0, 0.02},
+		{4, 1000, 0.98, 1.01},
+	} {
+		g := gainAt(t, NewPhaser(tc.stages, 0), tc.freq, map[string][]float64{
+			"min-freq": {1000},
+			"max-freq": {1000},
+			"feedback": {0},
+		})
+		if g < tc.min || g > tc.max {
+			t.Errorf("%d stages: got gain %v at %vHz, want %v to %v", tc.stages, g, tc.freq, tc.min, tc.max)
+		}
+	}
+}
+
+func TestPhaserPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	NewPhaser(0, 0)
+}
+
+func TestEnsemble(t *testing.T) {
+	t.Run("dry", func(t *testing.T) {
+		assertDelayed(t, renderModulation(t, NewEnsemble(0), map[string][]float64{"mix": {0}}), 0)
+	})
+	t.Run("unmodulated", func(t *testing.T) {
+		assertDelayed(t, renderModulation(t, NewEnsemble(0), map[string][]float64{"depth": {0}}), ensembleDelay)
+	})
+	t.Run("modulated", func(t *testing.T) {
+		left := renderModulation(t, NewEnsemble(0), nil)
+		right := renderModulation(t, NewEnsemble(0.25), nil)
+		if d := maxDiff(left, right); d < 0.05 {
+			t.Errorf("got channels at most %v apart, want them to differ", d)
+		}
+		if got := left.DominantFrequency(); math.Abs(got-440) > 5 {
+			t.Errorf("got dominant frequency %v, want 440", got)
+		}
+	})
+}
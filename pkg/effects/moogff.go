@@ -58,16 +58,15 @@ func NewMoogFF() ugen.UGen {
 		a1 = (TwcD - 2.0) / (TwcD + 2.0)
 	}
 
+	inInput := ugen.Input{Name: "in"}
+	freqInput := ugen.Input{Name: "freq", Default: 440}
+	gainInput := ugen.Input{Name: "gain"}
+	resetInput := ugen.Input{Name: "reset"}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		freq := cfg.InputSamples["freq"]
-		gain := cfg.InputSamples["gain"]
-		reset := cfg.InputSamples["reset"]
-
-		_ = in[len(out)-1]
-		_ = freq[len(out)-1]
-		_ = gain[len(out)-1]
-		_ = reset[len(out)-1]
+		in := inInput.Samples(cfg, len(out))
+		freq := freqInput.Samples(cfg, len(out))
+		gain := gainInput.Samples(cfg, len(out))
+		reset := resetInput.Samples(cfg, len(out))
 
 		for i := range out {
 			// Reset filter state if requested
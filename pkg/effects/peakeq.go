@@ -28,16 +28,20 @@ func NewPeakEQ() ugen.UGen {
 		b2 = (1 - (alpha / a)) * -b0rz
 	}
 
+	inInput := ugen.Input{Name: "in"}
+	wInput := ugen.Input{Name: "w", Default: 1200}
+	rqInput := ugen.Input{Name: "rq", Default: 1}
+	dbInput := ugen.Input{Name: "db"}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		w := cfg.InputSamples["w"]
-		rqInput := cfg.InputSamples["rq"]
-		dbInput := cfg.InputSamples["db"]
+		in := inInput.Samples(cfg, len(out))
+		w := wInput.Samples(cfg, len(out))
+		rqs := rqInput.Samples(cfg, len(out))
+		dbs := dbInput.Samples(cfg, len(out))
 
 		for i := range out {
 			freq := w[i]
-			rq := rqInput[i]
-			db := dbInput[i]
+			rq := rqs[i]
+			db := dbs[i]
 
 			if !coefficientsComputed || freq != prevFreq || rq != prevRQ || db != prevDB {
 				computeCoefficients(freq, rq, db)
@@ -0,0 +1,68 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// NewPhaser returns a phaser ugen: its input through a chain of
+// stages first-order all-pass filters, their break frequency swept by
+// an LFO, mixed with the input so that the filters' phase shift cuts
+// notches that sweep through the spectrum. The last stage is fed back
+// into the first. phase offsets the LFO, in periods, so that the
+// channels of a stereo phaser sweep apart.
+//
+// Its "rate" input is the LFO rate in Hz; "min-freq" and "max-freq"
+// the range of the sweep in Hz, swept exponentially; "feedback" the
+// gain, from -0.95 to 0.95, of the last stage fed back, which deepens
+// the notches and sharpens the peaks between them; and "mix" the
+// dry/wet mix, from 0 (dry) to 1 (wet), with the deepest notches at
+// 0.5.
+func NewPhaser(stages int, phase float64) ugen.UGen {
+	if stages < 1 {
+		panic(fmt.Errorf("phaser: stages must be positive, got %d", stages))
+	}
+
+	allpasses := make([]onePole, stages)
+	var osc lfo
+	var fb float64
+
+	inInput := ugen.Input{Name: "in"}
+	rateInput := ugen.Input{Name: "rate", Default: 0.5}
+	minFreqInput := ugen.Input{Name: "min-freq", Default: 200}
+	maxFreqInput := ugen.Input{Name: "max-freq", Default: 2000}
+	feedbackInput := ugen.Input{Name: "feedback", Default: 0.5}
+	mixInput := ugen.Input{Name: "mix", Default: 0.5}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		rates := rateInput.Samples(cfg, len(out))
+		minFreqs := minFreqInput.Samples(cfg, len(out))
+		maxFreqs := maxFreqInput.Samples(cfg, len(out))
+		feedbacks := feedbackInput.Samples(cfg, len(out))
+		mixes := mixInput.Samples(cfg, len(out))
+		sampleRate := float64(cfg.SampleRateHz)
+
+		for i := range out {
+			x := zapgremlins(in[i])
+
+			sweep := (1 + osc.at(phase)) / 2
+			osc.advance(rates[i], sampleRate)
+			freq := minFreqs[i] * math.Pow(maxFreqs[i]/minFreqs[i], sweep)
+			g := zdfGain(freq, sampleRate)
+			G := g / (1 + g)
+
+			// each stage is the low-pass output minus the high-pass
+			// output of a one-pole filter.
+			y := x + clampFeedback(feedbacks[i])*fb
+			for j := range allpasses {
+				y = 2*allpasses[j].lowpass(y, G) - y
+			}
+			fb = zapgremlins(y)
+
+			out[i] = (1-mixes[i])*x + mixes[i]*y
+		}
+	})
+}
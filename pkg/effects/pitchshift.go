@@ -87,16 +87,14 @@ func NewPitchShift(opts ...ugen.Option) ugen.UGen {
 		return frand()*2.0 - 1.0
 	}
 
+	inInput := ugen.Input{Name: "in"}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
+		in := inInput.Samples(cfg, len(out))
 		pitchRatios := cfg.InputSamples["pitchRatio"]
 		windowSizes := cfg.InputSamples["windowSize"]
 		pitchDispersions := cfg.InputSamples["pitchDispersion"]
 		timeDispersions := cfg.InputSamples["timeDispersion"]
 
-		// Ensure we have valid inputs
-		_ = in[len(out)-1]
-
 		// Initialize on first run
 		if buffer == nil {
 			// Get initial window size
@@ -0,0 +1,196 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// plateRate is the sample rate at which Dattorro gives the delays of
+// the plate, in samples.
+const plateRate = 29761
+
+// the delays of the plate's input diffusers and of the two halves of
+// its tank, in samples at plateRate.
+var (
+	plateDiffusers = [4]float64{142, 107, 379, 277}
+	// each half of the tank is a modulated all-pass filter, a delay, a
+	// damping filter, an all-pass filter and another delay.
+	plateTank = [2][4]float64{
+		{672, 4453, 1800, 3720},
+		{908, 4217, 2656, 3163},
+	}
+)
+
+// plateTap is an output tap of the plate: a delay, in samples at
+// plateRate, into one of the delays or all-pass filters of a half of
+// the tank, and its sign.
+type plateTap struct {
+	half, stage int
+	delay, sign float64
+}
+
+// plateTaps are the output taps of the left and right channels of the
+// plate. Stages 1 and 3 are the delays of each half, and stage 2 its
+// all-pass filter.
+var plateTaps = [2][7]plateTap{
+	{{1, 1, 266, 1}, {1, 1, 2974, 1}, {1, 2, 1913, -1}, {1, 3, 1996, 1}, {0, 1, 1990, -1}, {0, 2, 187, -1}, {0, 3, 1066, -1}},
+	{{0, 1, 353, 1}, {0, 1, 3627, 1}, {0, 2, 1228, -1}, {0, 3, 2673, 1}, {1, 1, 2111, -1}, {1, 2, 335, -1}, {1, 3, 121, -1}},
+}
+
+const (
+	// plateMinScale and plateMaxScale scale the delays of the plate's
+	// tank at the smallest and largest sizes; the middle size is
+	// Dattorro's.
+	plateMinScale = 0.5
+	plateMaxScale = 1.5
+
+	// plateExcursion is how far, in samples at plateRate, the
+	// modulated all-pass filters are swept either way at full
+	// modulation, and plateModRate the rate in Hz of their LFO.
+	plateExcursion = 32
+	plateModRate   = 1
+
+	// plateMaxDecayGain bounds the gain of the tank.
+	plateMaxDecayGain = 0.999
+
+	// plateMaxDamp and plateMinDamp are the cutoffs, in Hz, of the
+	// tank's damping filters without and with full damping.
+	plateMaxDamp = 20000.0
+	plateMinDamp = 1000.0
+
+	// plateGain scales the output.
+	plateGain = 0.6
+)
+
+// plateDiffusion are the coefficients of the plate's input diffusers,
+// in pairs, and of its decay diffusers, at full diffusion.
+var plateDiffusion = struct{ input1, input2, decay1, decay2 float64 }{0.75, 0.625, 0.7, 0.5}
+
+// plateSeconds converts a delay in samples at plateRate to seconds.
+func plateSeconds(samples float64) float64 {
+	return samples / plateRate
+}
+
+// NewPlate returns a ugen for one channel of Jon Dattorro's plate
+// reverb: the input, diffused by four all-pass filters, fed into a
+// tank of two halves that feed each other, each an all-pass filter
+// swept by an LFO, delays, a damping filter and another all-pass
+// filter. The output of each channel is a sum of taps into the tank.
+// channel, 0 or 1, picks the left or right channel; each computes the
+// whole tank.
+//
+// Its inputs are as for NewFDN, but for "bass" and "early", which a
+// plate lacks. The controls but "in" and "mix" are read once a block.
+func NewPlate(channel int) ugen.UGen {
+	if channel != 0 && channel != 1 {
+		panic(fmt.Errorf("plate: channel must be 0 or 1, got %d", channel))
+	}
+
+	var diffusers [len(plateDiffusers)]diffuser
+	for i := range diffusers {
+		diffusers[i] = newDiffuser(plateSeconds(plateDiffusers[i]))
+	}
+	type half struct {
+		mod, diffuse   diffuser
+		delay1, delay2 *DelayLine
+		damp           onePole
+		// end is the last output of the half, fed into the other.
+		end float64
+	}
+	var halves [2]half
+	for i := range halves {
+		lengths := plateTank[i]
+		halves[i] = half{
+			mod:     newDiffuser(plateSeconds(lengths[0]*plateMaxScale + plateExcursion)),
+			delay1:  NewDelayLine(conf.SampleRate, plateSeconds(lengths[1]*plateMaxScale)),
+			diffuse: newDiffuser(plateSeconds(lengths[2] * plateMaxScale)),
+			delay2:  NewDelayLine(conf.SampleRate, plateSeconds(lengths[3]*plateMaxScale)),
+		}
+	}
+	var osc lfo
+	// the scale of the tank's delays at the end of the last block,
+	// from which that of the next is ramped; 0 before the first.
+	var lastScale float64
+
+	inInput := ugen.Input{Name: "in"}
+	mixInput := ugen.Input{Name: "mix", Default: 1.0 / 3}
+	sizeInput := ugen.Input{Name: "size", Default: 0.5}
+	decayInput := ugen.Input{Name: "decay", Default: 2}
+	dampingInput := ugen.Input{Name: "damping", Default: 0.5}
+	diffusionInput := ugen.Input{Name: "diffusion", Default: 0.7}
+	modInput := ugen.Input{Name: "mod", Default: 0.3}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		n := len(out)
+		in := inInput.Samples(cfg, n)
+		mixes := mixInput.Samples(cfg, n)
+		sampleRate := float64(cfg.SampleRateHz)
+
+		scale := plateMinScale + (plateMaxScale-plateMinScale)*clamp01(sizeInput.Samples(cfg, n)[0], 0.5)
+		if lastScale == 0 {
+			lastScale = scale
+		}
+		// the tank's gain is applied twice a trip around each half.
+		loop := plateSeconds(plateTank[0][0]+plateTank[0][1]+plateTank[0][2]+plateTank[0][3]) * scale
+		gain := math.Min(math.Sqrt(decayGain(loop, clampDecay(decayInput.Samples(cfg, n)[0]))), plateMaxDecayGain)
+		damping := clamp01(dampingInput.Samples(cfg, n)[0], 0.5)
+		dampG := zdfGain(plateMaxDamp*math.Pow(plateMinDamp/plateMaxDamp, damping), sampleRate)
+		dampG /= 1 + dampG
+		diffusion := clamp01(diffusionInput.Samples(cfg, n)[0], 0)
+		excursion := plateSeconds(plateExcursion) * clamp01(modInput.Samples(cfg, n)[0], 0)
+
+		for i := range out {
+			t := float64(i+1) / float64(n)
+			k := (1-t)*lastScale + t*scale
+			x := zapgremlins(in[i])
+
+			diffused := x
+			for j := range diffusers {
+				g := plateDiffusion.input1
+				if j >= 2 {
+					g = plateDiffusion.input2
+				}
+				diffused = diffusers[j].process(diffused, plateSeconds(plateDiffusers[j]), diffusion*g)
+			}
+
+			// the halves' LFOs are in quadrature.
+			sweeps := [2]float64{osc.at(0), osc.at(0.25)}
+			osc.advance(plateModRate, sampleRate)
+			ends := [2]float64{halves[1].end, halves[0].end}
+			for j := range halves {
+				h := &halves[j]
+				lengths := plateTank[j]
+				v := diffused + gain*ends[j]
+				v = h.mod.process(v, plateSeconds(lengths[0])*k+excursion*sweeps[j], -diffusion*plateDiffusion.decay1)
+				h.delay1.WriteSample(v)
+				v = h.delay1.TapC(plateSeconds(lengths[1]) * k)
+				v = gain * h.damp.lowpass(v, dampG)
+				v = h.diffuse.process(v, plateSeconds(lengths[2])*k, diffusion*plateDiffusion.decay2)
+				h.delay2.WriteSample(v)
+				h.end = h.delay2.TapC(plateSeconds(lengths[3]) * k)
+			}
+
+			var wet float64
+			for _, tap := range plateTaps[channel] {
+				h := &halves[tap.half]
+				var line *DelayLine
+				switch tap.stage {
+				case 1:
+					line = h.delay1
+				case 2:
+					line = h.diffuse.line
+				default:
+					line = h.delay2
+				}
+				wet += tap.sign * line.TapC(plateSeconds(tap.delay)*k)
+			}
+			wet *= plateGain
+
+			out[i] = (1-mixes[i])*x + mixes[i]*wet
+		}
+		lastScale = scale
+	})
+}
@@ -0,0 +1,57 @@
+package effects
+
+import (
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+)
+
+const (
+	// minDecay and maxDecay bound the decay times, in seconds, of the
+	// reverbs.
+	minDecay = 0.01
+	maxDecay = 100
+)
+
+// diffuser is an all-pass filter around a delay line, which smears the
+// transients of its input in time without coloring it.
+type diffuser struct {
+	line *DelayLine
+}
+
+func newDiffuser(maxDelay float64) diffuser {
+	return diffuser{line: NewDelayLine(conf.SampleRate, maxDelay)}
+}
+
+// process returns the next output for the input x, with the delay in
+// seconds and the coefficient g.
+func (d *diffuser) process(x, delay, g float64) float64 {
+	v := d.line.TapC(delay - 1/d.line.sampleRateHz)
+	w := zapgremlins(x + g*v)
+	d.line.WriteSample(w)
+	return v - g*w
+}
+
+// clampDecay clamps a reverb's decay time to [minDecay, maxDecay]; a
+// NaN is minDecay.
+func clampDecay(decay float64) float64 {
+	if !(decay > minDecay) {
+		return minDecay
+	}
+	return math.Min(decay, maxDecay)
+}
+
+// decayGain returns the gain that a signal going around a loop delay
+// seconds long must be scaled by each time for it to fall by 60 dB in
+// decay seconds.
+func decayGain(delay, decay float64) float64 {
+	return math.Pow(10, -3*delay/decay)
+}
+
+// clamp01 clamps x to [0, 1]; a NaN is def.
+func clamp01(x, def float64) float64 {
+	if math.IsNaN(x) {
+		return def
+	}
+	return math.Max(0, math.Min(x, 1))
+}
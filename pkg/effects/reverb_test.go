@@ -0,0 +1,181 @@
+package effects
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// renderReverb renders two seconds of a reverb's wet output for in.
+func renderReverb(t *testing.T, u ugen.UGen, in []float64, inputs map[string][]float64) *audiotest.Audio {
+	t.Helper()
+	all := map[string][]float64{
+		"in":    in,
+		"mix":   {1},
+		"early": {0},
+	}
+	for port, sig := range inputs {
+		all[port] = sig
+	}
+	out, err := audiotest.RenderUGen(context.Background(), u, all, 2*conf.SampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// burst returns a tenth of a second of a sine at freq Hz, then silence.
+func burst(freq float64) []float64 {
+	n := 2 * conf.SampleRate
+	s := audiotest.Sine(freq, n)
+	for i := conf.SampleRate / 10; i < n; i++ {
+		s[i] = 0
+	}
+	return s
+}
+
+// decayRatio returns the level of a's tail a second later than at
+// 0.3 seconds, relative to it.
+func decayRatio(a *audiotest.Audio) float64 {
+	return a.Slice(1.3, 1.5).RMS() / a.Slice(0.3, 0.5).RMS()
+}
+
+func reverbs() map[string]func(channel int) ugen.UGen {
+	return map[string]func(channel int) ugen.UGen{
+		"fdn hadamard 8":     func(ch int) ugen.UGen { return NewFDN(8, "hadamard", ch) },
+		"fdn hadamard 16":    func(ch int) ugen.UGen { return NewFDN(16, "hadamard", ch) },
+		"fdn householder 12": func(ch int) ugen.UGen { return NewFDN(12, "householder", ch) },
+		"plate":              NewPlate,
+	}
+}
+
+func TestReverbDecay(t *testing.T) {
+	// with a two-second decay time, the tail falls by 30 dB a second.
+	want := math.Pow(10, -1.5)
+	impulse := audiotest.Impulse(2 * conf.SampleRate)
+	for name, newReverb := range reverbs() {
+		a := renderReverb(t, newReverb(0), impulse, map[string][]float64{
+			"decay":   {2},
+			"damping": {0},
+		})
+		if got := decayRatio(a); got < want/1.5 || got > want*1.5 {
+			t.Errorf("%s: the tail fell to %v of its level in a second, want %v", name, got, want)
+		}
+	}
+}
+
+func TestReverbDamping(t *testing.T) {
+	for name, newReverb := range reverbs() {
+		level := func(freq, damping float64) float64 {
+			a := renderReverb(t, newReverb(0), burst(freq), map[string][]float64{"damping": {damping}})
+			return a.Slice(1, 1.5).RMS()
+		}
+		if r := level(8000, 0.9) / level(8000, 0); r > 0.1 {
+			t.Errorf("%s: damping left %v of the high tail, want it shortened", name, r)
+		}
+		if r := level(150, 0.9) / level(150, 0); r < 0.5 {
+			t.Errorf("%s: damping left %v of the low tail, want it mostly kept", name, r)
+		}
+	}
+}
+
+func TestFDNBass(t *testing.T) {
+	level := func(freq, bass float64) float64 {
+		a := renderReverb(t, NewFDN(8, "hadamard", 0), burst(freq), map[string][]float64{
+			"decay":   {1},
+			"bass":    {bass},
+			"damping": {0},
+		})
+		return a.Slice(1, 1.5).RMS()
+	}
+	if r := level(60, 2) / level(60, 1); r < 3 {
+		t.Errorf("doubling the bass decay raised the low tail by %v, want it lengthened", r)
+	}
+	if r := level(8000, 2) / level(8000, 1); r < 0.8 || r > 1.25 {
+		t.Errorf("doubling the bass decay changed the high tail by %v, want it unchanged", r)
+	}
+}
+
+func TestReverbStereo(t *testing.T) {
+	noise := audiotest.Noise(1, 2*conf.SampleRate)
+	for name, newReverb := range reverbs() {
+		left := renderReverb(t, newReverb(0), noise, nil)
+		right := renderReverb(t, newReverb(1), noise, nil)
+		stereo := &audiotest.Audio{
+			SampleRate: conf.SampleRate,
+			Channels:   [][]float64{left.Channels[0], right.Channels[0]},
+		}
+		if c := stereo.Slice(0.5, 2).Correlation(); math.Abs(c) > 0.5 {
+			t.Errorf("%s: got a correlation of %v between the channels, want them decorrelated", name, c)
+		}
+	}
+}
+
+func TestReverbModulation(t *testing.T) {
+	// the size swept fast and the decay at its longest, with a loud
+	// input.
+	n := 2 * conf.SampleRate
+	size := make([]float64, n)
+	for i := range size {
+		size[i] = 0.5 + 0.5*math.Sin(2*math.Pi*5*float64(i)/float64(conf.SampleRate))
+	}
+	for name, newReverb := range reverbs() {
+		a := renderReverb(t, newReverb(0), scale(2, audiotest.Noise(1, n)), map[string][]float64{
+			"size":      size,
+			"decay":     {1000},
+			"mod":       {1},
+			"diffusion": {1},
+			"early":     {1},
+		})
+		for i, s := range a.Mono() {
+			if math.IsNaN(s) || math.Abs(s) > 100 {
+				t.Fatalf("%s: sample %d is %v", name, i, s)
+			}
+		}
+	}
+}
+
+func TestFDNPanics(t *testing.T) {
+	for _, tc := range []struct {
+		lines   int
+		matrix  string
+		channel int
+	}{
+		{7, "householder", 0},
+		{17, "householder", 0},
+		{12, "hadamard", 0},
+		{8, "identity", 0},
+		{8, "hadamard", 2},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewFDN(%d, %q, %d): expected a panic", tc.lines, tc.matrix, tc.channel)
+				}
+			}()
+			NewFDN(tc.lines, tc.matrix, tc.channel)
+		}()
+	}
+}
+
+func TestMixingMatrices(t *testing.T) {
+	// both matrices are orthogonal, so they preserve energy.
+	for name, mix := range map[string]func([]float64){"hadamard": hadamard, "householder": householder} {
+		v := []float64{1, -2, 0.5, 3, 0, -1, 2, 0.25}
+		var before, after float64
+		for _, x := range v {
+			before += x * x
+		}
+		mix(v)
+		for _, x := range v {
+			after += x * x
+		}
+		if math.Abs(after-before) > 1e-9 {
+			t.Errorf("%s: energy went from %v to %v", name, before, after)
+		}
+	}
+}
@@ -28,14 +28,13 @@ func NewRHPF() ugen.UGen {
 		a0 = (1.0 + C + b1) * 0.25
 	}
 
+	inInput := ugen.Input{Name: "in"}
+	freqInput := ugen.Input{Name: "freq", Default: 440}
+	resonInput := ugen.Input{Name: "reson", Default: 1}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		freq := cfg.InputSamples["freq"]
-		reson := cfg.InputSamples["reson"]
-
-		_ = in[len(out)-1]
-		_ = freq[len(out)-1]
-		_ = reson[len(out)-1]
+		in := inInput.Samples(cfg, len(out))
+		freq := freqInput.Samples(cfg, len(out))
+		reson := resonInput.Samples(cfg, len(out))
 
 		for i := range out {
 			if !coefficientsComputed || freq[i] != prevFreq || reson[i] != prevReson {
@@ -0,0 +1,77 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// sallenKeyFeedback is the feedback of the Sallen-Key filter at full
+// resonance, a little over the 2 at which the linear filter starts to
+// oscillate, so that it keeps oscillating through the saturation.
+const sallenKeyFeedback = 2.1
+
+// NewSallenKey returns a zero-delay-feedback model of the Sallen-Key
+// filter of the Korg MS-20, a 12 dB per octave filter whose resonance
+// saturates, growling as it's driven. It stays stable while its
+// cutoff and resonance are modulated at audio rate. mode is "lp" or
+// "hp". Its "freq" input is the cutoff in Hz and its "res" input,
+// from 0 to 1, the resonance; near 1, the filter oscillates on its
+// own at the cutoff. Its "drive" input scales the signal into the
+// saturation.
+//
+// The low-pass follows Will Pirkle's model of the Korg35 filter; the
+// high-pass mirrors it, swapping low-pass and high-pass stages.
+func NewSallenKey(mode string) ugen.UGen {
+	if mode != "lp" && mode != "hp" {
+		panic(fmt.Errorf("sallen-key: unknown mode %q", mode))
+	}
+	highpass := mode == "hp"
+
+	// the low-pass filter is a low-pass stage into a loop of a
+	// low-pass and a high-pass; the high-pass filter swaps low and
+	// high-pass throughout.
+	var first, second, loop onePole
+
+	inInput := ugen.Input{Name: "in"}
+	freqInput := ugen.Input{Name: "freq", Default: 440}
+	resInput := ugen.Input{Name: "res"}
+	driveInput := ugen.Input{Name: "drive", Default: 1}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		in := inInput.Samples(cfg, len(out))
+		freqs := freqInput.Samples(cfg, len(out))
+		ress := resInput.Samples(cfg, len(out))
+		drives := driveInput.Samples(cfg, len(out))
+		sampleRate := float64(cfg.SampleRateHz)
+
+		for i := range out {
+			x := zapgremlins(in[i])
+			drive := drives[i]
+			if !(drive > 0) {
+				drive = 0
+			}
+			g := zdfGain(freqs[i], sampleRate)
+			G := g / (1 + g)
+			K := sallenKeyFeedback * clampRes(ress[i])
+			alpha0 := 1 / (1 - K*G + K*G*G)
+
+			var y float64
+			if highpass {
+				y1 := first.highpass(x, G)
+				S := (loop.s - K*G*second.s) / (1 + g)
+				u := math.Tanh(drive * alpha0 * (y1 + S))
+				y = second.highpass(u, G)
+				loop.lowpass(K*y, G)
+			} else {
+				y1 := first.lowpass(x, G)
+				S := ((K-K*G)*second.s - loop.s) / (1 + g)
+				u := math.Tanh(drive * alpha0 * (y1 + S))
+				y = second.lowpass(u, G)
+				loop.highpass(K*y, G)
+			}
+			out[i] = y
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// svfMixes holds the mix of the input, band-pass, damped band-pass
+// and low-pass signals of a state-variable filter that makes each of
+// its outputs.
+var svfMixes = map[string][4]float64{
+	"lp":    {0, 0, 0, 1},
+	"bp":    {0, 2, 0, 0},
+	"hp":    {1, 0, -1, -1},
+	"notch": {1, 0, -1, 0},
+	"peak":  {-1, 0, 1, 2},
+	"ap":    {1, 0, -2, 0},
+}
+
+type (
+	// svf is a state-variable filter. It outputs its low-pass, and
+	// passes the rest of its state for the block to the ugens
+	// returned by NewSVFOutput, so that any number of its outputs
+	// can be taken while it filters only once.
+	svf struct {
+		inInput   ugen.Input
+		freqInput ugen.Input
+		resInput  ugen.Input
+
+		ic1, ic2 float64
+		state    svfState
+	}
+
+	// svfState holds the input, band-pass signal and damping of an
+	// svf at each sample of a block.
+	svfState struct {
+		x, v1, k []float64
+	}
+)
+
+// NewSVF returns a state-variable filter ugen, discretized with the
+// topology-preserving transform so that it stays stable while its
+// cutoff and resonance are modulated at audio rate. Its "freq" input
+// is the cutoff in Hz and its "res" input, from 0 to 1, the
+// resonance; at 1, the filter rings indefinitely.
+//
+// It outputs the low-pass. Its other outputs are made by the ugens
+// returned by NewSVFOutput, with their "in" input connected to it.
+func NewSVF() ugen.UGen {
+	return &svf{
+		inInput:   ugen.Input{Name: "in"},
+		freqInput: ugen.Input{Name: "freq", Default: 440},
+		resInput:  ugen.Input{Name: "res"},
+	}
+}
+
+func (f *svf) Data() any {
+	return &f.state
+}
+
+func (f *svf) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	in := f.inInput.Samples(cfg, len(out))
+	freqs := f.freqInput.Samples(cfg, len(out))
+	ress := f.resInput.Samples(cfg, len(out))
+	sampleRate := float64(cfg.SampleRateHz)
+
+	st := &f.state
+	if cap(st.x) < len(out) {
+		st.x = make([]float64, len(out))
+		st.v1 = make([]float64, len(out))
+		st.k = make([]float64, len(out))
+	}
+	st.x, st.v1, st.k = st.x[:len(out)], st.v1[:len(out)], st.k[:len(out)]
+
+	for i := range out {
+		x := zapgremlins(in[i])
+		g := zdfGain(freqs[i], sampleRate)
+		k := 2 * (1 - clampRes(ress[i]))
+
+		// Andrew Simper's formulation of the trapezoidal SVF.
+		a1 := 1 / (1 + g*(g+k))
+		a2 := g * a1
+		a3 := g * a2
+		v3 := x - f.ic2
+		v1 := a1*f.ic1 + a2*v3
+		v2 := f.ic2 + a2*f.ic1 + a3*v3
+		f.ic1 = ugen.ZapGremlins(2*v1 - f.ic1)
+		f.ic2 = ugen.ZapGremlins(2*v2 - f.ic2)
+
+		st.x[i], st.v1[i], st.k[i] = x, v1, k
+		out[i] = v2
+	}
+}
+
+// NewSVFOutput returns a ugen that outputs one mode of the
+// state-variable filter connected to its "in" input, from the state
+// it computed for the block: "lp", "bp", "hp", "notch", "peak" (the
+// low-pass minus the high-pass) or "ap". The band-pass has unity gain
+// at the cutoff with no resonance, rising with the resonance like the
+// peak.
+func NewSVFOutput(mode string) ugen.UGen {
+	mix, ok := svfMixes[mode]
+	if !ok {
+		panic(fmt.Errorf("svf: unknown mode %q", mode))
+	}
+
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		st, _ := cfg.InputData["in"].(*svfState)
+		lp := cfg.InputSamples["in"]
+		if st == nil || len(st.x) < len(out) || len(lp) < len(out) {
+			clear(out)
+			return
+		}
+		for i := range out {
+			out[i] = mix[0]*st.x[i] + (mix[1]+mix[2]*st.k[i])*st.v1[i] + mix[3]*lp[i]
+		}
+	})
+}
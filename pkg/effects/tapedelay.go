@@ -17,13 +17,15 @@ func NewTapeDelay() ugen.UGen {
 	// read head will decelerate.
 	var tape []float64
 	var readHead float64
+	inInput := ugen.Input{Name: "$0"}
+	delayInput := ugen.Input{Name: "delay"}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		in := cfg.InputSamples["$0"]
-		delays := cfg.InputSamples["delay"]
+		in := inInput.Samples(cfg, len(out))
+		delays := delayInput.Samples(cfg, len(out))
 
 		for i := range out {
 			delaySeconds := delays[i]
-			if delaySeconds < 0 {
+			if delaySeconds < 0 || math.IsNaN(delaySeconds) || math.IsInf(delaySeconds, 0) {
 				delaySeconds = 0
 			}
 			delaySamples := delaySeconds * float64(cfg.SampleRateHz)
@@ -8,30 +8,30 @@ import (
 )
 
 type (
-	WaveFolder struct{}
+	WaveFolder struct {
+		in, lo, hi ugen.Input
+	}
 )
 
 func NewWaveFolder() *WaveFolder {
-	return &WaveFolder{}
+	return &WaveFolder{
+		in: ugen.Input{Name: "in"},
+		lo: ugen.Input{Name: "lo", Default: -1},
+		hi: ugen.Input{Name: "hi", Default: 1},
+	}
 }
 
 func (w *WaveFolder) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-	in := cfg.InputSamples["in"]
-	los := cfg.InputSamples["lo"]
-	his := cfg.InputSamples["hi"]
+	in := w.in.Samples(cfg, len(out))
+	los := w.lo.Samples(cfg, len(out))
+	his := w.hi.Samples(cfg, len(out))
 
 	for i := range out {
 		out[i] = in[i]
 
 		x := in[i]
-		lo := -1.0
-		hi := 1.0
-		if len(los) > 0 {
-			lo = los[i]
-		}
-		if len(his) > 0 {
-			hi = math.Max(his[i], lo)
-		}
+		lo := los[i]
+		hi := math.Max(his[i], lo)
 
 		// transform x such -1 = lo, 0 = (lo + hi) / 2, 1 = hi
 		mid := (lo + hi) / 2
@@ -0,0 +1,53 @@
+package effects
+
+import (
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// minCutoff is the lowest cutoff, in Hz, of the zero-delay-feedback
+// filters.
+const minCutoff = 1
+
+// zdfGain returns the gain of the integrators of a zero-delay-feedback
+// filter with its cutoff at freq Hz, prewarped so that the digital
+// filter's cutoff lands on freq. The cutoff is clamped to the range in
+// which the filters are well behaved, below the Nyquist frequency.
+func zdfGain(freq, sampleRate float64) float64 {
+	if !(freq > minCutoff) {
+		freq = minCutoff
+	}
+	freq = math.Min(freq, 0.49*sampleRate)
+	return math.Tan(math.Pi * freq / sampleRate)
+}
+
+// clampRes clamps a resonance input to [0, 1]; a NaN is 0.
+func clampRes(res float64) float64 {
+	if !(res > 0) {
+		return 0
+	}
+	return math.Min(res, 1)
+}
+
+// onePole is the state of a one-pole filter discretized with the
+// trapezoidal rule, the building block of the ladder and Sallen-Key
+// filters.
+type onePole struct {
+	s float64
+}
+
+// lowpass returns the next sample of the low-pass output given the
+// next input, where G is g/(1+g) for the integrator gain g.
+func (p *onePole) lowpass(x, G float64) float64 {
+	v := (x - p.s) * G
+	y := v + p.s
+	p.s = ugen.ZapGremlins(y + v)
+	return y
+}
+
+// highpass returns the next sample of the high-pass output given the
+// next input.
+func (p *onePole) highpass(x, G float64) float64 {
+	return x - p.lowpass(x, G)
+}
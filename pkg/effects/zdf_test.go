@@ -0,0 +1,193 @@
+package effects
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// gainAt returns the gain of a filter for a quiet sine at freq Hz,
+// once it has settled.
+func gainAt(t *testing.T, u ugen.UGen, freq float64, inputs map[string][]float64) float64 {
+	t.Helper()
+	const amp = 0.01
+	n := conf.SampleRate / 2
+	in := map[string][]float64{"in": scale(amp, audiotest.Sine(freq, n))}
+	for port, sig := range inputs {
+		in[port] = sig
+	}
+	out, err := audiotest.RenderUGen(context.Background(), u, in, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.Slice(0.25, 0.5).RMS() / (amp / math.Sqrt2)
+}
+
+// newSVFMode returns a ugen that runs an SVF and outputs one of its
+// modes, wired as the svf function wires them.
+func newSVFMode(mode string) ugen.UGen {
+	filter := NewSVF()
+	if mode == "lp" {
+		return filter
+	}
+	output := NewSVFOutput(mode)
+	var lp []float64
+	outCfg := ugen.SampleConfig{
+		InputSamples: map[string][]float64{},
+		InputData:    map[string]any{"in": filter.(ugen.DataSource).Data()},
+	}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		if cap(lp) < len(out) {
+			lp = make([]float64, len(out))
+		}
+		lp = lp[:len(out)]
+		filter.Gen(ctx, cfg, lp)
+		outCfg.SampleRateHz = cfg.SampleRateHz
+		outCfg.InputSamples["in"] = lp
+		output.Gen(ctx, outCfg, out)
+	})
+}
+
+func TestSVF(t *testing.T) {
+	for _, tc := range []struct {
+		mode     string
+		res      float64
+		freq     float64
+		min, max float64
+	}{
+		{"lp", 0, 100, 0.98, 1.01},
+		{"lp", 0, 10000, 0, 0.02},
+		{"hp", 0, 100, 0, 0.02},
+		{"hp", 0, 10000, 0.98, 1.01},
+		{"bp", 0, 1000, 0.98, 1.02},
+		{"bp", 0.9, 1000, 9.8, 10.2},
+		{"bp", 0.9, 10000, 0, 0.3},
+		{"notch", 0, 1000, 0, 0.01},
+		{"notch", 0, 100, 0.98, 1.01},
+		{"peak", 0, 1000, 0.98, 1.02},
+		{"peak", 0.5, 1000, 1.96, 2.04},
+		{"ap", 0.3, 300, 0.99, 1.01},
+		{"ap", 0.3, 1000, 0.99, 1.01},
+		{"ap", 0.3, 3000, 0.99, 1.01},
+	} {
+		g := gainAt(t, newSVFMode(tc.mode), tc.freq, map[string][]float64{
+			"freq": {1000},
+			"res":  {tc.res},
+		})
+		if g < tc.min || g > tc.max {
+			t.Errorf("%s, res %v: got gain %v at %vHz, want %v to %v", tc.mode, tc.res, g, tc.freq, tc.min, tc.max)
+		}
+	}
+}
+
+func TestSVFPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	NewSVFOutput("comb")
+}
+
+func TestLadder(t *testing.T) {
+	controls := map[string][]float64{"freq": {1000}, "res": {0}}
+	if g := gainAt(t, NewLadder(), 100, controls); g < 0.98 || g > 1.01 {
+		t.Errorf("got gain %v in the passband, want 1", g)
+	}
+	// two octaves above the cutoff, 24dB per octave.
+	if g := gainAt(t, NewLadder(), 4000, controls); g > 0.005 {
+		t.Errorf("got gain %v two octaves above the cutoff, want under 0.005", g)
+	}
+}
+
+func TestSallenKey(t *testing.T) {
+	controls := map[string][]float64{"freq": {1000}, "res": {0}}
+	for _, tc := range []struct {
+		mode     string
+		freq     float64
+		min, max float64
+	}{
+		{"lp", 100, 0.97, 1.03},
+		{"lp", 10000, 0, 0.02},
+		{"hp", 100, 0, 0.02},
+		{"hp", 10000, 0.97, 1.03},
+	} {
+		if g := gainAt(t, NewSallenKey(tc.mode), tc.freq, controls); g < tc.min || g > tc.max {
+			t.Errorf("%s: got gain %v at %vHz, want %v to %v", tc.mode, g, tc.freq, tc.min, tc.max)
+		}
+	}
+}
+
+func TestSelfOscillation(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		u    ugen.UGen
+	}{
+		{"svf", newSVFMode("bp")},
+		{"ladder", NewLadder()},
+		{"sallen-key lp", NewSallenKey("lp")},
+		{"sallen-key hp", NewSallenKey("hp")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// an impulse, then silence.
+			n := conf.SampleRate
+			out, err := audiotest.RenderUGen(context.Background(), tc.u, map[string][]float64{
+				"in":   scale(0.1, audiotest.Impulse(n)),
+				"freq": {500},
+				"res":  {1},
+			}, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest := out.Slice(0.5, 1)
+			if rms := rest.RMS(); rms < 0.01 {
+				t.Fatalf("got RMS %v, want the filter ringing", rms)
+			}
+			if got := rest.DominantFrequency(); math.Abs(got-500) > 25 {
+				t.Errorf("got dominant frequency %v, want 500", got)
+			}
+		})
+	}
+}
+
+func TestZDFModulation(t *testing.T) {
+	// cutoff and resonance swept at audio rate over their whole range,
+	// with a loud input.
+	n := conf.SampleRate
+	freq := make([]float64, n)
+	res := make([]float64, n)
+	for i := range freq {
+		t := float64(i) / float64(conf.SampleRate)
+		freq[i] = 10000 + 10000*math.Sin(2*math.Pi*3000*t)
+		res[i] = 0.5 + 0.6*math.Sin(2*math.Pi*1700*t)
+	}
+	for _, tc := range []struct {
+		name string
+		u    ugen.UGen
+	}{
+		{"svf lp", newSVFMode("lp")},
+		{"svf bp", newSVFMode("bp")},
+		{"svf peak", newSVFMode("peak")},
+		{"ladder", NewLadder()},
+		{"sallen-key lp", NewSallenKey("lp")},
+		{"sallen-key hp", NewSallenKey("hp")},
+	} {
+		out, err := audiotest.RenderUGen(context.Background(), tc.u, map[string][]float64{
+			"in":   scale(2, audiotest.Noise(1, n)),
+			"freq": freq,
+			"res":  res,
+		}, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, s := range out.Mono() {
+			if math.IsNaN(s) || math.Abs(s) > 100 {
+				t.Fatalf("%s: sample %d is %v", tc.name, i, s)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/glojurelang/glojure/pkg/pkgmap"
 	github_com_jfhamlin_freeverb_go "github.com/jfhamlin/freeverb-go"
 	github_com_jfhamlin_muscrat_pkg_aio "github.com/jfhamlin/muscrat/pkg/aio"
+	github_com_jfhamlin_muscrat_pkg_audiotest "github.com/jfhamlin/muscrat/pkg/audiotest"
 	github_com_jfhamlin_muscrat_pkg_conf "github.com/jfhamlin/muscrat/pkg/conf"
 	github_com_jfhamlin_muscrat_pkg_effects "github.com/jfhamlin/muscrat/pkg/effects"
 	github_com_jfhamlin_muscrat_pkg_graph "github.com/jfhamlin/muscrat/pkg/graph"
@@ -13,6 +14,7 @@ import (
 	github_com_jfhamlin_muscrat_pkg_pattern "github.com/jfhamlin/muscrat/pkg/pattern"
 	github_com_jfhamlin_muscrat_pkg_sampler "github.com/jfhamlin/muscrat/pkg/sampler"
 	github_com_jfhamlin_muscrat_pkg_slice "github.com/jfhamlin/muscrat/pkg/slice"
+	github_com_jfhamlin_muscrat_pkg_spectral "github.com/jfhamlin/muscrat/pkg/spectral"
 	github_com_jfhamlin_muscrat_pkg_stochastic "github.com/jfhamlin/muscrat/pkg/stochastic"
 	github_com_jfhamlin_muscrat_pkg_ugen "github.com/jfhamlin/muscrat/pkg/ugen"
 	github_com_jfhamlin_muscrat_pkg_wavtabs "github.com/jfhamlin/muscrat/pkg/wavtabs"
@@ -40,6 +42,7 @@ func RegisterImports(_register func(string, interface{})) {
 	_register("github.com/jfhamlin/muscrat/pkg/aio.*KeyboardGate", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.KeyboardGate)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/aio.KeyboardNotes", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.KeyboardNotes)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/aio.*KeyboardNotes", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.KeyboardNotes)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/aio.ListenMIDI", github_com_jfhamlin_muscrat_pkg_aio.ListenMIDI)
 	_register("github.com/jfhamlin/muscrat/pkg/aio.MIDIControl", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.MIDIControl)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/aio.*MIDIControl", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.MIDIControl)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/aio.MIDIDevice", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.MIDIDevice)(nil)).Elem())
@@ -48,11 +51,18 @@ func RegisterImports(_register func(string, interface{})) {
 	_register("github.com/jfhamlin/muscrat/pkg/aio.*MIDIEnvelope", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.MIDIEnvelope)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/aio.NewInputDevice", github_com_jfhamlin_muscrat_pkg_aio.NewInputDevice)
 	_register("github.com/jfhamlin/muscrat/pkg/aio.NewMIDIInputDevice", github_com_jfhamlin_muscrat_pkg_aio.NewMIDIInputDevice)
+	_register("github.com/jfhamlin/muscrat/pkg/aio.NewOSCIn", github_com_jfhamlin_muscrat_pkg_aio.NewOSCIn)
+	_register("github.com/jfhamlin/muscrat/pkg/aio.NewOSCOut", github_com_jfhamlin_muscrat_pkg_aio.NewOSCOut)
 	_register("github.com/jfhamlin/muscrat/pkg/aio.NewQwertyMIDI", github_com_jfhamlin_muscrat_pkg_aio.NewQwertyMIDI)
 	_register("github.com/jfhamlin/muscrat/pkg/aio.NewSoftwareKeyboard", github_com_jfhamlin_muscrat_pkg_aio.NewSoftwareKeyboard)
 	_register("github.com/jfhamlin/muscrat/pkg/aio.NewWavOut", github_com_jfhamlin_muscrat_pkg_aio.NewWavOut)
+	_register("github.com/jfhamlin/muscrat/pkg/aio.OSCIn", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.OSCIn)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/aio.*OSCIn", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.OSCIn)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/aio.OSCOut", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.OSCOut)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/aio.*OSCOut", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.OSCOut)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/aio.QwertyMIDI", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.QwertyMIDI)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/aio.*QwertyMIDI", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.QwertyMIDI)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/aio.SetOSCControl", github_com_jfhamlin_muscrat_pkg_aio.SetOSCControl)
 	_register("github.com/jfhamlin/muscrat/pkg/aio.SoftwareKeyboard", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.SoftwareKeyboard)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/aio.*SoftwareKeyboard", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_aio.SoftwareKeyboard)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/aio.StdinChan", github_com_jfhamlin_muscrat_pkg_aio.StdinChan)
@@ -65,9 +75,25 @@ func RegisterImports(_register func(string, interface{})) {
 	_register("github.com/jfhamlin/muscrat/pkg/aio.WithDeviceName", github_com_jfhamlin_muscrat_pkg_aio.WithDeviceName)
 	_register("github.com/jfhamlin/muscrat/pkg/aio.WithVoices", github_com_jfhamlin_muscrat_pkg_aio.WithVoices)
 
+	// package github.com/jfhamlin/muscrat/pkg/audiotest
+	////////////////////////////////////////
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.Audio", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_audiotest.Audio)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.*Audio", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_audiotest.Audio)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.Impulse", github_com_jfhamlin_muscrat_pkg_audiotest.Impulse)
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.Noise", github_com_jfhamlin_muscrat_pkg_audiotest.Noise)
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.Render", github_com_jfhamlin_muscrat_pkg_audiotest.Render)
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.RenderContext", github_com_jfhamlin_muscrat_pkg_audiotest.RenderContext)
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.RenderUGen", github_com_jfhamlin_muscrat_pkg_audiotest.RenderUGen)
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.SilenceThreshold", float64(github_com_jfhamlin_muscrat_pkg_audiotest.SilenceThreshold))
+	_register("github.com/jfhamlin/muscrat/pkg/audiotest.Sine", github_com_jfhamlin_muscrat_pkg_audiotest.Sine)
+
 	// package github.com/jfhamlin/muscrat/pkg/conf
 	////////////////////////////////////////
+	_register("github.com/jfhamlin/muscrat/pkg/conf.APIPort", github_com_jfhamlin_muscrat_pkg_conf.APIPort)
 	_register("github.com/jfhamlin/muscrat/pkg/conf.BufferSize", github_com_jfhamlin_muscrat_pkg_conf.BufferSize)
+	_register("github.com/jfhamlin/muscrat/pkg/conf.EvalTimeout", github_com_jfhamlin_muscrat_pkg_conf.EvalTimeout)
+	_register("github.com/jfhamlin/muscrat/pkg/conf.OSCEval", github_com_jfhamlin_muscrat_pkg_conf.OSCEval)
+	_register("github.com/jfhamlin/muscrat/pkg/conf.OSCPort", github_com_jfhamlin_muscrat_pkg_conf.OSCPort)
 	_register("github.com/jfhamlin/muscrat/pkg/conf.SampleFilePaths", github_com_jfhamlin_muscrat_pkg_conf.SampleFilePaths)
 	_register("github.com/jfhamlin/muscrat/pkg/conf.SampleRate", github_com_jfhamlin_muscrat_pkg_conf.SampleRate)
 
@@ -77,23 +103,37 @@ func RegisterImports(_register func(string, interface{})) {
 	_register("github.com/jfhamlin/muscrat/pkg/effects.*DelayLine", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_effects.DelayLine)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewAllPass", github_com_jfhamlin_muscrat_pkg_effects.NewAllPass)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewAmplitude", github_com_jfhamlin_muscrat_pkg_effects.NewAmplitude)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewApplyGain", github_com_jfhamlin_muscrat_pkg_effects.NewApplyGain)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewBPF", github_com_jfhamlin_muscrat_pkg_effects.NewBPF)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewBitcrusher", github_com_jfhamlin_muscrat_pkg_effects.NewBitcrusher)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewChorus", github_com_jfhamlin_muscrat_pkg_effects.NewChorus)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewClip", github_com_jfhamlin_muscrat_pkg_effects.NewClip)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewCompressor", github_com_jfhamlin_muscrat_pkg_effects.NewCompressor)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewConvolver", github_com_jfhamlin_muscrat_pkg_effects.NewConvolver)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewDelay", github_com_jfhamlin_muscrat_pkg_effects.NewDelay)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewDelayLine", github_com_jfhamlin_muscrat_pkg_effects.NewDelayLine)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewEnsemble", github_com_jfhamlin_muscrat_pkg_effects.NewEnsemble)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewExpander", github_com_jfhamlin_muscrat_pkg_effects.NewExpander)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewFDN", github_com_jfhamlin_muscrat_pkg_effects.NewFDN)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewFlanger", github_com_jfhamlin_muscrat_pkg_effects.NewFlanger)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewFreeverb", github_com_jfhamlin_muscrat_pkg_effects.NewFreeverb)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewHPF", github_com_jfhamlin_muscrat_pkg_effects.NewHPF)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewHiShelf", github_com_jfhamlin_muscrat_pkg_effects.NewHiShelf)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewLPF", github_com_jfhamlin_muscrat_pkg_effects.NewLPF)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewLadder", github_com_jfhamlin_muscrat_pkg_effects.NewLadder)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewLimiter", github_com_jfhamlin_muscrat_pkg_effects.NewLimiter)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewLoShelf", github_com_jfhamlin_muscrat_pkg_effects.NewLoShelf)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewLowpassFilter", github_com_jfhamlin_muscrat_pkg_effects.NewLowpassFilter)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewMoogFF", github_com_jfhamlin_muscrat_pkg_effects.NewMoogFF)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewPeakEQ", github_com_jfhamlin_muscrat_pkg_effects.NewPeakEQ)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewPhaser", github_com_jfhamlin_muscrat_pkg_effects.NewPhaser)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewPitchShift", github_com_jfhamlin_muscrat_pkg_effects.NewPitchShift)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewPlate", github_com_jfhamlin_muscrat_pkg_effects.NewPlate)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewRHPF", github_com_jfhamlin_muscrat_pkg_effects.NewRHPF)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewRLPF", github_com_jfhamlin_muscrat_pkg_effects.NewRLPF)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewSVF", github_com_jfhamlin_muscrat_pkg_effects.NewSVF)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewSVFOutput", github_com_jfhamlin_muscrat_pkg_effects.NewSVFOutput)
+	_register("github.com/jfhamlin/muscrat/pkg/effects.NewSallenKey", github_com_jfhamlin_muscrat_pkg_effects.NewSallenKey)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewTapeDelay", github_com_jfhamlin_muscrat_pkg_effects.NewTapeDelay)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.NewWaveFolder", github_com_jfhamlin_muscrat_pkg_effects.NewWaveFolder)
 	_register("github.com/jfhamlin/muscrat/pkg/effects.WaveFolder", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_effects.WaveFolder)(nil)).Elem())
@@ -102,6 +142,7 @@ func RegisterImports(_register func(string, interface{})) {
 	// package github.com/jfhamlin/muscrat/pkg/graph
 	////////////////////////////////////////
 	_register("github.com/jfhamlin/muscrat/pkg/graph.AlignGraphs", github_com_jfhamlin_muscrat_pkg_graph.AlignGraphs)
+	_register("github.com/jfhamlin/muscrat/pkg/graph.Crossfade", github_com_jfhamlin_muscrat_pkg_graph.Crossfade)
 	_register("github.com/jfhamlin/muscrat/pkg/graph.Edge", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Edge)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/graph.*Edge", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Edge)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/graph.Graph", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Graph)(nil)).Elem())
@@ -114,10 +155,12 @@ func RegisterImports(_register func(string, interface{})) {
 	_register("github.com/jfhamlin/muscrat/pkg/graph.Node", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Node)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/graph.*Node", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Node)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/graph.NodeID", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.NodeID)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/graph.ParseGraph", github_com_jfhamlin_muscrat_pkg_graph.ParseGraph)
 	_register("github.com/jfhamlin/muscrat/pkg/graph.Queue", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Queue)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/graph.*Queue", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Queue)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/graph.QueueItem", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.QueueItem)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/graph.*QueueItem", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.QueueItem)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/graph.Render", github_com_jfhamlin_muscrat_pkg_graph.Render)
 	_register("github.com/jfhamlin/muscrat/pkg/graph.Runner", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Runner)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/graph.*Runner", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_graph.Runner)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/graph.SExprToGraph", github_com_jfhamlin_muscrat_pkg_graph.SExprToGraph)
@@ -139,10 +182,13 @@ func RegisterImports(_register func(string, interface{})) {
 	_register("github.com/jfhamlin/muscrat/pkg/osc.NewSaw", github_com_jfhamlin_muscrat_pkg_osc.NewSaw)
 	_register("github.com/jfhamlin/muscrat/pkg/osc.NewSine", github_com_jfhamlin_muscrat_pkg_osc.NewSine)
 	_register("github.com/jfhamlin/muscrat/pkg/osc.NewTri", github_com_jfhamlin_muscrat_pkg_osc.NewTri)
+	_register("github.com/jfhamlin/muscrat/pkg/osc.NewWavetable", github_com_jfhamlin_muscrat_pkg_osc.NewWavetable)
 	_register("github.com/jfhamlin/muscrat/pkg/osc.Osc", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_osc.Osc)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/osc.*Osc", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_osc.Osc)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/osc.Sampler", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_osc.Sampler)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/osc.SamplerFunc", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_osc.SamplerFunc)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/osc.Wavetable", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_osc.Wavetable)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/osc.*Wavetable", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_osc.Wavetable)(nil)))
 
 	// package github.com/jfhamlin/muscrat/pkg/pattern
 	////////////////////////////////////////
@@ -151,13 +197,35 @@ func RegisterImports(_register func(string, interface{})) {
 
 	// package github.com/jfhamlin/muscrat/pkg/sampler
 	////////////////////////////////////////
+	_register("github.com/jfhamlin/muscrat/pkg/sampler.Grains", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_sampler.Grains)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/sampler.*Grains", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_sampler.Grains)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/sampler.LoadChannels", github_com_jfhamlin_muscrat_pkg_sampler.LoadChannels)
 	_register("github.com/jfhamlin/muscrat/pkg/sampler.LoadSample", github_com_jfhamlin_muscrat_pkg_sampler.LoadSample)
+	_register("github.com/jfhamlin/muscrat/pkg/sampler.NewGrains", github_com_jfhamlin_muscrat_pkg_sampler.NewGrains)
 	_register("github.com/jfhamlin/muscrat/pkg/sampler.NewSampler", github_com_jfhamlin_muscrat_pkg_sampler.NewSampler)
 
 	// package github.com/jfhamlin/muscrat/pkg/slice
 	////////////////////////////////////////
 	_register("github.com/jfhamlin/muscrat/pkg/slice.FindIndexOfRisingEdge", github_com_jfhamlin_muscrat_pkg_slice.FindIndexOfRisingEdge)
 
+	// package github.com/jfhamlin/muscrat/pkg/spectral
+	////////////////////////////////////////
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.Frame", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_spectral.Frame)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.*Frame", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_spectral.Frame)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.Frames", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_spectral.Frames)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.*Frames", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_spectral.Frames)(nil)))
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.MaxSize", github_com_jfhamlin_muscrat_pkg_spectral.MaxSize)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.MinSize", github_com_jfhamlin_muscrat_pkg_spectral.MinSize)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewBinShift", github_com_jfhamlin_muscrat_pkg_spectral.NewBinShift)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewBlur", github_com_jfhamlin_muscrat_pkg_spectral.NewBlur)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewBrickWall", github_com_jfhamlin_muscrat_pkg_spectral.NewBrickWall)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewComb", github_com_jfhamlin_muscrat_pkg_spectral.NewComb)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewCross", github_com_jfhamlin_muscrat_pkg_spectral.NewCross)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewFFT", github_com_jfhamlin_muscrat_pkg_spectral.NewFFT)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewFreeze", github_com_jfhamlin_muscrat_pkg_spectral.NewFreeze)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewIFFT", github_com_jfhamlin_muscrat_pkg_spectral.NewIFFT)
+	_register("github.com/jfhamlin/muscrat/pkg/spectral.NewMagGate", github_com_jfhamlin_muscrat_pkg_spectral.NewMagGate)
+
 	// package github.com/jfhamlin/muscrat/pkg/stochastic
 	////////////////////////////////////////
 	_register("github.com/jfhamlin/muscrat/pkg/stochastic.NewNoise", github_com_jfhamlin_muscrat_pkg_stochastic.NewNoise)
@@ -169,10 +237,14 @@ func RegisterImports(_register func(string, interface{})) {
 
 	// package github.com/jfhamlin/muscrat/pkg/ugen
 	////////////////////////////////////////
+	_register("github.com/jfhamlin/muscrat/pkg/ugen.AppendIndexedInputs", github_com_jfhamlin_muscrat_pkg_ugen.AppendIndexedInputs)
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.CollectIndexedInputs", github_com_jfhamlin_muscrat_pkg_ugen.CollectIndexedInputs)
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.CubInterp", github_com_jfhamlin_muscrat_pkg_ugen.CubInterp)
+	_register("github.com/jfhamlin/muscrat/pkg/ugen.DataSource", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_ugen.DataSource)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.DefaultOptions", github_com_jfhamlin_muscrat_pkg_ugen.DefaultOptions)
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.GetKnobs", github_com_jfhamlin_muscrat_pkg_ugen.GetKnobs)
+	_register("github.com/jfhamlin/muscrat/pkg/ugen.Input", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_ugen.Input)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/ugen.*Input", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_ugen.Input)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.Interp", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_ugen.Interp)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.InterpCubic", github_com_jfhamlin_muscrat_pkg_ugen.InterpCubic)
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.InterpLinear", github_com_jfhamlin_muscrat_pkg_ugen.InterpLinear)
@@ -182,6 +254,7 @@ func RegisterImports(_register func(string, interface{})) {
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.KnobUpdate", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_ugen.KnobUpdate)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.*KnobUpdate", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_ugen.KnobUpdate)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.KnobValueChangeEvent", github_com_jfhamlin_muscrat_pkg_ugen.KnobValueChangeEvent)
+	_register("github.com/jfhamlin/muscrat/pkg/ugen.KnobValueSetEvent", github_com_jfhamlin_muscrat_pkg_ugen.KnobValueSetEvent)
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.KnobsChangedEvent", github_com_jfhamlin_muscrat_pkg_ugen.KnobsChangedEvent)
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.LinInterp", github_com_jfhamlin_muscrat_pkg_ugen.LinInterp)
 	_register("github.com/jfhamlin/muscrat/pkg/ugen.NewAbs", github_com_jfhamlin_muscrat_pkg_ugen.NewAbs)
@@ -236,8 +309,15 @@ func RegisterImports(_register func(string, interface{})) {
 	// package github.com/jfhamlin/muscrat/pkg/wavtabs
 	////////////////////////////////////////
 	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.DefaultResolution", github_com_jfhamlin_muscrat_pkg_wavtabs.DefaultResolution)
+	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.FrameSize", github_com_jfhamlin_muscrat_pkg_wavtabs.FrameSize)
+	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.Load", github_com_jfhamlin_muscrat_pkg_wavtabs.Load)
 	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.New", github_com_jfhamlin_muscrat_pkg_wavtabs.New)
+	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.NewHarmonicStack", github_com_jfhamlin_muscrat_pkg_wavtabs.NewHarmonicStack)
+	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.NewStack", github_com_jfhamlin_muscrat_pkg_wavtabs.NewStack)
 	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.NewWithWrap", github_com_jfhamlin_muscrat_pkg_wavtabs.NewWithWrap)
+	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.ReadWAV", github_com_jfhamlin_muscrat_pkg_wavtabs.ReadWAV)
+	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.Stack", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_wavtabs.Stack)(nil)).Elem())
+	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.*Stack", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_wavtabs.Stack)(nil)))
 	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.Table", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_wavtabs.Table)(nil)).Elem())
 	_register("github.com/jfhamlin/muscrat/pkg/wavtabs.*Table", reflect.TypeOf((*github_com_jfhamlin_muscrat_pkg_wavtabs.Table)(nil)))
 }
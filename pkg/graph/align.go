@@ -21,19 +21,20 @@ func AlignGraphs(a, b *Graph) GraphAlignment {
 	aNodes := make([]*Node, 0, len(a.Nodes))
 	bNodes := make([]*Node, 0, len(b.Nodes))
 
-	// all constants are identical
+	// all constants are identical. constants whose value isn't a
+	// float are matched like any other node.
 	aConstIDs := map[float64]NodeID{}
 	for _, n := range a.Nodes {
-		if n.Type == "const" {
-			aConstIDs[lang.First(n.Args).(float64)] = n.ID
+		if x, ok := constValue(n); ok {
+			aConstIDs[x] = n.ID
 		} else {
 			aNodes = append(aNodes, n)
 		}
 	}
 	// map constant nodes in b to constant nodes in a
 	for _, n := range b.Nodes {
-		if n.Type == "const" {
-			if id, ok := aConstIDs[lang.First(n.Args).(float64)]; ok {
+		if x, ok := constValue(n); ok {
+			if id, ok := aConstIDs[x]; ok {
 				identities[n.ID] = id
 			} // else no possible match, so don't add to bNodes
 		} else {
@@ -104,6 +105,20 @@ func AlignGraphs(a, b *Graph) GraphAlignment {
 	}
 }
 
+// constValue returns the value of n if it's a constant node with a
+// single float argument.
+func constValue(n *Node) (float64, bool) {
+	if n.Type != "const" {
+		return 0, false
+	}
+	args := seqToSlice(n.Args)
+	if len(args) != 1 {
+		return 0, false
+	}
+	x, ok := args[0].(float64)
+	return x, ok
+}
+
 func seqToSlice(s any) []any {
 	var res []any
 	for s := lang.Seq(s); s != nil; s = lang.Next(s) {
@@ -146,11 +161,7 @@ func seqsEqual(a, b any) bool {
 			if typeA != typeB {
 				return false
 			}
-			continue
-		}
-		kindA := typeA.Kind()
-		kindB := typeB.Kind()
-		if kindA == reflect.Slice || kindB == reflect.Slice {
+		} else if kindA, kindB := typeA.Kind(), typeB.Kind(); kindA == reflect.Slice || kindB == reflect.Slice {
 			if kindA != kindB {
 				return false
 			}
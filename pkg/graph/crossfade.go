@@ -0,0 +1,131 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// crossfader mixes the inputs on ports prefixed "from/" into
+	// those prefixed "to/" with an equal-power fade.
+	crossfader struct {
+		samples int
+		pos     int
+	}
+)
+
+var (
+	// crossfadeSeq distinguishes the crossfade nodes of successive
+	// crossfades, so that a new crossfade never inherits the state of
+	// one in progress when the graphs are aligned.
+	crossfadeSeq atomic.Int64
+
+	crossfadeCtor = lang.NewFnFunc(func(args ...any) any {
+		return &crossfader{samples: int(args[1].(int64))}
+	})
+)
+
+// Crossfade returns a graph that plays both from and to, fading the
+// output of from into that of to over the given number of
+// samples. Once the fade is done, the graph sounds the same as to, and
+// to may be played in its place without a discontinuity: when the
+// graphs are aligned, the nodes of to take over the state they had in
+// the crossfade graph.
+//
+// Likewise, the nodes of from take over the state of the nodes of the
+// graph playing when the crossfade graph is played, if that graph is
+// from.
+func Crossfade(from, to *Graph, samples int) *Graph {
+	seq := crossfadeSeq.Add(1)
+
+	res := &Graph{}
+	outs := map[int64]bool{}
+	addGraph := func(g *Graph, prefix string) {
+		outNodes := map[NodeID]int64{}
+		for _, n := range g.Nodes {
+			// an out node without a channel is copied like any
+			// other node, and the runner will reject the graph.
+			if ch, ok := lang.First(n.Args).(int64); ok && n.Sink && n.Type == "out" {
+				outNodes[n.ID] = ch
+				outs[ch] = true
+				continue
+			}
+			cp := *n
+			cp.ID = NodeID(prefix + string(n.ID))
+			res.Nodes = append(res.Nodes, &cp)
+		}
+		for _, e := range g.Edges {
+			src := NodeID(prefix + string(e.From))
+			if ch, ok := outNodes[e.To]; ok {
+				res.Edges = append(res.Edges, &Edge{
+					From: src,
+					To:   crossfadeNodeID(seq, ch),
+					Port: prefix + string(e.To) + "/" + e.Port,
+				})
+				continue
+			}
+			res.Edges = append(res.Edges, &Edge{
+				From: src,
+				To:   NodeID(prefix + string(e.To)),
+				Port: e.Port,
+			})
+		}
+	}
+	addGraph(from, "from/")
+	addGraph(to, "to/")
+
+	channels := make([]int64, 0, len(outs))
+	for ch := range outs {
+		channels = append(channels, ch)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	for _, ch := range channels {
+		id := crossfadeNodeID(seq, ch)
+		res.Nodes = append(res.Nodes, &Node{
+			ID:   id,
+			Type: "crossfade",
+			Ctor: crossfadeCtor,
+			Args: lang.NewVector(seq, int64(samples)),
+		})
+		outID := NodeID(fmt.Sprintf("crossfade-out-%d-%d", seq, ch))
+		res.Nodes = append(res.Nodes, &Node{
+			ID:   outID,
+			Type: "out",
+			Args: lang.NewVector(ch),
+			Sink: true,
+		})
+		res.Edges = append(res.Edges, &Edge{From: id, To: outID, Port: "in"})
+	}
+
+	return res
+}
+
+func crossfadeNodeID(seq, ch int64) NodeID {
+	return NodeID(fmt.Sprintf("crossfade-%d-%d", seq, ch))
+}
+
+// Gen implements ugen.UGen.
+func (c *crossfader) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	for port, in := range cfg.InputSamples {
+		from := strings.HasPrefix(port, "from/")
+		for i := range out {
+			t := 1.0
+			if c.pos+i < c.samples {
+				t = float64(c.pos+i) / float64(c.samples)
+			}
+			gain := math.Sin(t * math.Pi / 2)
+			if from {
+				gain = math.Cos(t * math.Pi / 2)
+			}
+			out[i] += in[i] * gain
+		}
+	}
+	c.pos += len(out)
+}
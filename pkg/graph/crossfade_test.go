@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+func TestCrossfade(t *testing.T) {
+	from := SExprToGraph(readGraph(`{
+:nodes ({:id "1", :type :sin, :args [], :key nil, :sink nil}
+        {:id "2", :type :out, :ctor nil, :args [0], :key nil, :sink true}
+        {:id "3", :type :out, :ctor nil, :args [1], :key nil, :sink true}),
+:edges ({:from "1", :to "2", :port "in"}
+        {:from "1", :to "3", :port "in"})
+}`))
+	to := SExprToGraph(readGraph(`{
+:nodes ({:id "1", :type :saw, :args [], :key nil, :sink nil}
+        {:id "2", :type :out, :ctor nil, :args [0], :key nil, :sink true}),
+:edges ({:from "1", :to "2", :port "in"})
+}`))
+
+	g := Crossfade(from, to, 100)
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if sinks := g.Sinks(); len(sinks) != 2 {
+		t.Errorf("got %d sinks, want 2", len(sinks))
+	}
+
+	// the nodes of each graph are aligned with their copies.
+	if id := AlignGraphs(g, to).NodeIdentities["1"]; id != "to/1" {
+		t.Errorf("saw aligned with %q, want %q", id, "to/1")
+	}
+	if id := AlignGraphs(from, g).NodeIdentities["from/1"]; id != "1" {
+		t.Errorf("sin aligned with %q, want %q", id, "1")
+	}
+
+	var xfade *Node
+	for _, n := range g.Nodes {
+		if n.Type == "crossfade" {
+			xfade = n
+			break
+		}
+	}
+	if xfade == nil {
+		t.Fatal("no crossfade node")
+	}
+
+	ones := make([]float64, 200)
+	for i := range ones {
+		ones[i] = 1
+	}
+	out := make([]float64, 200)
+	xfade.Construct().Gen(context.Background(), ugen.SampleConfig{
+		InputSamples: map[string][]float64{
+			"from/2/in": ones,
+			"to/2/in":   make([]float64, 200),
+		},
+	}, out)
+	if out[0] != 1 {
+		t.Errorf("got %v at the start of the fade, want 1", out[0])
+	}
+	if math.Abs(out[50]-math.Cos(math.Pi/4)) > 1e-9 {
+		t.Errorf("got %v halfway through the fade, want %v", out[50], math.Cos(math.Pi/4))
+	}
+	for i := 100; i < len(out); i++ {
+		if math.Abs(out[i]) > 1e-9 {
+			t.Fatalf("got %v at %d, after the fade, want 0", out[i], i)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// fuzzData draws choices from fuzz input, returning zeros once the
+// input is used up.
+type fuzzData []byte
+
+func (d *fuzzData) intn(n int) int {
+	if len(*d) == 0 {
+		return 0
+	}
+	b := (*d)[0]
+	*d = (*d)[1:]
+	return int(b) % n
+}
+
+func (d *fuzzData) choose(xs []any) any {
+	return xs[d.intn(len(xs))]
+}
+
+var (
+	// fuzzIDs are few, so that generated graphs have duplicate ids
+	// and edges between nodes that exist.
+	fuzzIDs = []any{"1", "2", "3", "4", "5", "6", "1", "2", "3", nil, int64(1)}
+
+	fuzzTypes = []any{
+		lang.NewKeyword("const"),
+		lang.NewKeyword("const"),
+		lang.NewKeyword("out"),
+		lang.NewKeyword("out"),
+		lang.NewKeyword("sum"),
+		lang.NewKeyword("product"),
+		lang.NewKeyword("max"),
+		"sum",
+		nil,
+	}
+
+	fuzzCtors = []any{
+		nil,
+		func() ugen.UGen { return ugen.NewSum() },
+		func() ugen.UGen { return ugen.NewProduct() },
+		func(x float64) ugen.UGen { return ugen.NewConstant(x) },
+		func() ugen.UGen { return ugen.NewConstant(math.NaN()) },
+		func() ugen.UGen { return &counter{} },
+		func() ugen.UGen { panic("constructor failed") },
+		func() int { return 1 },
+		"not a function",
+	}
+
+	fuzzArgs = []any{
+		float64(0),
+		float64(440),
+		float64(-1),
+		math.NaN(),
+		math.Inf(1),
+		int64(0),
+		int64(1),
+		int64(-1),
+		int64(7),
+		nil,
+		"x",
+		lang.NewKeyword("x"),
+		[]float64{1, 2},
+		lang.NewVector(float64(1)),
+	}
+
+	fuzzPorts = []any{"in", "$0", "$1", "w", "0", "1", "", nil, int64(0)}
+
+	fuzzSinks = []any{true, false, nil, "yes"}
+)
+
+// fuzzGraph returns the graph data of a random graph of the kind a
+// script might build, or might build with a typo: nodes with
+// duplicate or missing ids, unknown types, constructors that fail or
+// take arguments of the wrong type, constants that aren't floats, out
+// nodes with odd channels, and edges that form cycles or lead nowhere.
+func fuzzGraph(d *fuzzData) any {
+	const (
+		maxNodes = 8
+		maxEdges = 12
+	)
+	var nodes []any
+	for range d.intn(maxNodes + 1) {
+		node := lang.NewMap(
+			idKW, d.choose(fuzzIDs),
+			typeKW, d.choose(fuzzTypes),
+			ctorKW, d.choose(fuzzCtors),
+			sinkKW, d.choose(fuzzSinks),
+		)
+		switch d.intn(6) {
+		case 0:
+			// no args
+		case 1:
+			node = node.Assoc(argsKW, d.choose(fuzzArgs)).(lang.IPersistentMap)
+		default:
+			args := make([]any, d.intn(3))
+			for i := range args {
+				args[i] = d.choose(fuzzArgs)
+			}
+			node = node.Assoc(argsKW, lang.NewVector(args...)).(lang.IPersistentMap)
+		}
+		nodes = append(nodes, node)
+	}
+	var edges []any
+	for range d.intn(maxEdges + 1) {
+		edges = append(edges, lang.NewMap(
+			fromKW, d.choose(fuzzIDs),
+			toKW, d.choose(fuzzIDs),
+			portKW, d.choose(fuzzPorts),
+		))
+	}
+	return lang.NewMap(nodesKW, lang.NewList(nodes...), edgesKW, lang.NewList(edges...))
+}
+
+func addGraphSeeds(f *testing.F) {
+	f.Add([]byte{})
+	// a constant into an out node.
+	f.Add([]byte{2, 0, 0, 3, 0, 2, 1, 2, 1, 2, 0, 4, 2, 2, 3, 0, 0, 1, 0, 1, 0})
+	// a sum feeding back into itself.
+	f.Add([]byte{2, 0, 4, 1, 1, 0, 1, 2, 4, 2, 5, 0, 2, 1, 2, 0, 0, 1, 1, 1, 0})
+	f.Add([]byte("the quick brown fox jumps over the lazy dog"))
+}
+
+// FuzzGraph checks that no graph data makes parsing, validating,
+// running or replacing a graph panic.
+func FuzzGraph(f *testing.F) {
+	addGraphSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := fuzzData(data)
+		a, errA := ParseGraph(fuzzGraph(&d))
+		b, errB := ParseGraph(fuzzGraph(&d))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		r, out, done := startRunner(ctx)
+		defer func() {
+			cancel()
+			<-done
+			r.stopNodes(context.Background())
+		}()
+
+		var played bool
+		for _, g := range []*Graph{a, b} {
+			if g == nil {
+				continue
+			}
+			err := setGraph(r, out, g)
+			if verr := g.Validate(); verr != nil && err == nil {
+				t.Fatalf("SetGraph accepted an invalid graph: %v", verr)
+			}
+			if err != nil {
+				continue
+			}
+			played = true
+			for range 2 {
+				buf := <-out
+				if len(buf) != 2 || len(buf[0]) != conf.BufferSize {
+					t.Fatalf("got %d channels of %d samples", len(buf), len(buf[0]))
+				}
+			}
+		}
+		if played && r.Graph() == nil {
+			t.Fatal("no graph after a successful SetGraph")
+		}
+		if errA == nil && errB == nil {
+			AlignGraphs(a, b)
+		}
+	})
+}
+
+// FuzzAlignGraphs checks the invariants of alignments of random
+// valid graphs: nodes are only matched with nodes of the other graph
+// that are identical, no node of the first graph is matched with two
+// of the second, except for constants, which are shared, and a graph
+// aligned with itself is matched node for node.
+func FuzzAlignGraphs(f *testing.F) {
+	addGraphSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := fuzzData(data)
+		a, err := ParseGraph(fuzzGraph(&d))
+		if err != nil || a.Validate() != nil {
+			return
+		}
+		b, err := ParseGraph(fuzzGraph(&d))
+		if err != nil || b.Validate() != nil {
+			return
+		}
+
+		checkAlignment(t, a, b)
+		checkAlignment(t, b, a)
+
+		self := AlignGraphs(a, a)
+		for _, n := range a.Nodes {
+			if _, ok := constValue(n); ok || !seqsEqual(n.Args, n.Args) {
+				// constants may be matched with another of the same
+				// value, and nodes whose args aren't equal to
+				// themselves, like NaN, are never matched.
+				continue
+			}
+			if got := self.NodeIdentities[n.ID]; got != n.ID {
+				t.Errorf("node %q aligned with itself matched %q", n.ID, got)
+			}
+		}
+	})
+}
+
+// startRunner starts a runner. done is closed once it stops.
+func startRunner(ctx context.Context) (r *Runner, out chan [][]float64, done chan struct{}) {
+	out = make(chan [][]float64)
+	done = make(chan struct{})
+	r = NewRunner(ctx, ugen.SampleConfig{SampleRateHz: 44100}, out)
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	return r, out, done
+}
+
+// setGraph sets the graph of r, discarding r's output until it's
+// set, as SetGraph waits for the runner to finish its block.
+func setGraph(r *Runner, out chan [][]float64, g *Graph) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.SetGraph(g)
+	}()
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case <-out:
+			// with one CPU, the runner and this loop can hand
+			// off to each other indefinitely without SetGraph
+			// being scheduled.
+			runtime.Gosched()
+		}
+	}
+}
+
+func checkAlignment(t *testing.T, a, b *Graph) {
+	t.Helper()
+
+	matched := make(map[NodeID]NodeID)
+	for bID, aID := range AlignGraphs(a, b).NodeIdentities {
+		an, bn := a.Node(aID), b.Node(bID)
+		if an == nil || bn == nil {
+			t.Fatalf("matched %q with %q, not nodes of the graphs", bID, aID)
+		}
+		if x, ok := constValue(bn); ok {
+			if y, ok := constValue(an); !ok || x != y {
+				t.Errorf("matched constant %q (%v) with %q (%v)", bID, x, aID, an.Args)
+			}
+			continue
+		}
+		if an.Type != bn.Type || !seqsEqual(an.Args, bn.Args) {
+			t.Errorf("matched %q (%s %v) with %q (%s %v)", bID, bn.Type, bn.Args, aID, an.Type, an.Args)
+		}
+		if prev, ok := matched[aID]; ok {
+			t.Errorf("matched both %q and %q with %q", prev, bID, aID)
+		}
+		matched[aID] = bID
+	}
+}
+
+func TestParseGraphErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph string
+	}{
+		{"id not a string", `{:nodes ({:id 1, :type :sin})}`},
+		{"args not a sequence", `{:nodes ({:id "1", :type :const, :args 1.0})}`},
+		{"edge from not a string", `{:nodes ({:id "1", :type :sin}) :edges ({:from 1, :to "1", :port "w"})}`},
+		{"edge without port", `{:nodes ({:id "1", :type :sin}) :edges ({:from "1", :to "1"})}`},
+		{"nodes not a sequence", `{:nodes 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseGraph(readGraph(tt.graph)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSetGraphKeepsGraphOnError(t *testing.T) {
+	c := &counter{}
+	good := &Graph{
+		Nodes: []*Node{
+			{ID: "1", Type: "counter", Ctor: func() ugen.UGen { return c }},
+			{ID: "2", Type: "out", Args: lang.NewVector(int64(0)), Sink: true},
+		},
+		Edges: []*Edge{{From: "1", To: "2", Port: "in"}},
+	}
+	bad := &Graph{
+		Nodes: []*Node{
+			{ID: "1", Type: "counter", Ctor: func() ugen.UGen { return c }},
+			{ID: "3", Type: "broken", Ctor: func() ugen.UGen { panic("broken") }},
+			{ID: "2", Type: "out", Args: lang.NewVector(int64(0)), Sink: true},
+		},
+		Edges: []*Edge{{From: "1", To: "2", Port: "$0"}, {From: "3", To: "2", Port: "$1"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r, out, done := startRunner(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	if err := setGraph(r, out, good); err != nil {
+		t.Fatal(err)
+	}
+	if err := setGraph(r, out, bad); err == nil {
+		t.Fatal("expected an error setting a graph with a failing constructor")
+	}
+	if r.Graph() != good {
+		t.Error("SetGraph replaced the graph despite the error")
+	}
+	if c.stopped {
+		t.Error("SetGraph stopped a ugen of the graph still playing")
+	}
+}
+
+// failingStarter is a ugen that fails to start.
+type failingStarter struct {
+	counter
+}
+
+func (f *failingStarter) Start(ctx context.Context) error {
+	return errors.New("no device")
+}
+
+func TestSetGraphStartError(t *testing.T) {
+	bad := &Graph{
+		Nodes: []*Node{
+			{ID: "1", Type: "device", Ctor: func() ugen.UGen { return &failingStarter{} }},
+			{ID: "2", Type: "out", Args: lang.NewVector(int64(0)), Sink: true},
+		},
+		Edges: []*Edge{{From: "1", To: "2", Port: "in"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r, out, done := startRunner(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	err := setGraph(r, out, bad)
+	if err == nil || !strings.Contains(err.Error(), "no device") {
+		t.Fatalf("got error %v, want the start error", err)
+	}
+	if r.Graph() == bad {
+		t.Error("SetGraph played a graph whose ugen failed to start")
+	}
+}
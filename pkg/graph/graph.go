@@ -1,6 +1,9 @@
 package graph
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/glojurelang/glojure/pkg/lang"
 	"github.com/jfhamlin/muscrat/pkg/ugen"
 )
@@ -45,22 +48,51 @@ var (
 	keyKW   = lang.NewKeyword("key")
 )
 
+// SExprToGraph converts the graph data built by a script into a
+// Graph. It panics if the data is malformed; see ParseGraph.
 func SExprToGraph(sexpr any) *Graph {
-	g := &Graph{}
+	g, err := ParseGraph(sexpr)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// ParseGraph converts the graph data built by a script, a map of
+// :nodes and :edges, into a Graph. It returns an error rather than
+// panicking if the data is malformed. The graph should still be
+// checked with Validate.
+func ParseGraph(sexpr any) (g *Graph, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			g, err = nil, fmt.Errorf("malformed graph: %v", r)
+		}
+	}()
+
+	g = &Graph{}
 	nodes := lang.Get(sexpr, nodesKW)
 	edges := lang.Get(sexpr, edgesKW)
 
 	for s := lang.Seq(nodes); s != nil; s = lang.Next(s) {
 		node := lang.First(s)
-		id, _ := lang.Get(node, idKW).(string)
-		typ, _ := lang.Get(node, typeKW).(lang.Keyword)
+		id, ok := lang.Get(node, idKW).(string)
+		if !ok {
+			return nil, fmt.Errorf("node 'id' must be a string, got %T", lang.Get(node, idKW))
+		}
+		var typ string
+		if kw, ok := lang.Get(node, typeKW).(lang.Keyword); ok {
+			typ = kw.Name()
+		}
 		ctor := lang.Get(node, ctorKW)
 		args := lang.Get(node, argsKW)
+		if !isSeqable(args) {
+			return nil, fmt.Errorf("node %q: args must be a sequence, got %T", id, args)
+		}
 		key, _ := lang.Get(node, keyKW).(string)
 		sink, _ := lang.Get(node, sinkKW).(bool)
 		g.Nodes = append(g.Nodes, &Node{
 			ID:   NodeID(id),
-			Type: typ.Name(),
+			Type: typ,
 			Ctor: ctor,
 			Args: args,
 			Key:  key,
@@ -70,20 +102,17 @@ func SExprToGraph(sexpr any) *Graph {
 
 	for s := lang.Seq(edges); s != nil; s = lang.Next(s) {
 		edge := lang.First(s)
-		fromVal := lang.Get(edge, fromKW)
-		from, ok := fromVal.(string)
+		from, ok := lang.Get(edge, fromKW).(string)
 		if !ok {
-			panic("edge 'from' must be a string")
+			return nil, errors.New("edge 'from' must be a string")
 		}
-		toVal := lang.Get(edge, toKW)
-		to, ok := toVal.(string)
+		to, ok := lang.Get(edge, toKW).(string)
 		if !ok {
-			panic("edge 'to' must be a string")
+			return nil, errors.New("edge 'to' must be a string")
 		}
-		portVal := lang.Get(edge, portKW)
-		port, ok := portVal.(string)
+		port, ok := lang.Get(edge, portKW).(string)
 		if !ok {
-			panic("edge 'port' must be a string")
+			return nil, errors.New("edge 'port' must be a string")
 		}
 
 		g.Edges = append(g.Edges, &Edge{
@@ -93,7 +122,18 @@ func SExprToGraph(sexpr any) *Graph {
 		})
 	}
 
-	return g
+	return g, nil
+}
+
+// isSeqable returns true if x can be converted to a sequence.
+func isSeqable(x any) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	lang.Seq(x)
+	return true
 }
 
 func (g *Graph) Sinks() []*Node {
@@ -106,6 +146,27 @@ func (g *Graph) Sinks() []*Node {
 	return sinks
 }
 
+// Validate checks that node IDs are unique and that every edge
+// connects nodes in the graph.
+func (g *Graph) Validate() error {
+	ids := make(map[NodeID]bool, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if ids[n.ID] {
+			return fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		ids[n.ID] = true
+	}
+	for _, e := range g.Edges {
+		if !ids[e.From] {
+			return fmt.Errorf("edge to %q port %q is from unknown node %q", e.To, e.Port, e.From)
+		}
+		if !ids[e.To] {
+			return fmt.Errorf("edge from %q is to unknown node %q", e.From, e.To)
+		}
+	}
+	return nil
+}
+
 func (g *Graph) Node(id NodeID) *Node {
 	for _, n := range g.Nodes {
 		if n.ID == id {
@@ -0,0 +1,43 @@
+package graph
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		graph   string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			graph: `{:nodes ({:id "1", :type :sin} {:id "2", :type :out, :sink true})
+                 :edges ({:from "1", :to "2", :port "in"})}`,
+		},
+		{
+			name: "duplicate id",
+			graph: `{:nodes ({:id "1", :type :sin} {:id "1", :type :out, :sink true})
+                 :edges ()}`,
+			wantErr: true,
+		},
+		{
+			name: "unknown source",
+			graph: `{:nodes ({:id "2", :type :out, :sink true})
+                 :edges ({:from "1", :to "2", :port "in"})}`,
+			wantErr: true,
+		},
+		{
+			name: "unknown destination",
+			graph: `{:nodes ({:id "1", :type :sin})
+                 :edges ({:from "1", :to "2", :port "in"})}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SExprToGraph(readGraph(tt.graph)).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
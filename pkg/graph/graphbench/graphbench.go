@@ -0,0 +1,190 @@
+// Package graphbench measures how fast graph.Runner runs synthetic
+// graphs of controlled shape and size, so that changes to the
+// scheduler and optimizer can be compared between commits.
+package graphbench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/graph"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// Config describes one benchmark run.
+	Config struct {
+		Shape   Shape
+		Nodes   int
+		Workers int
+
+		// Blocks is the number of blocks timed, after Warmup blocks
+		// that aren't.
+		Blocks int
+		Warmup int
+	}
+
+	// Result holds the timings of one benchmark run. Times are in
+	// nanoseconds per block.
+	Result struct {
+		Shape     string `json:"shape"`
+		Nodes     int    `json:"nodes"`
+		Workers   int    `json:"workers"`
+		Blocks    int    `json:"blocks"`
+		BlockSize int    `json:"block_size"`
+
+		Mean float64 `json:"mean_ns"`
+		P50  float64 `json:"p50_ns"`
+		P99  float64 `json:"p99_ns"`
+		Max  float64 `json:"max_ns"`
+
+		// Realtime is the duration of a block's audio divided by the
+		// mean time to run it. Below 1, the graph can't be played.
+		Realtime float64 `json:"realtime"`
+	}
+
+	// Report is a set of results with a description of where they
+	// were measured.
+	Report struct {
+		Commit     string    `json:"commit,omitempty"`
+		GoVersion  string    `json:"go_version"`
+		OS         string    `json:"os"`
+		Arch       string    `json:"arch"`
+		NumCPU     int       `json:"num_cpu"`
+		SampleRate int       `json:"sample_rate"`
+		Time       time.Time `json:"time"`
+		Results    []Result  `json:"results"`
+	}
+)
+
+// Run runs a graph of the configured shape offline, as fast as the
+// runner can produce blocks, and times each block.
+func Run(ctx context.Context, cfg Config) (Result, error) {
+	g := cfg.Shape.Generate(cfg.Nodes)
+	if err := g.Validate(); err != nil {
+		return Result{}, fmt.Errorf("%s graph: %w", cfg.Shape.Name, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := make(chan [][]float64)
+	r := graph.NewRunner(ctx, ugen.SampleConfig{SampleRateHz: conf.SampleRate}, out)
+	r.SetNumWorkers(cfg.Workers)
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	if err := r.SetGraph(g); err != nil {
+		return Result{}, fmt.Errorf("%s graph: %w", cfg.Shape.Name, err)
+	}
+
+	// the runner hands over each block as soon as it's run, so the
+	// time between blocks is the time taken to run one.
+	times := make([]float64, 0, cfg.Blocks)
+	last := time.Now()
+	for i := range cfg.Warmup + cfg.Blocks {
+		select {
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		case <-out:
+		}
+		now := time.Now()
+		if i >= cfg.Warmup {
+			times = append(times, float64(now.Sub(last).Nanoseconds()))
+		}
+		last = now
+	}
+
+	res := Result{
+		Shape:     cfg.Shape.Name,
+		Nodes:     cfg.Nodes,
+		Workers:   cfg.Workers,
+		Blocks:    cfg.Blocks,
+		BlockSize: conf.BufferSize,
+	}
+	if len(times) == 0 {
+		return res, nil
+	}
+	slices.Sort(times)
+	var sum float64
+	for _, t := range times {
+		sum += t
+	}
+	res.Mean = sum / float64(len(times))
+	res.P50 = quantile(times, 0.5)
+	res.P99 = quantile(times, 0.99)
+	res.Max = times[len(times)-1]
+	blockNanos := float64(conf.BufferSize) / float64(conf.SampleRate) * 1e9
+	res.Realtime = blockNanos / res.Mean
+	return res, nil
+}
+
+// quantile returns the p quantile of sorted xs, interpolating between
+// samples.
+func quantile(xs []float64, p float64) float64 {
+	idx := p * float64(len(xs)-1)
+	lo, hi := int(math.Floor(idx)), int(math.Ceil(idx))
+	frac := idx - float64(lo)
+	return xs[lo]*(1-frac) + xs[hi]*frac
+}
+
+// ReadReport reads a report written by WriteReport.
+func ReadReport(r io.Reader) (*Report, error) {
+	var rep Report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("reading report: %w", err)
+	}
+	return &rep, nil
+}
+
+// WriteReport writes rep as indented JSON.
+func WriteReport(w io.Writer, rep *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// Comparison compares a result with the result of the same shape,
+// size and number of workers in a baseline report.
+type Comparison struct {
+	Result
+	Base Result
+
+	// Change is the relative change in mean block time from the
+	// baseline; negative is faster.
+	Change float64
+}
+
+// Compare pairs each result in rep with its counterpart in base.
+// Results with no counterpart are left out.
+func Compare(base, rep *Report) []Comparison {
+	type key struct {
+		shape          string
+		nodes, workers int
+	}
+	baseResults := make(map[key]Result)
+	for _, r := range base.Results {
+		baseResults[key{r.Shape, r.Nodes, r.Workers}] = r
+	}
+	var res []Comparison
+	for _, r := range rep.Results {
+		b, ok := baseResults[key{r.Shape, r.Nodes, r.Workers}]
+		if !ok || b.Mean == 0 {
+			continue
+		}
+		res = append(res, Comparison{Result: r, Base: b, Change: r.Mean/b.Mean - 1})
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+package graphbench
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/graph"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+func TestShapes(t *testing.T) {
+	for _, shape := range Shapes {
+		t.Run(shape.Name, func(t *testing.T) {
+			g := shape.Generate(16)
+			if err := g.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if len(g.Nodes) < 16 {
+				t.Errorf("got %d nodes, want at least 16", len(g.Nodes))
+			}
+
+			out, err := graph.Render(context.Background(), g, ugen.SampleConfig{SampleRateHz: 44100}, 4096)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var peak float64
+			for _, x := range out[0] {
+				if math.IsNaN(x) || math.IsInf(x, 0) {
+					t.Fatalf("got nonfinite output %v", x)
+				}
+				peak = math.Max(peak, math.Abs(x))
+			}
+			if peak == 0 {
+				t.Error("got silent output")
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	res, err := Run(context.Background(), Config{Shape: Shapes[0], Nodes: 8, Workers: 2, Blocks: 20, Warmup: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Blocks != 20 || res.Workers != 2 || res.Shape != "wide" {
+		t.Errorf("got %+v, want 20 blocks of wide with 2 workers", res)
+	}
+	if !(res.Mean > 0 && res.P50 <= res.P99 && res.P99 <= res.Max && res.Realtime > 0) {
+		t.Errorf("got inconsistent timings %+v", res)
+	}
+}
+
+func TestReportRoundTrip(t *testing.T) {
+	base := &Report{Results: []Result{
+		{Shape: "wide", Nodes: 8, Workers: 1, Mean: 100},
+		{Shape: "deep", Nodes: 8, Workers: 1, Mean: 100},
+	}}
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, base); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep := &Report{Results: []Result{
+		{Shape: "wide", Nodes: 8, Workers: 1, Mean: 80},
+		{Shape: "wide", Nodes: 8, Workers: 2, Mean: 50},
+	}}
+	cmp := Compare(got, rep)
+	if len(cmp) != 1 {
+		t.Fatalf("got %d comparisons, want 1", len(cmp))
+	}
+	if math.Abs(cmp[0].Change-(-0.2)) > 1e-9 {
+		t.Errorf("got change %v, want -0.2", cmp[0].Change)
+	}
+}
@@ -0,0 +1,199 @@
+package graphbench
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/effects"
+	"github.com/jfhamlin/muscrat/pkg/graph"
+	"github.com/jfhamlin/muscrat/pkg/osc"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// Shape generates a synthetic graph of a given size.
+	Shape struct {
+		// Name identifies the shape in results.
+		Name string
+
+		// Doc describes the graph the shape generates.
+		Doc string
+
+		// Generate returns a graph with about n nodes.
+		Generate func(n int) *graph.Graph
+	}
+)
+
+// Shapes are the graph shapes run by default.
+var Shapes = []Shape{
+	{
+		Name:     "wide",
+		Doc:      "n independent oscillators mixed to one output",
+		Generate: Wide,
+	},
+	{
+		Name:     "deep",
+		Doc:      "an oscillator through a chain of n filters",
+		Generate: Deep,
+	},
+	{
+		Name:     "feedback",
+		Doc:      "n/2 delay lines, each feeding back into its own input",
+		Generate: Feedback,
+	},
+	{
+		Name:     "arith",
+		Doc:      "n small arithmetic nodes, each reading two earlier nodes",
+		Generate: Arith,
+	},
+}
+
+// ShapeByName returns the shape with the given name.
+func ShapeByName(name string) (Shape, bool) {
+	for _, s := range Shapes {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Shape{}, false
+}
+
+// builder assigns node IDs and output ports while a graph is
+// generated.
+type builder struct {
+	g       *graph.Graph
+	outPort int
+}
+
+func newBuilder() *builder {
+	return &builder{g: &graph.Graph{}}
+}
+
+func (b *builder) node(typ string, ctor func() ugen.UGen) graph.NodeID {
+	id := graph.NodeID(fmt.Sprint(len(b.g.Nodes) + 1))
+	b.g.Nodes = append(b.g.Nodes, &graph.Node{ID: id, Type: typ, Ctor: ctor})
+	return id
+}
+
+func (b *builder) constant(x float64) graph.NodeID {
+	return b.node("const", func() ugen.UGen { return ugen.NewConstant(x) })
+}
+
+func (b *builder) sine(freq graph.NodeID) graph.NodeID {
+	id := b.node("sin", func() ugen.UGen { return osc.NewSine() })
+	b.edge(freq, id, "w")
+	return id
+}
+
+func (b *builder) edge(from, to graph.NodeID, port string) {
+	b.g.Edges = append(b.g.Edges, &graph.Edge{From: from, To: to, Port: port})
+}
+
+// out mixes ids to the first output channel and returns the graph.
+func (b *builder) out(ids ...graph.NodeID) *graph.Graph {
+	id := graph.NodeID(fmt.Sprint(len(b.g.Nodes) + 1))
+	b.g.Nodes = append(b.g.Nodes, &graph.Node{
+		ID:   id,
+		Type: "out",
+		Args: lang.NewVector(int64(0)),
+		Sink: true,
+	})
+	for _, from := range ids {
+		b.edge(from, id, fmt.Sprintf("$%d", b.outPort))
+		b.outPort++
+	}
+	return b.g
+}
+
+// Wide returns a graph of n sine oscillators, each with its own
+// frequency, mixed to one output. Every oscillator can run in
+// parallel.
+func Wide(n int) *graph.Graph {
+	b := newBuilder()
+	oscs := make([]graph.NodeID, n)
+	for i := range oscs {
+		oscs[i] = b.sine(b.constant(110 * float64(i+1)))
+	}
+	return b.out(oscs...)
+}
+
+// Deep returns a graph of a sine oscillator through a chain of n
+// low-pass filters. No two filters can run in parallel.
+func Deep(n int) *graph.Graph {
+	b := newBuilder()
+	freq := b.constant(5000)
+	prev := b.sine(b.constant(220))
+	for range n {
+		lpf := b.node("lpf", effects.NewLPF)
+		b.edge(prev, lpf, "in")
+		b.edge(freq, lpf, "freq")
+		prev = lpf
+	}
+	return b.out(prev)
+}
+
+// Feedback returns a graph of n/2 delay lines, each fed by the sum of
+// an oscillator and its own output, so that every delay is part of a
+// cycle.
+func Feedback(n int) *graph.Graph {
+	b := newBuilder()
+	delayTime := b.constant(0.01)
+	gain := b.constant(0.5)
+	delays := make([]graph.NodeID, max(n/2, 1))
+	for i := range delays {
+		sum := b.node("sum", ugen.NewSum)
+		delay := b.node("delay", func() ugen.UGen { return effects.NewDelay(0.1) })
+		fb := b.node("product", ugen.NewProduct)
+		b.edge(b.sine(b.constant(110*float64(i+1))), sum, "$0")
+		b.edge(fb, sum, "$1")
+		b.edge(sum, delay, "in")
+		b.edge(delayTime, delay, "delay")
+		b.edge(delay, fb, "$0")
+		b.edge(gain, fb, "$1")
+		delays[i] = delay
+	}
+	return b.out(delays...)
+}
+
+// Arith returns a graph of n binary arithmetic nodes, each reading
+// two nodes chosen at random from those before it, with the nodes
+// that nothing reads mixed to the output. The graph is the same for
+// the same n.
+func Arith(n int) *graph.Graph {
+	b := newBuilder()
+	r := rand.New(rand.NewSource(int64(n)))
+	ids := []graph.NodeID{b.sine(b.constant(220)), b.constant(0.5), b.constant(-0.25)}
+	read := make(map[graph.NodeID]bool)
+	for i := range n {
+		x, y := ids[r.Intn(len(ids))], ids[r.Intn(len(ids))]
+		read[x], read[y] = true, true
+
+		// products, maxima and minima of values in [-1, 1] stay in
+		// [-1, 1], however deep the graph.
+		var id graph.NodeID
+		switch i % 3 {
+		case 0:
+			id = b.node("product", ugen.NewProduct)
+			b.edge(x, id, "$0")
+			b.edge(y, id, "$1")
+		case 1:
+			id = b.node("max", ugen.NewMax)
+			b.edge(x, id, "0")
+			b.edge(y, id, "1")
+		case 2:
+			id = b.node("min", ugen.NewMin)
+			b.edge(x, id, "0")
+			b.edge(y, id, "1")
+		}
+		ids = append(ids, id)
+	}
+	var leaves []graph.NodeID
+	for _, id := range ids {
+		if !read[id] {
+			leaves = append(leaves, id)
+		}
+	}
+	return b.out(leaves...)
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// Render runs g offline, as fast as it can be run rather than in real
+// time, and returns the first numSamples samples of each output
+// channel. The graph's ugens are started before rendering and stopped
+// after.
+func Render(ctx context.Context, g *Graph, cfg ugen.SampleConfig, numSamples int) ([][]float64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan [][]float64)
+	r := NewRunner(ctx, cfg, out)
+
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+		r.stopNodes(context.Background())
+	}()
+
+	if err := r.SetGraph(g); err != nil {
+		return nil, err
+	}
+
+	res := make([][]float64, len(r.nextOut))
+	for i := range res {
+		res[i] = make([]float64, 0, numSamples+len(r.nextOut[i]))
+	}
+	for len(res[0]) < numSamples {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case buf := <-out:
+			for i := range res {
+				res[i] = append(res[i], buf[i]...)
+			}
+		}
+	}
+	for i := range res {
+		res[i] = res[i][:max(numSamples, 0)]
+	}
+	return res, nil
+}
+
+// stopNodes stops the ugens of the current graph. It must only be
+// called once the runner has stopped running.
+func (r *Runner) stopNodes(ctx context.Context) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.rs == nil {
+		return
+	}
+	for i := range r.rs.nodes {
+		if s, ok := r.rs.nodes[i].gen.(ugen.Stopper); ok {
+			s.Stop(ctx)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// counter outputs the number of samples it has generated.
+type counter struct {
+	n                int
+	started, stopped bool
+}
+
+func (c *counter) Start(ctx context.Context) error {
+	c.started = true
+	return nil
+}
+
+func (c *counter) Stop(ctx context.Context) error {
+	c.stopped = true
+	return nil
+}
+
+func (c *counter) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	for i := range out {
+		out[i] = float64(c.n)
+		c.n++
+	}
+}
+
+func TestRender(t *testing.T) {
+	c := &counter{}
+	g := &Graph{
+		Nodes: []*Node{
+			{ID: "1", Type: "counter", Ctor: func() ugen.UGen { return c }},
+			{ID: "2", Type: "out", Args: lang.NewVector(int64(0)), Sink: true},
+		},
+		Edges: []*Edge{{From: "1", To: "2", Port: "in"}},
+	}
+
+	const numSamples = 1000
+	out, err := Render(context.Background(), g, ugen.SampleConfig{SampleRateHz: 44100}, numSamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || len(out[0]) != numSamples || len(out[1]) != numSamples {
+		t.Fatalf("got %d channels of %d samples, want 2 of %d", len(out), len(out[0]), numSamples)
+	}
+	for i := range numSamples {
+		if out[0][i] != float64(i) || out[1][i] != 0 {
+			t.Fatalf("got (%v, %v) at sample %d, want (%d, 0)", out[0][i], out[1][i], i, i)
+		}
+	}
+	if !c.started || !c.stopped {
+		t.Errorf("got started=%v stopped=%v, want both", c.started, c.stopped)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Render(ctx, g, ugen.SampleConfig{SampleRateHz: 44100}, numSamples); err == nil {
+		t.Error("expected an error rendering with a canceled context")
+	}
+}
+
+// blockCounter is a data source whose data is the number of blocks
+// it has generated.
+type blockCounter struct {
+	blocks int
+}
+
+func (b *blockCounter) Data() any {
+	return &b.blocks
+}
+
+func (b *blockCounter) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	b.blocks++
+}
+
+func TestRenderData(t *testing.T) {
+	// the reader outputs the data of its "in" input, which is read
+	// after the counter has generated each block.
+	reader := ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		blocks, ok := cfg.InputData["in"].(*int)
+		if !ok {
+			return
+		}
+		for i := range out {
+			out[i] = float64(*blocks)
+		}
+	})
+	g := &Graph{
+		Nodes: []*Node{
+			{ID: "1", Type: "counter", Ctor: func() ugen.UGen { return &blockCounter{} }},
+			{ID: "2", Type: "reader", Ctor: func() ugen.UGen { return reader }},
+			{ID: "3", Type: "out", Args: lang.NewVector(int64(0)), Sink: true},
+		},
+		Edges: []*Edge{
+			{From: "1", To: "2", Port: "in"},
+			{From: "2", To: "3", Port: "in"},
+		},
+	}
+
+	out, err := Render(context.Background(), g, ugen.SampleConfig{SampleRateHz: 44100}, 3*conf.BufferSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range out[0] {
+		if want := float64(i/conf.BufferSize + 1); x != want {
+			t.Fatalf("got %v at sample %d, want %v", x, i, want)
+		}
+	}
+}
@@ -25,6 +25,8 @@ type (
 
 		nextID runNodeID
 
+		numWorkers int
+
 		epochChan chan runEpoch
 
 		nextOut [][]float64
@@ -48,6 +50,10 @@ type (
 
 		inputSampleMap map[string][]float64
 
+		// inputDataMap holds the data of incoming edges from
+		// ugens that are data sources.
+		inputDataMap map[string]any
+
 		// edges whose destination is this node
 		incomingEdges []*Edge
 
@@ -98,22 +104,46 @@ func NewRunner(ctx context.Context, cfg ugen.SampleConfig, out chan [][]float64)
 	return &Runner{
 		ctx:          ctx,
 		sampleConfig: cfg,
+		numWorkers:   numWorkers,
 		epochChan:    make(chan runEpoch),
 		nextOut:      nextOut,
 		out:          out,
 	}
 }
 
+// SetNumWorkers sets the number of goroutines that run the nodes of
+// the graph, from the next call to SetGraph. It defaults to the value
+// of the MUSCRAT_WORKERS environment variable, or half the number of
+// CPUs if it's unset.
+func (r *Runner) SetNumWorkers(n int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.numWorkers = max(n, 1)
+}
+
 func (r *Runner) getNextID() runNodeID {
 	r.nextID++
 	return r.nextID
 }
 
-func (r *Runner) SetGraph(g *Graph) {
+// SetGraph replaces the graph being run with g, keeping the ugens of
+// nodes that g shares with the previous graph so that they carry on
+// where they left off. If g is invalid or any of its ugens can't be
+// constructed, SetGraph returns an error and the previous graph keeps
+// running.
+func (r *Runner) SetGraph(g *Graph) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	rs := r.newRunState(g)
+	rs, err := r.newRunState(g)
+	if err != nil {
+		return err
+	}
 
 	makeRunFunc := func(nid runNodeID) Job {
 		node := rs.NodeByID(nid)
@@ -122,7 +152,7 @@ func (r *Runner) SetGraph(g *Graph) {
 		}
 	}
 
-	q := NewQueue(numWorkers)
+	q := NewQueue(r.numWorkers)
 	items := map[runNodeID]*QueueItem{}
 	for _, nid := range rs.nodeOrder {
 		items[nid] = q.AddItem(makeRunFunc(nid))
@@ -140,15 +170,41 @@ func (r *Runner) SetGraph(g *Graph) {
 	r.g = g
 	prevRS := r.rs
 	r.rs = rs
-	r.epochChan <- runEpoch{
+	select {
+	case r.epochChan <- runEpoch{
 		q:      q,
 		prevRS: prevRS,
+	}:
+	case <-r.ctx.Done():
+		q.Stop()
 	}
+	return nil
 }
 
+// Graph returns the graph most recently passed to SetGraph.
+func (r *Runner) Graph() *Graph {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.g
+}
+
+// Run runs the graph set with SetGraph, sending each block of output
+// to the runner's output channel, until ctx is done. No output is sent
+// until the first graph is set.
 func (r *Runner) Run(ctx context.Context) {
-	q := NewQueue(1)
-	q.Start(ctx)
+	var q *Queue
+	select {
+	case <-ctx.Done():
+		return
+	case nxt := <-r.epochChan:
+		q = nxt.q
+	}
+	// the queue's workers wait to be signaled, so they must be
+	// stopped rather than left to notice that ctx is done.
+	defer func() {
+		q.Stop()
+	}()
 
 	for {
 		select {
@@ -186,11 +242,15 @@ func (r *Runner) Run(ctx context.Context) {
 			copy(outputBuffer[i], out)
 		}
 
-		r.out <- outputBuffer
+		select {
+		case r.out <- outputBuffer:
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
-func (r *Runner) newRunState(g *Graph) *runState {
+func (r *Runner) newRunState(g *Graph) (*runState, error) {
 	// build a topological-ish ordering of the nodes in the graph
 	// (excluding nodes that are not ancestors of any sink). Because
 	// the graphs can contain cycles, this ordering is not guaranteed
@@ -250,10 +310,28 @@ func (r *Runner) newRunState(g *Graph) *runState {
 		nodeOrder:    order,
 		nodeIndexMap: make(map[runNodeID]int),
 	}
-	for i := range rs.nodeIndexMap {
-		// initialize to -1 so that we can detect nodes that are not
-		// ancestors of any sink.
-		rs.nodeIndexMap[i] = -1
+	var alignment GraphAlignment
+	if r.g != nil {
+		alignment = AlignGraphs(r.g, g)
+	}
+
+	// if a ugen can't be constructed or started, the new graph is
+	// abandoned, so
+	// the ugens constructed for it so far are stopped, and the nodes
+	// of the current graph are no longer marked as retained.
+	var constructed []ugen.UGen
+	fail := func(err error) (*runState, error) {
+		for _, u := range constructed {
+			if s, ok := u.(ugen.Stopper); ok {
+				s.Stop(r.ctx)
+			}
+		}
+		if r.rs != nil {
+			for i := range r.rs.nodes {
+				r.rs.nodes[i].retained = false
+			}
+		}
+		return nil, err
 	}
 
 	for i, id := range order {
@@ -261,13 +339,9 @@ func (r *Runner) newRunState(g *Graph) *runState {
 
 		node.id = id
 
-		graphNode := nodeMap[id] // BUGBUG: possible for node not to be found?
+		graphNode := nodeMap[id] // Validate ensures every edge is between nodes of g
 		node.node = graphNode
 
-		var alignment GraphAlignment
-		if r.g != nil {
-			alignment = AlignGraphs(r.g, g)
-		}
 		var nodeFound bool
 		if targetID, ok := alignment.NodeIdentities[graphNode.ID]; ok {
 			// find the target node in previous run state, and
@@ -292,9 +366,12 @@ func (r *Runner) newRunState(g *Graph) *runState {
 			// if a node of type out, we don't need to construct a UGen
 			if graphNode.Type == "out" {
 				// get the index of the out node
-				idx := lang.First(graphNode.Args).(int64)
+				idx, ok := lang.First(graphNode.Args).(int64)
+				if !ok {
+					return fail(fmt.Errorf("out node %q: channel must be an integer, got %v", graphNode.ID, lang.First(graphNode.Args)))
+				}
 				node.gen = ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, _ []float64) {
-					if int(idx) >= len(r.nextOut) {
+					if idx < 0 || int(idx) >= len(r.nextOut) {
 						fmt.Printf("out of bounds output index: %d\n", idx)
 						return
 					}
@@ -308,9 +385,16 @@ func (r *Runner) newRunState(g *Graph) *runState {
 					}
 				})
 			} else {
-				node.gen = graphNode.Construct()
+				u, err := construct(graphNode)
+				if err != nil {
+					return fail(err)
+				}
+				node.gen = u
+				constructed = append(constructed, u)
 				if s, ok := node.gen.(ugen.Starter); ok {
-					s.Start(r.ctx)
+					if err := s.Start(r.ctx); err != nil {
+						return fail(fmt.Errorf("starting %s node %q: %w", graphNode.Type, graphNode.ID, err))
+					}
 				}
 			}
 			node.value = make([]float64, conf.BufferSize)
@@ -332,17 +416,35 @@ func (r *Runner) newRunState(g *Graph) *runState {
 		for _, e := range n.incomingEdges {
 			inNode := rs.NodeByID(getID(e.From))
 			n.inputSampleMap[e.Port] = inNode.value
+			if ds, ok := inNode.gen.(ugen.DataSource); ok {
+				if n.inputDataMap == nil {
+					n.inputDataMap = make(map[string]any)
+				}
+				n.inputDataMap[e.Port] = ds.Data()
+			}
 		}
 	}
 
 	bootstrapCycles(rs)
 
-	return rs
+	return rs, nil
+}
+
+// construct constructs the ugen of n, returning an error rather than
+// panicking if its constructor fails, as it will if a script passes it
+// arguments of the wrong type.
+func construct(n *Node) (u ugen.UGen, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			u, err = nil, fmt.Errorf("constructing %s node %q: %v", n.Type, n.ID, r)
+		}
+	}()
+	return n.Construct(), nil
 }
 
 func (rs *runState) NodeByID(id runNodeID) *runNode {
-	index := rs.nodeIndexMap[id]
-	if index < 0 {
+	index, ok := rs.nodeIndexMap[id]
+	if !ok {
 		return nil
 	}
 	return &rs.nodes[index]
@@ -380,5 +482,6 @@ func (rn *runNode) run(ctx context.Context, cfg ugen.SampleConfig) {
 
 	clear(rn.value)
 	cfg.InputSamples = rn.inputSampleMap
+	cfg.InputData = rn.inputDataMap
 	rn.gen.Gen(ctx, cfg, rn.value)
 }
@@ -0,0 +1,395 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+	gljstdlib "github.com/glojurelang/glojure/pkg/stdlib"
+
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+	"github.com/jfhamlin/muscrat/pkg/refdoc"
+	"github.com/jfhamlin/muscrat/pkg/stdlib"
+)
+
+// ugenKeywords are the keyword arguments accepted by every ugen in
+// addition to its own.
+var ugenKeywords = []string{"mul", "add"}
+
+////////////////////////////////////////////////////////////////////////////////
+// Completion
+
+func (s *Server) completion(params TextDocumentPositionParams) *CompletionList {
+	list := &CompletionList{Items: []CompletionItem{}}
+	text, ok := s.document(params.TextDocument.URI)
+	if !ok {
+		return list
+	}
+	offset := offsetAt(text, params.Position)
+	prefix, start := tokenAt(text[:offset], offset)
+	rng := &Range{Start: positionAt(text, start), End: params.Position}
+
+	if strings.HasPrefix(prefix, ":") {
+		sym := s.enclosingUGen(text, offset)
+		if sym == nil {
+			return list
+		}
+		forms := enclosingForms(text, offset)
+		used := map[string]bool{}
+		for _, arg := range forms[len(forms)-1].args {
+			used[arg] = true
+		}
+		for _, name := range ugenArgNames(sym) {
+			kw := ":" + name
+			if used[kw] || !strings.HasPrefix(kw, prefix) {
+				continue
+			}
+			item := CompletionItem{
+				Label:    kw,
+				Kind:     completionKeyword,
+				TextEdit: &TextEdit{Range: *rng, NewText: kw},
+			}
+			if arg := ugenArg(sym, name); arg != nil {
+				item.Detail = "default " + refdoc.FormatDefault(arg.Default)
+				if arg.Doc != "" {
+					item.Documentation = &MarkupContent{Kind: "markdown", Value: arg.Doc}
+				}
+			}
+			list.Items = append(list.Items, item)
+		}
+		return list
+	}
+
+	name, ns := unqualified(prefix)
+	if ns == "" {
+		for def := range definitions(text) {
+			if strings.HasPrefix(def, prefix) && s.symbols[def] == nil {
+				list.Items = append(list.Items, CompletionItem{
+					Label:    def,
+					Kind:     completionVariable,
+					TextEdit: &TextEdit{Range: *rng, NewText: def},
+				})
+			}
+		}
+	}
+	for _, n := range s.names {
+		sym := s.symbols[n]
+		if !strings.HasPrefix(n, name) || (ns != "" && ns != sym.ns) {
+			continue
+		}
+		newText := n
+		if ns != "" {
+			newText = ns + "/" + n
+		}
+		list.Items = append(list.Items, CompletionItem{
+			Label:    n,
+			Kind:     completionKind(sym),
+			Detail:   sym.ns,
+			TextEdit: &TextEdit{Range: *rng, NewText: newText},
+			Data:     sym.ns + "/" + n,
+		})
+	}
+	return list
+}
+
+func (s *Server) resolveCompletion(item CompletionItem) CompletionItem {
+	if item.Data == "" {
+		return item
+	}
+	if sym := s.lookup(item.Data); sym != nil {
+		if doc, ok := sym.ref.EntryMarkdown(sym.Name); ok {
+			item.Documentation = &MarkupContent{Kind: "markdown", Value: doc}
+		}
+	}
+	return item
+}
+
+func completionKind(sym *symbol) int {
+	switch {
+	case sym.UGenArgs != nil || len(sym.Arglists) > 0:
+		return completionFunction
+	case strings.HasPrefix(sym.Name, "*") && strings.HasSuffix(sym.Name, "*"):
+		return completionVariable
+	default:
+		return completionConstant
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Hover
+
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	text, ok := s.document(params.TextDocument.URI)
+	if !ok {
+		return nil
+	}
+	offset := offsetAt(text, params.Position)
+	tok, start := tokenAt(text, offset)
+	if tok == "" {
+		return nil
+	}
+	rng := &Range{Start: positionAt(text, start), End: positionAt(text, start+len(tok))}
+
+	if strings.HasPrefix(tok, ":") {
+		sym := s.enclosingUGen(text, start)
+		if sym == nil {
+			return nil
+		}
+		arg := ugenArg(sym, tok[1:])
+		if arg == nil {
+			return nil
+		}
+		value := fmt.Sprintf("**%s** `%s` (default `%s`)", sym.Name, tok, refdoc.FormatDefault(arg.Default))
+		if arg.Doc != "" {
+			value += "\n\n" + arg.Doc
+		}
+		return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: rng}
+	}
+
+	sym := s.lookup(tok)
+	if sym == nil {
+		return nil
+	}
+	value := fmt.Sprintf("**%s/%s**", sym.ns, sym.Name)
+	if doc, ok := sym.ref.EntryMarkdown(sym.Name); ok {
+		value += "\n\n" + doc
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: rng}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Signature help
+
+func (s *Server) signatureHelp(params TextDocumentPositionParams) *SignatureHelp {
+	text, ok := s.document(params.TextDocument.URI)
+	if !ok {
+		return nil
+	}
+	forms := enclosingForms(text, offsetAt(text, params.Position))
+	for i := len(forms) - 1; i >= 0; i-- {
+		f := forms[i]
+		sym := s.lookup(f.head())
+		if sym == nil {
+			continue
+		}
+		if sym.UGenArgs != nil {
+			return ugenSignature(sym, f)
+		}
+		if len(sym.Arglists) > 0 {
+			return fnSignatures(sym, f)
+		}
+	}
+	return nil
+}
+
+// ugenSignature returns the signature of a ugen. Ugens take their
+// arguments positionally or as keywords; the active parameter is the
+// keyword awaiting a value, or else the next positional argument.
+func ugenSignature(sym *symbol, f *form) *SignatureHelp {
+	names := ugenArgNames(sym)
+	var label strings.Builder
+	label.WriteString("(" + sym.Name)
+	var params []ParameterInformation
+	for i, name := range names {
+		label.WriteString(" ")
+		p := ParameterInformation{Label: [2]int{label.Len(), label.Len()}}
+		if i < len(sym.UGenArgs) {
+			label.WriteString(name)
+			doc := fmt.Sprintf("Default `%s`.", refdoc.FormatDefault(sym.UGenArgs[i].Default))
+			if sym.UGenArgs[i].Doc != "" {
+				doc = sym.UGenArgs[i].Doc + " " + doc
+			}
+			p.Documentation = &MarkupContent{Kind: "markdown", Value: doc}
+		} else {
+			label.WriteString(":" + name)
+		}
+		p.Label[1] = label.Len()
+		params = append(params, p)
+	}
+	label.WriteString(")")
+	sig := SignatureInformation{Label: label.String(), Parameters: params}
+	if sym.Doc != "" {
+		sig.Documentation = &MarkupContent{Kind: "markdown", Value: firstParagraph(sym.Doc)}
+	}
+
+	active := len(f.args) - 1
+	if strings.HasPrefix(f.partial, ":") {
+		// a keyword being typed.
+		for j := len(names) - 1; j >= 0; j-- {
+			if strings.HasPrefix(":"+names[j], f.partial) {
+				active = j
+			}
+		}
+		return &SignatureHelp{Signatures: []SignatureInformation{sig}, ActiveParameter: active}
+	}
+	args := f.args[1:]
+	for i := len(args) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(args[i], ":") {
+			continue
+		}
+		// once the keyword's value is given, no parameter is active.
+		active = len(names)
+		for j, name := range names {
+			if args[i] == ":"+name && i == len(args)-1 {
+				active = j
+			}
+		}
+		break
+	}
+
+	return &SignatureHelp{Signatures: []SignatureInformation{sig}, ActiveParameter: active}
+}
+
+// fnSignatures returns a signature for each of a function's arglists,
+// choosing the first that accepts the arguments given so far.
+func fnSignatures(sym *symbol, f *form) *SignatureHelp {
+	given := len(f.args) - 1
+	help := &SignatureHelp{ActiveSignature: -1}
+	for _, al := range sym.Arglists {
+		var label strings.Builder
+		label.WriteString("(" + sym.Name)
+		var params []ParameterInformation
+		variadic := -1
+		for seq := lang.Seq(al); seq != nil; seq = seq.Next() {
+			param := lang.PrintString(seq.First())
+			if param == "&" {
+				variadic = len(params)
+				label.WriteString(" &")
+				continue
+			}
+			label.WriteString(" ")
+			start := label.Len()
+			label.WriteString(param)
+			params = append(params, ParameterInformation{Label: [2]int{start, label.Len()}})
+		}
+		label.WriteString(")")
+
+		if help.ActiveSignature < 0 && (given < len(params) || variadic >= 0) {
+			help.ActiveSignature = len(help.Signatures)
+			help.ActiveParameter = min(given, len(params)-1)
+		}
+		help.Signatures = append(help.Signatures, SignatureInformation{Label: label.String(), Parameters: params})
+	}
+	if help.ActiveSignature < 0 {
+		help.ActiveSignature = len(help.Signatures) - 1
+		help.ActiveParameter = given
+	}
+	return help
+}
+
+// firstParagraph returns the first paragraph of a docstring, joined
+// onto one line.
+func firstParagraph(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if i := strings.Index(doc, "\n\n"); i >= 0 {
+		doc = doc[:i]
+	}
+	return strings.Join(strings.Fields(doc), " ")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Definitions
+
+func (s *Server) definition(params TextDocumentPositionParams) (any, error) {
+	text, ok := s.document(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	tok, _ := tokenAt(text, offsetAt(text, params.Position))
+	if tok == "" || strings.HasPrefix(tok, ":") {
+		return nil, nil
+	}
+
+	if _, ns := unqualified(tok); ns == "" {
+		if offset, ok := definitions(text)[tok]; ok {
+			pos := positionAt(text, offset)
+			return &Location{URI: params.TextDocument.URI, Range: Range{Start: pos, End: pos}}, nil
+		}
+	}
+
+	sym := s.lookup(tok)
+	if sym == nil || sym.File == "" || sym.Line <= 0 {
+		return nil, nil
+	}
+	file, err := stdlibFile(sym.File)
+	if err != nil {
+		return nil, err
+	}
+	pos := Position{Line: sym.Line - 1}
+	return &Location{URI: pathURI(file), Range: Range{Start: pos, End: pos}}, nil
+}
+
+// stdlibFile returns the path of a standard library file, given its
+// path relative to the load path. Files embedded in the binary are
+// extracted to the user cache directory so that editors can open them.
+func stdlibFile(name string) (string, error) {
+	name = path.Clean(name)
+	if strings.HasPrefix(name, "mrat/") {
+		if dir := os.Getenv("MUSCRAT_STDLIB_PATH"); dir != "" {
+			return filepath.Join(dir, filepath.FromSlash(name)), nil
+		}
+		return extract(stdlib.StdLib, name)
+	}
+	return extract(gljstdlib.StdLib, name)
+}
+
+func extract(fsys interface{ ReadFile(string) ([]byte, error) }, name string) (string, error) {
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", name, err)
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(cache, "muscrat", "stdlib", filepath.FromSlash(name))
+	if existing, err := os.ReadFile(file); err == nil && string(existing) == string(data) {
+		return file, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Ugens
+
+// enclosingUGen returns the ugen whose form innermostly encloses
+// offset, if any.
+func (s *Server) enclosingUGen(text string, offset int) *symbol {
+	forms := enclosingForms(text, offset)
+	if len(forms) == 0 {
+		return nil
+	}
+	sym := s.lookup(forms[len(forms)-1].head())
+	if sym == nil || sym.UGenArgs == nil {
+		return nil
+	}
+	return sym
+}
+
+// ugenArgNames returns the names of the arguments accepted by a ugen,
+// including the common keyword arguments.
+func ugenArgNames(sym *symbol) []string {
+	var names []string
+	for _, arg := range sym.UGenArgs {
+		names = append(names, arg.Name)
+	}
+	return append(names, ugenKeywords...)
+}
+
+func ugenArg(sym *symbol, name string) *mrat.UGenArg {
+	for i := range sym.UGenArgs {
+		if sym.UGenArgs[i].Name == name {
+			return &sym.UGenArgs[i]
+		}
+	}
+	return nil
+}
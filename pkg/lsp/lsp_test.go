@@ -0,0 +1,298 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// client is a minimal LSP client for testing.
+type client struct {
+	t    *testing.T
+	conn *conn
+	msgs chan *message
+	id   int
+
+	// notifications received while waiting for responses.
+	pending []*message
+}
+
+func newClient(t *testing.T) *client {
+	ctx, cancel := context.WithCancel(context.Background())
+	sr, cw := io.Pipe()
+	cr, sw := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, sr, sw) }()
+	t.Cleanup(func() {
+		cancel()
+		cw.Close()
+		cr.Close()
+		<-done
+	})
+
+	c := &client{t: t, conn: newConn(cr, cw), msgs: make(chan *message, 16)}
+	go func() {
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+	return c
+}
+
+func (c *client) next() *message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.msgs:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		return msg
+	case <-time.After(15 * time.Second):
+		c.t.Fatal("timed out waiting for a message")
+	}
+	return nil
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// call sends a request and unmarshals its result into res. Any
+// notifications received meanwhile are kept for later.
+func (c *client) call(method string, params, res any) *responseError {
+	c.t.Helper()
+	c.id++
+	id, _ := json.Marshal(c.id)
+	p, _ := json.Marshal(params)
+	if err := c.conn.write(&message{ID: id, Method: method, Params: p}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.next()
+		if msg.ID == nil {
+			c.pending = append(c.pending, msg)
+			continue
+		}
+		if string(msg.ID) != string(id) {
+			continue
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if err := json.Unmarshal(msg.Result, res); err != nil {
+			c.t.Fatal(err)
+		}
+		return nil
+	}
+}
+
+// diagnostics waits for the diagnostics published for uri.
+func (c *client) diagnostics(uri string) []Diagnostic {
+	c.t.Helper()
+	for {
+		var msg *message
+		if len(c.pending) > 0 {
+			msg, c.pending = c.pending[0], c.pending[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.t.Fatal(err)
+		}
+		if params.URI == uri {
+			return params.Diagnostics
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+
+	var init map[string]any
+	if err := c.call("initialize", map[string]any{"capabilities": map[string]any{}}, &init); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := init["capabilities"].(map[string]any)["hoverProvider"]; !ok {
+		t.Fatalf("expected hover capability, got %v", init)
+	}
+	c.notify("initialized", map[string]any{})
+
+	const uri = "file:///tmp/muscrat-lsp-test.glj"
+	text := strings.Join([]string{
+		"(ns user)",
+		"(def cutoff 300)",
+		"(play (lpf (saw 110) :fr",
+		"           :freq cutoff",
+		"           ))",
+		"(play (lpf (saw 110) cutof",
+	}, "\n")
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "clojure", Text: text},
+	})
+
+	at := func(line, char int) TextDocumentPositionParams {
+		return TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     Position{Line: line, Character: char},
+		}
+	}
+
+	t.Run("completion", func(t *testing.T) {
+		var list CompletionList
+		if err := c.call("textDocument/completion", at(2, 24), &list); err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Items) != 1 || list.Items[0].Label != ":freq" {
+			t.Fatalf("got %v, want :freq", list.Items)
+		}
+		edit := list.Items[0].TextEdit
+		if edit == nil || edit.Range.Start != (Position{Line: 2, Character: 21}) {
+			t.Errorf("got edit %v, want one replacing :fr", edit)
+		}
+
+		if err := c.call("textDocument/completion", at(5, 26), &list); err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Items) != 1 || list.Items[0].Label != "cutoff" {
+			t.Errorf("got %v, want the document's own cutoff", list.Items)
+		}
+
+		if err := c.call("textDocument/completion", at(2, 10), &list); err != nil {
+			t.Fatal(err)
+		}
+		var lpf *CompletionItem
+		for i, item := range list.Items {
+			if item.Label == "lpf" {
+				lpf = &list.Items[i]
+			}
+		}
+		if lpf == nil {
+			t.Fatalf("expected lpf among %d completions", len(list.Items))
+		}
+		var resolved CompletionItem
+		if err := c.call("completionItem/resolve", lpf, &resolved); err != nil {
+			t.Fatal(err)
+		}
+		if resolved.Documentation == nil || !strings.Contains(resolved.Documentation.Value, "low-pass") {
+			t.Errorf("got documentation %v, want lpf's", resolved.Documentation)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var hover Hover
+		if err := c.call("textDocument/hover", at(2, 8), &hover); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(hover.Contents.Value, "**mrat.core/lpf**") || !strings.Contains(hover.Contents.Value, "low-pass") {
+			t.Errorf("got hover %q, want lpf's docs", hover.Contents.Value)
+		}
+
+		if err := c.call("textDocument/hover", at(3, 13), &hover); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(hover.Contents.Value, "cutoff frequency") || !strings.Contains(hover.Contents.Value, "`440`") {
+			t.Errorf("got hover %q, want the freq argument's docs", hover.Contents.Value)
+		}
+	})
+
+	t.Run("signatureHelp", func(t *testing.T) {
+		var help SignatureHelp
+		if err := c.call("textDocument/signatureHelp", at(2, 24), &help); err != nil {
+			t.Fatal(err)
+		}
+		if len(help.Signatures) != 1 {
+			t.Fatalf("got %v, want one signature", help.Signatures)
+		}
+		sig := help.Signatures[0]
+		if sig.Label != "(lpf in freq :mul :add)" {
+			t.Errorf("got label %q", sig.Label)
+		}
+		if help.ActiveParameter != 1 {
+			t.Errorf("got active parameter %d, want 1 (freq)", help.ActiveParameter)
+		}
+
+		if err := c.call("textDocument/signatureHelp", at(2, 20), &help); err != nil {
+			t.Fatal(err)
+		}
+		if help.ActiveParameter != 1 {
+			t.Errorf("got active parameter %d after one positional argument, want 1", help.ActiveParameter)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var loc Location
+		if err := c.call("textDocument/definition", at(3, 20), &loc); err != nil {
+			t.Fatal(err)
+		}
+		if loc.URI != uri || loc.Range.Start != (Position{Line: 1, Character: 5}) {
+			t.Errorf("got %v, want the def of cutoff", loc)
+		}
+
+		if err := c.call("textDocument/definition", at(2, 8), &loc); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(uriPath(loc.URI))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Base(uriPath(loc.URI)) != "core.glj" {
+			t.Errorf("got %s, want mrat/core.glj", loc.URI)
+		}
+		lines := strings.Split(string(data), "\n")
+		if loc.Range.Start.Line >= len(lines) || !strings.HasPrefix(lines[loc.Range.Start.Line], "(defugen lpf") {
+			t.Errorf("got line %d, want the defugen of lpf", loc.Range.Start.Line)
+		}
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		diags := c.diagnostics(uri)
+		if len(diags) != 1 || diags[0].Severity != severityError {
+			t.Fatalf("got %v, want one error for the unclosed form", diags)
+		}
+
+		c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": 2},
+			"contentChanges": []map[string]any{{"text": "(ns user)\n(def x (undefined-fn 1))\n"}},
+		})
+		diags = c.diagnostics(uri)
+		if len(diags) != 1 || !strings.Contains(diags[0].Message, "undefined-fn") {
+			t.Fatalf("got %v, want an error for undefined-fn", diags)
+		}
+		if diags[0].Range.Start.Line != 1 {
+			t.Errorf("got range %v, want one on line 1", diags[0].Range)
+		}
+
+		c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": 3},
+			"contentChanges": []map[string]any{{"text": "(ns user)\n(play (lpf (saw 110) :freq 300))\n"}},
+		})
+		if diags := c.diagnostics(uri); len(diags) != 0 {
+			t.Errorf("got %v, want no diagnostics", diags)
+		}
+	})
+
+	var res any
+	if err := c.call("textDocument/formatting", map[string]any{}, &res); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("got %v, want method not found", err)
+	}
+	if err := c.call("shutdown", nil, &res); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,136 @@
+package lsp
+
+// The subset of the Language Server Protocol types used by the server.
+// See https://microsoft.github.io/language-server-protocol/.
+
+type (
+	Position struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	}
+
+	Range struct {
+		Start Position `json:"start"`
+		End   Position `json:"end"`
+	}
+
+	Location struct {
+		URI   string `json:"uri"`
+		Range Range  `json:"range"`
+	}
+
+	TextDocumentIdentifier struct {
+		URI string `json:"uri"`
+	}
+
+	TextDocumentItem struct {
+		URI        string `json:"uri"`
+		LanguageID string `json:"languageId"`
+		Version    int    `json:"version"`
+		Text       string `json:"text"`
+	}
+
+	TextDocumentPositionParams struct {
+		TextDocument TextDocumentIdentifier `json:"textDocument"`
+		Position     Position               `json:"position"`
+	}
+
+	DidOpenTextDocumentParams struct {
+		TextDocument TextDocumentItem `json:"textDocument"`
+	}
+
+	DidChangeTextDocumentParams struct {
+		TextDocument struct {
+			URI     string `json:"uri"`
+			Version int    `json:"version"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+
+	DidCloseTextDocumentParams struct {
+		TextDocument TextDocumentIdentifier `json:"textDocument"`
+	}
+
+	DidSaveTextDocumentParams struct {
+		TextDocument TextDocumentIdentifier `json:"textDocument"`
+	}
+
+	MarkupContent struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+
+	TextEdit struct {
+		Range   Range  `json:"range"`
+		NewText string `json:"newText"`
+	}
+
+	CompletionItem struct {
+		Label         string         `json:"label"`
+		Kind          int            `json:"kind,omitempty"`
+		Detail        string         `json:"detail,omitempty"`
+		Documentation *MarkupContent `json:"documentation,omitempty"`
+		TextEdit      *TextEdit      `json:"textEdit,omitempty"`
+		// Data identifies the symbol completed, for
+		// completionItem/resolve.
+		Data string `json:"data,omitempty"`
+	}
+
+	CompletionList struct {
+		IsIncomplete bool             `json:"isIncomplete"`
+		Items        []CompletionItem `json:"items"`
+	}
+
+	Hover struct {
+		Contents MarkupContent `json:"contents"`
+		Range    *Range        `json:"range,omitempty"`
+	}
+
+	ParameterInformation struct {
+		// Label is the [start, end) offsets of the parameter in its
+		// signature's label.
+		Label         [2]int         `json:"label"`
+		Documentation *MarkupContent `json:"documentation,omitempty"`
+	}
+
+	SignatureInformation struct {
+		Label         string                 `json:"label"`
+		Documentation *MarkupContent         `json:"documentation,omitempty"`
+		Parameters    []ParameterInformation `json:"parameters"`
+	}
+
+	SignatureHelp struct {
+		Signatures      []SignatureInformation `json:"signatures"`
+		ActiveSignature int                    `json:"activeSignature"`
+		ActiveParameter int                    `json:"activeParameter"`
+	}
+
+	Diagnostic struct {
+		Range    Range  `json:"range"`
+		Severity int    `json:"severity"`
+		Code     string `json:"code,omitempty"`
+		Source   string `json:"source"`
+		Message  string `json:"message"`
+	}
+
+	PublishDiagnosticsParams struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+)
+
+// Completion item kinds.
+const (
+	completionFunction = 3
+	completionVariable = 6
+	completionKeyword  = 14
+	completionConstant = 21
+)
+
+// Diagnostic severities.
+const (
+	severityError   = 1
+	severityWarning = 2
+)
@@ -0,0 +1,437 @@
+// Package lsp implements a Language Server Protocol server for muscrat
+// scripts, so that any editor with LSP support can offer completion,
+// hover documentation, signature help, go-to-definition and
+// diagnostics for them.
+//
+// Completion, hover and signature help draw on the documentation of
+// mrat.core and clojure.core, including the arguments of ugens.
+// Definitions in the standard library are extracted to the user cache
+// directory so that editors can open them. Diagnostics come from a dry
+// run of each script with mrat.CheckSource: scripts are evaluated, but
+// nothing is played.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+	"github.com/jfhamlin/muscrat/pkg/refdoc"
+)
+
+const (
+	// checkDelay is how long to wait after a change to a document
+	// before checking it, so that checks don't run on every
+	// keystroke.
+	checkDelay = 500 * time.Millisecond
+
+	// checkTimeout bounds the time taken by a dry run.
+	checkTimeout = 10 * time.Second
+
+	diagnosticSource = "muscrat"
+)
+
+type (
+	// Server serves the Language Server Protocol over a connection.
+	Server struct {
+		conn *conn
+
+		// symbols are the documented symbols, with mrat.core taking
+		// precedence over clojure.core.
+		symbols map[string]*symbol
+		names   []string
+
+		docs    map[string]string
+		docsMtx sync.Mutex
+
+		// documents to check, and a timer to check them after
+		// checkDelay.
+		pendingChecks map[string]bool
+		checkTimer    *time.Timer
+		checkSignal   chan struct{}
+		checkMtx      sync.Mutex
+
+		shutdown bool
+	}
+
+	symbol struct {
+		mrat.Symbol
+		ns  string
+		ref *refdoc.Reference
+	}
+)
+
+// Serve serves the protocol on r and w until the client sends the exit
+// notification, r is closed, or ctx is done.
+func Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &Server{
+		conn:          newConn(r, w),
+		docs:          map[string]string{},
+		pendingChecks: map[string]bool{},
+		checkSignal:   make(chan struct{}, 1),
+	}
+	s.loadSymbols()
+	go s.runChecks(ctx)
+
+	msgs := make(chan *message)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := s.conn.read()
+			var rerr *responseError
+			if errors.As(err, &rerr) {
+				s.conn.reply(nil, nil, rerr)
+				continue
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case msg := <-msgs:
+			if msg.Method == "exit" {
+				return nil
+			}
+			s.handle(ctx, msg)
+		}
+	}
+}
+
+// ServeStdio serves the protocol on the process's standard input and
+// output. Anything else written to standard output, including output
+// from glojure, is redirected to standard error so that it doesn't
+// corrupt the protocol stream.
+func ServeStdio(ctx context.Context) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	lang.VarOut.BindRoot(os.Stderr)
+	log.SetOutput(os.Stderr)
+
+	return Serve(ctx, os.Stdin, stdout)
+}
+
+func (s *Server) loadSymbols() {
+	s.symbols = map[string]*symbol{}
+	for _, ns := range []string{"clojure.core", refdoc.Namespace} {
+		syms := mrat.GetPublics(ns)
+		ref := refdoc.New(syms)
+		for _, sym := range syms {
+			s.symbols[sym.Name] = &symbol{Symbol: sym, ns: ns, ref: ref}
+		}
+	}
+	for name := range s.symbols {
+		s.names = append(s.names, name)
+	}
+	sort.Strings(s.names)
+}
+
+// handle handles a request or notification.
+func (s *Server) handle(ctx context.Context, msg *message) {
+	var result any
+	var err error
+	if s.shutdown && msg.ID != nil {
+		s.conn.reply(msg.ID, nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"})
+		return
+	}
+
+	switch msg.Method {
+	case "initialize":
+		result = s.initialize()
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err = unmarshalParams(msg, &params); err == nil {
+			s.setDocument(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err = unmarshalParams(msg, &params); err == nil && len(params.ContentChanges) > 0 {
+			// with full document sync, the last change is the whole
+			// document.
+			s.setDocument(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if err = unmarshalParams(msg, &params); err == nil {
+			s.scheduleCheck(params.TextDocument.URI)
+		}
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err = unmarshalParams(msg, &params); err == nil {
+			s.closeDocument(params.TextDocument.URI)
+		}
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err = unmarshalParams(msg, &params); err == nil {
+			result = s.completion(params)
+		}
+	case "completionItem/resolve":
+		var item CompletionItem
+		if err = unmarshalParams(msg, &item); err == nil {
+			result = s.resolveCompletion(item)
+		}
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err = unmarshalParams(msg, &params); err == nil {
+			result = s.hover(params)
+		}
+	case "textDocument/signatureHelp":
+		var params TextDocumentPositionParams
+		if err = unmarshalParams(msg, &params); err == nil {
+			result = s.signatureHelp(params)
+		}
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err = unmarshalParams(msg, &params); err == nil {
+			result, err = s.definition(params)
+		}
+	default:
+		if msg.ID == nil {
+			// unknown notifications are ignored.
+			return
+		}
+		err = &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+
+	if msg.ID == nil {
+		if err != nil {
+			log.Printf("lsp: %s: %v", msg.Method, err)
+		}
+		return
+	}
+	if err := s.conn.reply(msg.ID, result, err); err != nil {
+		log.Printf("lsp: error replying to %s: %v", msg.Method, err)
+	}
+}
+
+func (s *Server) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    1, // full
+				"save":      true,
+			},
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{"(", ":"},
+				"resolveProvider":   true,
+			},
+			"hoverProvider": true,
+			"signatureHelpProvider": map[string]any{
+				"triggerCharacters":   []string{"(", " "},
+				"retriggerCharacters": []string{" "},
+			},
+			"definitionProvider": true,
+		},
+		"serverInfo": map[string]any{
+			"name": "muscrat",
+		},
+	}
+}
+
+func unmarshalParams(msg *message, v any) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Documents
+
+func (s *Server) setDocument(uri, text string) {
+	s.docsMtx.Lock()
+	s.docs[uri] = text
+	s.docsMtx.Unlock()
+
+	s.scheduleCheck(uri)
+}
+
+func (s *Server) closeDocument(uri string) {
+	s.docsMtx.Lock()
+	delete(s.docs, uri)
+	s.docsMtx.Unlock()
+
+	s.checkMtx.Lock()
+	delete(s.pendingChecks, uri)
+	s.checkMtx.Unlock()
+
+	s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{}})
+}
+
+func (s *Server) document(uri string) (string, bool) {
+	s.docsMtx.Lock()
+	defer s.docsMtx.Unlock()
+
+	text, ok := s.docs[uri]
+	return text, ok
+}
+
+// lookup returns the documented symbol named by the token name, which
+// may be qualified by its namespace.
+func (s *Server) lookup(name string) *symbol {
+	name, ns := unqualified(name)
+	sym := s.symbols[name]
+	if sym == nil || (ns != "" && ns != sym.ns) {
+		return nil
+	}
+	return sym
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Diagnostics
+
+// scheduleCheck checks the document after checkDelay.
+func (s *Server) scheduleCheck(uri string) {
+	s.checkMtx.Lock()
+	defer s.checkMtx.Unlock()
+
+	s.pendingChecks[uri] = true
+	if s.checkTimer == nil {
+		s.checkTimer = time.AfterFunc(checkDelay, func() {
+			select {
+			case s.checkSignal <- struct{}{}:
+			default:
+			}
+		})
+	} else {
+		s.checkTimer.Reset(checkDelay)
+	}
+}
+
+// runChecks checks the pending documents whenever signaled, until ctx
+// is done.
+func (s *Server) runChecks(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.checkSignal:
+		}
+
+		s.checkMtx.Lock()
+		uris := make([]string, 0, len(s.pendingChecks))
+		for uri := range s.pendingChecks {
+			uris = append(uris, uri)
+		}
+		s.pendingChecks = map[string]bool{}
+		s.checkMtx.Unlock()
+
+		sort.Strings(uris)
+		for _, uri := range uris {
+			text, ok := s.document(uri)
+			if !ok {
+				continue
+			}
+			diags := check(ctx, uri, text)
+			s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diags})
+		}
+	}
+}
+
+// check dry-runs the document's text and returns the diagnostics
+// found.
+func check(ctx context.Context, uri, text string) []Diagnostic {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	err := mrat.CheckSource(ctx, uriPath(uri), text)
+	if err == nil {
+		return []Diagnostic{}
+	}
+
+	diag := Diagnostic{
+		Severity: severityError,
+		Source:   diagnosticSource,
+		Message:  err.Error(),
+	}
+	var d *mrat.Diagnostic
+	if errors.As(err, &d) {
+		diag.Message = d.Message
+		diag.Code = string(d.Kind)
+		if d.Kind == mrat.DiagnosticCanceled {
+			diag.Severity = severityWarning
+		}
+		diag.Range = diagnosticRange(text, d)
+	}
+	return []Diagnostic{diag}
+}
+
+// diagnosticRange returns the range of text to mark for d: the form it
+// locates, or the first line if its location is unknown.
+func diagnosticRange(text string, d *mrat.Diagnostic) Range {
+	if d.Line <= 0 {
+		end := strings.IndexByte(text, '\n')
+		if end < 0 {
+			end = len(text)
+		}
+		return Range{End: positionAt(text, end)}
+	}
+
+	start := offsetAt(text, Position{Line: d.Line - 1})
+	for col := 1; col < d.Column && start < len(text) && text[start] != '\n'; col++ {
+		_, size := utf8.DecodeRuneInString(text[start:])
+		start += size
+	}
+	end := start
+	if d.Form != "" && strings.HasPrefix(text[start:], d.Form) {
+		end += len(d.Form)
+	} else if tok, tokStart := tokenAt(text, start); tokStart == start && tok != "" {
+		end += len(tok)
+	} else if nl := strings.IndexByte(text[start:], '\n'); nl >= 0 {
+		end += nl
+	} else {
+		end = len(text)
+	}
+	return Range{Start: positionAt(text, start), End: positionAt(text, end)}
+}
+
+// uriPath returns the file path of a file URI, or the empty string
+// for other URIs.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// pathURI returns the file URI of a path.
+func pathURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,188 @@
+package lsp
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	// defRegexp matches top-level definitions, capturing the name
+	// defined.
+	defRegexp = regexp.MustCompile(`\(\s*def[a-z-]*\s+(?:\^(?:\{[^}]*\}|\S+)\s+)*([^\s()\[\]{}"';]+)`)
+)
+
+type (
+	// form is an open list form enclosing a position in a document.
+	form struct {
+		// args are the completed elements of the form preceding the
+		// position, starting with the head. Nested forms and literals
+		// other than symbols and keywords are represented by the
+		// empty string.
+		args []string
+		// partial is the token the position is within, up to the
+		// position.
+		partial string
+	}
+)
+
+// offsetAt returns the byte offset in text of pos, whose character is
+// counted in UTF-16 code units. Positions past the end of a line are
+// clamped to the end of the line.
+func offsetAt(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for units := 0; offset < len(text) && units < pos.Character; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset
+}
+
+// positionAt returns the position of the byte offset in text.
+func positionAt(text string, offset int) Position {
+	offset = min(offset, len(text))
+	line := strings.Count(text[:offset], "\n")
+	start := strings.LastIndexByte(text[:offset], '\n') + 1
+	char := 0
+	for _, r := range text[start:offset] {
+		char += utf16.RuneLen(r)
+	}
+	return Position{Line: line, Character: char}
+}
+
+// isSymbolChar reports whether c may appear in a symbol or keyword.
+func isSymbolChar(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', ',', '(', ')', '[', ']', '{', '}', '"', ';', '\'', '`', '~', '@', '^', '\\':
+		return false
+	}
+	return true
+}
+
+// tokenAt returns the symbol or keyword containing offset, and the
+// offset at which it starts.
+func tokenAt(text string, offset int) (string, int) {
+	start := offset
+	for start > 0 && isSymbolChar(text[start-1]) {
+		start--
+	}
+	end := offset
+	for end < len(text) && isSymbolChar(text[end]) {
+		end++
+	}
+	return text[start:end], start
+}
+
+// enclosingForms returns the list forms enclosing offset, innermost
+// last. Strings, comments and character literals are skipped.
+func enclosingForms(text string, offset int) []*form {
+	type frame struct {
+		list bool
+		f    *form
+	}
+	stack := []frame{{f: &form{}}}
+	top := func() *form { return stack[len(stack)-1].f }
+
+	tokenStart := -1
+	endToken := func(i int) {
+		if tokenStart >= 0 {
+			top().args = append(top().args, text[tokenStart:i])
+			tokenStart = -1
+		}
+	}
+
+	for i := 0; i < offset; i++ {
+		c := text[i]
+		switch {
+		case c == ';':
+			endToken(i)
+			for i < offset && text[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			endToken(i)
+			for i++; i < offset && text[i] != '"'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+			if i < offset {
+				top().args = append(top().args, "")
+			}
+		case c == '\\' && tokenStart < 0:
+			// a character literal.
+			for i += 2; i < offset && isSymbolChar(text[i]); i++ {
+			}
+			i--
+			top().args = append(top().args, "")
+		case c == '(' || c == '[' || c == '{':
+			endToken(i)
+			stack = append(stack, frame{list: c == '(', f: &form{}})
+		case c == ')' || c == ']' || c == '}':
+			endToken(i)
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+				top().args = append(top().args, "")
+			}
+		case isSymbolChar(c) && !(c == '#' && tokenStart < 0):
+			if tokenStart < 0 {
+				tokenStart = i
+			}
+		default:
+			endToken(i)
+		}
+	}
+	if tokenStart >= 0 {
+		top().partial = text[tokenStart:offset]
+	}
+
+	var res []*form
+	for _, fr := range stack {
+		if fr.list {
+			res = append(res, fr.f)
+		}
+	}
+	return res
+}
+
+// head returns the symbol at the head of f, if any.
+func (f *form) head() string {
+	if len(f.args) == 0 {
+		return ""
+	}
+	return f.args[0]
+}
+
+// definitions returns the offsets of the names defined at the top
+// level of text, by name.
+func definitions(text string) map[string]int {
+	res := map[string]int{}
+	for _, m := range defRegexp.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[2]:m[3]]
+		if _, ok := res[name]; !ok {
+			res[name] = m[2]
+		}
+	}
+	return res
+}
+
+// unqualified returns name without its namespace, if any, and the
+// namespace.
+func unqualified(name string) (string, string) {
+	if i := strings.IndexByte(name, '/'); i > 0 && i < len(name)-1 {
+		return name[i+1:], name[:i]
+	}
+	return name, ""
+}
@@ -0,0 +1,191 @@
+// Package meter batches output samples for display and measures
+// their volume.
+package meter
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	// SamplesEvent is the event emitted with a batch of samples, one
+	// []float64 per channel.
+	SamplesEvent = "samples"
+
+	// VolumeEvent is the event emitted with the Volume of each batch.
+	VolumeEvent = "volume"
+
+	// updateRate is the approximate number of batches emitted per
+	// second.
+	updateRate = 15
+
+	// minDB is the floor of the volume in decibels.
+	minDB = -60.0
+
+	// Ballistics constants, at the ~15Hz update rate.
+	// Attack: 0.95 means ~3 updates to reach 95% (200ms at 15Hz)
+	// Release: 0.25 means ~12 updates to decay to 5% (800ms at 15Hz)
+	attackRate  = 0.95 // Fast attack
+	releaseRate = 0.25 // Moderate release for better responsiveness
+)
+
+type (
+	// Meter accumulates the samples written to it and emits them in
+	// batches about 15 times per second, each followed by the volume
+	// of the batch.
+	Meter struct {
+		emit func(event string, data any)
+
+		batchSamples      int
+		fastWindowSamples int
+		slowWindowSamples int
+
+		channelBuffers [][]float64
+		fastRMSBuffers [][]float64
+		slowRMSBuffers [][]float64
+		smoothedRMS    []float64
+		smoothedPeak   []float64
+
+		mtx sync.Mutex
+	}
+
+	// Volume is the volume of each channel of a batch of samples,
+	// with meter ballistics applied.
+	Volume struct {
+		RMS    []float64 `json:"rms"`
+		Peak   []float64 `json:"peak"`
+		RMSDB  []float64 `json:"rmsDB"`
+		PeakDB []float64 `json:"peakDB"`
+	}
+)
+
+// New returns a Meter for samples at sampleRate, emitting batches in
+// multiples of bufferSize samples. emit is called with SamplesEvent
+// and a copy of each batch, then with VolumeEvent and its Volume.
+func New(sampleRate, bufferSize int, emit func(event string, data any)) *Meter {
+	// send at ~15 times per second, in multiples of bufferSize
+	batchSamples := sampleRate / updateRate
+	batchSamples = (batchSamples/bufferSize + 1) * bufferSize
+
+	return &Meter{
+		emit:         emit,
+		batchSamples: batchSamples,
+		// Time constants for dual RMS windows
+		fastWindowSamples: int(float64(sampleRate) * 0.020), // 20ms fast window
+		slowWindowSamples: int(float64(sampleRate) * 0.300), // 300ms slow window
+	}
+}
+
+// Write adds samples, one []float64 per channel, to the current
+// batch. The samples are copied, so the caller may reuse them.
+func (m *Meter) Write(samples [][]float64) {
+	if len(samples) == 0 {
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	// Initialize buffers if needed
+	if len(m.channelBuffers) != len(samples) {
+		m.channelBuffers = make([][]float64, len(samples))
+		m.fastRMSBuffers = make([][]float64, len(samples))
+		m.slowRMSBuffers = make([][]float64, len(samples))
+		m.smoothedRMS = make([]float64, len(samples))
+		m.smoothedPeak = make([]float64, len(samples))
+	}
+
+	// Append new samples to all buffers
+	for i := range samples {
+		m.channelBuffers[i] = append(m.channelBuffers[i], samples[i]...)
+		m.fastRMSBuffers[i] = trimWindow(append(m.fastRMSBuffers[i], samples[i]...), m.fastWindowSamples)
+		m.slowRMSBuffers[i] = trimWindow(append(m.slowRMSBuffers[i], samples[i]...), m.slowWindowSamples)
+	}
+
+	if len(m.channelBuffers[0]) < m.batchSamples {
+		return
+	}
+
+	cpy := make([][]float64, len(m.channelBuffers))
+	for i := range m.channelBuffers {
+		cpy[i] = make([]float64, len(m.channelBuffers[i]))
+		copy(cpy[i], m.channelBuffers[i])
+	}
+	m.emit(SamplesEvent, cpy)
+	m.emit(VolumeEvent, m.volume())
+
+	// Clear main buffer
+	for i := range m.channelBuffers {
+		m.channelBuffers[i] = m.channelBuffers[i][:0]
+	}
+}
+
+// volume returns the volume of the current batch, updating the
+// smoothed levels.
+func (m *Meter) volume() *Volume {
+	n := len(m.channelBuffers)
+	vol := &Volume{
+		RMS:    make([]float64, n),
+		Peak:   make([]float64, n),
+		RMSDB:  make([]float64, n),
+		PeakDB: make([]float64, n),
+	}
+
+	for i := range m.channelBuffers {
+		// Use the maximum of fast and slow RMS
+		currentRMS := math.Max(rms(m.fastRMSBuffers[i]), rms(m.slowRMSBuffers[i]))
+
+		// Find peak in current buffer
+		currentPeak := 0.0
+		for _, v := range m.channelBuffers[i] {
+			currentPeak = math.Max(currentPeak, math.Abs(v))
+		}
+
+		// Apply ballistics
+		if currentRMS > m.smoothedRMS[i] {
+			m.smoothedRMS[i] += (currentRMS - m.smoothedRMS[i]) * attackRate
+		} else {
+			m.smoothedRMS[i] += (currentRMS - m.smoothedRMS[i]) * releaseRate
+		}
+
+		if currentPeak > m.smoothedPeak[i] {
+			// Instant attack for peaks
+			m.smoothedPeak[i] = currentPeak
+		} else {
+			// Slow decay for peaks
+			m.smoothedPeak[i] += (currentPeak - m.smoothedPeak[i]) * releaseRate
+		}
+
+		vol.RMS[i] = m.smoothedRMS[i]
+		vol.Peak[i] = m.smoothedPeak[i]
+		vol.RMSDB[i] = decibels(m.smoothedRMS[i])
+		vol.PeakDB[i] = decibels(m.smoothedPeak[i])
+	}
+	return vol
+}
+
+func trimWindow(buf []float64, size int) []float64 {
+	if len(buf) > size {
+		return buf[len(buf)-size:]
+	}
+	return buf
+}
+
+func rms(buf []float64) float64 {
+	if len(buf) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range buf {
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(buf)))
+}
+
+// decibels converts a linear level to decibels, with a -60dB floor.
+func decibels(level float64) float64 {
+	if level <= 0 {
+		return minDB
+	}
+	return math.Max(20*math.Log10(level), minDB)
+}
@@ -0,0 +1,41 @@
+package mod
+
+import (
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+)
+
+func TestConformance(t *testing.T) {
+	ugentest.Run(t,
+		ugentest.Spec{
+			Name: "envelope",
+			New:  func() ugen.UGen { return NewEnvelope() },
+			Inputs: map[string]ugentest.Signal{
+				"trigger": ugentest.Gate(4),
+				"level$0": ugentest.Const(0),
+				"level$1": ugentest.Const(1),
+				"level$2": ugentest.Const(0),
+				"time$0":  ugentest.Const(0.01),
+				"time$1":  ugentest.Const(0.1),
+			},
+		},
+		ugentest.Spec{
+			Name: "envelope-curves",
+			New: func() ugen.UGen {
+				return NewEnvelope(WithCurve([]any{"exp", -4.0, "hold"}), WithReleaseNode(2))
+			},
+			Inputs: map[string]ugentest.Signal{
+				"trigger": ugentest.Gate(4),
+				"level$0": ugentest.Const(0.01),
+				"level$1": ugentest.Const(1),
+				"level$2": ugentest.Const(0.5),
+				"level$3": ugentest.Const(0),
+				"time$0":  ugentest.Const(0.01),
+				"time$1":  ugentest.Const(0.05),
+				"time$2":  ugentest.Const(0.05),
+			},
+		},
+	)
+}
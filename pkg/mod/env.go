@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/jfhamlin/muscrat/pkg/bufferpool"
 	"github.com/jfhamlin/muscrat/pkg/ugen"
 )
 
@@ -85,7 +84,10 @@ func NewEnvelope(opts ...EnvOption) ugen.UGen {
 
 	setupStage := func(cfg ugen.SampleConfig, levels, times [][]float64, idx int) {
 		stageLevel := levels[stage][idx]
-		stageTime := times[stage-1][idx]
+		stageTime := 0.0
+		if stage-1 < len(times) {
+			stageTime = times[stage-1][idx]
+		}
 		stageShape := o.curve[(stage-1)%len(o.curve)]
 
 		var curve float64
@@ -114,24 +116,33 @@ func NewEnvelope(opts ...EnvOption) ugen.UGen {
 				delta = math.Exp(curve / float64(counter))
 			}
 		case shapeExp:
-			delta = math.Pow(stageLevel/level, 1/float64(counter))
+			if level*stageLevel <= 0 {
+				// an exponential segment can't start, end or cross
+				// zero, so fall back to a linear one.
+				shape = shapeLin
+				delta = (stageLevel - level) / float64(counter)
+			} else {
+				delta = math.Pow(stageLevel/level, 1/float64(counter))
+			}
 		case shapeHold:
 			level = levels[stage-1][idx]
 			delta = 0
 		}
 	}
 
+	gateInput := ugen.Input{Name: "trigger"}
+	var levels, times [][]float64
+	var zeros []float64
+
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 		n := len(out)
 
-		levels := getSampleArrays(cfg.InputSamples, "level")
-		times := getSampleArrays(cfg.InputSamples, "time")
-		gate := cfg.InputSamples["trigger"]
-		if len(gate) == 0 {
-			buf := bufferpool.Get(n)
-			gate = *buf
-			defer bufferpool.Put(buf)
+		if len(zeros) < n {
+			zeros = make([]float64, n)
 		}
+		levels = appendSampleArrays(levels[:0], cfg.InputSamples, "level", zeros[:n])
+		times = appendSampleArrays(times[:0], cfg.InputSamples, "time", zeros[:n])
+		gate := gateInput.Samples(cfg, n)
 		if len(levels) == 0 {
 			return
 		}
@@ -196,31 +207,29 @@ func NewEnvelope(opts ...EnvOption) ugen.UGen {
 	})
 }
 
-func getSampleArrays(inputs map[string][]float64, name string) [][]float64 {
-	var numSamples int
-
+// appendSampleArrays appends the inputs named name$<int> to dst, in
+// order of their indices, and returns the extended slice. Missing and
+// short inputs are replaced by zeros.
+func appendSampleArrays(dst [][]float64, inputs map[string][]float64, name string, zeros []float64) [][]float64 {
+	base := len(dst)
 	prefix := name + "$"
-	var res [][]float64
 	for key, in := range inputs {
 		if strings.HasPrefix(key, prefix) {
 			idx, err := strconv.Atoi(key[len(prefix):])
-			if err != nil {
+			if err != nil || idx < 0 {
 				continue
 			}
-			for idx >= len(res) {
-				res = append(res, nil)
-			}
-			res[idx] = in
-			if numSamples == 0 {
-				numSamples = len(in)
+			for base+idx >= len(dst) {
+				dst = append(dst, nil)
 			}
+			dst[base+idx] = in
 		}
 	}
-	// fill in any missing inputs with arrays of zeros.
-	for i, in := range res {
-		if len(in) == 0 {
-			res[i] = make([]float64, numSamples)
+	// fill in any missing inputs with zeros.
+	for i := base; i < len(dst); i++ {
+		if len(dst[i]) < len(zeros) {
+			dst[i] = zeros
 		}
 	}
-	return res
+	return dst
 }
@@ -0,0 +1,217 @@
+package mrat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/glojurelang/glojure/pkg/glj"
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/console"
+)
+
+type (
+	// cancelWriter is an io.Writer that aborts evaluation when
+	// written to after its context is done.
+	cancelWriter struct {
+		ctx context.Context
+		w   io.Writer
+	}
+
+	// evalRun tracks whether an evaluation started by runEval is
+	// stuck.
+	evalRun struct {
+		started chan struct{}
+		done    chan struct{}
+		stuck   bool
+	}
+
+	// evalRunKey is the context key under which runEval passes the
+	// evaluation's evalRun.
+	evalRunKey struct{}
+)
+
+// ErrEvalStuck is the error returned while a canceled evaluation that
+// can't be interrupted is still running.
+var ErrEvalStuck = errors.New("evaluation stuck; restart required")
+
+var (
+	cancelKW = lang.NewKeyword("mrat/cancel")
+
+	// evalSem serializes evaluations. It is held until an
+	// evaluation actually returns, even if its caller has given up
+	// on it, as glojure doesn't support concurrent evaluation.
+	evalSem = make(chan struct{}, 1)
+
+	// evalStuck is closed while the evaluation holding evalSem is
+	// stuck, so that later evaluations fail instead of queuing
+	// behind it.
+	evalStuck = make(chan struct{})
+	evalMu    sync.Mutex
+)
+
+// stuckGrace is how long a canceled evaluation is given to reach a
+// cancellation check, once it has called startScript, before it's
+// considered stuck. glojure can't interrupt a loop that neither
+// prints nor builds a graph, so a stuck evaluation may run until the
+// process exits.
+const stuckGrace = 250 * time.Millisecond
+
+// Write implements io.Writer.
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	checkCanceled(w.ctx)
+	return w.w.Write(p)
+}
+
+// checkCanceled aborts the evaluation in progress if ctx is done.
+func checkCanceled(ctx context.Context) {
+	if err := ctx.Err(); err != nil {
+		panic(err)
+	}
+}
+
+// startScript marks the evaluation running under ctx as having
+// loaded mrat.core. Loading can't be interrupted, but always
+// finishes, so evaluations canceled before then aren't considered
+// stuck.
+func startScript(ctx context.Context) {
+	if run, ok := ctx.Value(evalRunKey{}).(*evalRun); ok {
+		close(run.started)
+	}
+}
+
+// newGraphAtom returns an atom in which to collect the graph built by
+// a script. Evaluation is aborted if a node or edge is added after
+// ctx is done, which stops most runaway scripts, as any loop that
+// builds a graph adds to the atom.
+func newGraphAtom(ctx context.Context) *lang.Atom {
+	graphAtom := lang.NewAtom(glj.Read(`{:nodes [] :edges []}`))
+	graphAtom.AddWatch(cancelKW, lang.NewFnFunc(func(args ...any) any {
+		checkCanceled(ctx)
+		return nil
+	}))
+	return graphAtom
+}
+
+// runEval calls eval on a new goroutine once any evaluation in
+// progress has finished, and waits for it to return or for ctx to be
+// done, whichever comes first. In the latter case, a canceled
+// Diagnostic is returned immediately. eval continues in the
+// background until it reaches a cancellation check, and its result
+// is discarded; if it doesn't reach one within stuckGrace of calling
+// startScript, later evaluations fail with ErrEvalStuck until it
+// returns.
+func runEval[T any](ctx context.Context, file string, eval func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, newCanceledDiagnostic(err, file)
+	}
+
+	evalMu.Lock()
+	stuck := evalStuck
+	evalMu.Unlock()
+	select {
+	case evalSem <- struct{}{}:
+	case <-stuck:
+		return zero, newStuckDiagnostic(file)
+	case <-ctx.Done():
+		return zero, newCanceledDiagnostic(ctx.Err(), file)
+	}
+
+	type result struct {
+		val T
+		err error
+	}
+	done := make(chan result, 1)
+	run := &evalRun{started: make(chan struct{}), done: make(chan struct{})}
+	ctx = context.WithValue(ctx, evalRunKey{}, run)
+	go func() {
+		defer func() {
+			run.finish()
+			<-evalSem
+		}()
+
+		val, err := eval(ctx)
+		done <- result{val: val, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if err := ctx.Err(); err != nil && res.err != nil {
+			// the failure was (most likely) caused by the
+			// cancellation.
+			return res.val, newCanceledDiagnostic(err, file)
+		}
+		return res.val, res.err
+	case <-ctx.Done():
+		go run.watch()
+		return zero, newCanceledDiagnostic(ctx.Err(), file)
+	}
+}
+
+// watch marks the canceled evaluation as stuck if it doesn't finish
+// within stuckGrace of calling startScript.
+func (r *evalRun) watch() {
+	select {
+	case <-r.started:
+	case <-r.done:
+		return
+	}
+	select {
+	case <-time.After(stuckGrace):
+	case <-r.done:
+		return
+	}
+
+	evalMu.Lock()
+	defer evalMu.Unlock()
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	r.stuck = true
+	close(evalStuck)
+	console.Log(console.Warn, "a canceled evaluation could not be interrupted; restart to evaluate again", nil)
+}
+
+// finish marks the evaluation as finished, so that later evaluations
+// can run if it was stuck. It must be called before evalSem is
+// released.
+func (r *evalRun) finish() {
+	evalMu.Lock()
+	defer evalMu.Unlock()
+	close(r.done)
+	if r.stuck {
+		evalStuck = make(chan struct{})
+	}
+}
+
+// newCanceledDiagnostic builds a Diagnostic for an evaluation of file
+// aborted because its context is done with err.
+func newCanceledDiagnostic(err error, file string) *Diagnostic {
+	msg := "evaluation canceled"
+	if errors.Is(err, context.DeadlineExceeded) {
+		msg = "evaluation timed out"
+	}
+	return &Diagnostic{
+		Kind:    DiagnosticCanceled,
+		Message: msg,
+		File:    file,
+		err:     err,
+	}
+}
+
+// newStuckDiagnostic builds a Diagnostic for an evaluation of file
+// refused because a canceled evaluation is stuck.
+func newStuckDiagnostic(file string) *Diagnostic {
+	return &Diagnostic{
+		Kind:    DiagnosticCanceled,
+		Message: ErrEvalStuck.Error(),
+		File:    file,
+		err:     ErrEvalStuck,
+	}
+}
@@ -0,0 +1,93 @@
+package mrat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glojurelang/glojure/pkg/glj"
+	"github.com/glojurelang/glojure/pkg/lang"
+)
+
+func TestEvalStringTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	res, err := EvalStringContext(ctx, `(doseq [i (range)] (sin i))`, "cancel-test.timeout")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("evaluation took %v to time out", elapsed)
+	}
+	if res.Diagnostic == nil || res.Diagnostic.Kind != DiagnosticCanceled {
+		t.Errorf("expected a canceled diagnostic, got %+v", res.Diagnostic)
+	}
+
+	// evaluation works normally afterwards.
+	res, err = EvalString(`(+ 1 2)`, "cancel-test.timeout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Value != "3" {
+		t.Errorf("got value %q, want %q", res.Value, "3")
+	}
+}
+
+func TestEvalStringCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := EvalStringContext(ctx, `(+ 1 2)`, "cancel-test.canceled")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a canceled error, got %v", err)
+	}
+}
+
+func TestEvalStringTimeoutBusyLoop(t *testing.T) {
+	if _, err := EvalString(`(def stop (atom false))`, "cancel-test.busy"); err != nil {
+		t.Fatal(err)
+	}
+	stop := glj.Var("cancel-test.busy", "stop").(*lang.Var).Deref().(*lang.Atom)
+	defer stop.Reset(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// a loop that never prints or builds a graph can't be
+	// interrupted.
+	_, err := EvalStringContext(ctx, `(loop [] (if @stop :stopped (recur)))`, "cancel-test.busy")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+
+	// later evaluations fail rather than running alongside it.
+	start := time.Now()
+	_, err = EvalString(`(+ 1 2)`, "cancel-test.busy")
+	if !errors.Is(err, ErrEvalStuck) {
+		t.Fatalf("expected a stuck error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("evaluation took %v to fail", elapsed)
+	}
+
+	// and work normally once it returns.
+	stop.Reset(true)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		res, err := EvalString(`(+ 1 2)`, "cancel-test.busy")
+		if errors.Is(err, ErrEvalStuck) && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "3" {
+			t.Errorf("got value %q, want %q", res.Value, "3")
+		}
+		break
+	}
+}
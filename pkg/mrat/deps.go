@@ -0,0 +1,144 @@
+package mrat
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/glojurelang/glojure/pkg/glj"
+	"github.com/glojurelang/glojure/pkg/lang"
+	"github.com/glojurelang/glojure/pkg/runtime"
+)
+
+type (
+	// scriptFS is a load path entry for a directory of scripts. It
+	// records the files loaded from it, so that a script can be
+	// re-evaluated when any namespace it requires changes.
+	scriptFS struct {
+		dir  string
+		fsys fs.FS
+	}
+)
+
+var (
+	loadMtx sync.Mutex
+	// addedPaths holds the script directories added to the load path.
+	addedPaths = map[string]bool{}
+	// loadRecorders holds the files loaded by each evaluation in
+	// progress.
+	loadRecorders = map[*[]string]bool{}
+	// scriptLibs maps the libs loaded from script directories to
+	// their files.
+	scriptLibs = map[string]string{}
+
+	unloadLibsOnce sync.Once
+	unloadLibsFn   lang.IFn
+)
+
+// Open implements fs.FS.
+func (s *scriptFS) Open(name string) (fs.File, error) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(s.dir, filepath.FromSlash(name))
+
+	loadMtx.Lock()
+	defer loadMtx.Unlock()
+
+	scriptLibs[libName(name)] = path
+	for rec := range loadRecorders {
+		*rec = append(*rec, path)
+	}
+	return f, nil
+}
+
+// addScriptDir adds dir to the load path if it isn't already.
+func addScriptDir(dir string) {
+	loadMtx.Lock()
+	defer loadMtx.Unlock()
+
+	if !addedPaths[dir] {
+		runtime.AddLoadPath(&scriptFS{dir: dir, fsys: os.DirFS(dir)})
+		addedPaths[dir] = true
+	}
+}
+
+// libName returns the name of the lib loaded from the file with the
+// given load path-relative name.
+func libName(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.NewReplacer("/", ".", "_", "-").Replace(name)
+}
+
+// scriptLibFile returns the path of the file loaded from a script
+// directory with the given load path-relative name.
+func scriptLibFile(name string) (string, bool) {
+	loadMtx.Lock()
+	defer loadMtx.Unlock()
+
+	path, ok := scriptLibs[libName(name)]
+	if !ok || !strings.HasSuffix(path, filepath.FromSlash(name)) {
+		return "", false
+	}
+	return path, true
+}
+
+// recordLoads starts recording the files loaded from script
+// directories. The returned function stops recording and returns the
+// files loaded in the meantime, in the order they were first loaded.
+func recordLoads() func() []string {
+	var files []string
+
+	loadMtx.Lock()
+	loadRecorders[&files] = true
+	loadMtx.Unlock()
+
+	return func() []string {
+		loadMtx.Lock()
+		delete(loadRecorders, &files)
+		loadMtx.Unlock()
+
+		seen := map[string]bool{}
+		var res []string
+		for _, f := range files {
+			if !seen[f] {
+				seen[f] = true
+				res = append(res, f)
+			}
+		}
+		return res
+	}
+}
+
+// unloadScriptLibs marks all libs loaded from script directories as
+// not loaded, so that the next require of each reloads it from disk.
+func unloadScriptLibs() {
+	loadMtx.Lock()
+	libs := make([]string, 0, len(scriptLibs))
+	for lib := range scriptLibs {
+		libs = append(libs, lib)
+	}
+	loadMtx.Unlock()
+
+	if len(libs) == 0 {
+		return
+	}
+	sort.Strings(libs)
+
+	unloadLibsOnce.Do(func() {
+		unloadLibsFn = glj.Var("clojure.core", "eval").Invoke(glj.Read(
+			`(fn [libs] (dosync (apply commute @#'clojure.core/*loaded-libs* disj (map symbol libs))))`,
+		)).(lang.IFn)
+	})
+
+	anyLibs := make([]any, len(libs))
+	for i, lib := range libs {
+		anyLibs[i] = lib
+	}
+	unloadLibsFn.Invoke(lang.NewVector(anyLibs...))
+}
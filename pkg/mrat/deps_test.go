@@ -0,0 +1,72 @@
+package mrat
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/glojurelang/glojure/pkg/glj"
+	"github.com/glojurelang/glojure/pkg/lang"
+)
+
+func TestEvalScriptDependencies(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	main := write("deps_test_main.glj", `(ns deps-test-main
+  (:use [mrat.core])
+  (:require [deps-test.notes :as notes]))
+(def freq notes/freq)
+(play (sin freq))`)
+	notes := write("deps_test/notes.glj", `(ns deps-test.notes
+  (:require [deps-test.tuning :as tuning]))
+(def freq (* 2 tuning/base))`)
+	tuning := write("deps_test/tuning.glj", `(ns deps-test.tuning)
+(def base 110)`)
+
+	_, deps, err := EvalScript(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{notes, tuning}; !reflect.DeepEqual(deps, want) {
+		t.Errorf("got dependencies %v, want %v", deps, want)
+	}
+	if got := glj.Var("deps-test-main", "freq").(*lang.Var).Deref(); got != int64(220) {
+		t.Errorf("got freq %v, want 220", got)
+	}
+
+	// changes to a transitive dependency are picked up by the next
+	// evaluation of the script.
+	write("deps_test/tuning.glj", `(ns deps-test.tuning)
+(def base 220)`)
+	if _, _, err := EvalScript(main); err != nil {
+		t.Fatal(err)
+	}
+	if got := glj.Var("deps-test-main", "freq").(*lang.Var).Deref(); got != int64(440) {
+		t.Errorf("got freq %v after change, want 440", got)
+	}
+
+	// dependencies are reported even if evaluation fails.
+	write("deps_test/tuning.glj", `(ns deps-test.tuning)
+(def base (undefined-fn))`)
+	_, deps, err = EvalScript(main)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(deps) != 2 {
+		t.Errorf("got dependencies %v, want 2", deps)
+	}
+	if diag, ok := err.(*Diagnostic); !ok || diag.File != tuning {
+		t.Errorf("expected a diagnostic in %s, got %v", tuning, err)
+	}
+}
@@ -0,0 +1,251 @@
+package mrat
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+	"github.com/glojurelang/glojure/pkg/reader"
+)
+
+type (
+	// DiagnosticKind classifies the stage at which evaluation failed.
+	DiagnosticKind string
+
+	// StackFrame is a frame of a glojure-level stack trace.
+	StackFrame struct {
+		File   string `json:"file,omitempty"`
+		Line   int    `json:"line,omitempty"`
+		Column int    `json:"column,omitempty"`
+		Form   string `json:"form,omitempty"`
+	}
+
+	// Diagnostic describes a failure to evaluate a script or source
+	// text. It implements error, and is the error returned by
+	// EvalScript, EvalString and EvalRegion when a form fails to read
+	// or evaluate, or when the resulting graph is invalid.
+	//
+	// File, Line and Column locate the offending form in the
+	// evaluated source when its location is known; Line and Column
+	// are 1-based and zero if unknown.
+	Diagnostic struct {
+		Kind    DiagnosticKind `json:"kind"`
+		Message string         `json:"message"`
+		File    string         `json:"file,omitempty"`
+		Line    int            `json:"line,omitempty"`
+		Column  int            `json:"column,omitempty"`
+		Form    string         `json:"form,omitempty"`
+
+		// Stack is the glojure stack at the point of failure, most
+		// recent call first.
+		Stack []StackFrame `json:"stack,omitempty"`
+
+		// err is the underlying error, if any.
+		err error
+	}
+)
+
+const (
+	// DiagnosticRead indicates that the source could not be read.
+	DiagnosticRead DiagnosticKind = "read"
+	// DiagnosticCompile indicates that a form could not be analyzed
+	// or macroexpanded, e.g. because of an unresolved symbol.
+	DiagnosticCompile DiagnosticKind = "compile"
+	// DiagnosticRuntime indicates that evaluating a form failed.
+	DiagnosticRuntime DiagnosticKind = "runtime"
+	// DiagnosticGraph indicates that the graph built by the script
+	// is invalid.
+	DiagnosticGraph DiagnosticKind = "graph"
+	// DiagnosticCanceled indicates that evaluation was canceled or
+	// timed out.
+	DiagnosticCanceled DiagnosticKind = "canceled"
+)
+
+const gljStackHeader = "\n\nGLJ Stack:\n"
+
+var (
+	// glojure's load wraps failures in messages of this form.
+	loadErrorRegexp = regexp.MustCompile(`^error (reading|evaluating) [^:]+: `)
+	// reader errors and stack frames are prefixed with a position.
+	positionRegexp = regexp.MustCompile(`^(.+?):(\d+):(\d+):\s?`)
+	// macroexpansion errors end with the namespace and line number.
+	macroLineRegexp = regexp.MustCompile(` in [^\s:]+:(\d+)$`)
+)
+
+// Error implements the error interface.
+func (d *Diagnostic) Error() string {
+	var sb strings.Builder
+	if d.File != "" {
+		sb.WriteString(d.File)
+		if d.Line > 0 {
+			fmt.Fprintf(&sb, ":%d", d.Line)
+			if d.Column > 0 {
+				fmt.Fprintf(&sb, ":%d", d.Column)
+			}
+		}
+		sb.WriteString(": ")
+	}
+	if d.Kind == DiagnosticCanceled {
+		sb.WriteString(d.Message)
+	} else {
+		fmt.Fprintf(&sb, "%s error: %s", d.Kind, d.Message)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the underlying error, if any.
+func (d *Diagnostic) Unwrap() error {
+	return d.err
+}
+
+// newDiagnostic builds a Diagnostic from a value recovered while
+// evaluating the source in file. Frames in the glojure stack that
+// refer to file by its base name are resolved to file, and the
+// location of the diagnostic is that of the most recent frame in file.
+func newDiagnostic(r any, file string) *Diagnostic {
+	var d *Diagnostic
+	if err, ok := r.(error); ok && errors.As(err, &d) {
+		return d
+	}
+
+	msg := strings.TrimSpace(fmt.Sprint(r))
+	d = &Diagnostic{Kind: DiagnosticRuntime}
+
+	// failures in required namespaces are wrapped once per load.
+	for m := loadErrorRegexp.FindStringSubmatch(msg); m != nil; m = loadErrorRegexp.FindStringSubmatch(msg) {
+		msg = msg[len(m[0]):]
+		if m[1] == "reading" {
+			d.Kind = DiagnosticRead
+		}
+	}
+
+	if d.Kind == DiagnosticRead || isReaderError(r) {
+		d.Kind = DiagnosticRead
+		if frame, rest, ok := parsePosition(msg); ok {
+			d.File, _ = resolveFile(frame.File, file)
+			d.Line, d.Column = frame.Line, frame.Column
+			msg = rest
+		}
+		d.Message = msg
+		return d
+	}
+
+	stack := ""
+	if i := strings.Index(msg, gljStackHeader); i >= 0 {
+		msg, stack = msg[:i], msg[i+len(gljStackHeader):]
+	}
+	d.Message = strings.TrimSpace(msg)
+
+	for _, line := range strings.Split(stack, "\n") {
+		if line == "" {
+			continue
+		}
+		frame, form, ok := parsePosition(line)
+		if !ok {
+			// frames for forms without position metadata are printed
+			// with nil positions.
+			_, form, _ = strings.Cut(line, "\t")
+		}
+		frame.Form = strings.TrimSpace(form)
+		if frame.File == "" && frame.Form == "" {
+			continue
+		}
+		d.Stack = append(d.Stack, frame)
+	}
+
+	// errors that weren't raised while invoking a function were
+	// raised by the analyzer or by a macro.
+	if len(d.Stack) == 0 || strings.HasPrefix(d.Message, "unable to resolve") {
+		d.Kind = DiagnosticCompile
+	}
+
+	// locate the diagnostic at the most recent frame in the script
+	// or one of its dependencies.
+	located := false
+	for i, frame := range d.Stack {
+		path, ok := resolveFile(frame.File, file)
+		d.Stack[i].File = path
+		if ok && frame.Line > 0 && !located {
+			d.File, d.Line, d.Column, d.Form = path, frame.Line, frame.Column, frame.Form
+			located = true
+		}
+	}
+	if !located {
+		d.File = file
+		if m := macroLineRegexp.FindStringSubmatch(d.Message); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+		}
+	}
+	return d
+}
+
+// newGraphDiagnostic builds a Diagnostic for a graph validation
+// failure in the graph built by the script in file.
+func newGraphDiagnostic(r any, file string) *Diagnostic {
+	return &Diagnostic{
+		Kind:    DiagnosticGraph,
+		Message: strings.TrimSpace(fmt.Sprint(r)),
+		File:    file,
+	}
+}
+
+// locateForm attributes d to form, the top-level form being
+// evaluated, if d has no location of its own.
+func (d *Diagnostic) locateForm(form any) {
+	if d.Kind == DiagnosticRead {
+		return
+	}
+	if d.Form == "" {
+		d.Form = lang.PrintString(form)
+	}
+	meta, ok := form.(lang.IMeta)
+	if !ok || meta.Meta() == nil {
+		return
+	}
+	line, _ := lang.Get(meta.Meta(), lang.KWLine).(int)
+	col, _ := lang.Get(meta.Meta(), lang.KWColumn).(int)
+	if d.Line == 0 {
+		d.Line = line
+	}
+	if d.Line == line && d.Column == 0 {
+		d.Column = col
+	}
+}
+
+func isReaderError(r any) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+	var readErr *reader.Error
+	return errors.As(err, &readErr)
+}
+
+// parsePosition parses a "file:line:col: " prefix from s, returning
+// the position as a frame and the remainder of s.
+func parsePosition(s string) (StackFrame, string, bool) {
+	m := positionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return StackFrame{}, s, false
+	}
+	line, _ := strconv.Atoi(m[2])
+	col, _ := strconv.Atoi(m[3])
+	return StackFrame{File: m[1], Line: line, Column: col}, s[len(m[0]):], true
+}
+
+// resolveFile maps a file name as recorded by the glojure loader,
+// which is relative to the load path, to the path of the script or of
+// the dependency it names. It reports whether name is one of them.
+func resolveFile(name, script string) (string, bool) {
+	if name == filepath.Base(script) {
+		return script, true
+	}
+	if path, ok := scriptLibFile(name); ok {
+		return path, true
+	}
+	return name, false
+}
@@ -0,0 +1,93 @@
+package mrat
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvalScriptDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		kind   DiagnosticKind
+		line   int
+		column int
+		form   string
+	}{
+		{
+			name:   "read",
+			src:    "(play (sin 440)",
+			kind:   DiagnosticRead,
+			line:   2,
+			column: 15,
+		},
+		{
+			name:   "unresolved",
+			src:    "(play (undefined-ugen 440))",
+			kind:   DiagnosticCompile,
+			line:   2,
+			column: 8,
+			form:   "undefined-ugen",
+		},
+		{
+			name: "macro",
+			src:  "\n(let [x])",
+			kind: DiagnosticCompile,
+			line: 3,
+		},
+		{
+			name:   "runtime",
+			src:    "(defn f [x] (nth [] x))\n(play (sin (f 3)))",
+			kind:   DiagnosticRuntime,
+			line:   2,
+			column: 13,
+			form:   "(nth [] x)",
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ns := "diagnostic-test-" + tc.name
+			path := filepath.Join(dir, "diagnostic_test_"+tc.name+".glj")
+			src := "(ns " + ns + " (:use [mrat.core]))\n" + tc.src
+			if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, _, err := EvalScript(path)
+			var diag *Diagnostic
+			if !errors.As(err, &diag) {
+				t.Fatalf("expected a diagnostic, got %v", err)
+			}
+			if diag.Kind != tc.kind {
+				t.Errorf("got kind %q, want %q (%v)", diag.Kind, tc.kind, diag)
+			}
+			if diag.File != path {
+				t.Errorf("got file %q, want %q", diag.File, path)
+			}
+			if diag.Line != tc.line || diag.Column != tc.column {
+				t.Errorf("got position %d:%d, want %d:%d", diag.Line, diag.Column, tc.line, tc.column)
+			}
+			if diag.Form != tc.form {
+				t.Errorf("got form %q, want %q", diag.Form, tc.form)
+			}
+		})
+	}
+}
+
+func TestEvalStringDiagnostic(t *testing.T) {
+	res, err := EvalString("(def a 1)\n  (inc (undefined-fn))", "diagnostic-test.eval")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	diag := res.Diagnostic
+	if diag == nil {
+		t.Fatal("expected the result to include a diagnostic")
+	}
+	if diag.Kind != DiagnosticCompile || diag.Line != 2 || diag.Column != 9 {
+		t.Errorf("got %s at %d:%d, want compile at 2:9", diag.Kind, diag.Line, diag.Column)
+	}
+}
@@ -0,0 +1,253 @@
+package mrat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/glojurelang/glojure/pkg/glj"
+	"github.com/glojurelang/glojure/pkg/lang"
+	"github.com/glojurelang/glojure/pkg/reader"
+
+	"github.com/jfhamlin/muscrat/pkg/graph"
+)
+
+const (
+	// DefaultNamespace is the namespace in which source text is
+	// evaluated when no namespace is given and none is declared.
+	DefaultNamespace = "user"
+
+	// evalSourceName is the file name reported for forms evaluated
+	// from source text rather than from a file.
+	evalSourceName = "<eval>"
+)
+
+var (
+	nsDeclRegexp = regexp.MustCompile(`(?m)^\s*\(\s*ns\s+([^\s()\[\]{}"]+)`)
+)
+
+type (
+	// EvalResult is the result of evaluating source text.
+	EvalResult struct {
+		// Value is the printed representation of the value of the last
+		// form evaluated.
+		Value string `json:"value"`
+
+		// Namespace is the namespace that was current after evaluation.
+		Namespace string `json:"namespace"`
+
+		// Graph is the graph built by the evaluated forms. It is nil if
+		// the forms did not play anything.
+		Graph *graph.Graph `json:"-"`
+
+		// Stdout is everything printed during evaluation.
+		Stdout string `json:"stdout"`
+
+		// Error is the error message if evaluation failed.
+		Error string `json:"error,omitempty"`
+
+		// Diagnostic describes the failure if evaluation failed.
+		Diagnostic *Diagnostic `json:"diagnostic,omitempty"`
+
+		// scenes holds the scenes declared by the evaluated forms.
+		scenes *sceneDecls
+	}
+
+	// Region identifies a span of source text by byte offsets, with
+	// Start inclusive and End exclusive.
+	Region struct {
+		Start int `json:"start"`
+		End   int `json:"end"`
+	}
+)
+
+// EvalString evaluates all forms in src in the namespace ns. If ns is
+// empty, the namespace declared by the first ns form in src is used,
+// or DefaultNamespace if there is none. Unlike EvalScript, the
+// namespace is not reloaded, so only the definitions in src are
+// replaced.
+//
+// The returned result is non-nil even when an error is returned, and
+// includes anything printed before the error occurred.
+func EvalString(src, ns string) (*EvalResult, error) {
+	return EvalStringContext(context.Background(), src, ns)
+}
+
+// EvalStringContext is like EvalString, but returns a canceled
+// Diagnostic as soon as ctx is done.
+func EvalStringContext(ctx context.Context, src, ns string) (*EvalResult, error) {
+	return evalSource(ctx, src, ns)
+}
+
+// EvalRegion evaluates the forms within region of src. src is the
+// full source text of a buffer, which is used to determine the
+// namespace when ns is empty and to report positions relative to the
+// start of the buffer.
+func EvalRegion(src string, region Region, ns string) (*EvalResult, error) {
+	return EvalRegionContext(context.Background(), src, region, ns)
+}
+
+// EvalRegionContext is like EvalRegion, but returns a canceled
+// Diagnostic as soon as ctx is done.
+func EvalRegionContext(ctx context.Context, src string, region Region, ns string) (*EvalResult, error) {
+	if region.Start < 0 || region.End > len(src) || region.Start > region.End {
+		err := fmt.Errorf("invalid region [%d, %d) for source of length %d", region.Start, region.End, len(src))
+		return &EvalResult{Error: err.Error()}, err
+	}
+	if ns == "" {
+		ns = declaredNamespace(src)
+	}
+
+	// pad the region so that positions reported by the reader match
+	// the positions in the full source.
+	prefix := src[:region.Start]
+	line := strings.Count(prefix, "\n")
+	col := len(prefix) - (strings.LastIndex(prefix, "\n") + 1)
+	padded := strings.Repeat("\n", line) + strings.Repeat(" ", col) + src[region.Start:region.End]
+
+	return evalSource(ctx, padded, ns)
+}
+
+// CheckSource evaluates src as the contents of the script at path,
+// without playing anything, and returns the Diagnostic describing the
+// first problem found, if any: a form that fails to read or evaluate,
+// or an invalid graph. Libs are loaded from the script's directory, as
+// for EvalScript, and reloaded so that their latest versions are
+// checked against. path may be empty for unsaved scripts.
+//
+// Like EvalString, CheckSource redefines the vars defined in src.
+func CheckSource(ctx context.Context, path, src string) error {
+	if path != "" {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		addScriptDir(filepath.Dir(absPath))
+	}
+	ns := declaredNamespace(src)
+	if ns == "" {
+		ns = DefaultNamespace
+	}
+
+	_, err := runEval(ctx, evalSourceName, func(ctx context.Context) (*EvalResult, error) {
+		unloadScriptLibs()
+		return evalForms(ctx, src, ns)
+	})
+	return err
+}
+
+// declaredNamespace returns the name of the namespace declared by the
+// first ns form in src, or the empty string if there is none.
+func declaredNamespace(src string) string {
+	match := nsDeclRegexp.FindStringSubmatch(src)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func evalSource(ctx context.Context, src, ns string) (*EvalResult, error) {
+	if ns == "" {
+		ns = declaredNamespace(src)
+	}
+	if ns == "" {
+		ns = DefaultNamespace
+	}
+
+	res, err := runEval(ctx, evalSourceName, func(ctx context.Context) (*EvalResult, error) {
+		return evalForms(ctx, src, ns)
+	})
+	if res == nil {
+		res = &EvalResult{}
+	}
+	if err != nil {
+		res.Error = err.Error()
+		errors.As(err, &res.Diagnostic)
+	}
+	return res, err
+}
+
+// evalForms evaluates the forms in src in the namespace ns.
+func evalForms(ctx context.Context, src, ns string) (res *EvalResult, err error) {
+	var stdout strings.Builder
+	var form any
+	res = &EvalResult{}
+	defer func() {
+		if r := recover(); r != nil {
+			diag := newDiagnostic(r, evalSourceName)
+			if form != nil {
+				diag.locateForm(form)
+			}
+			err = diag
+		}
+		res.Stdout = stdout.String()
+	}()
+
+	require := glj.Var("clojure.core", "require")
+	require.Invoke(glj.Read("mrat.core"))
+	startScript(ctx)
+
+	graphAtom := newGraphAtom(ctx)
+
+	pushScriptBindings(graphAtom, &cancelWriter{ctx: ctx, w: io.MultiWriter(&consoleWriter{}, &stdout)})
+	defer popScriptBindings()
+
+	lang.PushThreadBindings(lang.NewMap(lang.VarCurrentNS, enterNamespace(ns)))
+	defer lang.PopThreadBindings()
+
+	eval := glj.Var("clojure.core", "eval")
+	rdr := reader.New(strings.NewReader(src),
+		reader.WithFilename(evalSourceName),
+		reader.WithGetCurrentNS(currentNamespace))
+
+	var value any
+	for {
+		var readErr error
+		form, readErr = rdr.ReadOne()
+		if readErr == reader.ErrEOF {
+			break
+		}
+		if readErr != nil {
+			return res, newDiagnostic(readErr, evalSourceName)
+		}
+		value = eval.Invoke(form)
+	}
+
+	res.Value = lang.PrintString(value)
+	res.Namespace = currentNamespace().Name().String()
+
+	g, err := scriptGraph(graphAtom, evalSourceName)
+	if err != nil {
+		return res, err
+	}
+	if len(g.Sinks()) > 0 {
+		res.Graph = g
+	}
+	if res.scenes, err = readSceneDecls(""); err != nil {
+		return res, newDiagnostic(err, evalSourceName)
+	}
+	return res, nil
+}
+
+// enterNamespace returns the namespace named name, creating it with
+// mrat.core referred if it doesn't exist yet.
+func enterNamespace(name string) *lang.Namespace {
+	sym := lang.NewSymbol(name)
+	if ns := lang.FindNamespace(sym); ns != nil {
+		return ns
+	}
+
+	lang.PushThreadBindings(lang.NewMap(lang.VarCurrentNS, lang.VarCurrentNS.Deref()))
+	defer lang.PopThreadBindings()
+
+	glj.Var("clojure.core", "eval").Invoke(glj.Read(fmt.Sprintf("(ns %s (:use [mrat.core]))", name)))
+	return lang.FindNamespace(sym)
+}
+
+func currentNamespace() *lang.Namespace {
+	return lang.VarCurrentNS.Deref().(*lang.Namespace)
+}
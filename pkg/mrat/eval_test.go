@@ -0,0 +1,90 @@
+package mrat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalString(t *testing.T) {
+	res, err := EvalString(`(ns eval-test.basic (:use [mrat.core]))
+(defn f [x] (* 2 x))
+(println "hello")
+(f 21)`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Value != "42" {
+		t.Errorf("got value %q, want %q", res.Value, "42")
+	}
+	if res.Namespace != "eval-test.basic" {
+		t.Errorf("got namespace %q, want %q", res.Namespace, "eval-test.basic")
+	}
+	if res.Stdout != "hello\n" {
+		t.Errorf("got stdout %q, want %q", res.Stdout, "hello\n")
+	}
+	if res.Graph != nil {
+		t.Errorf("expected no graph, got %d nodes", len(res.Graph.Nodes))
+	}
+
+	// redefining a function in the namespace leaves other vars alone.
+	res, err = EvalString(`(def y 1) (f y)`, "eval-test.basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Value != "2" {
+		t.Errorf("got value %q, want %q", res.Value, "2")
+	}
+}
+
+func TestEvalStringGraph(t *testing.T) {
+	res, err := EvalString(`(play (sin 440))`, "eval-test.graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Graph == nil {
+		t.Fatal("expected a graph")
+	}
+	if got := len(res.Graph.Sinks()); got != 2 {
+		t.Errorf("got %d sinks, want 2", got)
+	}
+}
+
+func TestEvalRegion(t *testing.T) {
+	src := `(ns eval-test.region (:use [mrat.core]))
+(def a 1)
+(def b (+ a 10))
+`
+	start := strings.Index(src, "(def b")
+	region := Region{Start: start, End: len(src)}
+
+	if _, err := EvalRegion(src, Region{Start: 0, End: start}, ""); err != nil {
+		t.Fatal(err)
+	}
+	res, err := EvalRegion(src, region, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Namespace != "eval-test.region" {
+		t.Errorf("got namespace %q, want %q", res.Namespace, "eval-test.region")
+	}
+	if res.Value != "#'eval-test.region/b" {
+		t.Errorf("got value %q", res.Value)
+	}
+
+	if _, err := EvalRegion(src, Region{Start: 10, End: 5}, ""); err == nil {
+		t.Error("expected an error for an invalid region")
+	}
+}
+
+func TestEvalStringError(t *testing.T) {
+	res, err := EvalString(`(println "before") (undefined-fn)`, "eval-test.error")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if res.Error == "" {
+		t.Error("expected the result to record the error")
+	}
+	if res.Stdout != "before\n" {
+		t.Errorf("got stdout %q, want %q", res.Stdout, "before\n")
+	}
+}
@@ -0,0 +1,74 @@
+package mrat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/graph"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+var (
+	// fuzzUGens are ugens with positional, keyword, multi-channel and
+	// non-expanding arguments. ugens that touch files, devices or the
+	// network are left out.
+	fuzzUGens = []string{
+		"sin", "saw", "pulse", "lpf", "rlpf", "delayn", "allpass", "combc",
+		"pan2", "splay", "smp", "latch", "impulse-pattern", "supersaw",
+		"line", "bitcrush", "wfold", "pitch-shift", "amplitude",
+	}
+
+	fuzzArgs = []string{
+		"440", "0", "-1", "1/3", "1e308", "##NaN", "##Inf", "nil", "true",
+		`"x"`, ":x", ":mul", ":add", ":freq", ":in", ":max-delay-time",
+		"[1 2]", "[]", "{:a 1}",
+	}
+)
+
+// fuzzUGenExpr returns a call of a ugen with random arguments, some
+// of which may be calls of ugens.
+func fuzzUGenExpr(data *[]byte, depth int) string {
+	next := func(n int) int {
+		if len(*data) == 0 {
+			return 0
+		}
+		b := (*data)[0]
+		*data = (*data)[1:]
+		return int(b) % n
+	}
+
+	var sb strings.Builder
+	sb.WriteString("(" + fuzzUGens[next(len(fuzzUGens))])
+	for range next(6) {
+		sb.WriteString(" ")
+		if depth > 0 && next(4) == 0 {
+			sb.WriteString(fuzzUGenExpr(data, depth-1))
+		} else {
+			sb.WriteString(fuzzArgs[next(len(fuzzArgs))])
+		}
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// FuzzUGenArgs checks that no arguments to a ugen, however wrong,
+// make evaluating or playing it panic: they're either rejected when
+// the script is evaluated, or when the graph is constructed.
+func FuzzUGenArgs(f *testing.F) {
+	f.Add([]byte{0, 1, 0})
+	f.Add([]byte{3, 2, 0, 1})
+	f.Add([]byte{5, 3, 0, 14, 0, 15})
+	f.Add([]byte{10, 1, 11})
+	f.Add([]byte{0, 2, 12})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		src := "(play " + fuzzUGenExpr(&data, 2) + ")"
+		res, err := EvalString(src, "fuzz-test.ugen-args")
+		if err != nil || res.Graph == nil {
+			return
+		}
+		cfg := ugen.SampleConfig{SampleRateHz: conf.SampleRate}
+		graph.Render(context.Background(), res.Graph, cfg, conf.BufferSize)
+	})
+}
@@ -0,0 +1,108 @@
+package mrat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jfhamlin/muscrat/pkg/console"
+	"github.com/jfhamlin/muscrat/pkg/graph"
+)
+
+// maxGraphHistory is the number of played graphs kept for Revert.
+const maxGraphHistory = 64
+
+type (
+	// GraphHistoryEntry is a graph played by the server.
+	GraphHistoryEntry struct {
+		// Time is when the graph was first played.
+		Time time.Time `json:"time"`
+
+		// SourceHash is a hex-encoded SHA-256 hash of the source that
+		// built the graph.
+		SourceHash string `json:"sourceHash"`
+
+		// Description describes where the graph came from.
+		Description string `json:"description"`
+
+		// Playing is true if the graph is the one currently playing.
+		Playing bool `json:"playing"`
+
+		Graph *graph.Graph `json:"-"`
+	}
+
+	// graphHistory is a bounded history of played graphs, oldest
+	// first, with the position of the entry playing.
+	graphHistory struct {
+		entries []*GraphHistoryEntry
+		pos     int
+	}
+)
+
+// add records a newly played graph.
+func (h *graphHistory) add(g *graph.Graph, hash [32]byte, desc string) {
+	h.entries = append(h.entries, &GraphHistoryEntry{
+		Time:        time.Now(),
+		SourceHash:  hex.EncodeToString(hash[:]),
+		Description: desc,
+		Graph:       g,
+	})
+	if len(h.entries) > maxGraphHistory {
+		h.entries = h.entries[len(h.entries)-maxGraphHistory:]
+	}
+	h.pos = len(h.entries) - 1
+}
+
+// History returns the graphs played by the server, most recent
+// first. Graphs played by Revert aren't added to the history; instead
+// the entry that was reverted to is marked as playing.
+func (s *Server) History() []GraphHistoryEntry {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := make([]GraphHistoryEntry, 0, len(s.history.entries))
+	for i := len(s.history.entries) - 1; i >= 0; i-- {
+		entry := *s.history.entries[i]
+		entry.Playing = i == s.history.pos
+		res = append(res, entry)
+	}
+	return res
+}
+
+// Revert plays the graph n entries before the one playing in the
+// history, and returns its entry. Revert(1) restores the previous
+// graph, and negative values of n step forward again after a
+// revert. Revert(0) replays the current entry, e.g. after silencing
+// the output.
+//
+// As with any new graph, the reverted graph is aligned with the one
+// playing, so the state of unchanged nodes carries over.
+func (s *Server) Revert(n int) (*GraphHistoryEntry, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	target := s.history.pos - n
+	if target < 0 || target >= len(s.history.entries) {
+		return nil, fmt.Errorf("cannot revert %d graphs: %d in history, playing %d",
+			n, len(s.history.entries), len(s.history.entries)-1-s.history.pos)
+	}
+
+	entry := s.history.entries[target]
+	if err := s.PlayGraph(entry.Graph); err != nil {
+		return nil, err
+	}
+	s.history.pos = target
+
+	console.Log(console.Info, fmt.Sprintf("reverted to %s from %s",
+		entry.Description, entry.Time.Format(time.TimeOnly)), nil)
+
+	res := *entry
+	res.Playing = true
+	return &res, nil
+}
+
+// sourceHash returns the hash of src recorded in the graph history.
+func sourceHash(src string) [32]byte {
+	return sha256.Sum256([]byte(src))
+}
@@ -0,0 +1,74 @@
+package mrat
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRevert(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, src := range []string{`(play (sin 220))`, `(play (saw 220))`, `(play (sqr 220))`} {
+		if _, err := srv.EvalString(ctx, src, "history-test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hist := srv.History()
+	if len(hist) != 3 {
+		t.Fatalf("got %d history entries, want 3", len(hist))
+	}
+	if !hist[0].Playing || hist[0].SourceHash == hist[1].SourceHash {
+		t.Errorf("unexpected history %+v", hist)
+	}
+
+	entry, err := srv.Revert(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Graph != hist[2].Graph {
+		t.Error("expected to revert to the first graph")
+	}
+	if hist := srv.History(); !hist[2].Playing || len(hist) != 3 {
+		t.Errorf("expected the first graph to be playing, got %+v", hist)
+	}
+
+	// step forward again.
+	entry, err = srv.Revert(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Graph != hist[1].Graph {
+		t.Error("expected to step forward to the second graph")
+	}
+
+	if _, err := srv.Revert(2); err == nil {
+		t.Error("expected an error reverting past the start of the history")
+	}
+}
+
+func TestServerEvalRegionInvalid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `(play (sin 220))`
+	for _, region := range []Region{{Start: 10, End: 5}, {Start: 0, End: len(src) + 1}, {Start: -1, End: 3}} {
+		if _, err := srv.EvalRegion(ctx, src, region, "history-test"); err == nil {
+			t.Errorf("region %+v: expected an error", region)
+		}
+	}
+	if hist := srv.History(); len(hist) != 0 {
+		t.Errorf("got %d history entries, want 0", len(hist))
+	}
+}
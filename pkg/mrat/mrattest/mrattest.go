@@ -0,0 +1,100 @@
+// Package mrattest runs muscrat tests under go test. Tests are written
+// in .glj files with clojure.test and the audio assertions of the
+// mrat.test namespace:
+//
+//	func TestInstruments(t *testing.T) {
+//		mrattest.Run(t, "testdata/*_test.glj")
+//	}
+//
+// Each test file must declare a namespace, and libs are loaded from
+// its directory, so tests can sit alongside the instruments they
+// test.
+package mrattest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/audiotest/golden"
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+)
+
+// Run runs the tests in the files matching the glob patterns. Each
+// file is run as a subtest named after the file, and each of its
+// tests as a subtest of that, so they can be selected with -run.
+func Run(t *testing.T, patterns ...string) {
+	t.Helper()
+
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no test files match %s", strings.Join(patterns, ", "))
+	}
+
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".glj"), func(t *testing.T) {
+			tests, err := mrat.LoadTests(t.Context(), file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, test := range tests {
+				t.Run(test.Name, func(t *testing.T) {
+					res, err := test.Run(t.Context())
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, f := range res.Failures {
+						t.Errorf("%s:%d: %s", filepath.Base(test.File), test.Line, f)
+					}
+					if len(res.Failures) > 0 && res.Output != "" {
+						t.Logf("output:\n%s", res.Output)
+					}
+				})
+			}
+		})
+	}
+}
+
+// RenderScript renders seconds of the script at path, failing the test
+// if it can't be evaluated.
+func RenderScript(t testing.TB, path string, seconds float64) *audiotest.Audio {
+	t.Helper()
+	a, err := mrat.RenderScript(t.Context(), path, seconds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// RenderExpr renders seconds of a ugen expression, failing the test if
+// it can't be evaluated.
+func RenderExpr(t testing.TB, expr string, seconds float64) *audiotest.Audio {
+	t.Helper()
+	a, err := mrat.RenderExpr(t.Context(), expr, seconds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// GoldenScript renders seconds of the script at path and checks the
+// audio against the reference file for name, as golden.Check does.
+func GoldenScript(t testing.TB, name, path string, seconds float64, opts ...golden.Option) {
+	t.Helper()
+	golden.Check(t, name, RenderScript(t, path, seconds), opts...)
+}
+
+// GoldenExpr renders seconds of a ugen expression and checks the audio
+// against the reference file for name, as golden.Check does.
+func GoldenExpr(t testing.TB, name, expr string, seconds float64, opts ...golden.Option) {
+	t.Helper()
+	golden.Check(t, name, RenderExpr(t, expr, seconds), opts...)
+}
@@ -0,0 +1,28 @@
+package mrattest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	Run(t, "testdata/*_test.glj")
+}
+
+func TestRenderExpr(t *testing.T) {
+	a := RenderExpr(t, "[(sin 220) (* -1 (sin 220))]", 0.5)
+	if got := a.Duration(); got != 0.5 {
+		t.Errorf("got duration %v, want 0.5", got)
+	}
+	if got := a.Channel(0).DominantFrequency(); math.Abs(got-220) > 1 {
+		t.Errorf("got dominant frequency %v, want 220", got)
+	}
+	if got := a.Correlation(); got > -0.99 {
+		t.Errorf("got correlation %v, want -1", got)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	GoldenScript(t, "tone", "testdata/tone.glj", 0.05)
+	GoldenExpr(t, "stereo", "[(saw 220) (tri 330)]", 0.02)
+}
@@ -0,0 +1,249 @@
+package mrat
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jfhamlin/muscrat/pkg/aio"
+	"github.com/jfhamlin/muscrat/pkg/console"
+	"github.com/jfhamlin/muscrat/pkg/oscproto"
+	"github.com/jfhamlin/muscrat/pkg/pubsub"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// OSCOption configures the OSC control server.
+	OSCOption func(*oscOptions)
+
+	oscOptions struct {
+		eval bool
+	}
+)
+
+// WithOSCEval enables the /eval and /eval/file addresses, which let
+// any client that can reach the server run arbitrary code. OSC has no
+// authentication, so only enable them on trusted networks.
+func WithOSCEval() OSCOption {
+	return func(o *oscOptions) {
+		o.eval = true
+	}
+}
+
+// ListenOSC serves OSC control messages on the UDP address addr until
+// ctx is done. See ServeOSC.
+func (s *Server) ListenOSC(ctx context.Context, addr string, opts ...OSCOption) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeOSC(ctx, conn, opts...)
+}
+
+// ServeOSC serves OSC control messages received on conn until ctx is
+// done. The following addresses are handled:
+//
+//   - /knob/<name> f: set the knobs named name to f.
+//   - /knob/<name>/norm f: set the knobs named name to f in [0, 1],
+//     scaled to each knob's range.
+//   - /eval s [ns]: evaluate the source s, in the namespace ns if
+//     given (see EvalString). Requires WithOSCEval.
+//   - /eval/file path: evaluate the script at path. Requires
+//     WithOSCEval.
+//   - /scene/<name>/register path [key]: register a scene playing the
+//     script at path, triggered by key if given. Requires WithOSCEval,
+//     since triggering the scene evaluates the script.
+//   - /transport/silence: play silence.
+//   - /transport/revert [n]: revert n graphs, 1 by default.
+//   - /transport/gain f: set the output gain.
+//   - /transport/cancel: cancel evaluations in progress.
+//   - /transport/tempo bpm [beats]: set the tempo of quantized scene
+//     transitions, for graphs without a constant tempo; see
+//     Server.SetTempo.
+//   - /scene/<name>: trigger a scene.
+//   - /cue/list names...: set the cue list to the named scenes.
+//   - /cue/next, /cue/prev, /cue/goto i: step through the cue list.
+//
+// In addition, the numeric arguments of every message are available
+// to the osc-in ugen reading the message's address. Bundles are
+// dispatched at their time tags, so that changes can be scheduled.
+func (s *Server) ServeOSC(ctx context.Context, conn net.PacketConn, opts ...OSCOption) error {
+	var o oscOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	srv := oscproto.NewServer()
+	srv.ErrorLog = func(err error) {
+		console.Log(console.Warn, fmt.Sprintf("invalid OSC packet: %v", err), nil)
+	}
+
+	srv.Handle("", func(msg *oscproto.Message) {
+		aio.SetOSCControl(msg.Address, msg.Floats())
+	})
+
+	srv.Handle("/knob/*", func(msg *oscproto.Message) {
+		s.setKnobs(msg, strings.TrimPrefix(msg.Address, "/knob/"), false)
+	})
+	srv.Handle("/knob/*/norm", func(msg *oscproto.Message) {
+		name := strings.TrimSuffix(strings.TrimPrefix(msg.Address, "/knob/"), "/norm")
+		s.setKnobs(msg, name, true)
+	})
+
+	// evaluations and scene changes can take a while; don't hold up
+	// the messages that follow. errors are logged to the console.
+	if o.eval {
+		srv.Handle("/eval", func(msg *oscproto.Message) {
+			src, ok := stringArg(msg, 0)
+			if !ok {
+				return
+			}
+			var ns string
+			if len(msg.Args) > 1 {
+				if ns, ok = stringArg(msg, 1); !ok {
+					return
+				}
+			}
+			go s.EvalString(ctx, src, ns)
+		})
+		srv.Handle("/eval/file", func(msg *oscproto.Message) {
+			if path, ok := stringArg(msg, 0); ok {
+				go s.EvalScript(ctx, path, true)
+			}
+		})
+		srv.Handle("/scene/*/register", func(msg *oscproto.Message) {
+			scene := Scene{Name: strings.TrimSuffix(strings.TrimPrefix(msg.Address, "/scene/"), "/register")}
+			var ok bool
+			if scene.Script, ok = stringArg(msg, 0); !ok {
+				return
+			}
+			if len(msg.Args) > 1 {
+				if scene.Key, ok = stringArg(msg, 1); !ok {
+					return
+				}
+			}
+			if err := s.RegisterScene(scene); err != nil {
+				console.Log(console.Warn, err.Error(), nil)
+			}
+		})
+	}
+
+	srv.Handle("/transport/silence", func(msg *oscproto.Message) {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		s.PlayGraph(ZeroGraph())
+	})
+	srv.Handle("/transport/revert", func(msg *oscproto.Message) {
+		n := 1
+		if args := msg.Floats(); len(args) > 0 {
+			n = int(args[0])
+		}
+		if _, err := s.Revert(n); err != nil {
+			console.Log(console.Warn, err.Error(), nil)
+		}
+	})
+	srv.Handle("/transport/gain", func(msg *oscproto.Message) {
+		if gain, ok := floatArg(msg, 0); ok {
+			s.SetGain(gain)
+		}
+	})
+	srv.Handle("/transport/cancel", func(msg *oscproto.Message) {
+		s.CancelEval()
+	})
+	srv.Handle("/transport/tempo", func(msg *oscproto.Message) {
+		bpm, ok := floatArg(msg, 0)
+		if !ok {
+			return
+		}
+		s.mtx.RLock()
+		beats := s.beatsPerBar
+		s.mtx.RUnlock()
+		if args := msg.Floats(); len(args) > 1 {
+			beats = int(args[1])
+		}
+		if err := s.SetTempo(bpm, beats); err != nil {
+			console.Log(console.Warn, err.Error(), nil)
+		}
+	})
+
+	srv.Handle("/scene/*", func(msg *oscproto.Message) {
+		name := strings.TrimPrefix(msg.Address, "/scene/")
+		go func() {
+			if err := s.TriggerScene(ctx, name, Transition{}); err != nil {
+				console.Log(console.Warn, err.Error(), nil)
+			}
+		}()
+	})
+	srv.Handle("/cue/list", func(msg *oscproto.Message) {
+		names := make([]string, len(msg.Args))
+		for i := range msg.Args {
+			var ok bool
+			if names[i], ok = stringArg(msg, i); !ok {
+				return
+			}
+		}
+		if err := s.SetCueList(names); err != nil {
+			console.Log(console.Warn, err.Error(), nil)
+		}
+	})
+	srv.Handle("/cue/next", func(msg *oscproto.Message) {
+		go s.NextCue(ctx)
+	})
+	srv.Handle("/cue/prev", func(msg *oscproto.Message) {
+		go s.PrevCue(ctx)
+	})
+	srv.Handle("/cue/goto", func(msg *oscproto.Message) {
+		if i, ok := floatArg(msg, 0); ok {
+			go s.GoToCue(ctx, int(i))
+		}
+	})
+
+	return srv.Serve(ctx, conn)
+}
+
+// setKnobs sets the knobs with the given name to the first argument of
+// msg. If norm is true, the argument is scaled from [0, 1] to each
+// knob's range.
+func (s *Server) setKnobs(msg *oscproto.Message, name string, norm bool) {
+	value, ok := floatArg(msg, 0)
+	if !ok {
+		return
+	}
+	for _, k := range ugen.GetKnobs() {
+		if k.Name != name {
+			continue
+		}
+		v := value
+		if norm {
+			v = k.Min + v*(k.Max-k.Min)
+		}
+		update := ugen.KnobUpdate{ID: k.ID, Value: v}
+		pubsub.Publish(ugen.KnobValueChangeEvent, update)
+		pubsub.Publish(ugen.KnobValueSetEvent, update)
+	}
+}
+
+// floatArg returns the i'th numeric argument of msg, logging a
+// warning if there is none.
+func floatArg(msg *oscproto.Message, i int) (float64, bool) {
+	args := msg.Floats()
+	if i >= len(args) {
+		console.Log(console.Warn, fmt.Sprintf("OSC message %s: expected a number at argument %d", msg, i), nil)
+		return 0, false
+	}
+	return args[i], true
+}
+
+// stringArg returns the i'th argument of msg if it's a string,
+// logging a warning if it isn't.
+func stringArg(msg *oscproto.Message, i int) (string, bool) {
+	if i < len(msg.Args) {
+		if s, ok := msg.Args[i].(string); ok {
+			return s, true
+		}
+	}
+	console.Log(console.Warn, fmt.Sprintf("OSC message %s: expected a string at argument %d", msg, i), nil)
+	return "", false
+}
@@ -0,0 +1,291 @@
+package mrat
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfhamlin/muscrat/pkg/aio"
+	"github.com/jfhamlin/muscrat/pkg/oscproto"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+func TestServeOSC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.ServeOSC(ctx, conn, WithOSCEval())
+
+	client, err := oscproto.Dial(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	send := func(p oscproto.Packet) {
+		t.Helper()
+		if err := client.Send(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	value := func(u ugen.UGen) float64 {
+		out := make([]float64, 1)
+		u.Gen(ctx, ugen.SampleConfig{}, out)
+		return out[0]
+	}
+
+	// evaluation.
+	send(oscproto.NewMessage("/eval", `(play (osc-in "/fader/1"))`, "osc-test"))
+	waitFor("evaluation", func() bool { return len(srv.History()) == 1 })
+	var found bool
+	for _, n := range srv.History()[0].Graph.Nodes {
+		found = found || n.Type == "osc-in"
+	}
+	if !found {
+		t.Error("expected an osc-in node in the evaluated graph")
+	}
+
+	// control signals.
+	fader := aio.NewOSCIn("/fader/1", 0, 0.5)
+	if v := value(fader); v != 0.5 {
+		t.Errorf("got %v before any message, want the default 0.5", v)
+	}
+	send(oscproto.NewMessage("/fader/1", float32(0.25)))
+	waitFor("fader value", func() bool { return value(fader) == 0.25 })
+
+	// knobs.
+	knob := ugen.NewKnob("osc-test-knob", 0, 0, 10, 0.1, "")
+	knob.Start(ctx)
+	defer knob.Stop(ctx)
+	send(oscproto.NewMessage("/knob/osc-test-knob", float32(2)))
+	waitFor("knob value", func() bool { return value(knob) == 2 })
+	send(oscproto.NewMessage("/knob/osc-test-knob/norm", float32(0.5)))
+	waitFor("normalized knob value", func() bool { return value(knob) == 5 })
+
+	// bundles are dispatched at their time tags.
+	xy := aio.NewOSCIn("/xy/1", 1, 0)
+	at := time.Now().Add(200 * time.Millisecond)
+	send(&oscproto.Bundle{
+		Time:     oscproto.NewTimeTag(at),
+		Elements: []oscproto.Packet{oscproto.NewMessage("/xy/1", float32(0.1), float32(0.9))},
+	})
+	waitFor("scheduled value", func() bool { return value(xy) == float64(float32(0.9)) })
+	if now := time.Now(); now.Before(at.Add(-time.Millisecond)) {
+		t.Errorf("bundle dispatched %s early", at.Sub(now))
+	}
+
+	// transport.
+	send(oscproto.NewMessage("/eval", `(play (saw 220))`, "osc-test"))
+	waitFor("second evaluation", func() bool { return len(srv.History()) == 2 })
+	send(oscproto.NewMessage("/transport/revert"))
+	waitFor("revert", func() bool { return srv.History()[1].Playing })
+
+	// scenes.
+	// registering doesn't evaluate the script.
+	send(oscproto.NewMessage("/scene/osc-test/register", filepath.Join(t.TempDir(), "osc_scene.glj"), "o"))
+	send(oscproto.NewMessage("/cue/list", "osc-test"))
+	waitFor("cue list", func() bool { return slices.Equal(srv.Cues().Scenes, []string{"osc-test"}) })
+	if scenes := srv.Scenes(); len(scenes) != 1 || scenes[0].Key != "o" || scenes[0].Name != "osc-test" {
+		t.Errorf("got scenes %+v, want osc-test with key o", scenes)
+	}
+}
+
+func TestServeOSCEvalDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.ServeOSC(ctx, conn)
+
+	client, err := oscproto.Dial(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// messages are handled in order, so once the knob is set the
+	// evaluations would have started.
+	knob := ugen.NewKnob("osc-noeval-knob", 0, 0, 10, 0.1, "")
+	knob.Start(ctx)
+	defer knob.Stop(ctx)
+	for _, msg := range []*oscproto.Message{
+		oscproto.NewMessage("/eval", `(play (saw 220))`, "osc-test"),
+		oscproto.NewMessage("/eval/file", "testdata/nonexistent.glj"),
+		oscproto.NewMessage("/scene/osc-noeval/register", "testdata/nonexistent.glj"),
+		oscproto.NewMessage("/knob/osc-noeval-knob", float32(3)),
+	} {
+		if err := client.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := make([]float64, 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for knob.Gen(ctx, ugen.SampleConfig{}, out); out[0] != 3; {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for knob value")
+		}
+		time.Sleep(time.Millisecond)
+		knob.Gen(ctx, ugen.SampleConfig{}, out)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if h := srv.History(); len(h) != 0 {
+		t.Errorf("got %d evaluated graphs with eval disabled, want 0", len(h))
+	}
+	if scenes := srv.Scenes(); len(scenes) != 0 {
+		t.Errorf("got %d registered scenes with eval disabled, want 0", len(scenes))
+	}
+}
+
+func TestOSCOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	src := fmt.Sprintf(`(osc-out [0.5 (sin 1)] :address "/lfo" :to %q :rate 100)`, conn.LocalAddr())
+	if _, err := srv.EvalString(ctx, src, "osc-out-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := oscproto.Parse(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, ok := p.(*oscproto.Message)
+	if !ok || msg.Address != "/lfo" || len(msg.Args) != 2 || msg.Args[0] != float32(0.5) {
+		t.Errorf("unexpected packet %v", p)
+	}
+}
+
+func TestOSCOutAllocs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	o := aio.NewOSCOut(conn.LocalAddr().String(), "/lfo", 1000)
+	if err := o.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer o.Stop(ctx)
+
+	cfg := ugen.SampleConfig{
+		SampleRateHz: 44100,
+		InputSamples: map[string][]float64{"$0": make([]float64, 128), "$1": make([]float64, 128)},
+	}
+	out := make([]float64, 128)
+	o.Gen(ctx, cfg, out)
+	if allocs := testing.AllocsPerRun(100, func() { o.Gen(ctx, cfg, out) }); allocs > 0 {
+		t.Errorf("got %v allocations per block, want 0", allocs)
+	}
+}
+
+func TestOSCOutInvalidAddress(t *testing.T) {
+	o := aio.NewOSCOut("127.0.0.1:9", "lfo", 10)
+	if err := o.Start(context.Background()); err == nil {
+		o.Stop(context.Background())
+		t.Error("expected an error starting with an address without a leading slash")
+	}
+}
+
+func TestOSCOutNoValues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	o := aio.NewOSCOut(conn.LocalAddr().String(), "/bang", 0)
+	if err := o.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer o.Stop(ctx)
+
+	// a trigger in each block, with no values to send.
+	trig := make([]float64, 128)
+	trig[64] = 1
+	cfg := ugen.SampleConfig{
+		SampleRateHz: 44100,
+		InputSamples: map[string][]float64{"trig": trig},
+	}
+	out := make([]float64, 128)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 3 {
+			o.Gen(ctx, cfg, out)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Gen blocked")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := oscproto.Parse(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, ok := p.(*oscproto.Message); !ok || msg.Address != "/bang" || len(msg.Args) != 0 {
+		t.Errorf("unexpected packet %v", p)
+	}
+}
@@ -0,0 +1,794 @@
+package mrat
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/glojurelang/glojure/pkg/glj"
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/aio"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/console"
+	"github.com/jfhamlin/muscrat/pkg/graph"
+)
+
+// TransitionKind is how a scene replaces the playing graph.
+type TransitionKind string
+
+const (
+	// TransitionImmediate plays the scene as soon as it's ready.
+	TransitionImmediate TransitionKind = "immediate"
+	// TransitionCrossfade fades from the playing graph into the scene.
+	TransitionCrossfade TransitionKind = "crossfade"
+	// TransitionQuantized plays the scene at the start of the next
+	// bar.
+	TransitionQuantized TransitionKind = "quantized"
+)
+
+// MIDITriggerType is the kind of MIDI message that triggers a scene.
+type MIDITriggerType string
+
+const (
+	// MIDINote triggers on a note on message.
+	MIDINote MIDITriggerType = "note"
+	// MIDIControl triggers when a controller's value rises to 64 or
+	// more, as when a button mapped to the controller is pressed.
+	MIDIControl MIDITriggerType = "control"
+)
+
+var (
+	scenesKW     = lang.NewKeyword("scenes")
+	cuesKW       = lang.NewKeyword("cues")
+	cueTriggerKW = lang.NewKeyword("cue-trigger")
+	nameKW       = lang.NewKeyword("name")
+	scriptKW     = lang.NewKeyword("script")
+	transitionKW = lang.NewKeyword("transition")
+	fadeKW       = lang.NewKeyword("fade")
+	midiKW       = lang.NewKeyword("midi")
+	deviceKW     = lang.NewKeyword("device")
+	channelKW    = lang.NewKeyword("channel")
+	numberKW     = lang.NewKeyword("number")
+)
+
+const (
+	// defaultBPM and defaultBeatsPerBar match the default tempo of
+	// scripts.
+	defaultBPM         = 135
+	defaultBeatsPerBar = 4
+)
+
+type (
+	// Transition describes how to move to a scene.
+	Transition struct {
+		Kind TransitionKind `json:"kind"`
+
+		// Duration is the length of a crossfade. A quantized
+		// transition with a non-zero Duration crossfades from the
+		// start of the bar.
+		Duration time.Duration `json:"duration"`
+	}
+
+	// MIDITrigger maps a MIDI note or controller to a scene or to the
+	// next cue.
+	MIDITrigger struct {
+		// Device is a regular expression matching the name of the
+		// MIDI input port to listen to. If empty, messages from any
+		// port already in use, e.g. by a script, match.
+		Device string `json:"device,omitempty"`
+
+		// Channel is the 0-based MIDI channel. Negative values match
+		// any channel.
+		Channel int `json:"channel"`
+
+		Type MIDITriggerType `json:"type"`
+
+		// Number is the note or controller number.
+		Number int `json:"number"`
+	}
+
+	// Scene is a named graph, or a script producing one, that can be
+	// played on demand.
+	Scene struct {
+		Name string `json:"name"`
+
+		// Script is the path of a script evaluated each time the
+		// scene is triggered, so that the scene reflects the latest
+		// edits to it.
+		Script string `json:"script,omitempty"`
+
+		// Graph is played if Script is empty.
+		Graph *graph.Graph `json:"-"`
+
+		// Transition is used when the scene is triggered without
+		// one. The zero value plays the scene immediately.
+		Transition Transition `json:"transition"`
+
+		// Key is a keystroke that triggers the scene.
+		Key string `json:"key,omitempty"`
+
+		// MIDI, if set, triggers the scene.
+		MIDI *MIDITrigger `json:"midi,omitempty"`
+	}
+
+	// CueList is the sequence of scenes stepped through by NextCue
+	// and PrevCue.
+	CueList struct {
+		Scenes []string `json:"scenes"`
+
+		// Position is the index of the last cue played, or -1.
+		Position int `json:"position"`
+	}
+
+	// midiTrigger is a MIDITrigger registered with the server. Exactly
+	// one of scene and cue is set.
+	midiTrigger struct {
+		MIDITrigger
+
+		device *regexp.Regexp
+		scene  string
+		cue    bool
+
+		// high is true if the controller's last value was 64 or more.
+		high bool
+	}
+
+	// sceneDecls holds the scenes, cue list and cue trigger declared
+	// by a script with scene, cue-list and cue-trigger.
+	sceneDecls struct {
+		scenes     []Scene
+		cues       []string
+		hasCues    bool
+		cueTrigger *MIDITrigger
+	}
+
+	// scheduledFunc is a function to call once the server has output
+	// a given number of samples.
+	scheduledFunc struct {
+		at int64
+		fn func()
+	}
+)
+
+// RegisterScene registers a scene, replacing any registered scene with
+// the same name.
+func (s *Server) RegisterScene(scene Scene) error {
+	if scene.Name == "" {
+		return fmt.Errorf("scene has no name")
+	}
+	if scene.Script == "" && scene.Graph == nil {
+		return fmt.Errorf("scene %s has neither a script nor a graph", scene.Name)
+	}
+	if err := scene.Transition.validate(); err != nil {
+		return fmt.Errorf("scene %s: %w", scene.Name, err)
+	}
+	if scene.Script != "" {
+		path, err := filepath.Abs(scene.Script)
+		if err != nil {
+			return err
+		}
+		scene.Script = path
+	}
+	var trig *midiTrigger
+	if scene.MIDI != nil {
+		var err error
+		if trig, err = s.newMIDITrigger(*scene.MIDI); err != nil {
+			return fmt.Errorf("scene %s: %w", scene.Name, err)
+		}
+		trig.scene = scene.Name
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.removeMIDITriggers(func(t *midiTrigger) bool { return t.scene == scene.Name })
+	if trig != nil {
+		s.midiTriggers = append(s.midiTriggers, trig)
+	}
+	for i, sc := range s.scenes {
+		if sc.Name == scene.Name {
+			s.scenes[i] = &scene
+			return nil
+		}
+	}
+	s.scenes = append(s.scenes, &scene)
+	return nil
+}
+
+// Scenes returns the registered scenes, in the order they were first
+// registered.
+func (s *Server) Scenes() []Scene {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := make([]Scene, len(s.scenes))
+	for i, sc := range s.scenes {
+		res[i] = *sc
+	}
+	return res
+}
+
+// TriggerScene plays the named scene with the given transition, or
+// with the scene's own transition if tr is the zero Transition. A
+// script scene is evaluated before TriggerScene returns, and is
+// aborted as for EvalScript; quantized transitions then happen in the
+// background.
+func (s *Server) TriggerScene(ctx context.Context, name string, tr Transition) error {
+	s.mtx.RLock()
+	scene := s.scene(name)
+	s.mtx.RUnlock()
+
+	if scene == nil {
+		return fmt.Errorf("no scene named %s", name)
+	}
+	return s.triggerScene(ctx, scene, tr)
+}
+
+// TriggerSceneKey plays the scene bound to the keystroke key with the
+// scene's own transition.
+func (s *Server) TriggerSceneKey(ctx context.Context, key string) error {
+	s.mtx.RLock()
+	var scene *Scene
+	for _, sc := range s.scenes {
+		if sc.Key == key {
+			scene = sc
+			break
+		}
+	}
+	s.mtx.RUnlock()
+
+	if scene == nil {
+		return fmt.Errorf("no scene bound to key %q", key)
+	}
+	return s.triggerScene(ctx, scene, Transition{})
+}
+
+// CurrentScene returns the name of the scene last played, if any.
+func (s *Server) CurrentScene() string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.currentScene
+}
+
+// SetCueList sets the scenes stepped through by NextCue and PrevCue,
+// and rewinds to the start of the list.
+func (s *Server) SetCueList(names []string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, name := range names {
+		if s.scene(name) == nil {
+			return fmt.Errorf("no scene named %s", name)
+		}
+	}
+	s.cues = CueList{
+		Scenes:   append([]string(nil), names...),
+		Position: -1,
+	}
+	return nil
+}
+
+// Cues returns the cue list.
+func (s *Server) Cues() CueList {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := s.cues
+	res.Scenes = append([]string(nil), res.Scenes...)
+	return res
+}
+
+// SetCueTrigger sets a MIDI trigger that plays the next cue, or
+// removes it if trig is nil.
+func (s *Server) SetCueTrigger(trig *MIDITrigger) error {
+	var t *midiTrigger
+	if trig != nil {
+		var err error
+		if t, err = s.newMIDITrigger(*trig); err != nil {
+			return err
+		}
+		t.cue = true
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.removeMIDITriggers(func(t *midiTrigger) bool { return t.cue })
+	if t != nil {
+		s.midiTriggers = append(s.midiTriggers, t)
+	}
+	return nil
+}
+
+// NextCue plays the scene after the last cue played.
+func (s *Server) NextCue(ctx context.Context) error {
+	return s.GoToCue(ctx, s.Cues().Position+1)
+}
+
+// PrevCue plays the scene before the last cue played.
+func (s *Server) PrevCue(ctx context.Context) error {
+	return s.GoToCue(ctx, s.Cues().Position-1)
+}
+
+// GoToCue plays the scene at index i in the cue list with the
+// scene's own transition.
+func (s *Server) GoToCue(ctx context.Context, i int) error {
+	s.mtx.RLock()
+	var name string
+	var scene *Scene
+	n := len(s.cues.Scenes)
+	if i >= 0 && i < n {
+		name = s.cues.Scenes[i]
+		scene = s.scene(name)
+	}
+	s.mtx.RUnlock()
+
+	var err error
+	switch {
+	case i < 0 || i >= n:
+		err = fmt.Errorf("no cue %d: %d cues", i, n)
+	case scene == nil:
+		err = fmt.Errorf("cue %d: no scene named %s", i, name)
+	}
+	if err != nil {
+		console.Log(console.Warn, err.Error(), nil)
+		return err
+	}
+	if err := s.triggerScene(ctx, scene, Transition{}); err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	s.cues.Position = i
+	s.mtx.Unlock()
+	return nil
+}
+
+// SetTempo sets the tempo used to quantize scene transitions to the
+// bar when the graph playing doesn't have a constant tempo set with
+// setcps!, in which case a bar is a cycle of that tempo. Bars are
+// counted from the start of the server. The default is 135 bpm in
+// 4/4, matching the default tempo of scripts.
+func (s *Server) SetTempo(bpm float64, beatsPerBar int) error {
+	if bpm <= 0 || beatsPerBar <= 0 {
+		return fmt.Errorf("invalid tempo: %v bpm, %d beats per bar", bpm, beatsPerBar)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.bpm = bpm
+	s.beatsPerBar = beatsPerBar
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (tr Transition) validate() error {
+	switch tr.Kind {
+	case "", TransitionImmediate, TransitionQuantized:
+	case TransitionCrossfade:
+		if tr.Duration <= 0 {
+			return fmt.Errorf("crossfade transition needs a duration")
+		}
+	default:
+		return fmt.Errorf("unknown transition %q", tr.Kind)
+	}
+	if tr.Duration < 0 {
+		return fmt.Errorf("negative transition duration %s", tr.Duration)
+	}
+	return nil
+}
+
+// declareScenes registers the scenes, cue list and cue trigger
+// declared by a script. The cue list is only replaced, rewinding it,
+// if it has changed, so that the script can be edited during a show.
+func (s *Server) declareScenes(decls *sceneDecls) error {
+	if decls == nil {
+		return nil
+	}
+	for _, scene := range decls.scenes {
+		if err := s.RegisterScene(scene); err != nil {
+			return err
+		}
+	}
+	if decls.hasCues && !slices.Equal(decls.cues, s.Cues().Scenes) {
+		if err := s.SetCueList(decls.cues); err != nil {
+			return err
+		}
+	}
+	if decls.cueTrigger != nil {
+		if err := s.SetCueTrigger(decls.cueTrigger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSceneDecls returns the declarations collected in *scenes* by
+// the script being evaluated. Relative script paths are resolved
+// against dir, if it's not empty.
+func readSceneDecls(dir string) (*sceneDecls, error) {
+	decls := glj.Var("mrat.core", "*scenes*").(*lang.Var).Deref().(*lang.Atom).Deref()
+
+	res := &sceneDecls{}
+	for sq := lang.Seq(lang.Get(decls, scenesKW)); sq != nil; sq = lang.Next(sq) {
+		scene, err := parseScene(lang.First(sq), dir)
+		if err != nil {
+			return nil, err
+		}
+		res.scenes = append(res.scenes, scene)
+	}
+	if cues := lang.Get(decls, cuesKW); cues != nil {
+		res.hasCues = true
+		res.cues = []string{}
+		for sq := lang.Seq(cues); sq != nil; sq = lang.Next(sq) {
+			res.cues = append(res.cues, lang.First(sq).(string))
+		}
+	}
+	if trig := lang.Get(decls, cueTriggerKW); trig != nil {
+		t, err := parseMIDITrigger(trig)
+		if err != nil {
+			return nil, fmt.Errorf("cue trigger: %w", err)
+		}
+		res.cueTrigger = &t
+	}
+	return res, nil
+}
+
+// parseScene converts a scene declared with scene into a Scene.
+func parseScene(v any, dir string) (Scene, error) {
+	name, _ := lang.Get(v, nameKW).(string)
+	scene := Scene{Name: name}
+	script, ok := lang.Get(v, scriptKW).(string)
+	if !ok {
+		return scene, fmt.Errorf("scene %s: script must be a string, got %v", name, lang.Get(v, scriptKW))
+	}
+	if dir != "" && !filepath.IsAbs(script) {
+		script = filepath.Join(dir, script)
+	}
+	scene.Script = script
+	if key := lang.Get(v, keyKW); key != nil {
+		if scene.Key, ok = key.(string); !ok {
+			return scene, fmt.Errorf("scene %s: key must be a string, got %v", name, key)
+		}
+	}
+	if kind := lang.Get(v, transitionKW); kind != nil {
+		kw, ok := kind.(lang.Keyword)
+		if !ok {
+			return scene, fmt.Errorf("scene %s: transition must be a keyword, got %v", name, kind)
+		}
+		scene.Transition.Kind = TransitionKind(kw.Name())
+	}
+	if fade := lang.Get(v, fadeKW); fade != nil {
+		if !lang.IsNumber(fade) {
+			return scene, fmt.Errorf("scene %s: fade must be a number of seconds, got %v", name, fade)
+		}
+		scene.Transition.Duration = time.Duration(lang.AsFloat64(fade) * float64(time.Second))
+	}
+	if midi := lang.Get(v, midiKW); midi != nil {
+		trig, err := parseMIDITrigger(midi)
+		if err != nil {
+			return scene, fmt.Errorf("scene %s: %w", name, err)
+		}
+		scene.MIDI = &trig
+	}
+	return scene, nil
+}
+
+// parseMIDITrigger converts a map of :type, :number, :channel and
+// :device into a MIDITrigger.
+func parseMIDITrigger(v any) (MIDITrigger, error) {
+	trig := MIDITrigger{Channel: -1}
+	kw, ok := lang.Get(v, typeKW).(lang.Keyword)
+	if !ok {
+		return trig, fmt.Errorf("MIDI trigger type must be :note or :control, got %v", lang.Get(v, typeKW))
+	}
+	trig.Type = MIDITriggerType(kw.Name())
+	if trig.Number, ok = lang.AsInt(lang.Get(v, numberKW)); !ok {
+		return trig, fmt.Errorf("MIDI trigger number must be an integer, got %v", lang.Get(v, numberKW))
+	}
+	if ch := lang.Get(v, channelKW); ch != nil {
+		if trig.Channel, ok = lang.AsInt(ch); !ok {
+			return trig, fmt.Errorf("MIDI trigger channel must be an integer, got %v", ch)
+		}
+	}
+	if dev := lang.Get(v, deviceKW); dev != nil {
+		if trig.Device, ok = dev.(string); !ok {
+			return trig, fmt.Errorf("MIDI trigger device must be a string, got %v", dev)
+		}
+	}
+	return trig, nil
+}
+
+// scene returns the scene with the given name. s.mtx must be held.
+func (s *Server) scene(name string) *Scene {
+	for _, sc := range s.scenes {
+		if sc.Name == name {
+			return sc
+		}
+	}
+	return nil
+}
+
+func (s *Server) triggerScene(ctx context.Context, scene *Scene, tr Transition) (err error) {
+	defer func() {
+		if err != nil {
+			console.Log(console.Error, fmt.Sprintf("error playing scene %s", scene.Name), errorData(err))
+		}
+	}()
+
+	if tr == (Transition{}) {
+		tr = scene.Transition
+	}
+	if err := tr.validate(); err != nil {
+		return err
+	}
+
+	g, hash, err := s.sceneGraph(ctx, scene)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch tr.Kind {
+	case TransitionQuantized:
+		barSamples := s.barSamples()
+		at := (s.samplePos.Load()/barSamples + 1) * barSamples
+		s.schedule(at, func() {
+			s.mtx.Lock()
+			defer s.mtx.Unlock()
+
+			if err := s.playScene(scene, g, hash, tr.Duration); err != nil {
+				console.Log(console.Error, fmt.Sprintf("error playing scene %s", scene.Name), errorData(err))
+			}
+		})
+	case TransitionCrossfade:
+		return s.playScene(scene, g, hash, tr.Duration)
+	default:
+		return s.playScene(scene, g, hash, 0)
+	}
+	return nil
+}
+
+// barSamples returns the length of a bar in samples. s.mtx must be
+// held.
+func (s *Server) barSamples() int64 {
+	if cps := math.Float64frombits(s.cps.Load()); cps > 0 {
+		return int64(math.Round(float64(s.sampleRate) / cps))
+	}
+	return int64(math.Round(float64(s.sampleRate) * 60 / s.bpm * float64(s.beatsPerBar)))
+}
+
+// graphCPS returns the tempo, in cycles per second, set with setcps!
+// by the script that built g, if it's constant. Of a crossfade, it
+// returns the tempo of the graph faded in.
+func graphCPS(g *graph.Graph) (float64, bool) {
+	nodes := map[graph.NodeID]*graph.Node{}
+	var pipe graph.NodeID
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+		if n.Type == "cps" {
+			pipe = n.ID
+		}
+	}
+	// the runner takes the last edge into a port.
+	var in *graph.Node
+	for _, e := range g.Edges {
+		if e.To == pipe && e.Port == "in" {
+			in = nodes[e.From]
+		}
+	}
+	if in == nil || in.Type != "const" {
+		return 0, false
+	}
+	cps, ok := lang.First(in.Args).(float64)
+	return cps, ok && cps > 0
+}
+
+// sceneGraph returns the graph of scene, evaluating its script if it
+// has one, and the hash of its source.
+func (s *Server) sceneGraph(ctx context.Context, scene *Scene) (*graph.Graph, [32]byte, error) {
+	if scene.Script == "" {
+		return scene.Graph, [32]byte{}, nil
+	}
+
+	ctx, cancel := s.evalContext(ctx)
+	defer cancel()
+
+	g, deps, err := EvalScriptContext(ctx, scene.Script)
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	hash, err := scriptHash(scene.Script, deps)
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	return g, hash, nil
+}
+
+// playScene plays g, the graph of scene, crossfading from the playing
+// graph over fade if it's non-zero. s.mtx must be held.
+func (s *Server) playScene(scene *Scene, g *graph.Graph, hash [32]byte, fade time.Duration) error {
+	cur := s.runner.Graph()
+	if fade <= 0 || cur == nil {
+		if err := s.PlayGraph(g); err != nil {
+			return err
+		}
+	} else {
+		samples := int64(fade.Seconds() * float64(s.sampleRate))
+		xfade := graph.Crossfade(cur, g, int(samples))
+		if err := s.PlayGraph(xfade); err != nil {
+			return err
+		}
+
+		// once the fade is done, drop the graph faded out, unless
+		// another graph has been played in the meantime. allow for
+		// the buffers between the runner and the output.
+		s.schedule(s.samplePos.Load()+samples+2*int64(conf.BufferSize), func() {
+			s.mtx.Lock()
+			defer s.mtx.Unlock()
+
+			if s.runner.Graph() == xfade {
+				s.PlayGraph(g)
+			}
+		})
+	}
+	s.history.add(g, hash, "scene "+scene.Name)
+	s.currentScene = scene.Name
+
+	console.Log(console.Info, fmt.Sprintf("playing scene %s", scene.Name), nil)
+	return nil
+}
+
+// schedule arranges for fn to be called once the server has output at
+// samples in total. Functions scheduled for the same time are called
+// in the order they were scheduled.
+func (s *Server) schedule(at int64, fn func()) {
+	s.schedMtx.Lock()
+	defer s.schedMtx.Unlock()
+
+	s.scheduled = append(s.scheduled, scheduledFunc{at: at, fn: fn})
+	sort.SliceStable(s.scheduled, func(i, j int) bool {
+		return s.scheduled[i].at < s.scheduled[j].at
+	})
+}
+
+// runScheduled calls scheduled functions as they come due. It runs
+// apart from sendSamples, as playing a graph waits on the runner,
+// which may itself be waiting on sendSamples.
+func (s *Server) runScheduled(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.samplesSent:
+		}
+
+		pos := s.samplePos.Load()
+		s.schedMtx.Lock()
+		var due []scheduledFunc
+		for len(s.scheduled) > 0 && s.scheduled[0].at <= pos {
+			due = append(due, s.scheduled[0])
+			s.scheduled = s.scheduled[1:]
+		}
+		s.schedMtx.Unlock()
+
+		for _, f := range due {
+			f.fn()
+		}
+	}
+}
+
+func (s *Server) newMIDITrigger(trig MIDITrigger) (*midiTrigger, error) {
+	switch trig.Type {
+	case MIDINote, MIDIControl:
+	default:
+		return nil, fmt.Errorf("unknown MIDI trigger type %q", trig.Type)
+	}
+	if trig.Number < 0 || trig.Number > 127 {
+		return nil, fmt.Errorf("invalid MIDI %s number %d", trig.Type, trig.Number)
+	}
+
+	t := &midiTrigger{MIDITrigger: trig}
+	if trig.Device != "" {
+		re, err := regexp.Compile(trig.Device)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := aio.ListenMIDI(s.ctx, trig.Device); err != nil {
+			return nil, err
+		}
+		t.device = re
+	}
+	return t, nil
+}
+
+// removeMIDITriggers removes the MIDI triggers for which remove
+// returns true. s.mtx must be held.
+func (s *Server) removeMIDITriggers(remove func(*midiTrigger) bool) {
+	triggers := s.midiTriggers[:0]
+	for _, t := range s.midiTriggers {
+		if !remove(t) {
+			triggers = append(triggers, t)
+		}
+	}
+	s.midiTriggers = triggers
+}
+
+// handleMIDI triggers the scenes and cues mapped to a MIDI message.
+func (s *Server) handleMIDI(_ string, data any) {
+	env, ok := data.(*aio.MIDIEnvelope)
+	if !ok {
+		return
+	}
+
+	s.mtx.Lock()
+	var scenes []*Scene
+	var cue bool
+	for _, t := range s.midiTriggers {
+		if !t.match(env) {
+			continue
+		}
+		if t.cue {
+			cue = true
+		} else if scene := s.scene(t.scene); scene != nil {
+			scenes = append(scenes, scene)
+		}
+	}
+	ctx := s.ctx
+	s.mtx.Unlock()
+
+	if len(scenes) == 0 && !cue {
+		return
+	}
+
+	// evaluation may take a while; don't hold up the MIDI
+	// thread. errors are logged to the console.
+	go func() {
+		for _, scene := range scenes {
+			s.triggerScene(ctx, scene, Transition{})
+		}
+		if cue {
+			s.NextCue(ctx)
+		}
+	}()
+}
+
+// match returns true if the message in env triggers t. s.mtx must be
+// held.
+func (t *midiTrigger) match(env *aio.MIDIEnvelope) bool {
+	if t.device != nil && !t.device.MatchString(env.DeviceName) {
+		return false
+	}
+
+	var channel, num, val uint8
+	switch t.Type {
+	case MIDINote:
+		if !env.Message.GetNoteOn(&channel, &num, &val) || val == 0 {
+			return false
+		}
+	case MIDIControl:
+		if !env.Message.GetControlChange(&channel, &num, &val) {
+			return false
+		}
+	}
+	if int(num) != t.Number || (t.Channel >= 0 && int(channel) != t.Channel) {
+		return false
+	}
+	if t.Type == MIDIControl {
+		wasHigh := t.high
+		t.high = val >= 64
+		return t.high && !wasHigh
+	}
+	return true
+}
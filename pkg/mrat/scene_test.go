@@ -0,0 +1,198 @@
+package mrat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfhamlin/muscrat/pkg/graph"
+)
+
+func TestScenes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// a bar every 10ms.
+	graphs := map[string]*graph.Graph{}
+	for name, src := range map[string]string{
+		"intro": `(setcps! 100) (play (sin 220))`,
+		"verse": `(setcps! 100) (play (saw 220))`,
+		"outro": `(setcps! 100) (play (sqr 220))`,
+	} {
+		res, err := EvalString(src, "scene-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		graphs[name] = res.Graph
+	}
+	for _, scene := range []Scene{
+		{Name: "intro", Graph: graphs["intro"], Key: "1"},
+		{Name: "verse", Graph: graphs["verse"], Transition: Transition{Kind: TransitionCrossfade, Duration: 20 * time.Millisecond}},
+		{Name: "outro", Graph: graphs["outro"], Transition: Transition{Kind: TransitionQuantized}},
+	} {
+		if err := srv.RegisterScene(scene); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := srv.RegisterScene(Scene{Name: "bad", Graph: graphs["intro"], Transition: Transition{Kind: TransitionCrossfade}}); err == nil {
+		t.Error("expected an error registering a crossfade without a duration")
+	}
+	waitForGraph := func(want *graph.Graph) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for srv.runner.Graph() != want {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for scene graph")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if err := srv.SetCueList([]string{"intro", "verse", "outro"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.NextCue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if srv.runner.Graph() != graphs["intro"] || srv.CurrentScene() != "intro" {
+		t.Fatal("expected the intro to play immediately")
+	}
+
+	if err := srv.NextCue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitForGraph(graphs["verse"])
+
+	if err := srv.NextCue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitForGraph(graphs["outro"])
+	if cues := srv.Cues(); cues.Position != 2 {
+		t.Errorf("got cue position %d, want 2", cues.Position)
+	}
+	if err := srv.NextCue(ctx); err == nil {
+		t.Error("expected an error advancing past the last cue")
+	}
+
+	if err := srv.TriggerSceneKey(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	waitForGraph(graphs["intro"])
+
+	if hist := srv.History(); len(hist) != 4 || hist[0].Description != "scene intro" {
+		t.Errorf("unexpected history %+v", hist)
+	}
+}
+
+func TestGraphCPS(t *testing.T) {
+	graphs := map[string]*graph.Graph{}
+	for name, src := range map[string]string{
+		"default":   `(play (sin 220))`,
+		"set":       `(setcps! 2) (play (sin 220))`,
+		"modulated": `(setcps! (+ 2 (sin 1))) (play (sin 220))`,
+	} {
+		res, err := EvalString(src, "scene-test.cps")
+		if err != nil {
+			t.Fatal(err)
+		}
+		graphs[name] = res.Graph
+	}
+
+	for _, test := range []struct {
+		name string
+		g    *graph.Graph
+		cps  float64
+		ok   bool
+	}{
+		{"default", graphs["default"], 135.0 / 60 / 4, true},
+		{"set", graphs["set"], 2, true},
+		{"modulated", graphs["modulated"], 0, false},
+		{"crossfade", graph.Crossfade(graphs["default"], graphs["set"], 100), 2, true},
+		{"zero", ZeroGraph(), 0, false},
+	} {
+		cps, ok := graphCPS(test.g)
+		if cps != test.cps || ok != test.ok {
+			t.Errorf("%s: got %v, %v, want %v, %v", test.name, cps, ok, test.cps, test.ok)
+		}
+	}
+}
+
+func TestDeclareScenes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewServer()
+	if err := srv.Start(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"declare_intro.glj": `(ns declare-intro (:use [mrat.core])) (play (sin 220))`,
+		"declare_verse.glj": `(ns declare-verse (:use [mrat.core])) (play (saw 220))`,
+		"declare_show.glj": `(ns declare-show (:use [mrat.core]))
+(defscene intro "declare_intro.glj" :key "1")
+(defscene verse "declare_verse.glj" :transition :crossfade :fade 0.5 :midi {:type :note :number 60})
+(cue-list intro verse)
+(cue-trigger {:type :control :number 64 :channel 0})`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	show := filepath.Join(dir, "declare_show.glj")
+	if err := srv.EvalScript(ctx, show, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Scene{
+		{Name: "intro", Script: filepath.Join(dir, "declare_intro.glj"), Key: "1"},
+		{
+			Name:       "verse",
+			Script:     filepath.Join(dir, "declare_verse.glj"),
+			Transition: Transition{Kind: TransitionCrossfade, Duration: 500 * time.Millisecond},
+			MIDI:       &MIDITrigger{Channel: -1, Type: MIDINote, Number: 60},
+		},
+	}
+	if got := srv.Scenes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got scenes %+v, want %+v", got, want)
+	}
+	if cues := srv.Cues(); !slices.Equal(cues.Scenes, []string{"intro", "verse"}) {
+		t.Errorf("got cues %v, want [intro verse]", cues.Scenes)
+	}
+
+	// re-evaluating the script keeps the cue position.
+	if err := srv.NextCue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.EvalScript(ctx, show, true); err != nil {
+		t.Fatal(err)
+	}
+	if cues := srv.Cues(); cues.Position != 0 {
+		t.Errorf("got cue position %d after re-evaluation, want 0", cues.Position)
+	}
+
+	// changing the cue list starts it over.
+	if _, err := srv.EvalString(ctx, `(cue-list "verse")`, "declare-show"); err != nil {
+		t.Fatal(err)
+	}
+	if cues := srv.Cues(); !slices.Equal(cues.Scenes, []string{"verse"}) || cues.Position != -1 {
+		t.Errorf("got cues %+v, want [verse] at -1", cues)
+	}
+
+	if _, err := srv.EvalString(ctx, `(cue-list "chorus")`, "declare-show"); err == nil {
+		t.Error("expected an error declaring a cue list with an unknown scene")
+	}
+	if _, err := srv.EvalString(ctx, `(scene "bad" 42)`, "declare-show"); err == nil {
+		t.Error("expected an error declaring a scene without a script path")
+	}
+}
@@ -1,17 +1,16 @@
 package mrat
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
-	"runtime/debug"
 	"sort"
 	"strings"
 
 	"github.com/glojurelang/glojure/pkg/glj"
 	"github.com/glojurelang/glojure/pkg/lang"
-	"github.com/glojurelang/glojure/pkg/runtime"
 
 	"github.com/jfhamlin/muscrat/pkg/conf"
 	"github.com/jfhamlin/muscrat/pkg/console"
@@ -19,8 +18,6 @@ import (
 )
 
 var (
-	addedPaths = map[string]bool{}
-
 	typeKW  = lang.NewKeyword("type")
 	outKW   = lang.NewKeyword("out")
 	argsKW  = lang.NewKeyword("args")
@@ -41,6 +38,14 @@ type (
 		sb strings.Builder
 	}
 
+	// scriptResult is the result of evaluating a script: the graph
+	// it builds, the scenes it declares, and its dependencies.
+	scriptResult struct {
+		graph  *graph.Graph
+		scenes *sceneDecls
+		deps   []string
+	}
+
 	UGenArg struct {
 		Name    string `json:"name"`
 		Default any    `json:"default"`
@@ -53,6 +58,12 @@ type (
 		Doc      string    `json:"doc"`
 		Arglists []any     `json:"arglists"`
 		UGenArgs []UGenArg `json:"ugenargs"`
+
+		// File, Line and Column locate the symbol's definition, with
+		// File relative to the load path.
+		File   string `json:"-"`
+		Line   int    `json:"-"`
+		Column int    `json:"-"`
 	}
 )
 
@@ -98,64 +109,135 @@ func (cw *consoleWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-func EvalScript(filename string) (res *graph.Graph, err error) {
+// EvalScript evaluates the script in filename and returns the graph
+// it builds. It also returns the dependencies of the script: the
+// files of all namespaces loaded from script directories while it was
+// evaluated, excluding the script itself. Dependencies are returned
+// even if evaluation fails, so that they can be watched for a fix.
+//
+// Namespaces loaded from script directories are reloaded on every
+// evaluation, so changes to any of the script's dependencies take
+// effect.
+func EvalScript(filename string) (*graph.Graph, []string, error) {
+	return EvalScriptContext(context.Background(), filename)
+}
+
+// EvalScriptContext is like EvalScript, but returns a canceled
+// Diagnostic as soon as ctx is done.
+func EvalScriptContext(ctx context.Context, filename string) (*graph.Graph, []string, error) {
+	res, err := evalScriptFile(ctx, filename)
+	return res.graph, res.deps, err
+}
+
+// evalScriptFile is like EvalScriptContext, but also returns the
+// scenes declared by the script.
+func evalScriptFile(ctx context.Context, filename string) (scriptResult, error) {
 	console.Log(console.Info, fmt.Sprintf("evaluating %s", filename), nil)
+
+	// get the absolute path to the script
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return scriptResult{}, err
+	}
+
+	return runEval(ctx, absPath, func(ctx context.Context) (scriptResult, error) {
+		return evalScript(ctx, absPath)
+	})
+}
+
+func evalScript(ctx context.Context, filename string) (res scriptResult, err error) {
+	stopRecording := recordLoads()
+	defer func() {
+		for _, f := range stopRecording() {
+			if f != filename {
+				res.deps = append(res.deps, f)
+			}
+		}
+	}()
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("%v\n%s", r, debug.Stack())
+			err = newDiagnostic(r, filename)
 		}
 	}()
 
 	require := glj.Var("clojure.core", "require")
 	require.Invoke(glj.Read("mrat.core"))
+	startScript(ctx)
 
-	graphAtom := lang.NewAtom(glj.Read(`{:nodes [] :edges []}`))
-
-	lang.PushThreadBindings(getScriptThreadBindings(graphAtom))
-	defer lang.PopThreadBindings()
+	graphAtom := newGraphAtom(ctx)
 
-	{ // initialize other dynamic vars
-		pipeFn := glj.Var("mrat.core", "pipe")
-		impulse := glj.Var("mrat.core", "impulse")
-		setCPS := glj.Var("mrat.core", "setcps!")
+	pushScriptBindings(graphAtom, &cancelWriter{ctx: ctx, w: &consoleWriter{}})
+	defer popScriptBindings()
 
-		pipe := pipeFn.Invoke()
-		lang.PushThreadBindings(lang.NewMap(
-			glj.Var("mrat.core", "*cps*"), pipe,
-			glj.Var("mrat.core", "*tctick*"), impulse.Invoke(pipe),
-		))
+	// get the directory of the file and the file name
+	dir := filepath.Dir(filename)
+	name := filepath.Base(filename)
 
-		// default to 135 bpm
-		setCPS.Invoke(135.0 / 60.0 / 4.0)
+	addScriptDir(dir)
+	unloadScriptLibs()
+	require.Invoke(glj.Read(strings.TrimSuffix(name, ".glj")), lang.NewKeyword("reload"))
 
-		defer lang.PopThreadBindings()
+	if res.graph, err = scriptGraph(graphAtom, filename); err != nil {
+		return res, err
 	}
-
-	// get the absolute path to the script
-	absPath, err := filepath.Abs(filename)
-	if err != nil {
-		return nil, err
+	if res.scenes, err = readSceneDecls(dir); err != nil {
+		return res, newDiagnostic(err, filename)
 	}
-	filename = absPath
+	return res, nil
+}
 
-	// get the directory of the file and the file name
-	dir := filepath.Dir(filename)
-	name := filepath.Base(filename)
+// pushScriptBindings establishes the dynamic bindings a script
+// expects while it is evaluated. Nodes created by the script are
+// collected in graphAtom, and anything printed is written to out.
+// Every call must be paired with a call to popScriptBindings.
+func pushScriptBindings(graphAtom *lang.Atom, out io.Writer) {
+	lang.PushThreadBindings(getScriptThreadBindings(graphAtom, out))
+
+	// initialize other dynamic vars
+	cpsPipe := glj.Var("mrat.core", "cps-pipe")
+	impulse := glj.Var("mrat.core", "impulse")
+	setCPS := glj.Var("mrat.core", "setcps!")
+
+	pipe := cpsPipe.Invoke()
+	lang.PushThreadBindings(lang.NewMap(
+		glj.Var("mrat.core", "*cps*"), pipe,
+		glj.Var("mrat.core", "*tctick*"), impulse.Invoke(pipe),
+		glj.Var("mrat.core", "*scenes*"), lang.NewAtom(lang.NewMap()),
+	))
+
+	// default to 135 bpm
+	setCPS.Invoke(135.0 / 60.0 / 4.0)
+}
 
-	if !addedPaths[dir] {
-		// add the directory as a fs.FS to the load path
-		runtime.AddLoadPath(os.DirFS(dir))
-		addedPaths[dir] = true
-	}
-	require.Invoke(glj.Read(strings.TrimSuffix(name, ".glj")), lang.NewKeyword("reload"))
+func popScriptBindings() {
+	lang.PopThreadBindings()
+	lang.PopThreadBindings()
+}
+
+// scriptGraph converts the graph collected in graphAtom during
+// evaluation of file into a graph.Graph. If the graph is invalid, the
+// returned error is a graph Diagnostic.
+func scriptGraph(graphAtom *lang.Atom, file string) (res *graph.Graph, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, newGraphDiagnostic(r, file)
+		}
+	}()
 
+	require := glj.Var("clojure.core", "require")
 	require.Invoke(glj.Read("mrat.graph"))
 	simplifyGraph := glj.Var("mrat.graph", "simplify-graph")
-	g := simplifyGraph.Invoke(graphAtom.Deref())
-	return graph.SExprToGraph(g), nil
+	g, err := graph.ParseGraph(simplifyGraph.Invoke(graphAtom.Deref()))
+	if err != nil {
+		return nil, newGraphDiagnostic(err, file)
+	}
+	if err := g.Validate(); err != nil {
+		return nil, newGraphDiagnostic(err, file)
+	}
+	return g, nil
 }
 
-func getScriptThreadBindings(graphAtom *lang.Atom) lang.IPersistentMap {
+func getScriptThreadBindings(graphAtom *lang.Atom, out io.Writer) lang.IPersistentMap {
 	anyPaths := make([]any, len(conf.SampleFilePaths))
 	for i, p := range conf.SampleFilePaths {
 		anyPaths[i] = p
@@ -165,16 +247,25 @@ func getScriptThreadBindings(graphAtom *lang.Atom) lang.IPersistentMap {
 	return lang.NewMap(
 		glj.Var("mrat.core", "*graph*"), graphAtom,
 		glj.Var("mrat.core", "*sample-file-paths*"), sampleFilePathsAtom,
-		glj.Var("clojure.core", "*out*"), &consoleWriter{},
+		glj.Var("clojure.core", "*out*"), out,
 	)
 }
 
+// GetNSPublics returns the public vars of mrat.core, sorted by doc
+// group and name. The UGenArgs of the ugens defined with defugen are
+// non-nil, even if they take no arguments.
 func GetNSPublics() []Symbol {
+	return GetPublics("mrat.core")
+}
+
+// GetPublics returns the public vars of the namespace ns, loading it
+// if necessary, sorted as by GetNSPublics.
+func GetPublics(ns string) []Symbol {
 	require := glj.Var("clojure.core", "require")
-	require.Invoke(glj.Read("mrat.core"))
+	require.Invoke(glj.Read(ns))
 
 	nsPublics := glj.Var("clojure.core", "ns-publics")
-	publics := nsPublics.Invoke(glj.Read("mrat.core"))
+	publics := nsPublics.Invoke(glj.Read(ns))
 
 	docgroupKW := lang.NewKeyword("docgroup")
 	docKW := lang.NewKeyword("doc")
@@ -182,6 +273,9 @@ func GetNSPublics() []Symbol {
 	ugenargsKW := lang.NewKeyword("ugenargs")
 	nameKW := lang.NewKeyword("name")
 	defaultKW := lang.NewKeyword("default")
+	fileKW := lang.NewKeyword("file")
+	lineKW := lang.NewKeyword("line")
+	columnKW := lang.NewKeyword("column")
 
 	var res []Symbol
 	for s := lang.Seq(publics); s != nil; s = s.Next() {
@@ -199,6 +293,10 @@ func GetNSPublics() []Symbol {
 		}
 		uargs := meta.ValAt(ugenargsKW)
 		var ugenargs []UGenArg
+		if uargs != nil {
+			// distinguish ugens without arguments from plain functions.
+			ugenargs = []UGenArg{}
+		}
 		for s := lang.Seq(uargs); s != nil; s = s.Next() {
 			m := s.First().(*lang.Map)
 			name := m.ValAt(nameKW).(string)
@@ -211,12 +309,19 @@ func GetNSPublics() []Symbol {
 			})
 		}
 
+		file, _ := meta.ValAt(fileKW).(string)
+		line, _ := meta.ValAt(lineKW).(int)
+		column, _ := meta.ValAt(columnKW).(int)
+
 		sym := Symbol{
 			Name:     name,
 			Group:    docgroup,
 			Doc:      doc,
 			Arglists: arglist,
 			UGenArgs: ugenargs,
+			File:     file,
+			Line:     line,
+			Column:   column,
 		}
 		res = append(res, sym)
 	}
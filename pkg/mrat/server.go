@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jfhamlin/muscrat/pkg/bufferpool"
@@ -64,6 +67,42 @@ type (
 		outputChannel chan [][]float64
 
 		lastFileHash [32]byte
+		// files loaded by the last evaluated script, other than the
+		// script itself.
+		scriptDeps []string
+
+		// graphs played from evaluations, for Revert.
+		history graphHistory
+
+		// evaluations are aborted after evalTimeout, or when
+		// evalCtx is canceled by CancelEval.
+		evalTimeout time.Duration
+		evalCtx     context.Context
+		cancelEvals context.CancelFunc
+
+		// registered scenes, the cue list stepping through them, and
+		// the MIDI messages triggering them.
+		scenes       []*Scene
+		cues         CueList
+		midiTriggers []*midiTrigger
+		currentScene string
+
+		// tempo for quantized scene transitions.
+		bpm         float64
+		beatsPerBar int
+
+		// cps holds the bits of the constant tempo set with setcps!
+		// by the script of the graph playing, or 0.
+		cps atomic.Uint64
+
+		// samplePos is the number of samples output. samplesSent is
+		// signaled each time it's updated.
+		samplePos   atomic.Int64
+		samplesSent chan struct{}
+
+		// functions to call at a given sample position, in order.
+		scheduled []scheduledFunc
+		schedMtx  sync.Mutex
 
 		started bool
 
@@ -80,6 +119,11 @@ func NewServer() *Server {
 		gain:          1,
 		targetGain:    1,
 		outputChannel: make(chan [][]float64, 1),
+		evalTimeout:   conf.EvalTimeout,
+		cues:          CueList{Position: -1},
+		bpm:           defaultBPM,
+		beatsPerBar:   defaultBeatsPerBar,
+		samplesSent:   make(chan struct{}, 1),
 	}
 }
 
@@ -109,23 +153,37 @@ func (s *Server) Start(ctx context.Context, noSystemAudio bool) error {
 
 	go s.runner.Run(ctx)
 	go s.sendSamples()
+	go s.runScheduled(ctx)
+
+	unsubscribe := pubsub.Subscribe("midi", s.handleMIDI)
+	context.AfterFunc(ctx, unsubscribe)
 
 	s.PlayGraph(ZeroGraph())
 	return nil
 }
 
-func (s *Server) EvalScript(path string, force bool) (err error) {
+// EvalScript evaluates the script at path and plays the resulting
+// graph. Unless force is true, the script isn't evaluated if neither
+// it nor its dependencies have changed since the last evaluation.
+//
+// Evaluation is aborted when ctx is done, when it takes longer than
+// the evaluation timeout, or when CancelEval is called; the playing
+// graph is left untouched.
+func (s *Server) EvalScript(ctx context.Context, path string, force bool) (err error) {
 	defer func() {
 		if err != nil {
-			console.Log(console.Error, fmt.Sprintf("error evaluating %s", path), err.Error())
+			console.Log(console.Error, fmt.Sprintf("error evaluating %s", path), errorData(err))
 		}
 	}()
 
-	script, err := os.ReadFile(path)
+	s.mtx.RLock()
+	deps := s.scriptDeps
+	s.mtx.RUnlock()
+
+	hash, err := scriptHash(path, deps)
 	if err != nil {
 		return err
 	}
-	hash := sha256.Sum256(script)
 
 	s.mtx.RLock()
 	if !force && bytes.Equal(hash[:], s.lastFileHash[:]) {
@@ -134,20 +192,176 @@ func (s *Server) EvalScript(path string, force bool) (err error) {
 	}
 	s.mtx.RUnlock()
 
-	g, err := EvalScript(path)
+	ctx, cancel := s.evalContext(ctx)
+	defer cancel()
+
+	res, err := evalScriptFile(ctx, path)
+	if err == nil {
+		err = s.declareScenes(res.scenes)
+	}
+	g, deps := res.graph, res.deps
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if deps != nil || err == nil {
+		s.scriptDeps = deps
+	}
 	if err != nil {
 		fmt.Println("failed to eval script:", err)
 		return err
 	}
 
+	// rehash, as the script's dependencies may have changed.
+	if h, err := scriptHash(path, deps); err == nil {
+		hash = h
+	}
+	s.lastFileHash = hash
+
+	if err := s.PlayGraph(g); err != nil {
+		fmt.Println("failed to play script:", err)
+		return err
+	}
+	s.history.add(g, hash, filepath.Base(path))
+
+	return nil
+}
+
+// ScriptDependencies returns the files loaded by the last script
+// evaluated with EvalScript, other than the script itself.
+func (s *Server) ScriptDependencies() []string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return append([]string(nil), s.scriptDeps...)
+}
+
+// scriptHash returns a hash of the contents of the script at path and
+// of its dependencies. Dependencies that can't be read are skipped.
+func scriptHash(path string, deps []string) ([32]byte, error) {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	h := sha256.New()
+	h.Write(script)
+	for _, dep := range deps {
+		b, err := os.ReadFile(dep)
+		if err != nil {
+			continue
+		}
+		h.Write([]byte(dep))
+		h.Write(b)
+	}
+
+	var res [32]byte
+	copy(res[:], h.Sum(nil))
+	return res, nil
+}
+
+// EvalString evaluates src in the namespace ns (see EvalString). If
+// the evaluated forms play anything, the resulting graph replaces the
+// playing graph; otherwise the playing graph is left untouched, so
+// that redefining a single function doesn't disturb the output.
+// Evaluation is aborted as for EvalScript.
+func (s *Server) EvalString(ctx context.Context, src, ns string) (*EvalResult, error) {
+	ctx, cancel := s.evalContext(ctx)
+	defer cancel()
+
+	res, err := EvalStringContext(ctx, src, ns)
+	return s.handleEvalResult(res, err, src)
+}
+
+// EvalRegion evaluates the forms in region of src (see EvalRegion),
+// playing the resulting graph as EvalString does.
+func (s *Server) EvalRegion(ctx context.Context, src string, region Region, ns string) (*EvalResult, error) {
+	ctx, cancel := s.evalContext(ctx)
+	defer cancel()
+
+	res, err := EvalRegionContext(ctx, src, region, ns)
+	if err != nil {
+		// the region may be invalid, so don't slice the source.
+		return s.handleEvalResult(res, err, "")
+	}
+	return s.handleEvalResult(res, nil, src[region.Start:region.End])
+}
+
+// SetEvalTimeout sets the maximum time an evaluation may take before
+// it is aborted. Zero means no limit.
+func (s *Server) SetEvalTimeout(timeout time.Duration) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	s.lastFileHash = hash
+	s.evalTimeout = timeout
+}
+
+// CancelEval aborts all evaluations in progress.
+func (s *Server) CancelEval() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
 
-	s.PlayGraph(g)
+	if s.cancelEvals != nil {
+		s.cancelEvals()
+		s.evalCtx, s.cancelEvals = nil, nil
+	}
+}
 
-	return nil
+// evalContext returns a context for an evaluation derived from ctx,
+// which is canceled after the evaluation timeout or by CancelEval.
+func (s *Server) evalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	s.mtx.Lock()
+	if s.evalCtx == nil {
+		s.evalCtx, s.cancelEvals = context.WithCancel(context.Background())
+	}
+	evalCtx := s.evalCtx
+	timeout := s.evalTimeout
+	s.mtx.Unlock()
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	stop := context.AfterFunc(evalCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+func (s *Server) handleEvalResult(res *EvalResult, err error, src string) (*EvalResult, error) {
+	if err == nil {
+		if err = s.declareScenes(res.scenes); err != nil {
+			res.Error = err.Error()
+		}
+	}
+	if err != nil {
+		console.Log(console.Error, "error evaluating source", errorData(err))
+		return res, err
+	}
+	if res.Graph != nil {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		if err := s.PlayGraph(res.Graph); err != nil {
+			console.Log(console.Error, "error playing graph", err.Error())
+			return res, err
+		}
+		s.history.add(res.Graph, sourceHash(src), fmt.Sprintf("eval in %s", res.Namespace))
+	}
+	return res, nil
+}
+
+// errorData returns the data to log to the console for an evaluation
+// error: the Diagnostic describing it if there is one, so that
+// consumers can locate the error in the source, or else its message.
+func errorData(err error) any {
+	var diag *Diagnostic
+	if errors.As(err, &diag) {
+		return diag
+	}
+	return err.Error()
 }
 
 func (s *Server) SetGain(gain float64) {
@@ -156,8 +370,15 @@ func (s *Server) SetGain(gain float64) {
 
 ////////////////////////////////////////////////////////////////////////////////
 
-func (s *Server) PlayGraph(g *graph.Graph) {
-	s.runner.SetGraph(g)
+// PlayGraph replaces the graph being played with g. If g can't be
+// played, the previous graph keeps playing and the error is returned.
+func (s *Server) PlayGraph(g *graph.Graph) error {
+	if err := s.runner.SetGraph(g); err != nil {
+		return err
+	}
+	cps, _ := graphCPS(g)
+	s.cps.Store(math.Float64bits(cps))
+	return nil
 }
 
 func (s *Server) sendSamples() {
@@ -200,6 +421,12 @@ func (s *Server) sendSamples() {
 		// publish samples
 		pubsub.Publish("samples", channelSamples)
 
+		s.samplePos.Add(int64(len(channelSamples[0])))
+		select {
+		case s.samplesSent <- struct{}{}:
+		default:
+		}
+
 		for _, smps := range channelSamples {
 			smps := smps
 			bufferpool.Put(&smps)
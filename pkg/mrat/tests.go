@@ -0,0 +1,233 @@
+package mrat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/glojurelang/glojure/pkg/glj"
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+)
+
+var (
+	testKW     = lang.NewKeyword("test")
+	lineKW     = lang.NewKeyword("line")
+	messageKW  = lang.NewKeyword("message")
+	expectedKW = lang.NewKeyword("expected")
+	actualKW   = lang.NewKeyword("actual")
+	passKW     = lang.NewKeyword("pass")
+	failKW     = lang.NewKeyword("fail")
+	errorKW    = lang.NewKeyword("error")
+)
+
+type (
+	// Test is a test defined with clojure.test's deftest in a test
+	// file.
+	Test struct {
+		// Name is the name of the test's var.
+		Name string
+		// File is the absolute path of the test file, and Line the
+		// line on which the test is defined.
+		File string
+		Line int
+
+		v *lang.Var
+	}
+
+	// TestResult is the result of running a Test.
+	TestResult struct {
+		// Assertions is the number of assertions made, including
+		// those that failed.
+		Assertions int
+		Failures   []TestFailure
+		// Output is everything printed by the test.
+		Output string
+	}
+
+	// TestFailure is a failed assertion, or an error thrown by a
+	// test outside of any assertion.
+	TestFailure struct {
+		Error bool
+		// Context is the description given by any enclosing testing
+		// forms.
+		Context  string
+		Message  string
+		Expected string
+		Actual   string
+	}
+)
+
+// String formats the failure as clojure.test reports it.
+func (f TestFailure) String() string {
+	var b strings.Builder
+	if f.Error {
+		b.WriteString("ERROR")
+	} else {
+		b.WriteString("FAIL")
+	}
+	if f.Context != "" {
+		fmt.Fprintf(&b, " %s", f.Context)
+	}
+	if f.Message != "" {
+		fmt.Fprintf(&b, ": %s", f.Message)
+	}
+	fmt.Fprintf(&b, "\nexpected: %s\n  actual: %s", f.Expected, f.Actual)
+	return b.String()
+}
+
+// LoadTests loads the test file at path and returns the tests it
+// defines, in the order they're defined. The file must declare a
+// namespace. As for EvalScript, libs are loaded from the file's
+// directory and reloaded so that the latest versions are tested.
+func LoadTests(ctx context.Context, path string) ([]*Test, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	src, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	ns := declaredNamespace(string(src))
+	if ns == "" {
+		return nil, fmt.Errorf("%s: test file must declare a namespace", path)
+	}
+
+	return runEval(ctx, absPath, func(ctx context.Context) (tests []*Test, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				tests, err = nil, newDiagnostic(r, absPath)
+			}
+		}()
+
+		require := glj.Var("clojure.core", "require")
+		require.Invoke(glj.Read("mrat.core"))
+		startScript(ctx)
+
+		pushScriptBindings(newGraphAtom(ctx), &cancelWriter{ctx: ctx, w: &consoleWriter{}})
+		defer popScriptBindings()
+
+		addScriptDir(filepath.Dir(absPath))
+		unloadScriptLibs()
+		require.Invoke(glj.Read(strings.TrimSuffix(filepath.Base(absPath), ".glj")), lang.NewKeyword("reload"))
+
+		interns := glj.Var("clojure.core", "ns-interns").Invoke(lang.NewSymbol(ns))
+		for s := lang.Seq(interns); s != nil; s = s.Next() {
+			kv := s.First().(*lang.MapEntry)
+			v := kv.Val().(*lang.Var)
+			meta := v.Meta()
+			if meta == nil || meta.ValAt(testKW) == nil {
+				continue
+			}
+			line, _ := meta.ValAt(lineKW).(int)
+			tests = append(tests, &Test{
+				Name: kv.Key().(*lang.Symbol).Name(),
+				File: absPath,
+				Line: line,
+				v:    v,
+			})
+		}
+		sort.Slice(tests, func(i, j int) bool {
+			if tests[i].Line != tests[j].Line {
+				return tests[i].Line < tests[j].Line
+			}
+			return tests[i].Name < tests[j].Name
+		})
+		return tests, nil
+	})
+}
+
+// Run runs the test, with any fixtures its namespace uses. Failed
+// assertions are reported in the result; the returned error is
+// non-nil only if the test couldn't be run, e.g. because ctx is done.
+func (t *Test) Run(ctx context.Context) (*TestResult, error) {
+	return runEval(ctx, t.File, func(ctx context.Context) (res *TestResult, err error) {
+		res = &TestResult{}
+		var out strings.Builder
+		defer func() {
+			if r := recover(); r != nil {
+				err = newDiagnostic(r, t.File)
+			}
+			res.Output = out.String()
+		}()
+
+		w := &cancelWriter{ctx: ctx, w: &out}
+		pushScriptBindings(newGraphAtom(ctx), w)
+		defer popScriptBindings()
+
+		lang.PushThreadBindings(lang.NewMap(
+			glj.Var("clojure.test", "report"), lang.NewFnFunc(func(args ...any) any {
+				res.record(args[0])
+				return nil
+			}),
+			glj.Var("clojure.test", "*test-out*"), w,
+		))
+		defer lang.PopThreadBindings()
+
+		startScript(ctx)
+		glj.Var("clojure.test", "test-vars").Invoke(lang.NewVector(t.v))
+		return res, nil
+	})
+}
+
+// record records a clojure.test report.
+func (r *TestResult) record(report any) {
+	m, ok := report.(lang.ILookup)
+	if !ok {
+		return
+	}
+	typ := m.ValAt(typeKW)
+	switch typ {
+	case passKW:
+		r.Assertions++
+		return
+	case failKW, errorKW:
+	default:
+		return
+	}
+	if typ == failKW {
+		r.Assertions++
+	}
+
+	f := TestFailure{
+		Error:    typ == errorKW,
+		Context:  glj.Var("clojure.test", "testing-contexts-str").Invoke().(string),
+		Expected: lang.PrintString(m.ValAt(expectedKW)),
+	}
+	f.Message, _ = m.ValAt(messageKW).(string)
+	if err, ok := m.ValAt(actualKW).(error); ok {
+		f.Actual = err.Error()
+	} else {
+		f.Actual = lang.PrintString(m.ValAt(actualKW))
+	}
+	r.Failures = append(r.Failures, f)
+}
+
+// RenderScript evaluates the script at path and renders seconds of the
+// graph it plays offline.
+func RenderScript(ctx context.Context, path string, seconds float64) (*audiotest.Audio, error) {
+	g, _, err := EvalScriptContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return audiotest.RenderContext(ctx, g, seconds)
+}
+
+// RenderExpr evaluates the ugen expression expr in DefaultNamespace,
+// plays it, and renders seconds of the result offline. expr may also
+// be a vector of expressions, one per channel.
+func RenderExpr(ctx context.Context, expr string, seconds float64) (*audiotest.Audio, error) {
+	res, err := EvalStringContext(ctx, "(play "+expr+"\n)", DefaultNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if res.Graph == nil {
+		return nil, fmt.Errorf("expression %q plays nothing", expr)
+	}
+	return audiotest.RenderContext(ctx, res.Graph, seconds)
+}
@@ -0,0 +1,85 @@
+package mrat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunTests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tests_test_file.glj")
+	src := `(ns tests-test-file
+  (:use [mrat.core])
+  (:require [clojure.test :refer [deftest is testing]]
+            [mrat.test :refer :all]))
+
+(deftest passing
+  (println "rendering")
+  (let [a (render {:dur 0.1} (sin 440))]
+    (assert-peak a 0.9 1)
+    (is (< (abs (dc a)) 0.05))))
+
+(deftest failing
+  (testing "a quiet tone"
+    (assert-rms (render {:dur 0.1} (* 0.1 (sin 440))) 0.5 1)))
+
+(deftest throwing
+  (throw (ex-info "boom" {})))
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	tests, err := LoadTests(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, test := range tests {
+		names = append(names, test.Name)
+	}
+	if got := strings.Join(names, " "); got != "passing failing throwing" {
+		t.Fatalf("got tests %q, want them in definition order", got)
+	}
+
+	res, err := tests[0].Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Assertions != 2 || len(res.Failures) != 0 {
+		t.Errorf("got %d assertions and failures %v, want 2 passing", res.Assertions, res.Failures)
+	}
+	if res.Output != "rendering\n" {
+		t.Errorf("got output %q", res.Output)
+	}
+
+	res, err = tests[1].Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Failures) != 1 {
+		t.Fatalf("got failures %v, want 1", res.Failures)
+	}
+	f := res.Failures[0]
+	if f.Error || f.Context != "a quiet tone" || f.Message != "rms out of range" || f.Expected != "(<= 0.5 rms 1)" || !strings.HasPrefix(f.Actual, "0.07") {
+		t.Errorf("got failure %+v", f)
+	}
+
+	res, err = tests[2].Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Failures) != 1 || !res.Failures[0].Error || !strings.Contains(res.Failures[0].Actual, "boom") {
+		t.Errorf("got failures %v, want an error", res.Failures)
+	}
+
+	if err := os.WriteFile(path, []byte("(deftest x)"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTests(ctx, path); err == nil {
+		t.Error("expected an error loading a test file without a namespace")
+	}
+}
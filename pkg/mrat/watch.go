@@ -3,24 +3,81 @@ package mrat
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+// watchDebounce is how long to wait after a change to a watched file
+// before re-evaluating, so that a burst of events from a single save
+// triggers a single evaluation.
+const watchDebounce = 100 * time.Millisecond
+
+// watchFile evaluates the script at path, then re-evaluates it
+// whenever it or any of its dependencies change.
+//
+// The directories containing the files are watched rather than the
+// files themselves, so that saves that replace a file by renaming a
+// new one over it are seen.
 func watchFile(ctx context.Context, path string, srv *Server) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return fmt.Errorf("failed to create watcher: %w", err)
 	}
-	if err := watcher.Add(path); err != nil {
-		return fmt.Errorf("failed to add path to watcher: %w", err)
+	defer watcher.Close()
+
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	updateWatches := func() {
+		files = map[string]bool{path: true}
+		for _, dep := range srv.ScriptDependencies() {
+			files[dep] = true
+		}
+
+		newDirs := map[string]bool{}
+		for file := range files {
+			newDirs[filepath.Dir(file)] = true
+		}
+		for dir := range newDirs {
+			if dirs[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				fmt.Println("failed to add path to watcher:", err)
+				delete(newDirs, dir)
+			}
+		}
+		for dir := range dirs {
+			if !newDirs[dir] {
+				watcher.Remove(dir)
+			}
+		}
+		dirs = newDirs
 	}
 
-	defer watcher.Close()
+	evalScript := func(force bool) {
+		if err := srv.EvalScript(ctx, path, force); err != nil {
+			fmt.Println("failed to eval script:", err)
+		}
+		updateWatches()
+	}
 
-	if err := srv.EvalScript(path, true); err != nil {
-		fmt.Println("failed to eval script:", err)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to add path to watcher: %w", err)
 	}
+	dirs[filepath.Dir(path)] = true
+
+	evalScript(true)
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
 
 	for {
 		select {
@@ -30,11 +87,14 @@ func watchFile(ctx context.Context, path string, srv *Server) error {
 			if !ok {
 				return nil
 			}
-			if evt.Has(fsnotify.Write) {
-				if err := srv.EvalScript(path, false); err != nil {
-					fmt.Println("failed to eval script:", err)
-				}
+			if evt.Op == fsnotify.Chmod || !files[filepath.Clean(evt.Name)] {
+				continue
 			}
+			// a rename or remove is usually followed by the creation
+			// of the new file; wait for things to settle.
+			debounce.Reset(watchDebounce)
+		case <-debounce.C:
+			evalScript(false)
 		case err, ok := <-watcher.Errors:
 			if !ok {
 				return fmt.Errorf("watcher stopped")
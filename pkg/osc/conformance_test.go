@@ -0,0 +1,55 @@
+package osc
+
+import (
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+	"github.com/jfhamlin/muscrat/pkg/wavtabs"
+)
+
+func TestConformance(t *testing.T) {
+	inputs := map[string]ugentest.Signal{
+		"w":      ugentest.Const(220),
+		"phase":  ugentest.Const(0.1),
+		"sync":   ugentest.Const(0),
+		"dc":     ugentest.Const(0.5),
+		"iphase": ugentest.Const(0.25),
+	}
+	ctors := []struct {
+		name string
+		new  func(...ugen.Option) ugen.UGen
+	}{
+		{"sine", NewSine},
+		{"saw", NewSaw},
+		{"pulse", NewPulse},
+		{"tri", NewTri},
+		{"phasor", NewPhasor},
+		{"lfsaw", NewLFSaw},
+		{"lfpulse", NewLFPulse},
+	}
+	var specs []ugentest.Spec
+	for _, c := range ctors {
+		specs = append(specs, ugentest.Spec{
+			Name:   c.name,
+			New:    func() ugen.UGen { return c.new() },
+			Inputs: inputs,
+		})
+	}
+
+	stack, err := wavtabs.NewHarmonicStack([][]float64{{1}, {1, 0.5, 0.25}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs = append(specs, ugentest.Spec{
+		Name: "wavetable",
+		New:  func() ugen.UGen { return NewWavetable(stack) },
+		Inputs: map[string]ugentest.Signal{
+			"w":      ugentest.Const(220),
+			"pos":    ugentest.Const(0.5),
+			"phase":  ugentest.Const(0.1),
+			"iphase": ugentest.Const(0.25),
+		},
+	})
+	ugentest.Run(t, specs...)
+}
@@ -0,0 +1,41 @@
+package osc
+
+import (
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/audiotest/golden"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+// TestGolden checks the output of the oscillators against reference
+// files in testdata/golden. After an intended change to how an
+// oscillator sounds, regenerate them with go test -update.
+func TestGolden(t *testing.T) {
+	const n = golden.DefaultSamples
+	glide := make([]float64, n)
+	for i := range glide {
+		glide[i] = 110 * (1 + 3*float64(i)/n)
+	}
+
+	tests := []struct {
+		name   string
+		ugen   ugen.UGen
+		inputs map[string][]float64
+	}{
+		{"sine", NewSine(), map[string][]float64{"w": {440}}},
+		{"sine-fm", NewSine(), map[string][]float64{"w": {440}, "phase": audiotest.Sine(110, n)}},
+		{"saw", NewSaw(), map[string][]float64{"w": glide}},
+		{"saw-sync", NewSaw(), map[string][]float64{"w": {440}, "sync": audiotest.Sine(150, n)}},
+		{"pulse", NewPulse(), map[string][]float64{"w": glide, "dc": {0.25}}},
+		{"tri", NewTri(), map[string][]float64{"w": glide}},
+		{"phasor", NewPhasor(), map[string][]float64{"w": {440}, "iphase": {0.5}}},
+		{"lfsaw", NewLFSaw(), map[string][]float64{"w": {440}}},
+		{"lfpulse", NewLFPulse(), map[string][]float64{"w": {440}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			golden.UGen(t, test.name, test.ugen, test.inputs)
+		})
+	}
+}
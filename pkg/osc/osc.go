@@ -61,15 +61,15 @@ func (o *Osc) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 	if !o.initialized {
 		o.initialized = true
 		initialW := 440.0
-		if len(ws) > 0 {
+		if len(ws) > 0 && isFinite(ws[0]) {
 			initialW = ws[0]
 		}
-		if len(iphases) > 0 {
+		if len(iphases) > 0 && isFinite(iphases[0]) {
 			o.initialPhase = iphases[0]
 			o.phase = o.initialPhase - (initialW / float64(cfg.SampleRateHz))
 			o.lastSamplePhase = o.initialPhase - (initialW / float64(cfg.SampleRateHz))
 		}
-		if len(phases) > 0 {
+		if len(phases) > 0 && isFinite(phases[0]) {
 			o.lastSamplePhase += phases[0]
 		}
 		mod1(&o.initialPhase)
@@ -84,18 +84,24 @@ func (o *Osc) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 
 	// TODO: pull all the conditional logic out of the loop
 
+	// NaN or infinite inputs are ignored, holding the phase, so that
+	// a bad value upstream can't leave the oscillator stuck.
+
 	for i := range out {
 		dc := o.options.DefaultDutyCycle
-		if len(dcs) > 0 {
+		if len(dcs) > 0 && isFinite(dcs[i]) {
 			dc = dcs[i]
 		}
 		w := 440.0 // default frequency
 		if len(ws) > 0 {
 			w = ws[i]
+			if !isFinite(w) {
+				w = 0
+			}
 		}
 
 		samplePhase := phase
-		if len(phases) > 0 {
+		if len(phases) > 0 && isFinite(phases[i]) {
 			// phase is an offset in [0, 1)
 			samplePhase += phases[i]
 			mod1(&samplePhase)
@@ -109,7 +115,7 @@ func (o *Osc) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
 		mod1(&phase)
 
 		// sync on the falling edge of the sync input if present
-		if len(syncs) > 0 {
+		if len(syncs) > 0 && isFinite(syncs[i]) {
 			if syncs[i] < lastSync {
 				phase = 0.0
 			}
@@ -129,3 +135,7 @@ func (f SamplerFunc) Sample(phase, dPhase, dutyCycle float64) float64 {
 func mod1(x *float64) {
 	*x -= math.Floor(*x)
 }
+
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
@@ -1,8 +1,6 @@
 package osc
 
 import (
-	"math"
-
 	"github.com/jfhamlin/muscrat/pkg/ugen"
 )
 
@@ -11,5 +9,5 @@ func NewPhasor(opts ...ugen.Option) ugen.UGen {
 }
 
 func samplePhasor(phase, dPhase, dutyCycle float64) float64 {
-	return math.Max(0, math.Min(1, phase/dutyCycle))
+	return dcPhase(phase, dutyCycle)
 }
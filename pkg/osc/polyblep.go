@@ -67,5 +67,10 @@ func sampleTri(phase, dPhase, dutyCycle float64) float64 {
 }
 
 func dcPhase(phase, dc float64) float64 {
+	if phase == 0 {
+		// the start of the cycle, whatever the duty cycle. with a
+		// duty cycle of zero, phase/dc would be NaN.
+		return 0
+	}
 	return math.Max(0, math.Min(1, phase/dc))
 }
@@ -32,8 +32,7 @@ func NewSine(opts ...ugen.Option) ugen.UGen {
 }
 
 func sampleSine(phase, dPhase, dutyCycle float64) float64 {
-	phase /= dutyCycle
-	phase = math.Max(0, math.Min(1, phase))
+	phase = dcPhase(phase, dutyCycle)
 
 	x := phase - math.Floor(phase)
 	x = x * float64(sineTableSize)
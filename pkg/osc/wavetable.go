@@ -0,0 +1,78 @@
+package osc
+
+import (
+	"context"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/wavtabs"
+)
+
+const (
+	// blSampleRate is the sample rate for which wavtabs band-limits
+	// its tables. Frequencies are scaled to it, so that no harmonics
+	// alias at lower sample rates.
+	blSampleRate = 44100
+)
+
+type (
+	// Wavetable is an oscillator that scans across the frames of a
+	// wavetable stack.
+	Wavetable struct {
+		stack *wavtabs.Stack
+
+		initialized bool
+		phase       float64
+		pos         float64
+	}
+)
+
+// NewWavetable returns an oscillator that plays the frames of s. Its
+// inputs are the frequency, "w"; the position across the frames, in
+// [0, 1], "pos"; a phase offset, "phase"; and the initial phase,
+// "iphase".
+func NewWavetable(s *wavtabs.Stack) ugen.UGen {
+	return &Wavetable{stack: s}
+}
+
+func (wt *Wavetable) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	ws := cfg.InputSamples["w"]
+	poss := cfg.InputSamples["pos"]
+	phases := cfg.InputSamples["phase"]
+	iphases := cfg.InputSamples["iphase"]
+
+	if !wt.initialized {
+		wt.initialized = true
+		if len(iphases) > 0 && isFinite(iphases[0]) {
+			wt.phase = iphases[0]
+			mod1(&wt.phase)
+		}
+	}
+
+	sampleRate := float64(cfg.SampleRateHz)
+	phase, pos := wt.phase, wt.pos
+	for i := range out {
+		// NaN or infinite inputs are ignored, as in Osc.
+		w := 440.0
+		if len(ws) > 0 {
+			w = ws[i]
+			if !isFinite(w) {
+				w = 0
+			}
+		}
+		if len(poss) > 0 && isFinite(poss[i]) {
+			pos = poss[i]
+		}
+		samplePhase := phase
+		if len(phases) > 0 && isFinite(phases[i]) {
+			samplePhase += phases[i]
+			mod1(&samplePhase)
+		}
+
+		out[i] = wt.stack.HermiteBL(math.Abs(w)*blSampleRate/sampleRate, pos, samplePhase)
+
+		phase += w / sampleRate
+		mod1(&phase)
+	}
+	wt.phase, wt.pos = phase, pos
+}
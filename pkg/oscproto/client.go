@@ -0,0 +1,34 @@
+package oscproto
+
+import "net"
+
+type (
+	// Client sends OSC packets to a UDP address.
+	Client struct {
+		conn net.Conn
+	}
+)
+
+// Dial returns a client sending packets to the UDP address addr.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Send sends a packet.
+func (c *Client) Send(p Packet) error {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(data)
+	return err
+}
+
+// Close closes the client's connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,78 @@
+package oscproto
+
+import "strings"
+
+// Match reports whether address matches the OSC address pattern. In
+// a pattern, '?' matches any single character and '*' any sequence of
+// characters, other than '/'; '[abc]', '[a-z]' and '[!a-z]' match a
+// character in or not in a set; and '{foo,bar}' matches either of the
+// comma-separated strings.
+func Match(pattern, address string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// collapse runs of stars, then try each split up to the
+			// next '/'.
+			pattern = strings.TrimLeft(pattern, "*")
+			for i := 0; i <= len(address); i++ {
+				if Match(pattern, address[i:]) {
+					return true
+				}
+				if i < len(address) && address[i] == '/' {
+					break
+				}
+			}
+			return false
+		case '?':
+			if len(address) == 0 || address[0] == '/' {
+				return false
+			}
+		case '[':
+			end := strings.IndexByte(pattern, ']')
+			if end < 0 || len(address) == 0 || !matchSet(pattern[1:end], address[0]) {
+				return false
+			}
+			pattern, address = pattern[end+1:], address[1:]
+			continue
+		case '{':
+			end := strings.IndexByte(pattern, '}')
+			if end < 0 {
+				return false
+			}
+			for _, alt := range strings.Split(pattern[1:end], ",") {
+				if strings.HasPrefix(address, alt) && Match(pattern[end+1:], address[len(alt):]) {
+					return true
+				}
+			}
+			return false
+		default:
+			if len(address) == 0 || address[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, address = pattern[1:], address[1:]
+	}
+	return len(address) == 0
+}
+
+// matchSet reports whether c is matched by the contents of a '[...]'
+// pattern.
+func matchSet(set string, c byte) bool {
+	negate := strings.HasPrefix(set, "!")
+	if negate {
+		set = set[1:]
+	}
+	for i := 0; i < len(set); i++ {
+		if i+2 < len(set) && set[i+1] == '-' {
+			if set[i] <= c && c <= set[i+2] {
+				return !negate
+			}
+			i += 2
+			continue
+		}
+		if set[i] == c {
+			return !negate
+		}
+	}
+	return negate
+}
@@ -0,0 +1,150 @@
+package oscproto
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	packets := []Packet{
+		NewMessage("/empty"),
+		NewMessage("/args", int32(-3), float32(0.5), "hello", []byte{1, 2, 3}, int64(1<<40),
+			2.25, TimeTag(42), true, false, nil, Impulse{}, Char('x'), RGBA{1, 2, 3, 4}, MIDI{0, 0x90, 60, 100}),
+		&Bundle{
+			Time: NewTimeTag(time.Unix(1700000000, 500000000)),
+			Elements: []Packet{
+				NewMessage("/a", int32(1)),
+				&Bundle{Time: Immediately, Elements: []Packet{NewMessage("/b", "c")}},
+			},
+		},
+	}
+	for _, p := range packets {
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data)%4 != 0 {
+			t.Errorf("%v: encoded to %d bytes, not a multiple of 4", p, len(data))
+		}
+		got, err := Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("round trip of %v gave %v", p, got)
+		}
+	}
+
+	// ints are sent as int32 where possible.
+	data, _ := NewMessage("/i", 7, 1<<40).MarshalBinary()
+	got, _ := Parse(data)
+	if args := got.(*Message).Args; args[0] != int32(7) || args[1] != int64(1<<40) {
+		t.Errorf("unexpected int arguments %#v", args)
+	}
+
+	for _, data := range [][]byte{nil, []byte("/abc"), []byte("/ab\x00,z\x00\x00"), []byte("#bundle\x00")} {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("expected an error parsing %q", data)
+		}
+	}
+}
+
+func TestTimeTag(t *testing.T) {
+	now := time.Now()
+	if d := NewTimeTag(now).Time().Sub(now); d < -time.Microsecond || d > time.Microsecond {
+		t.Errorf("time tag round trip off by %s", d)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, address string
+		want             bool
+	}{
+		{"/fader/1", "/fader/1", true},
+		{"/fader/1", "/fader/10", false},
+		{"/fader/*", "/fader/10", true},
+		{"/fader/*", "/fader/1/x", false},
+		{"/*/1", "/fader/1", true},
+		{"/fader/?", "/fader/1", true},
+		{"/fader/?", "/fader/12", false},
+		{"/fader/[0-9]", "/fader/5", true},
+		{"/fader/[!0-9]", "/fader/5", false},
+		{"/fader/[abc]", "/fader/b", true},
+		{"/{fader,knob}/1", "/knob/1", true},
+		{"/{fader,knob}/1", "/button/1", false},
+		{"/knob/*", "/knob/", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.address); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type received struct {
+		msg *Message
+		at  time.Time
+	}
+	msgs := make(chan received, 10)
+	srv := NewServer()
+	srv.Handle("/fader/*", func(msg *Message) {
+		msgs <- received{msg: msg, at: time.Now()}
+	})
+	go srv.Serve(ctx, conn)
+
+	client, err := Dial(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.Send(NewMessage("/other", float32(1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Send(NewMessage("/fader/1", float32(0.25))); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-msgs:
+		if r.msg.Address != "/fader/1" || r.msg.Floats()[0] != 0.25 {
+			t.Errorf("unexpected message %v", r.msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+
+	// a bundle is dispatched at its time.
+	start := time.Now()
+	at := start.Add(100 * time.Millisecond)
+	err = client.Send(&Bundle{
+		Time:     NewTimeTag(at),
+		Elements: []Packet{NewMessage("/fader/2", float32(1))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-msgs:
+		if r.msg.Address != "/fader/2" {
+			t.Errorf("unexpected message %v", r.msg)
+		}
+		if r.at.Before(at.Add(-time.Millisecond)) {
+			t.Errorf("bundle dispatched %s early", at.Sub(r.at))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for bundle")
+	}
+}
@@ -0,0 +1,385 @@
+// Package oscproto implements the Open Sound Control 1.0 protocol:
+// encoding and decoding of messages and bundles, address pattern
+// matching, and a UDP server and client.
+package oscproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type (
+	// Packet is an OSC message or bundle.
+	Packet interface {
+		// MarshalBinary encodes the packet for transmission.
+		MarshalBinary() ([]byte, error)
+
+		packet()
+	}
+
+	// Message is an OSC message. Arguments are decoded as int32
+	// ('i'), float32 ('f'), string ('s' and 'S'), []byte ('b'), int64
+	// ('h'), float64 ('d'), TimeTag ('t'), bool ('T' and 'F'), nil
+	// ('N'), Impulse ('I'), Char ('c'), RGBA ('r') and MIDI ('m').
+	//
+	// When encoding, int and uint values are sent as int32 if they
+	// fit, and as int64 otherwise.
+	Message struct {
+		Address string
+		Args    []any
+	}
+
+	// Bundle is an OSC bundle: a set of packets to be handled
+	// atomically at a given time.
+	Bundle struct {
+		Time     TimeTag
+		Elements []Packet
+	}
+
+	// TimeTag is an OSC time tag: an NTP timestamp, with seconds
+	// since 1900 in the high 32 bits and fractions of a second in
+	// the low 32 bits.
+	TimeTag uint64
+
+	// Impulse is the OSC "infinitum" or impulse argument, which has
+	// no value.
+	Impulse struct{}
+
+	// Char is an ASCII character argument.
+	Char rune
+
+	// RGBA is a 32-bit color argument.
+	RGBA [4]byte
+
+	// MIDI is a MIDI message argument: port ID, status byte and two
+	// data bytes.
+	MIDI [4]byte
+)
+
+const (
+	// Immediately is the time tag of a bundle to be handled as soon
+	// as it's received.
+	Immediately TimeTag = 1
+
+	bundleTag = "#bundle"
+
+	// ntpEpochOffset is the number of seconds between the NTP epoch
+	// (1900) and the Unix epoch (1970).
+	ntpEpochOffset = 2208988800
+)
+
+var (
+	errShortPacket = errors.New("osc: packet too short")
+)
+
+func (*Message) packet() {}
+func (*Bundle) packet()  {}
+
+// NewMessage returns a message with the given address and arguments.
+func NewMessage(address string, args ...any) *Message {
+	return &Message{Address: address, Args: args}
+}
+
+// NewTimeTag returns the time tag for t.
+func NewTimeTag(t time.Time) TimeTag {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return TimeTag(secs<<32 | frac)
+}
+
+// Time returns the time represented by the time tag.
+func (t TimeTag) Time() time.Time {
+	secs := int64(t>>32) - ntpEpochOffset
+	nsecs := int64((uint64(t) & math.MaxUint32) * uint64(time.Second) >> 32)
+	return time.Unix(secs, nsecs)
+}
+
+// String implements fmt.Stringer.
+func (m *Message) String() string {
+	return fmt.Sprintf("%s %v", m.Address, m.Args)
+}
+
+// Floats returns the numeric arguments of the message as float64s,
+// skipping any others. Booleans are 0 or 1.
+func (m *Message) Floats() []float64 {
+	res := make([]float64, 0, len(m.Args))
+	for _, arg := range m.Args {
+		switch arg := arg.(type) {
+		case int32:
+			res = append(res, float64(arg))
+		case int64:
+			res = append(res, float64(arg))
+		case float32:
+			res = append(res, float64(arg))
+		case float64:
+			res = append(res, arg)
+		case bool:
+			if arg {
+				res = append(res, 1)
+			} else {
+				res = append(res, 0)
+			}
+		}
+	}
+	return res
+}
+
+// MarshalBinary implements Packet.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	if len(m.Address) == 0 || m.Address[0] != '/' {
+		return nil, fmt.Errorf("osc: invalid address %q", m.Address)
+	}
+
+	var buf bytes.Buffer
+	writeString(&buf, m.Address)
+
+	tags := []byte{','}
+	var data bytes.Buffer
+	for _, arg := range m.Args {
+		switch arg := arg.(type) {
+		case int32:
+			tags = append(tags, 'i')
+			binary.Write(&data, binary.BigEndian, arg)
+		case int:
+			tags = appendInt(tags, &data, int64(arg))
+		case int64:
+			tags = append(tags, 'h')
+			binary.Write(&data, binary.BigEndian, arg)
+		case uint:
+			if uint64(arg) > math.MaxInt64 {
+				return nil, fmt.Errorf("osc: integer argument %d out of range", arg)
+			}
+			tags = appendInt(tags, &data, int64(arg))
+		case float32:
+			tags = append(tags, 'f')
+			binary.Write(&data, binary.BigEndian, arg)
+		case float64:
+			tags = append(tags, 'd')
+			binary.Write(&data, binary.BigEndian, arg)
+		case string:
+			tags = append(tags, 's')
+			writeString(&data, arg)
+		case []byte:
+			tags = append(tags, 'b')
+			binary.Write(&data, binary.BigEndian, int32(len(arg)))
+			data.Write(arg)
+			data.Write(make([]byte, pad(len(arg))-len(arg)))
+		case TimeTag:
+			tags = append(tags, 't')
+			binary.Write(&data, binary.BigEndian, uint64(arg))
+		case bool:
+			if arg {
+				tags = append(tags, 'T')
+			} else {
+				tags = append(tags, 'F')
+			}
+		case nil:
+			tags = append(tags, 'N')
+		case Impulse:
+			tags = append(tags, 'I')
+		case Char:
+			tags = append(tags, 'c')
+			binary.Write(&data, binary.BigEndian, int32(arg))
+		case RGBA:
+			tags = append(tags, 'r')
+			data.Write(arg[:])
+		case MIDI:
+			tags = append(tags, 'm')
+			data.Write(arg[:])
+		default:
+			return nil, fmt.Errorf("osc: unsupported argument type %T", arg)
+		}
+	}
+	writeString(&buf, string(tags))
+	buf.Write(data.Bytes())
+	return buf.Bytes(), nil
+}
+
+// MarshalBinary implements Packet.
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	writeString(&buf, bundleTag)
+	binary.Write(&buf, binary.BigEndian, uint64(b.Time))
+	for _, el := range b.Elements {
+		data, err := el.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		binary.Write(&buf, binary.BigEndian, int32(len(data)))
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// Parse decodes an OSC packet.
+func Parse(data []byte) (Packet, error) {
+	if len(data) == 0 || len(data)%4 != 0 {
+		return nil, fmt.Errorf("osc: invalid packet size %d", len(data))
+	}
+	switch data[0] {
+	case '/':
+		return parseMessage(data)
+	case '#':
+		return parseBundle(data)
+	default:
+		return nil, fmt.Errorf("osc: invalid packet starting with %q", data[0])
+	}
+}
+
+func parseMessage(data []byte) (*Message, error) {
+	addr, data, err := readString(data)
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{Address: addr}
+	if len(data) == 0 {
+		// some old implementations omit the type tag string when
+		// there are no arguments.
+		return msg, nil
+	}
+
+	tags, data, err := readString(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 || tags[0] != ',' {
+		return nil, fmt.Errorf("osc: invalid type tag string %q", tags)
+	}
+
+	for _, tag := range tags[1:] {
+		var arg any
+		switch tag {
+		case 'i', 'c', 'f', 'r', 'm':
+			if len(data) < 4 {
+				return nil, errShortPacket
+			}
+			bits := binary.BigEndian.Uint32(data)
+			switch tag {
+			case 'i':
+				arg = int32(bits)
+			case 'c':
+				arg = Char(bits)
+			case 'f':
+				arg = math.Float32frombits(bits)
+			case 'r':
+				arg = RGBA(data[:4])
+			case 'm':
+				arg = MIDI(data[:4])
+			}
+			data = data[4:]
+		case 'h', 'd', 't':
+			if len(data) < 8 {
+				return nil, errShortPacket
+			}
+			bits := binary.BigEndian.Uint64(data)
+			switch tag {
+			case 'h':
+				arg = int64(bits)
+			case 'd':
+				arg = math.Float64frombits(bits)
+			case 't':
+				arg = TimeTag(bits)
+			}
+			data = data[8:]
+		case 's', 'S':
+			arg, data, err = readString(data)
+			if err != nil {
+				return nil, err
+			}
+		case 'b':
+			if len(data) < 4 {
+				return nil, errShortPacket
+			}
+			n := int(binary.BigEndian.Uint32(data))
+			data = data[4:]
+			if n < 0 || pad(n) > len(data) {
+				return nil, errShortPacket
+			}
+			arg = append([]byte(nil), data[:n]...)
+			data = data[pad(n):]
+		case 'T':
+			arg = true
+		case 'F':
+			arg = false
+		case 'N':
+			arg = nil
+		case 'I':
+			arg = Impulse{}
+		default:
+			return nil, fmt.Errorf("osc: unsupported type tag %q", tag)
+		}
+		msg.Args = append(msg.Args, arg)
+	}
+	return msg, nil
+}
+
+func parseBundle(data []byte) (*Bundle, error) {
+	tag, data, err := readString(data)
+	if err != nil {
+		return nil, err
+	}
+	if tag != bundleTag {
+		return nil, fmt.Errorf("osc: invalid bundle tag %q", tag)
+	}
+	if len(data) < 8 {
+		return nil, errShortPacket
+	}
+	b := &Bundle{Time: TimeTag(binary.BigEndian.Uint64(data))}
+	data = data[8:]
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errShortPacket
+		}
+		n := int(binary.BigEndian.Uint32(data))
+		data = data[4:]
+		if n < 0 || n > len(data) {
+			return nil, errShortPacket
+		}
+		el, err := Parse(data[:n])
+		if err != nil {
+			return nil, err
+		}
+		b.Elements = append(b.Elements, el)
+		data = data[n:]
+	}
+	return b, nil
+}
+
+func appendInt(tags []byte, data *bytes.Buffer, i int64) []byte {
+	if i >= math.MinInt32 && i <= math.MaxInt32 {
+		binary.Write(data, binary.BigEndian, int32(i))
+		return append(tags, 'i')
+	}
+	binary.Write(data, binary.BigEndian, i)
+	return append(tags, 'h')
+}
+
+// writeString writes s as an OSC string: null-terminated and padded
+// with nulls to a multiple of four bytes.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.Write(make([]byte, pad(len(s)+1)-len(s)))
+}
+
+// readString reads an OSC string from data, returning it and the
+// remaining data.
+func readString(data []byte) (string, []byte, error) {
+	n := bytes.IndexByte(data, 0)
+	if n < 0 {
+		return "", nil, fmt.Errorf("osc: unterminated string")
+	}
+	end := pad(n + 1)
+	if end > len(data) {
+		return "", nil, errShortPacket
+	}
+	return string(data[:n]), data[end:], nil
+}
+
+// pad rounds n up to a multiple of four.
+func pad(n int) int {
+	return (n + 3) &^ 3
+}
@@ -0,0 +1,132 @@
+package oscproto
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+type (
+	// HandlerFunc handles an OSC message.
+	HandlerFunc func(msg *Message)
+
+	// Server dispatches the OSC messages it receives to handlers by
+	// address. Messages in bundles are dispatched at the bundle's
+	// time.
+	Server struct {
+		// ErrorLog, if set, is called with errors decoding packets.
+		ErrorLog func(err error)
+
+		handlers []handler
+		mtx      sync.RWMutex
+	}
+
+	handler struct {
+		pattern string
+		fn      HandlerFunc
+	}
+)
+
+// maxPacketSize is the largest UDP payload.
+const maxPacketSize = 65535
+
+// NewServer returns a server with no handlers.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Handle registers fn to handle messages to addresses matching the
+// OSC address pattern (see Match). If pattern is the empty string, fn
+// handles all messages. Every matching handler is called, in the
+// order they were registered.
+func (s *Server) Handle(pattern string, fn HandlerFunc) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.handlers = append(s.handlers, handler{pattern: pattern, fn: fn})
+}
+
+// ListenAndServe listens on the UDP address addr and serves OSC
+// packets until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, conn)
+}
+
+// Serve serves OSC packets received on conn until ctx is done. conn
+// is closed when Serve returns.
+func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		p, err := Parse(buf[:n])
+		if err != nil {
+			if s.ErrorLog != nil {
+				s.ErrorLog(err)
+			}
+			continue
+		}
+		s.Dispatch(ctx, p)
+	}
+}
+
+// Dispatch calls the handlers for the messages in p. Messages in a
+// bundle with a time tag in the future are dispatched at that time,
+// unless ctx is done by then.
+func (s *Server) Dispatch(ctx context.Context, p Packet) {
+	switch p := p.(type) {
+	case *Message:
+		s.dispatchMessage(p)
+	case *Bundle:
+		delay := time.Until(p.Time.Time())
+		if p.Time == Immediately || delay <= 0 {
+			for _, el := range p.Elements {
+				s.Dispatch(ctx, el)
+			}
+			return
+		}
+		time.AfterFunc(delay, func() {
+			if ctx.Err() != nil {
+				return
+			}
+			for _, el := range p.Elements {
+				s.Dispatch(ctx, el)
+			}
+		})
+	}
+}
+
+func (s *Server) dispatchMessage(msg *Message) {
+	s.mtx.RLock()
+	var fns []HandlerFunc
+	for _, h := range s.handlers {
+		if h.pattern == "" || Match(h.pattern, msg.Address) {
+			fns = append(fns, h.fn)
+		}
+	}
+	s.mtx.RUnlock()
+
+	for _, fn := range fns {
+		fn(msg)
+	}
+}
@@ -2,29 +2,39 @@ package pattern
 
 import (
 	"context"
-	"math/rand"
 
 	"github.com/jfhamlin/muscrat/pkg/ugen"
 )
 
-func NewChoose() ugen.UGen {
+// NewChoose returns a ugen that outputs one of its indexed inputs,
+// chosen at random each time its trigger input rises above zero.
+func NewChoose(opts ...ugen.Option) ugen.UGen {
+	o := ugen.DefaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	index := 0
 	lastTrig := 0.0
+	trigInput := ugen.Input{Name: "trigger"}
+	var vals [][]float64
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		trigs := cfg.InputSamples["trigger"]
-		vals := ugen.CollectIndexedInputs(cfg)
+		trigs := trigInput.Samples(cfg, len(out))
+		vals = ugen.AppendIndexedInputs(vals[:0], cfg)
 		if len(vals) == 0 {
 			return
 		}
 		// if index is out of bounds, resample
 		if index >= len(vals) {
-			index = rand.Intn(len(vals))
+			index = o.Rand.Intn(len(vals))
 		}
 		for i := range out {
 			if trigs[i] > 0.0 && lastTrig <= 0.0 {
-				index = rand.Intn(len(vals))
+				index = o.Rand.Intn(len(vals))
+			}
+			if v := vals[index]; len(v) > i {
+				out[i] = v[i]
 			}
-			out[i] = vals[index][i]
 			lastTrig = trigs[i]
 		}
 	})
@@ -0,0 +1,34 @@
+package pattern
+
+import (
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+)
+
+func TestConformance(t *testing.T) {
+	ugentest.Run(t,
+		ugentest.Spec{
+			Name: "choose",
+			New:  func() ugen.UGen { return NewChoose(ugen.WithSeed(1)) },
+			Inputs: map[string]ugentest.Signal{
+				"trigger": ugentest.Gate(10),
+				"$0":      ugentest.Const(1),
+				"$1":      ugentest.Const(1.1),
+				"$2":      ugentest.Const(1.2),
+			},
+		},
+		ugentest.Spec{
+			Name: "sequencer",
+			New:  NewSequencer,
+			Inputs: map[string]ugentest.Signal{
+				"trigger": ugentest.Gate(10),
+				"sync":    ugentest.Gate(1),
+				"$0":      ugentest.Const(1),
+				"$1":      ugentest.Const(1.1),
+				"$2":      ugentest.Const(1.2),
+			},
+		},
+	)
+}
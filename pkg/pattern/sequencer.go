@@ -6,19 +6,23 @@ import (
 	"github.com/jfhamlin/muscrat/pkg/ugen"
 )
 
+// NewSequencer returns a ugen that steps through its indexed inputs,
+// advancing each time its trigger input rises above zero and
+// returning to the first each time its sync input does.
 func NewSequencer() ugen.UGen {
 	index := 0
 	lastTrig := 1.0
 	lastSync := 1.0
+	trigInput := ugen.Input{Name: "trigger"}
+	syncInput := ugen.Input{Name: "sync"}
+	var vals [][]float64
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		trigs := cfg.InputSamples["trigger"]
-		syncs := cfg.InputSamples["sync"]
-		if len(syncs) == 0 {
-			syncs = ugen.Zeros
-		}
+		trigs := trigInput.Samples(cfg, len(out))
+		syncs := syncInput.Samples(cfg, len(out))
+		_ = trigs[len(out)-1]
 		_ = syncs[len(out)-1]
 
-		vals := ugen.CollectIndexedInputs(cfg)
+		vals = ugen.AppendIndexedInputs(vals[:0], cfg)
 		if len(vals) == 0 {
 			return
 		}
@@ -32,7 +36,9 @@ func NewSequencer() ugen.UGen {
 			if syncs[i] > 0 && lastSync <= 0 {
 				index = 0 // this case before or after the increment case?
 			}
-			out[i] = vals[index][i]
+			if v := vals[index]; len(v) > i {
+				out[i] = v[i]
+			}
 			lastTrig = trigs[i]
 			lastSync = syncs[i]
 		}
@@ -0,0 +1,147 @@
+package refdoc
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+const htmlStyle = `
+body { font-family: -apple-system, "Inter", sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; line-height: 1.5; color: #1b2636; }
+code, pre { font-family: ui-monospace, monospace; font-size: 0.9em; }
+pre { background: #f3f4f6; padding: 0.75em; overflow-x: auto; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #d1d5db; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+h3 { border-top: 1px solid #e5e7eb; padding-top: 1em; }
+.undocumented { color: #b45309; font-weight: bold; }
+`
+
+// WriteHTML writes the reference as a standalone HTML page.
+func (r *Reference) WriteHTML(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("<!DOCTYPE html>\n<!-- Code generated by cmd/mratdoc. DO NOT EDIT. -->\n")
+	fmt.Fprintf(&b, "<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s reference</title>\n", Namespace)
+	fmt.Fprintf(&b, "<style>%s</style>\n</head>\n<body>\n", htmlStyle)
+	fmt.Fprintf(&b, "<h1>%s reference</h1>\n", Namespace)
+	fmt.Fprintf(&b, "<p>%s</p>\n", r.htmlText(nil, r.summary()))
+
+	b.WriteString("<h2>Contents</h2>\n<ul>\n")
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a>:", g.Anchor, html.EscapeString(g.Name))
+		for i, e := range g.Entries {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, " %s", htmlLink(e))
+		}
+		b.WriteString("</li>\n")
+	}
+	if len(r.Undocumented)+len(r.UndocumentedArgs) > 0 {
+		b.WriteString("<li><a href=\"#undocumented\">Undocumented</a></li>\n")
+	}
+	b.WriteString("</ul>\n")
+
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "<h2 id=\"%s\">%s</h2>\n", g.Anchor, html.EscapeString(g.Name))
+		for _, e := range g.Entries {
+			r.writeHTMLEntry(&b, e)
+		}
+	}
+
+	if len(r.Undocumented)+len(r.UndocumentedArgs) > 0 {
+		b.WriteString("<h2 id=\"undocumented\">Undocumented</h2>\n")
+		if len(r.Undocumented) > 0 {
+			b.WriteString("<p>These symbols have no docstring:</p>\n")
+			writeHTMLList(&b, r.Undocumented)
+		}
+		if len(r.UndocumentedArgs) > 0 {
+			b.WriteString("<p>These ugens have undocumented arguments:</p>\n")
+			writeHTMLList(&b, r.UndocumentedArgs)
+		}
+	}
+
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *Reference) writeHTMLEntry(b *strings.Builder, e *Entry) {
+	fmt.Fprintf(b, "<h3 id=\"%s\"><code>%s</code></h3>\n", e.Anchor, html.EscapeString(e.Name))
+
+	if usage := e.Usage(); len(usage) > 0 {
+		fmt.Fprintf(b, "<pre><code>%s</code></pre>\n", html.EscapeString(strings.Join(usage, "\n")))
+	}
+
+	if strings.TrimSpace(e.Doc) == "" {
+		b.WriteString("<p class=\"undocumented\">Undocumented.</p>\n")
+	}
+	inList := false
+	for _, blk := range blocks(e.Doc) {
+		if inList && blk.kind != listItemBlock {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+		switch blk.kind {
+		case codeBlock:
+			fmt.Fprintf(b, "<pre><code>%s</code></pre>\n", html.EscapeString(blk.text))
+		case headingBlock:
+			fmt.Fprintf(b, "<h4>%s</h4>\n", r.htmlText(e, blk.text))
+		case listItemBlock:
+			if !inList {
+				b.WriteString("<ul>\n")
+				inList = true
+			}
+			fmt.Fprintf(b, "<li>%s</li>\n", r.htmlText(e, blk.text))
+		default:
+			fmt.Fprintf(b, "<p>%s</p>\n", r.htmlText(e, blk.text))
+		}
+	}
+	if inList {
+		b.WriteString("</ul>\n")
+	}
+
+	if len(e.UGenArgs) == 0 {
+		return
+	}
+	b.WriteString("<table>\n<tr><th>Argument</th><th>Default</th><th>Description</th></tr>\n")
+	for _, arg := range e.UGenArgs {
+		doc := "<span class=\"undocumented\">Undocumented.</span>"
+		if strings.TrimSpace(arg.Doc) != "" {
+			doc = r.htmlText(e, arg.Doc)
+		}
+		fmt.Fprintf(b, "<tr><td><code>%s</code></td><td><code>%s</code></td><td>%s</td></tr>\n",
+			html.EscapeString(arg.Name), html.EscapeString(FormatDefault(arg.Default)), doc)
+	}
+	b.WriteString("</table>\n")
+}
+
+// htmlText renders docstring text as HTML.
+func (r *Reference) htmlText(e *Entry, text string) string {
+	var b strings.Builder
+	for _, s := range r.spans(e, text) {
+		switch {
+		case s.link != nil:
+			b.WriteString(htmlLink(s.link))
+		case s.code:
+			fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(s.text))
+		default:
+			b.WriteString(html.EscapeString(s.text))
+		}
+	}
+	return b.String()
+}
+
+func writeHTMLList(b *strings.Builder, entries []*Entry) {
+	b.WriteString("<ul>\n")
+	for _, e := range entries {
+		fmt.Fprintf(b, "<li>%s</li>\n", htmlLink(e))
+	}
+	b.WriteString("</ul>\n")
+}
+
+func htmlLink(e *Entry) string {
+	return fmt.Sprintf("<a href=\"#%s\"><code>%s</code></a>", e.Anchor, html.EscapeString(e.Name))
+}
@@ -0,0 +1,165 @@
+package refdoc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "*", `\*`, "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;",
+	)
+)
+
+// EntryMarkdown returns the Markdown documentation of the named entry
+// for display outside the reference, e.g. by an editor, and whether
+// there is such an entry.
+func (r *Reference) EntryMarkdown(name string) (string, bool) {
+	e, ok := r.entries[name]
+	if !ok {
+		return "", false
+	}
+	var b strings.Builder
+	r.writeMarkdownEntry(&b, e, true)
+	return b.String(), true
+}
+
+// WriteMarkdown writes the reference as a Markdown document.
+func (r *Reference) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<!-- Code generated by cmd/mratdoc. DO NOT EDIT. -->\n\n")
+	fmt.Fprintf(&b, "# %s reference\n\n", Namespace)
+	fmt.Fprintf(&b, "%s\n\n", r.summary())
+
+	b.WriteString("## Contents\n\n")
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "- [%s](#%s):", g.Name, g.Anchor)
+		for i, e := range g.Entries {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, " %s", markdownLink(e))
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Undocumented)+len(r.UndocumentedArgs) > 0 {
+		b.WriteString("- [Undocumented](#undocumented)\n")
+	}
+
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n\n## %s\n", g.Anchor, g.Name)
+		for _, e := range g.Entries {
+			r.writeMarkdownEntry(&b, e, false)
+		}
+	}
+
+	if len(r.Undocumented)+len(r.UndocumentedArgs) > 0 {
+		b.WriteString("\n<a id=\"undocumented\"></a>\n\n## Undocumented\n")
+		if len(r.Undocumented) > 0 {
+			b.WriteString("\nThese symbols have no docstring:\n\n")
+			writeMarkdownList(&b, r.Undocumented)
+		}
+		if len(r.UndocumentedArgs) > 0 {
+			b.WriteString("\nThese ugens have undocumented arguments:\n\n")
+			writeMarkdownList(&b, r.UndocumentedArgs)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownEntry writes the documentation of e. If standalone is
+// true, it's written for display outside the reference, without a
+// heading or links to other entries.
+func (r *Reference) writeMarkdownEntry(b *strings.Builder, e *Entry, standalone bool) {
+	if !standalone {
+		fmt.Fprintf(b, "\n<a id=\"%s\"></a>\n\n### `%s`\n\n", e.Anchor, e.Name)
+	}
+
+	if usage := e.Usage(); len(usage) > 0 {
+		fmt.Fprintf(b, "```clojure\n%s\n```\n\n", strings.Join(usage, "\n"))
+	}
+
+	if strings.TrimSpace(e.Doc) == "" {
+		b.WriteString("**Undocumented.**\n")
+	}
+	var prev blockKind
+	for i, blk := range blocks(e.Doc) {
+		// keep the items of a list together.
+		if i > 0 && !(blk.kind == listItemBlock && prev == listItemBlock) {
+			b.WriteString("\n")
+		}
+		prev = blk.kind
+		switch blk.kind {
+		case codeBlock:
+			fmt.Fprintf(b, "```%s\n%s\n```\n", blk.lang, blk.text)
+		case headingBlock:
+			fmt.Fprintf(b, "#### %s\n", r.markdownText(e, blk.text, standalone))
+		case listItemBlock:
+			fmt.Fprintf(b, "- %s\n", r.markdownText(e, blk.text, standalone))
+		default:
+			fmt.Fprintf(b, "%s\n", r.markdownText(e, blk.text, standalone))
+		}
+	}
+
+	if len(e.UGenArgs) == 0 {
+		return
+	}
+	b.WriteString("\n| Argument | Default | Description |\n| --- | --- | --- |\n")
+	for _, arg := range e.UGenArgs {
+		doc := "*Undocumented.*"
+		if strings.TrimSpace(arg.Doc) != "" {
+			doc = strings.ReplaceAll(r.markdownText(e, arg.Doc, standalone), "|", `\|`)
+			doc = strings.Join(strings.Fields(doc), " ")
+		}
+		def := strings.ReplaceAll(markdownCode(FormatDefault(arg.Default)), "|", `\|`)
+		fmt.Fprintf(b, "| %s | %s | %s |\n", markdownCode(arg.Name), def, doc)
+	}
+}
+
+// markdownText renders docstring text, escaping characters that
+// would otherwise be taken for markup. Unless standalone is true,
+// the names of other entries are linked.
+func (r *Reference) markdownText(e *Entry, text string, standalone bool) string {
+	var b strings.Builder
+	for _, s := range r.spans(e, text) {
+		switch {
+		case s.link != nil && !standalone:
+			b.WriteString(markdownLink(s.link))
+		case s.code:
+			b.WriteString(markdownCode(s.text))
+		default:
+			b.WriteString(markdownEscaper.Replace(s.text))
+		}
+	}
+	return b.String()
+}
+
+func (r *Reference) summary() string {
+	return fmt.Sprintf("The %d public symbols of the %s namespace, by group. "+
+		"Ugens take their arguments in the order listed, or as keyword "+
+		"arguments, and also take `:mul` and `:add` arguments to scale "+
+		"and offset their output. %d symbols are undocumented.",
+		r.NumEntries(), Namespace, len(r.Undocumented))
+}
+
+func writeMarkdownList(b *strings.Builder, entries []*Entry) {
+	for _, e := range entries {
+		fmt.Fprintf(b, "- %s\n", markdownLink(e))
+	}
+}
+
+func markdownLink(e *Entry) string {
+	return fmt.Sprintf("[%s](#%s)", markdownCode(e.Name), e.Anchor)
+}
+
+// markdownCode returns s as a code span.
+func markdownCode(s string) string {
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
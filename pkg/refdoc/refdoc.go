@@ -0,0 +1,372 @@
+// Package refdoc generates reference documentation for the mrat.core
+// library from the metadata collected by mrat.GetNSPublics.
+//
+// Docstrings are treated as lightweight Markdown: paragraphs, "#"
+// headings, "-" lists, `code` and fenced code blocks. Names of other
+// symbols in backticks or single quotes, or following "see", are
+// linked to their entries.
+package refdoc
+
+//go:generate go run ../../cmd/mratdoc -out ../../docs/reference
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/glojurelang/glojure/pkg/lang"
+
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+)
+
+const (
+	// Namespace is the namespace documented.
+	Namespace = "mrat.core"
+
+	// otherGroup is the group of symbols without a docgroup.
+	otherGroup = "Other"
+)
+
+var (
+	// linkRegexp matches the candidates for links to other symbols in
+	// docstring text: names in single quotes, and names following
+	// "see".
+	linkRegexp = regexp.MustCompile(`'([^'\s]+)'|\b[Ss]ee (?:also )?([^\s,;:()'"]+)`)
+
+	anchorReplacer = strings.NewReplacer(
+		"+", "plus", "*", "star", "/", "slash", "<", "lt", ">", "gt",
+		"=", "eq", "!", "bang", "?", "q", ".", "dot", "&", "amp",
+		"'", "quote", "%", "pct", "#", "hash", "_", "-",
+	)
+)
+
+type (
+	// Reference is the reference documentation for a namespace.
+	Reference struct {
+		Groups []*Group
+
+		// Undocumented lists the entries without a docstring.
+		Undocumented []*Entry
+
+		// UndocumentedArgs lists the ugens with undocumented
+		// arguments.
+		UndocumentedArgs []*Entry
+
+		entries map[string]*Entry
+	}
+
+	// Group is a group of entries sharing a docgroup.
+	Group struct {
+		Name    string
+		Anchor  string
+		Entries []*Entry
+	}
+
+	// Entry documents a single symbol.
+	Entry struct {
+		mrat.Symbol
+		Anchor string
+
+		// params are the names of the symbol's parameters, which
+		// aren't linked when quoted in its docstring.
+		params map[string]bool
+	}
+
+	blockKind int
+
+	// block is a block-level element of a docstring.
+	block struct {
+		kind blockKind
+		text string
+		// lang is the language of a code block.
+		lang string
+	}
+
+	// span is an inline element of docstring text.
+	span struct {
+		text string
+		code bool
+		// link is the entry linked to, if any.
+		link *Entry
+	}
+)
+
+const (
+	paragraphBlock blockKind = iota
+	headingBlock
+	listItemBlock
+	codeBlock
+)
+
+// New returns the reference for the given symbols, as returned by
+// mrat.GetNSPublics. Groups appear in the order of the symbols, with
+// symbols lacking a docgroup last.
+func New(syms []mrat.Symbol) *Reference {
+	ref := &Reference{entries: map[string]*Entry{}}
+
+	groups := map[string]*Group{}
+	anchors := map[string]bool{}
+	var other *Group
+	for _, sym := range syms {
+		name := sym.Group
+		if name == "" {
+			name = otherGroup
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &Group{Name: name, Anchor: uniqueAnchor(anchors, "group-"+groupSlug(name))}
+			groups[name] = g
+			if sym.Group == "" {
+				other = g
+			} else {
+				ref.Groups = append(ref.Groups, g)
+			}
+		}
+
+		e := &Entry{
+			Symbol: sym,
+			Anchor: uniqueAnchor(anchors, "sym-"+slug(sym.Name)),
+			params: map[string]bool{},
+		}
+		for _, arg := range sym.UGenArgs {
+			e.params[arg.Name] = true
+		}
+		for _, al := range sym.Arglists {
+			for _, p := range strings.FieldsFunc(lang.PrintString(al), isArglistSep) {
+				e.params[p] = true
+			}
+		}
+		g.Entries = append(g.Entries, e)
+		ref.entries[sym.Name] = e
+
+		if strings.TrimSpace(sym.Doc) == "" {
+			ref.Undocumented = append(ref.Undocumented, e)
+		}
+		for _, arg := range sym.UGenArgs {
+			if strings.TrimSpace(arg.Doc) == "" {
+				ref.UndocumentedArgs = append(ref.UndocumentedArgs, e)
+				break
+			}
+		}
+	}
+	if other != nil {
+		ref.Groups = append(ref.Groups, other)
+	}
+	return ref
+}
+
+// NumEntries returns the number of entries in the reference.
+func (r *Reference) NumEntries() int {
+	return len(r.entries)
+}
+
+// Usage returns the forms calling the entry's symbol, one per
+// arglist. A ugen's arguments are listed by name; they may also be
+// given as keyword arguments, as may :mul and :add.
+func (e *Entry) Usage() []string {
+	if e.UGenArgs != nil {
+		parts := []string{e.Name}
+		for _, arg := range e.UGenArgs {
+			parts = append(parts, arg.Name)
+		}
+		return []string{"(" + strings.Join(parts, " ") + ")"}
+	}
+
+	var res []string
+	for _, al := range e.Arglists {
+		args := strings.TrimSuffix(strings.TrimPrefix(lang.PrintString(al), "["), "]")
+		if args == "" {
+			res = append(res, "("+e.Name+")")
+		} else {
+			res = append(res, "("+e.Name+" "+args+")")
+		}
+	}
+	return res
+}
+
+// FormatDefault formats the default value of a ugen argument as it
+// would be written in a script.
+func FormatDefault(v any) string {
+	s := lang.PrintString(v)
+	if strings.HasPrefix(s, "#object[") {
+		// don't leak addresses into the docs.
+		return fmt.Sprintf("#object[%T]", v)
+	}
+	return s
+}
+
+// blocks splits a docstring into blocks.
+func blocks(doc string) []block {
+	var res []block
+	var cur *block
+	flush := func() {
+		if cur != nil {
+			res = append(res, *cur)
+			cur = nil
+		}
+	}
+
+	lines := dedent(doc)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			code := block{kind: codeBlock, lang: strings.TrimPrefix(trimmed, "```")}
+			var body []string
+			for i++; i < len(lines); i++ {
+				if idx := strings.Index(lines[i], "```"); idx >= 0 {
+					if rest := strings.TrimRight(lines[i][:idx], " \t"); rest != "" {
+						body = append(body, rest)
+					}
+					break
+				}
+				body = append(body, lines[i])
+			}
+			code.text = strings.Join(body, "\n")
+			res = append(res, code)
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			res = append(res, block{kind: headingBlock, text: strings.TrimSpace(strings.TrimLeft(trimmed, "#"))})
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flush()
+			cur = &block{kind: listItemBlock, text: strings.TrimSpace(trimmed[2:])}
+		case cur != nil && cur.kind == listItemBlock:
+			cur.text += " " + trimmed
+		case cur != nil:
+			cur.text += "\n" + trimmed
+		default:
+			cur = &block{kind: paragraphBlock, text: trimmed}
+		}
+	}
+	flush()
+	return res
+}
+
+// dedent splits a docstring into lines, removing the indentation
+// common to all lines after the first.
+func dedent(doc string) []string {
+	lines := strings.Split(strings.TrimSpace(doc), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= indent && indent > 0 {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = strings.TrimLeft(lines[i], " \t")
+		}
+	}
+	return lines
+}
+
+// spans splits docstring text into inline elements, linking the
+// names of entries other than e. e may be nil.
+func (r *Reference) spans(e *Entry, text string) []span {
+	var res []span
+	for i, part := range strings.Split(text, "`") {
+		if part == "" {
+			continue
+		}
+		if i%2 == 1 {
+			res = append(res, span{text: part, code: true, link: r.linkTo(e, part)})
+			continue
+		}
+
+		pos := 0
+		for _, m := range linkRegexp.FindAllStringSubmatchIndex(part, -1) {
+			start, end := m[2], m[3]
+			if start < 0 {
+				start, end = m[4], m[5]
+			}
+			name := part[start:end]
+			link := r.linkTo(e, name)
+			if link == nil {
+				// allow for the end of a sentence.
+				name = strings.TrimRight(name, ".")
+				end = start + len(name)
+				link = r.linkTo(e, name)
+			}
+			if link == nil {
+				continue
+			}
+			if m[2] >= 0 {
+				// replace the quotes with code formatting.
+				start, end = m[0], m[1]
+			}
+			if pos < start {
+				res = append(res, span{text: part[pos:start]})
+			}
+			res = append(res, span{text: name, code: true, link: link})
+			pos = end
+		}
+		if pos < len(part) {
+			res = append(res, span{text: part[pos:]})
+		}
+	}
+	return res
+}
+
+// linkTo returns the entry named name, unless it's from or one of
+// from's parameters.
+func (r *Reference) linkTo(from *Entry, name string) *Entry {
+	to := r.entries[name]
+	if to == nil || to == from || (from != nil && from.params[name]) {
+		return nil
+	}
+	return to
+}
+
+// slug returns a name suitable for use in an anchor.
+func slug(name string) string {
+	s := strings.ToLower(anchorReplacer.Replace(name))
+	s = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return -1
+	}, s)
+	if s == "" {
+		s = "x"
+	}
+	return s
+}
+
+// groupSlug returns a name suitable for use in an anchor for a
+// group, keeping only its letters and digits.
+func groupSlug(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		case r == ' ':
+			return '-'
+		}
+		return -1
+	}, name)
+}
+
+func uniqueAnchor(anchors map[string]bool, anchor string) string {
+	res := anchor
+	for i := 2; anchors[res]; i++ {
+		res = fmt.Sprintf("%s-%d", anchor, i)
+	}
+	anchors[res] = true
+	return res
+}
+
+func isArglistSep(r rune) bool {
+	return strings.ContainsRune(" []{}&", r)
+}
@@ -0,0 +1,102 @@
+package refdoc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/mrat"
+)
+
+func TestReferenceUpToDate(t *testing.T) {
+	ref := New(mrat.GetNSPublics())
+
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		Namespace + ".md":   func(b *bytes.Buffer) error { return ref.WriteMarkdown(b) },
+		Namespace + ".html": func(b *bytes.Buffer) error { return ref.WriteHTML(b) },
+	} {
+		var got bytes.Buffer
+		if err := write(&got); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join("..", "..", "docs", "reference", name)
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("%s is out of date; run go generate ./pkg/refdoc", path)
+		}
+	}
+}
+
+func TestReference(t *testing.T) {
+	ref := New([]mrat.Symbol{
+		{
+			Name:  "sin",
+			Group: "Oscillators",
+			Doc:   "A sine wave.\n  See saw. Its 'freq' is in Hz.",
+			UGenArgs: []mrat.UGenArg{
+				{Name: "freq", Default: int64(440), Doc: "Frequency | in Hz."},
+				{Name: "phase", Default: nil},
+			},
+		},
+		{
+			Name:     "saw",
+			Group:    "Oscillators",
+			Doc:      "A sawtooth wave, like 'sin'.\n\n  # Aliasing\n\n  - not *band-limited*\n  - <loud>",
+			UGenArgs: []mrat.UGenArg{{Name: "freq", Default: int64(440), Doc: "Frequency."}},
+		},
+		{
+			Name:     "*group*",
+			Arglists: []any{},
+		},
+	})
+
+	if len(ref.Groups) != 2 || ref.Groups[0].Name != "Oscillators" || ref.Groups[1].Name != otherGroup {
+		t.Fatalf("got groups %v, want Oscillators then %s", ref.Groups, otherGroup)
+	}
+	if len(ref.Undocumented) != 1 || ref.Undocumented[0].Name != "*group*" {
+		t.Errorf("got undocumented %v, want *group*", ref.Undocumented)
+	}
+	if len(ref.UndocumentedArgs) != 1 || ref.UndocumentedArgs[0].Name != "sin" {
+		t.Errorf("got undocumented args %v, want sin", ref.UndocumentedArgs)
+	}
+
+	var md bytes.Buffer
+	if err := ref.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<a id=\"sym-stargroupstar\"></a>",
+		"```clojure\n(sin freq phase)\n```",
+		// cross-links, but not to parameters.
+		"See [`saw`](#sym-saw). Its 'freq' is in Hz.",
+		"like [`sin`](#sym-sin).",
+		"#### Aliasing\n\n- not \\*band-limited\\*\n- &lt;loud&gt;\n",
+		"| `freq` | `440` | Frequency \\| in Hz. |",
+		"| `phase` | `nil` | *Undocumented.* |",
+		"These symbols have no docstring:\n\n- [`*group*`](#sym-stargroupstar)",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("expected Markdown to contain %q, got:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := ref.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h3 id=\"sym-sin\"><code>sin</code></h3>",
+		"See <a href=\"#sym-saw\"><code>saw</code></a>.",
+		"<ul>\n<li>not *band-limited*</li>\n<li>&lt;loud&gt;</li>\n</ul>",
+		"<tr><td><code>phase</code></td><td><code>nil</code></td><td><span class=\"undocumented\">Undocumented.</span></td></tr>",
+	} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, html.String())
+		}
+	}
+}
@@ -11,7 +11,7 @@ const (
 type (
 	cacheEntry struct {
 		key  string
-		data []float64
+		data [][]float64
 		prev *cacheEntry
 		next *cacheEntry
 	}
@@ -32,7 +32,7 @@ var (
 	}
 )
 
-func (c *sampleCache) get(key string) ([]float64, bool) {
+func (c *sampleCache) get(key string) ([][]float64, bool) {
 	c.RLock()
 	defer c.RUnlock()
 
@@ -59,7 +59,7 @@ func (c *sampleCache) get(key string) ([]float64, bool) {
 	return entry.data, true
 }
 
-func (c *sampleCache) set(key string, data []float64) {
+func (c *sampleCache) set(key string, data [][]float64) {
 	c.Lock()
 	defer c.Unlock()
 
@@ -68,9 +68,9 @@ func (c *sampleCache) set(key string, data []float64) {
 	}
 
 	// remove the oldest entry if the cache is too large
-	for c.size+len(data)*8 > maxCacheSizeBytes {
+	for c.size+sizeOf(data) > maxCacheSizeBytes {
 		delete(c.entries, c.tail.key)
-		c.size -= len(c.tail.data) * 8
+		c.size -= sizeOf(c.tail.data)
 
 		c.tail = c.tail.prev
 		if c.tail != nil {
@@ -92,5 +92,14 @@ func (c *sampleCache) set(key string, data []float64) {
 	c.head = entry
 
 	c.entries[key] = entry
-	c.size += len(data) * 8
+	c.size += sizeOf(data)
+}
+
+// sizeOf returns the size of the samples of data in bytes.
+func sizeOf(data [][]float64) int {
+	size := 0
+	for _, ch := range data {
+		size += len(ch) * 8
+	}
+	return size
 }
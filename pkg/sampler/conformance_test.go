@@ -0,0 +1,58 @@
+package sampler
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+)
+
+// sineBuffer returns a tenth of a second of a 1kHz sine. Samples are
+// loaded at the engine's sample rate, so the buffer is made at the
+// rate being checked.
+func sineBuffer() []float64 {
+	buf := make([]float64, conf.SampleRate/10)
+	for i := range buf {
+		buf[i] = math.Sin(2 * math.Pi * 1000 * float64(i) / float64(conf.SampleRate))
+	}
+	return buf
+}
+
+func TestConformance(t *testing.T) {
+	ugentest.Run(t, ugentest.Spec{
+		Name: "sampler",
+		New:  func() ugen.UGen { return NewSampler(sineBuffer()) },
+		Inputs: map[string]ugentest.Signal{
+			"trigger":   ugentest.Gate(4),
+			"rate":      ugentest.Const(1),
+			"loop":      ugentest.Const(0),
+			"start-pos": ugentest.Const(0),
+			"end-pos":   ugentest.Const(math.MaxInt32),
+		},
+	}, ugentest.Spec{
+		Name: "grains",
+		New: func() ugen.UGen {
+			return NewGrains(sineBuffer(), "tukey", 16, 1, ugen.WithSeed(1))
+		},
+		Inputs: map[string]ugentest.Signal{
+			"pos":     ugentest.Const(0.5),
+			"jitter":  ugentest.Const(0.2),
+			"size":    ugentest.Const(0.05),
+			"density": ugentest.Const(50),
+			"pitch":   ugentest.Const(1.5),
+			"spread":  ugentest.Const(0.5),
+		},
+	}, ugentest.Spec{
+		Name: "grains (triggered)",
+		New: func() ugen.UGen {
+			return NewGrains(sineBuffer(), "hann", 16, 0, ugen.WithSeed(1))
+		},
+		Inputs: map[string]ugentest.Signal{
+			"trigger": ugentest.Gate(20),
+			"size":    ugentest.Const(0.03),
+			"pitch":   ugentest.Const(-1),
+		},
+	})
+}
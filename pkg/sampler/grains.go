@@ -0,0 +1,249 @@
+package sampler
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+const (
+	// windowResolution is the number of points in a grain window's
+	// table.
+	windowResolution = 1024
+)
+
+type (
+	// Grains is a granular synthesizer. It plays overlapping,
+	// windowed grains of a buffer, each from its own position and at
+	// its own rate, panned across the stereo field.
+	Grains struct {
+		buf     []float64
+		window  []float64
+		channel int
+		opts    ugen.Options
+
+		grains    []grain
+		lastTrig  bool
+		untilNext float64
+	}
+
+	grain struct {
+		index float64
+		rate  float64
+		// t is the grain's progress through its window, in [0, 1),
+		// and dt the progress per sample.
+		t, dt float64
+		gain  float64
+	}
+)
+
+// NewGrains returns a granular synthesizer that plays grains of buf,
+// shaped by the named window: "hann", "tukey" or "trapezoid". At most
+// maxGrains grains play at once; grains spawned beyond that are
+// dropped. The output is the given channel, 0 for left and 1 for
+// right, of the stereo mix of the grains. Left and right are
+// generated by separate ugens, which play the same grains if given
+// the same inputs and seed.
+//
+// Its inputs are:
+//   - "pos", the position in the buffer from which grains start, from
+//     0 for its start to 1 for its end;
+//   - "jitter", the maximum random offset from pos, in the same units;
+//   - "size", the length of a grain in seconds;
+//   - "density", the number of grains spawned per second;
+//   - "trigger", which, if connected, spawns a grain on each rising
+//     edge instead of at the rate set by density;
+//   - "pitch", the rate at which grains play the buffer, where 1 is
+//     the original pitch and negative rates play backwards;
+//   - "spread", the maximum distance from the center, in [0, 1], at
+//     which grains are panned at random.
+func NewGrains(buf []float64, window string, maxGrains, channel int, opts ...ugen.Option) ugen.UGen {
+	if len(buf) == 0 {
+		return ugen.NewConstant(0)
+	}
+	o := ugen.DefaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Grains{
+		buf:     buf,
+		window:  newWindow(window),
+		channel: channel,
+		opts:    o,
+		grains:  make([]grain, 0, max(maxGrains, 1)),
+	}
+}
+
+func (g *Grains) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	poss := cfg.InputSamples["pos"]
+	jitters := cfg.InputSamples["jitter"]
+	sizes := cfg.InputSamples["size"]
+	densities := cfg.InputSamples["density"]
+	trigs, triggered := cfg.InputSamples["trigger"]
+	pitches := cfg.InputSamples["pitch"]
+	spreads := cfg.InputSamples["spread"]
+
+	sampleRate := float64(cfg.SampleRateHz)
+	for i := range out {
+		spawn := false
+		if triggered {
+			trig := len(trigs) > 0 && trigs[i] > 0
+			spawn = trig && !g.lastTrig
+			g.lastTrig = trig
+		} else {
+			density := inputAt(densities, i, 10)
+			if density > 0 && !math.IsInf(density, 0) {
+				if g.untilNext <= 0 {
+					spawn = true
+					g.untilNext += sampleRate / density
+					if g.untilNext <= 0 {
+						// more than a grain per sample; spawn one.
+						g.untilNext = sampleRate / density
+					}
+				}
+				g.untilNext--
+			} else {
+				g.untilNext = 0
+			}
+		}
+		if spawn {
+			g.spawn(
+				sampleRate,
+				inputAt(poss, i, 0),
+				inputAt(jitters, i, 0),
+				inputAt(sizes, i, 0.1),
+				inputAt(pitches, i, 1),
+				inputAt(spreads, i, 0),
+			)
+		}
+
+		out[i] = g.next()
+	}
+}
+
+// spawn starts a grain, unless too many are playing or the inputs
+// make no sense.
+func (g *Grains) spawn(sampleRate, pos, jitter, size, pitch, spread float64) {
+	// the random numbers are drawn whether or not a grain is
+	// spawned, so that the left and right channels stay in step.
+	rnd := g.opts.Rand
+	offset := 2*rnd.Float64() - 1
+	pan := 2*rnd.Float64() - 1
+
+	if len(g.grains) == cap(g.grains) {
+		return
+	}
+	pos += jitter * offset
+	dur := size * sampleRate
+	if !isFinite(pos) || !isFinite(pitch) || !isFinite(dur) || dur < 1 {
+		return
+	}
+	if !isFinite(spread) {
+		spread = 0
+	}
+	spread = math.Max(0, math.Min(1, spread))
+
+	// equal-power panning, as with pan2
+	theta := math.Pi / 4 * (spread*pan + 1)
+	gain := math.Cos(theta)
+	if g.channel == 1 {
+		gain = math.Sin(theta)
+	}
+
+	pos -= math.Floor(pos)
+	g.grains = append(g.grains, grain{
+		index: pos * float64(len(g.buf)),
+		rate:  pitch,
+		dt:    1 / dur,
+		gain:  gain,
+	})
+}
+
+// next returns the sum of the playing grains' next samples, and
+// advances them.
+func (g *Grains) next() float64 {
+	var sum float64
+	bufLen := float64(len(g.buf))
+	for j := 0; j < len(g.grains); {
+		gr := &g.grains[j]
+		sum += gr.gain * g.windowAt(gr.t) * cubic(g.buf, gr.index)
+
+		gr.t += gr.dt
+		if gr.t >= 1 {
+			// remove the grain by replacing it with the last one.
+			last := len(g.grains) - 1
+			g.grains[j] = g.grains[last]
+			g.grains = g.grains[:last]
+			continue
+		}
+		gr.index += gr.rate
+		if gr.index >= bufLen || gr.index < 0 {
+			gr.index -= bufLen * math.Floor(gr.index/bufLen)
+		}
+		if !(gr.index >= 0 && gr.index < bufLen) {
+			// rounding can wrap a tiny negative index to bufLen,
+			// and a huge rate leaves no useful precision at all.
+			gr.index = 0
+		}
+		j++
+	}
+	return sum
+}
+
+// windowAt returns the window's value at t in [0, 1].
+func (g *Grains) windowAt(t float64) float64 {
+	x := t * windowResolution
+	i := int(x)
+	if i >= windowResolution {
+		return g.window[windowResolution]
+	}
+	frac := x - float64(i)
+	return g.window[i] + frac*(g.window[i+1]-g.window[i])
+}
+
+// newWindow returns a table of the named window over [0, 1].
+func newWindow(name string) []float64 {
+	var f func(t float64) float64
+	switch name {
+	case "hann":
+		f = func(t float64) float64 {
+			return 0.5 - 0.5*math.Cos(2*math.Pi*t)
+		}
+	case "tukey":
+		// a Tukey window with half of its length tapered.
+		const alpha = 0.5
+		f = func(t float64) float64 {
+			t = math.Min(t, 1-t)
+			if t >= alpha/2 {
+				return 1
+			}
+			return 0.5 - 0.5*math.Cos(2*math.Pi*t/alpha)
+		}
+	case "trapezoid":
+		// ramps over the first and last quarters.
+		f = func(t float64) float64 {
+			return math.Min(1, 4*math.Min(t, 1-t))
+		}
+	default:
+		panic(fmt.Errorf("grains: unknown window %q", name))
+	}
+
+	w := make([]float64, windowResolution+1)
+	for i := range w {
+		w[i] = f(float64(i) / windowResolution)
+	}
+	return w
+}
+
+func inputAt(samples []float64, i int, def float64) float64 {
+	if len(samples) == 0 {
+		return def
+	}
+	return samples[i]
+}
+
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
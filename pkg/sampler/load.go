@@ -13,7 +13,35 @@ import (
 	"github.com/mewkiz/flac"
 )
 
+// LoadSample loads an audio file into a buffer, resampled to the
+// engine's sample rate. The channels of a multi-channel file are mixed
+// down to one.
 func LoadSample(filename string) (res []float64) {
+	chans := LoadChannels(filename)
+	if len(chans) == 1 {
+		return chans[0]
+	}
+
+	key := filename + "#mono"
+	if data, ok := cache.get(key); ok {
+		return data[0]
+	}
+	defer func() {
+		cache.set(key, [][]float64{res})
+	}()
+
+	res = make([]float64, len(chans[0]))
+	for _, ch := range chans {
+		for i, s := range ch {
+			res[i] += s / float64(len(chans))
+		}
+	}
+	return res
+}
+
+// LoadChannels loads an audio file into a buffer per channel,
+// resampled to the engine's sample rate.
+func LoadChannels(filename string) (res [][]float64) {
 	if data, ok := cache.get(filename); ok {
 		return data
 	}
@@ -41,9 +69,7 @@ func LoadSample(filename string) (res []float64) {
 	}
 }
 
-// TODO: multiple channels
-
-func loadFlac(filename string, f *os.File) []float64 {
+func loadFlac(filename string, f *os.File) [][]float64 {
 	stream, err := flac.New(f)
 	if err != nil {
 		panic(fmt.Errorf("load-sample: error parsing FLAC header: %v", err))
@@ -53,7 +79,7 @@ func loadFlac(filename string, f *os.File) []float64 {
 	maxVal := 1 << (stream.Info.BitsPerSample - 1)
 	scaleFactor := 1.0 / float64(maxVal)
 
-	var samples []float64
+	chans := make([][]float64, stream.Info.NChannels)
 	for {
 		frame, err := stream.ParseNext()
 		if err != nil {
@@ -62,25 +88,20 @@ func loadFlac(filename string, f *os.File) []float64 {
 			}
 			panic(fmt.Errorf("load-sample: error parsing FLAC data: %v", err))
 		}
-		for i := 0; i < len(frame.Subframes[0].Samples); i++ {
-			var sum float64
-			for _, block := range frame.Subframes {
-				sum += float64(block.Samples[i])
+		for ch, block := range frame.Subframes {
+			for _, s := range block.Samples {
+				chans[ch] = append(chans[ch], float64(s)*scaleFactor)
 			}
-			avg := sum / float64(len(frame.Subframes))
-			samples = append(samples, avg*scaleFactor)
 		}
 	}
 
-	// if stream.Info.SampleRate != sampleRate {
-	// 	rsmp := resampler.NewWithSkipZeros(1, micSampleRate, conf.SampleRate, 10)
-	// 	samples, err = rsmp.Process(samples)
-	// }
-
-	return samples
+	for ch := range chans {
+		chans[ch] = resample(chans[ch], int(stream.Info.SampleRate))
+	}
+	return chans
 }
 
-func loadWav(filename string, f *os.File) []float64 {
+func loadWav(filename string, f *os.File) [][]float64 {
 	dec := wav.NewDecoder(f)
 	if !dec.IsValidFile() {
 		panic(fmt.Errorf("load-sample: file '%s' is not a valid WAV file", filename))
@@ -96,38 +117,35 @@ func loadWav(filename string, f *os.File) []float64 {
 		if n == 0 {
 			break
 		}
-		intSamples = append(intSamples, audioBuf.Data...)
+		intSamples = append(intSamples, audioBuf.Data[:n]...)
 	}
 	bitDepth := dec.SampleBitDepth()
 
-	floatSamples := make([]float64, 0, len(intSamples))
-
-	for _, s := range intSamples {
+	// the samples are interleaved, one for each channel in turn.
+	numChans := int(dec.NumChans)
+	chans := make([][]float64, numChans)
+	for ch := range chans {
+		chans[ch] = make([]float64, 0, len(intSamples)/numChans)
+	}
+	for i, s := range intSamples {
 		floatSample := float64(s) / float64(int(1)<<uint(bitDepth-1))
 		if floatSample > 1 {
 			floatSample = 1
 		} else if floatSample < -1 {
 			floatSample = -1
 		}
-		floatSamples = append(floatSamples, floatSample)
+		chans[i%numChans] = append(chans[i%numChans], floatSample)
 	}
 
-	deviceSampleRate := conf.SampleRate
-	// TODO: use lib to resample
-	if dec.SampleRate != uint32(deviceSampleRate) {
-		outputSamples := make([]float64, len(floatSamples)*deviceSampleRate/int(dec.SampleRate))
-		for i := range outputSamples {
-			t := float64(i) / float64(len(outputSamples)-1)
-			outputSamples[i] = floatSamples[int(t*float64(len(floatSamples)-1))]
-		}
-		floatSamples = outputSamples
+	for ch := range chans {
+		chans[ch] = resample(chans[ch], int(dec.SampleRate))
 	}
-	fmt.Println("loaded", filename, "with", len(floatSamples), "samples")
+	fmt.Println("loaded", filename, "with", len(chans[0]), "samples")
 
-	return floatSamples
+	return chans
 }
 
-func loadMP3(filename string, f *os.File) []float64 {
+func loadMP3(filename string, f *os.File) [][]float64 {
 	dec, err := mp3.NewDecoder(f)
 	if err != nil {
 		panic(fmt.Errorf("load-sample: error creating MP3 decoder: %v", err))
@@ -135,7 +153,7 @@ func loadMP3(filename string, f *os.File) []float64 {
 
 	sampleRate := dec.SampleRate()
 
-	var intSamples []int
+	chans := make([][]float64, 2)
 	for {
 		data := make([]byte, 2048)
 		n, err := dec.Read(data)
@@ -146,34 +164,32 @@ func loadMP3(filename string, f *os.File) []float64 {
 			panic(fmt.Errorf("load-sample: error reading MP3 data: %v", err))
 		}
 		// a sample is always 4 bytes (two 16-bit samples, little-endian,
-		// one for each channel) we average the two channels to get a
-		// single sample
-		for i := 0; i < n; i += 4 {
-			sample1 := int(int16(data[i]) | int16(data[i+1])<<8)
-			sample2 := int(int16(data[i+2]) | int16(data[i+3])<<8)
-			intSamples = append(intSamples, (sample1+sample2)/2)
+		// one for each channel)
+		for i := 0; i+4 <= n; i += 4 {
+			for ch := range chans {
+				sample := int16(data[i+2*ch]) | int16(data[i+2*ch+1])<<8
+				chans[ch] = append(chans[ch], float64(sample)/float64(1<<15))
+			}
 		}
 	}
 
-	floatSamples := make([]float64, 0, len(intSamples))
-	for _, s := range intSamples {
-		floatSample := float64(s) / float64(1<<15)
-		if floatSample > 1 {
-			floatSample = 1
-		} else if floatSample < -1 {
-			floatSample = -1
-		}
-		floatSamples = append(floatSamples, floatSample)
+	for ch := range chans {
+		chans[ch] = resample(chans[ch], sampleRate)
 	}
+	return chans
+}
 
-	if sampleRate != conf.SampleRate {
-		outputSamples := make([]float64, len(floatSamples)*conf.SampleRate/int(sampleRate))
-		for i := range outputSamples {
-			t := float64(i) / float64(len(outputSamples)-1)
-			outputSamples[i] = floatSamples[int(t*float64(len(floatSamples)-1))]
-		}
-		floatSamples = outputSamples
+// resample resamples samples from the given rate to the engine's
+// sample rate.
+func resample(samples []float64, sampleRate int) []float64 {
+	// TODO: use lib to resample
+	if sampleRate == conf.SampleRate || len(samples) < 2 {
+		return samples
 	}
-
-	return floatSamples
+	outputSamples := make([]float64, len(samples)*conf.SampleRate/sampleRate)
+	for i := range outputSamples {
+		t := float64(i) / float64(max(len(outputSamples)-1, 1))
+		outputSamples[i] = samples[int(t*float64(len(samples)-1))]
+	}
+	return outputSamples
 }
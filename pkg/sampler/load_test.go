@@ -0,0 +1,89 @@
+package sampler
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+)
+
+// writeWAV writes 16-bit interleaved samples with the given number of
+// channels to a WAV file at the engine's sample rate.
+func writeWAV(t *testing.T, channels int, data []int) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "sample.wav")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	enc := wav.NewEncoder(f, conf.SampleRate, 16, channels, 1)
+	buf := &audio.IntBuffer{
+		Format:         &audio.Format{NumChannels: channels, SampleRate: conf.SampleRate},
+		Data:           data,
+		SourceBitDepth: 16,
+	}
+	if err := enc.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadChannels(t *testing.T) {
+	// a ramp on the left and its inverse on the right, longer than the
+	// decoder's buffer.
+	const n = 3000
+	data := make([]int, 0, 2*n)
+	for i := range n {
+		data = append(data, i, -i/2)
+	}
+	filename := writeWAV(t, 2, data)
+
+	chans := LoadChannels(filename)
+	if len(chans) != 2 {
+		t.Fatalf("got %d channels, want 2", len(chans))
+	}
+	for ch, wantScale := range []float64{1, -0.5} {
+		if len(chans[ch]) != n {
+			t.Fatalf("channel %d has %d samples, want %d", ch, len(chans[ch]), n)
+		}
+		for i, s := range chans[ch] {
+			want := math.Trunc(float64(i)*wantScale) / (1 << 15)
+			if math.Abs(s-want) > 1e-12 {
+				t.Fatalf("channel %d sample %d is %v, want %v", ch, i, s, want)
+			}
+		}
+	}
+
+	mono := LoadSample(filename)
+	if len(mono) != n {
+		t.Fatalf("mixdown has %d samples, want %d", len(mono), n)
+	}
+	for i, s := range mono {
+		if want := (chans[0][i] + chans[1][i]) / 2; math.Abs(s-want) > 1e-12 {
+			t.Fatalf("mixdown sample %d is %v, want %v", i, s, want)
+		}
+	}
+}
+
+func TestLoadSampleMono(t *testing.T) {
+	filename := writeWAV(t, 1, []int{0, 1 << 14, -(1 << 14)})
+	got := LoadSample(filename)
+	want := []float64{0, 0.5, -0.5}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -16,64 +16,50 @@ func NewSampler(buf []float64) ugen.UGen {
 	index := 0.0
 	lastTrig := false
 	stopped := true
-	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		trigs := cfg.InputSamples["trigger"]
-		rates := cfg.InputSamples["rate"]
-		loops := cfg.InputSamples["loop"]
-		startIndex := cfg.InputSamples["start-pos"]
-		endIndex := cfg.InputSamples["end-pos"]
 
-		lastIdx := len(out) - 1
-		_ = trigs[lastIdx]
-		_ = rates[lastIdx]
-		_ = loops[lastIdx]
-		_ = startIndex[lastIdx]
-		_ = endIndex[lastIdx]
+	trigInput := ugen.Input{Name: "trigger"}
+	rateInput := ugen.Input{Name: "rate", Default: 1}
+	loopInput := ugen.Input{Name: "loop"}
+	startInput := ugen.Input{Name: "start-pos"}
+	endInput := ugen.Input{Name: "end-pos", Default: sampleLen}
+	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+		trigs := trigInput.Samples(cfg, len(out))
+		rates := rateInput.Samples(cfg, len(out))
+		loops := loopInput.Samples(cfg, len(out))
+		startIndex := startInput.Samples(cfg, len(out))
+		endIndex := endInput.Samples(cfg, len(out))
 
 		for i := range out {
 			if !lastTrig && trigs[i] > 0 {
 				stopped = false
 				index = startIndex[i]
 			}
+			// a nonfinite rate or position would leave the index
+			// unusable, so stop rather than index the buffer with it.
+			if math.IsNaN(index) || math.IsInf(index, 0) {
+				index = 0
+				stopped = true
+			}
 			lastTrig = trigs[i] > 0
 
 			rate := rates[i]
 
 			end := endIndex[i]
-			if end == 0 {
+			if end == 0 || math.IsNaN(end) {
 				end = 1
 			} else if end > sampleLen {
 				end = sampleLen
 			}
 
 			if stopped {
+				out[i] = 0
 				continue
 			}
 
-			if index >= end {
+			if index >= end || index < 0 {
 				out[i] = 0
 			} else {
-				// cubic interpolation
-				sampleIndexF, frac := math.Modf(index)
-				sampleIndex := int(sampleIndexF)
-
-				s0 := buf[sampleIndex]
-				var s1, s2, s3 float64
-				sample := s0
-				if sampleIndex+1 < len(buf) {
-					s1 = buf[sampleIndex+1]
-					sample += (s1 - s0) * frac
-				}
-				if sampleIndex+2 < len(buf) {
-					s2 = buf[sampleIndex+2]
-					sample += (0.5*s2 - s1 + 0.5*s0) * frac * frac
-				}
-				if sampleIndex+3 < len(buf) {
-					s3 = buf[sampleIndex+3]
-					sample += (-0.5*s3 + 1.5*s2 - 1.5*s1 + 0.5*s0) * frac * frac * frac
-				}
-
-				out[i] = sample
+				out[i] = cubic(buf, index)
 			}
 
 			index += rate
@@ -89,3 +75,27 @@ func NewSampler(buf []float64) ugen.UGen {
 		}
 	})
 }
+
+// cubic returns the sample at a fractional index in buf, by cubic
+// interpolation. The index must be in [0, len(buf)).
+func cubic(buf []float64, index float64) float64 {
+	sampleIndexF, frac := math.Modf(index)
+	sampleIndex := int(sampleIndexF)
+
+	s0 := buf[sampleIndex]
+	var s1, s2, s3 float64
+	sample := s0
+	if sampleIndex+1 < len(buf) {
+		s1 = buf[sampleIndex+1]
+		sample += (s1 - s0) * frac
+	}
+	if sampleIndex+2 < len(buf) {
+		s2 = buf[sampleIndex+2]
+		sample += (0.5*s2 - s1 + 0.5*s0) * frac * frac
+	}
+	if sampleIndex+3 < len(buf) {
+		s3 = buf[sampleIndex+3]
+		sample += (-0.5*s3 + 1.5*s2 - 1.5*s1 + 0.5*s0) * frac * frac * frac
+	}
+	return sample
+}
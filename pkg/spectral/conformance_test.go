@@ -0,0 +1,63 @@
+package spectral
+
+import (
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+)
+
+func TestConformance(t *testing.T) {
+	// spectral ugens are checked in chains from an FFT to an IFFT,
+	// since on their own they pass frames rather than samples. Frame
+	// sizes are in samples, so they're scaled with the rate being
+	// checked, to analyze the same length of time.
+	fftSize := func() int {
+		return 1024 * max(conf.SampleRate/44100, 1)
+	}
+	spec := func(name string, newTransform func() ugen.UGen, inputs map[string]ugentest.Signal) ugentest.Spec {
+		in := map[string]ugentest.Signal{"in": ugentest.Sine(440)}
+		for port, sig := range inputs {
+			in[port] = sig
+		}
+		return ugentest.Spec{
+			Name: name,
+			New: func() ugen.UGen {
+				var with ugen.UGen
+				if _, ok := inputs["with"]; ok {
+					with = NewFFT(fftSize(), 4)
+				}
+				stages := []ugen.UGen{NewFFT(fftSize(), 4)}
+				if newTransform != nil {
+					stages = append(stages, newTransform())
+				}
+				return newChain(with, append(stages, NewIFFT())...)
+			},
+			Inputs: in,
+		}
+	}
+	ugentest.Run(t,
+		spec("fft", nil, nil),
+		spec("freeze", NewFreeze, map[string]ugentest.Signal{"freeze": ugentest.Gate(2)}),
+		spec("blur", NewBlur, map[string]ugentest.Signal{"amount": ugentest.Const(0.8)}),
+		spec("brick-wall", NewBrickWall, map[string]ugentest.Signal{
+			"lo": ugentest.Const(200),
+			"hi": ugentest.Const(2000),
+		}),
+		spec("comb", NewComb, map[string]ugentest.Signal{
+			"spacing": ugentest.Const(300),
+			"phase":   ugentest.Const(0.1),
+			"width":   ugentest.Const(0.7),
+		}),
+		spec("bin-shift", NewBinShift, map[string]ugentest.Signal{
+			"stretch": ugentest.Const(1.5),
+			"shift":   ugentest.Const(100),
+		}),
+		spec("gate", NewMagGate, map[string]ugentest.Signal{"threshold": ugentest.Const(0.1)}),
+		spec("cross", NewCross, map[string]ugentest.Signal{
+			"with":   ugentest.Sine(1000),
+			"amount": ugentest.Const(0.5),
+		}),
+	)
+}
@@ -0,0 +1,171 @@
+package spectral
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// analysis is an FFT ugen.
+	analysis struct {
+		fft    *fourier.FFT
+		window []float64
+
+		// ring holds the last Size samples of the input, the
+		// oldest at pos.
+		ring []float64
+		pos  int
+		// untilNext is the number of samples until the next frame
+		// is completed.
+		untilNext int
+
+		seq []float64
+		out Frames
+	}
+
+	// synthesis is an IFFT ugen.
+	synthesis struct {
+		fft *fourier.FFT
+		// window is the synthesis window, scaled so that the
+		// overlapping windows add up to one.
+		window []float64
+		size   int
+		hop    int
+
+		// acc accumulates the overlapping windows. The sample at pos
+		// is the next to be output.
+		acc []float64
+		pos int
+
+		seq []float64
+	}
+)
+
+// NewFFT returns a ugen that analyzes its "in" input into frames of
+// size samples, windowed with a Hann window. Successive frames overlap
+// by all but size/overlap samples. size must be a power of 2 from
+// MinSize to MaxSize, and overlap a power of 2 from 2 to size/4.
+func NewFFT(size, overlap int) ugen.UGen {
+	if size < MinSize || size > MaxSize || bits.OnesCount(uint(size)) != 1 {
+		panic(fmt.Errorf("fft: size must be a power of 2 from %d to %d, got %d", MinSize, MaxSize, size))
+	}
+	if overlap < 2 || overlap > size/4 || bits.OnesCount(uint(overlap)) != 1 {
+		panic(fmt.Errorf("fft: overlap must be a power of 2 from 2 to %d, got %d", size/4, overlap))
+	}
+	a := &analysis{
+		fft:       fourier.NewFFT(size),
+		window:    hann(size),
+		ring:      make([]float64, size),
+		untilNext: size / overlap,
+		seq:       make([]float64, size),
+	}
+	a.out.reset(size, size/overlap)
+	return a
+}
+
+func (a *analysis) Data() any {
+	return &a.out
+}
+
+func (a *analysis) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	clear(out)
+	in := cfg.InputSamples["in"]
+	a.out.reset(a.out.Size, a.out.Hop)
+	for i := range out {
+		x := 0.0
+		if len(in) > 0 && isFinite(in[i]) {
+			x = in[i]
+		}
+		a.ring[a.pos] = x
+		a.pos = (a.pos + 1) % len(a.ring)
+
+		a.untilNext--
+		if a.untilNext > 0 {
+			continue
+		}
+		a.untilNext = a.out.Hop
+
+		n := copy(a.seq, a.ring[a.pos:])
+		copy(a.seq[n:], a.ring[:a.pos])
+		for j, w := range a.window {
+			a.seq[j] *= w
+		}
+		a.fft.Coefficients(a.out.add(i).Bins, a.seq)
+		out[i] = 1
+	}
+}
+
+// NewIFFT returns a ugen that resynthesizes the frames of its "in"
+// input, from an FFT ugen or a transform, by overlap-adding them with
+// a Hann window. The output lags the input to the FFT by the frame
+// size.
+func NewIFFT() ugen.UGen {
+	return &synthesis{}
+}
+
+// reset prepares s for frames of the given size and hop.
+func (s *synthesis) reset(size, hop int) {
+	s.size, s.hop = size, hop
+	s.fft = fourier.NewFFT(size)
+	s.acc = make([]float64, 2*size)
+	s.pos = 0
+	s.seq = make([]float64, size)
+
+	// the windows are applied twice, once for analysis and once for
+	// synthesis, so the squares of the overlapping windows are
+	// normalized to add up to one.
+	s.window = hann(size)
+	norm := make([]float64, hop)
+	for j, w := range s.window {
+		norm[j%hop] += w * w
+	}
+	for j := range s.window {
+		// the DFT is unnormalized, so its inverse is scaled by the
+		// size.
+		s.window[j] /= norm[j%hop] * float64(size)
+	}
+}
+
+func (s *synthesis) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	in, _ := cfg.InputData["in"].(*Frames)
+	var frames []Frame
+	if in != nil && in.Size > 0 {
+		if in.Size != s.size || in.Hop != s.hop {
+			s.reset(in.Size, in.Hop)
+		}
+		frames = in.Frames
+	}
+
+	for i := range out {
+		for len(frames) > 0 && frames[0].Offset <= i {
+			s.add(frames[0].Bins)
+			frames = frames[1:]
+		}
+		if s.acc == nil {
+			continue
+		}
+		out[i] = s.acc[s.pos]
+		s.acc[s.pos] = 0
+		s.pos = (s.pos + 1) % len(s.acc)
+	}
+}
+
+// add overlap-adds the window with the given bins, starting from the
+// next sample to be output after the current one.
+func (s *synthesis) add(bins []complex128) {
+	s.fft.Sequence(s.seq, bins)
+	for j, x := range s.seq {
+		// a transform may have left bins that aren't finite, which
+		// would otherwise stay in the accumulator.
+		if !isFinite(x) {
+			continue
+		}
+		k := (s.pos + 1 + j) % len(s.acc)
+		s.acc[k] += x * s.window[j]
+	}
+}
@@ -0,0 +1,123 @@
+// Package spectral implements short-time Fourier transform (STFT)
+// analysis and resynthesis, and transforms of the spectra in between.
+//
+// An FFT ugen analyzes overlapping, windowed frames of its input, and
+// an IFFT ugen overlap-adds them back into a signal. Transforms such
+// as NewFreeze and NewBinShift sit between them, each reading the
+// frames of its "in" input and passing on its own. Frames are passed
+// between ugens as data, through ugen.DataSource; the samples a
+// spectral ugen outputs are 1 at each sample at which a frame was
+// completed and 0 otherwise.
+package spectral
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+const (
+	// MinSize and MaxSize are the smallest and largest frame sizes.
+	MinSize = 16
+	MaxSize = 32768
+)
+
+type (
+	// Frame is the spectrum of one window of a signal.
+	Frame struct {
+		// Bins holds the Fourier coefficients of the window, for
+		// the frequencies from 0 to the Nyquist frequency.
+		Bins []complex128
+
+		// Offset is the index of the sample in the block at which
+		// the window ended.
+		Offset int
+	}
+
+	// Frames are the frames of a signal completed in one block of
+	// samples, in order.
+	Frames struct {
+		// Size is the number of samples in a window, and Hop the
+		// number between the ends of successive windows.
+		Size, Hop int
+
+		Frames []Frame
+	}
+)
+
+// Bins returns the number of bins in a frame.
+func (fs *Frames) Bins() int {
+	return fs.Size/2 + 1
+}
+
+// BinFreq returns the frequency in Hz of bin k.
+func (fs *Frames) BinFreq(k int, sampleRate float64) float64 {
+	return float64(k) * sampleRate / float64(fs.Size)
+}
+
+// Amplitude returns the amplitude of the sinusoid that would give a
+// bin the magnitude of c.
+func (fs *Frames) Amplitude(c complex128) float64 {
+	// the coefficients of a Hann window sum to half its size, and a
+	// sinusoid's energy is split between positive and negative
+	// frequencies.
+	return 4 * cmplx.Abs(c) / float64(fs.Size)
+}
+
+// reset removes all frames, and sets the frame size and hop.
+func (fs *Frames) reset(size, hop int) {
+	fs.Size, fs.Hop = size, hop
+	fs.Frames = fs.Frames[:0]
+}
+
+// add adds a frame that ended at offset, reusing the storage of
+// frames removed by reset, and returns it. Its bins hold whatever the
+// reused frame's did.
+func (fs *Frames) add(offset int) *Frame {
+	n := len(fs.Frames)
+	if n < cap(fs.Frames) {
+		fs.Frames = fs.Frames[:n+1]
+	} else {
+		fs.Frames = append(fs.Frames, Frame{})
+	}
+	f := &fs.Frames[n]
+	f.Offset = offset
+	if bins := fs.Bins(); cap(f.Bins) >= bins {
+		f.Bins = f.Bins[:bins]
+	} else {
+		f.Bins = make([]complex128, bins)
+	}
+	return f
+}
+
+// copyFrom makes fs a copy of src, or empty if src is nil.
+func (fs *Frames) copyFrom(src *Frames) {
+	if src == nil {
+		fs.Frames = fs.Frames[:0]
+		return
+	}
+	fs.reset(src.Size, src.Hop)
+	for _, f := range src.Frames {
+		copy(fs.add(f.Offset).Bins, f.Bins)
+	}
+}
+
+// hann returns a periodic Hann window of the given size.
+func hann(size int) []float64 {
+	w := make([]float64, size)
+	for i := range w {
+		w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size))
+	}
+	return w
+}
+
+// resize returns s with length n, zeroed if it had to be reallocated.
+func resize(s []float64, n int) []float64 {
+	if len(s) == n {
+		return s
+	}
+	return make([]float64, n)
+}
+
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
@@ -0,0 +1,304 @@
+package spectral
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+const sampleRate = 44100
+
+// chain is a ugen that runs an FFT, transforms and an IFFT in order,
+// passing the frames of each to the "in" input of the next, as the
+// runner does. Every stage sees the chain's inputs, so the FFT
+// analyzes its "in" input and transforms read their controls from it.
+// If with is set, its frames are passed to each stage's "with"
+// input, and it analyzes the chain's "with" input.
+type chain struct {
+	stages []ugen.UGen
+	with   ugen.UGen
+
+	data    []map[string]any
+	withIn  map[string][]float64
+	withOut []float64
+}
+
+func newChain(with ugen.UGen, stages ...ugen.UGen) *chain {
+	c := &chain{stages: stages, with: with, withIn: map[string][]float64{}}
+	for i := range stages {
+		data := map[string]any{}
+		if i > 0 {
+			data["in"] = stages[i-1].(ugen.DataSource).Data()
+		}
+		if with != nil {
+			data["with"] = with.(ugen.DataSource).Data()
+		}
+		c.data = append(c.data, data)
+	}
+	return c
+}
+
+func (c *chain) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	if c.with != nil {
+		if len(c.withOut) != len(out) {
+			c.withOut = make([]float64, len(out))
+		}
+		c.withIn["in"] = cfg.InputSamples["with"]
+		withCfg := cfg
+		withCfg.InputSamples = c.withIn
+		c.with.Gen(ctx, withCfg, c.withOut)
+	}
+	for i, s := range c.stages {
+		stageCfg := cfg
+		stageCfg.InputData = c.data[i]
+		s.Gen(ctx, stageCfg, out)
+	}
+}
+
+// render renders seconds of a ugen with the given inputs, in blocks
+// of blockSize samples.
+func render(u ugen.UGen, seconds float64, blockSize int, inputs map[string]func(t float64) float64) *audiotest.Audio {
+	n := int(seconds * sampleRate)
+	res := make([]float64, 0, n+blockSize)
+	cfg := ugen.SampleConfig{SampleRateHz: sampleRate}
+	for start := 0; start < n; start += blockSize {
+		cfg.InputSamples = make(map[string][]float64)
+		for port, sig := range inputs {
+			in := make([]float64, blockSize)
+			for i := range in {
+				in[i] = sig(float64(start+i) / sampleRate)
+			}
+			cfg.InputSamples[port] = in
+		}
+		out := make([]float64, blockSize)
+		u.Gen(context.Background(), cfg, out)
+		res = append(res, out...)
+	}
+	return &audiotest.Audio{SampleRate: sampleRate, Channels: [][]float64{res[:n]}}
+}
+
+func sine(freq, amp float64) func(float64) float64 {
+	return func(t float64) float64 { return amp * math.Sin(2*math.Pi*freq*t) }
+}
+
+func constant(x float64) func(float64) float64 {
+	return func(float64) float64 { return x }
+}
+
+func TestResynthesis(t *testing.T) {
+	noise := make([]float64, sampleRate)
+	rnd := rand.New(rand.NewSource(1))
+	for i := range noise {
+		noise[i] = 2*rnd.Float64() - 1
+	}
+	in := func(t float64) float64 { return noise[int(math.Round(t*sampleRate))] }
+
+	for _, tc := range []struct {
+		size, overlap, blockSize int
+	}{
+		{64, 2, 128},
+		{1024, 4, 128},
+		{2048, 8, 61},
+		{256, 4, 1},
+	} {
+		c := newChain(nil, NewFFT(tc.size, tc.overlap), NewIFFT())
+		out := render(c, 0.2, tc.blockSize, map[string]func(float64) float64{"in": in})
+		samples := out.Mono()
+		// the first window is incomplete, so the output is compared
+		// from after it, delayed by the frame size.
+		for i := 2 * tc.size; i < len(samples); i++ {
+			if want := noise[i-tc.size]; math.Abs(samples[i]-want) > 1e-9 {
+				t.Errorf("size %d, overlap %d: sample %d is %v, want %v",
+					tc.size, tc.overlap, i, samples[i], want)
+				break
+			}
+		}
+	}
+}
+
+func TestFFTFrames(t *testing.T) {
+	fft := NewFFT(256, 4)
+	fs := fft.(ugen.DataSource).Data().(*Frames)
+	out := make([]float64, 200)
+	var offsets []int
+	for range 3 {
+		fft.Gen(context.Background(), ugen.SampleConfig{SampleRateHz: sampleRate}, out)
+		for _, f := range fs.Frames {
+			offsets = append(offsets, f.Offset)
+			if len(f.Bins) != 129 {
+				t.Fatalf("got %d bins, want 129", len(f.Bins))
+			}
+			if out[f.Offset] != 1 {
+				t.Errorf("no trigger at offset %d", f.Offset)
+			}
+		}
+	}
+	// a frame every 64 samples, the first after 64 samples.
+	want := []int{63, 127, 191, 55, 119, 183, 47, 111, 175}
+	if len(offsets) != len(want) {
+		t.Fatalf("got frames at %v, want %v", offsets, want)
+	}
+	for i := range want {
+		if offsets[i] != want[i] {
+			t.Fatalf("got frames at %v, want %v", offsets, want)
+		}
+	}
+}
+
+func TestNewFFTPanics(t *testing.T) {
+	for _, tc := range []struct{ size, overlap int }{
+		{1000, 4}, {8, 2}, {1 << 20, 4}, {1024, 1}, {1024, 3}, {64, 32},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewFFT(%d, %d): expected a panic", tc.size, tc.overlap)
+				}
+			}()
+			NewFFT(tc.size, tc.overlap)
+		}()
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		u      ugen.UGen
+		inputs map[string]func(float64) float64
+		// wantFreq is the dominant frequency of the output, or 0 if
+		// it should be silent.
+		wantFreq float64
+	}{
+		{
+			name:     "brick-wall pass",
+			u:        NewBrickWall(),
+			inputs:   map[string]func(float64) float64{"lo": constant(500), "hi": constant(2000)},
+			wantFreq: 1000,
+		},
+		{
+			name:   "brick-wall stop",
+			u:      NewBrickWall(),
+			inputs: map[string]func(float64) float64{"lo": constant(1500)},
+		},
+		{
+			name:     "gate pass",
+			u:        NewMagGate(),
+			inputs:   map[string]func(float64) float64{"threshold": constant(0.25)},
+			wantFreq: 1000,
+		},
+		{
+			name:   "gate stop",
+			u:      NewMagGate(),
+			inputs: map[string]func(float64) float64{"threshold": constant(0.75)},
+		},
+		{
+			name:     "bin shift",
+			u:        NewBinShift(),
+			inputs:   map[string]func(float64) float64{"shift": constant(500)},
+			wantFreq: 1500,
+		},
+		{
+			name:     "bin stretch",
+			u:        NewBinShift(),
+			inputs:   map[string]func(float64) float64{"stretch": constant(2)},
+			wantFreq: 2000,
+		},
+		{
+			// 1kHz is a quarter of the way between teeth 800Hz
+			// apart.
+			name:     "comb pass",
+			u:        NewComb(),
+			inputs:   map[string]func(float64) float64{"spacing": constant(800), "width": constant(0.6)},
+			wantFreq: 1000,
+		},
+		{
+			name:   "comb stop",
+			u:      NewComb(),
+			inputs: map[string]func(float64) float64{"spacing": constant(800), "width": constant(0.1)},
+		},
+		{
+			name:     "blur",
+			u:        NewBlur(),
+			inputs:   map[string]func(float64) float64{"amount": constant(0.9)},
+			wantFreq: 1000,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inputs := map[string]func(float64) float64{"in": sine(1000, 0.5)}
+			for port, sig := range tc.inputs {
+				inputs[port] = sig
+			}
+			c := newChain(nil, NewFFT(1024, 4), tc.u, NewIFFT())
+			out := render(c, 0.5, 128, inputs).Slice(0.1, 0.5)
+			if tc.wantFreq == 0 {
+				if peak := out.Peak(); peak > 0.01 {
+					t.Errorf("got peak %v, want silence", peak)
+				}
+				return
+			}
+			if got := out.DominantFrequency(); math.Abs(got-tc.wantFreq) > 50 {
+				t.Errorf("got dominant frequency %v, want %v", got, tc.wantFreq)
+			}
+			if rms := out.RMS(); rms < 0.1 {
+				t.Errorf("got RMS %v, want the sine", rms)
+			}
+		})
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	// a sine that stops at 0.2s, frozen from 0.15s.
+	c := newChain(nil, NewFFT(1024, 4), NewFreeze(), NewIFFT())
+	out := render(c, 0.6, 128, map[string]func(float64) float64{
+		"in": func(t float64) float64 {
+			if t >= 0.2 {
+				return 0
+			}
+			return sine(440, 0.5)(t)
+		},
+		"freeze": func(t float64) float64 {
+			if t >= 0.15 {
+				return 1
+			}
+			return 0
+		},
+	})
+	held := out.Slice(0.3, 0.6)
+	if got := held.DominantFrequency(); math.Abs(got-440) > 20 {
+		t.Errorf("got dominant frequency %v, want 440", got)
+	}
+	if rms := held.RMS(); math.Abs(rms-0.5/math.Sqrt2) > 0.05 {
+		t.Errorf("got RMS %v, want about %v", rms, 0.5/math.Sqrt2)
+	}
+}
+
+func TestCross(t *testing.T) {
+	// noise given the magnitudes of a sine sounds like the sine.
+	rnd := rand.New(rand.NewSource(1))
+	in := func(float64) float64 { return 2*rnd.Float64() - 1 }
+	for _, tc := range []struct {
+		amount   float64
+		wantFreq float64
+	}{
+		{1, 1000},
+		{0, 0},
+	} {
+		c := newChain(NewFFT(1024, 4), NewFFT(1024, 4), NewCross(), NewIFFT())
+		out := render(c, 0.5, 128, map[string]func(float64) float64{
+			"in":     in,
+			"with":   sine(1000, 0.5),
+			"amount": constant(tc.amount),
+		}).Slice(0.1, 0.5)
+		got := out.DominantFrequency()
+		if tc.wantFreq != 0 && math.Abs(got-tc.wantFreq) > 50 {
+			t.Errorf("amount %v: got dominant frequency %v, want %v", tc.amount, got, tc.wantFreq)
+		}
+		if tc.wantFreq == 0 && math.Abs(got-1000) < 50 {
+			t.Errorf("amount %v: got dominant frequency %v, want the noise", tc.amount, got)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package spectral
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+type (
+	// transform is a ugen that transforms each frame of its "in"
+	// input.
+	transform struct {
+		// apply transforms a copy of a frame of the input in place.
+		apply func(cfg ugen.SampleConfig, fs *Frames, f *Frame)
+		// finish, if set, is called after the frames of each block
+		// have been transformed.
+		finish func(cfg ugen.SampleConfig)
+
+		out Frames
+	}
+)
+
+func (t *transform) Data() any {
+	return &t.out
+}
+
+func (t *transform) Gen(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
+	clear(out)
+	in, _ := cfg.InputData["in"].(*Frames)
+	t.out.copyFrom(in)
+	for i := range t.out.Frames {
+		f := &t.out.Frames[i]
+		t.apply(cfg, &t.out, f)
+		out[f.Offset] = 1
+	}
+	if t.finish != nil {
+		t.finish(cfg)
+	}
+}
+
+// control returns the value of an input at the sample at which f
+// ended, or def if the input isn't connected.
+func control(cfg ugen.SampleConfig, name string, f *Frame, def float64) float64 {
+	in := cfg.InputSamples[name]
+	if len(in) == 0 {
+		return def
+	}
+	return in[f.Offset]
+}
+
+// NewFreeze returns a transform that, while its "freeze" input is
+// positive, holds the magnitudes of the last frame before it became
+// positive, advancing each bin's phase at the rate it was then
+// advancing so that the held sound doesn't buzz at the frame rate.
+func NewFreeze() ugen.UGen {
+	var mags, phases, lastPhases, dphases []float64
+	return &transform{
+		apply: func(cfg ugen.SampleConfig, fs *Frames, f *Frame) {
+			if len(mags) != len(f.Bins) {
+				mags = make([]float64, len(f.Bins))
+				phases = make([]float64, len(f.Bins))
+				lastPhases = make([]float64, len(f.Bins))
+				dphases = make([]float64, len(f.Bins))
+			}
+			frozen := control(cfg, "freeze", f, 0) > 0
+			for k, c := range f.Bins {
+				mag, phase := cmplx.Polar(c)
+				if !frozen {
+					mags[k] = mag
+					phases[k] = phase
+					dphases[k] = phase - lastPhases[k]
+				}
+				lastPhases[k] = phase
+				if frozen {
+					phases[k] = math.Remainder(phases[k]+dphases[k], 2*math.Pi)
+					f.Bins[k] = cmplx.Rect(mags[k], phases[k])
+				}
+			}
+		},
+	}
+}
+
+// NewBlur returns a transform that smears the magnitude of each bin
+// over time. Its "amount" input, from 0 to 1, is the fraction of a
+// bin's magnitude carried over from one frame to the next; 0 leaves
+// the frames as they are.
+func NewBlur() ugen.UGen {
+	var mags []float64
+	return &transform{
+		apply: func(cfg ugen.SampleConfig, fs *Frames, f *Frame) {
+			mags = resize(mags, len(f.Bins))
+			amount := control(cfg, "amount", f, 0)
+			if !isFinite(amount) {
+				amount = 0
+			}
+			// stop short of 1, which would hold the magnitudes
+			// forever.
+			amount = math.Max(0, math.Min(0.999, amount))
+			for k, c := range f.Bins {
+				mag, phase := cmplx.Polar(c)
+				mags[k] = amount*mags[k] + (1-amount)*mag
+				f.Bins[k] = cmplx.Rect(mags[k], phase)
+			}
+		},
+	}
+}
+
+// NewBrickWall returns a transform that zeroes the bins below its
+// "lo" input and above its "hi" input, both in Hz.
+func NewBrickWall() ugen.UGen {
+	return &transform{
+		apply: func(cfg ugen.SampleConfig, fs *Frames, f *Frame) {
+			lo := control(cfg, "lo", f, 0)
+			hi := control(cfg, "hi", f, math.Inf(1))
+			sampleRate := float64(cfg.SampleRateHz)
+			for k := range f.Bins {
+				if freq := fs.BinFreq(k, sampleRate); freq < lo || freq > hi {
+					f.Bins[k] = 0
+				}
+			}
+		},
+	}
+}
+
+// NewComb returns a transform that masks the spectrum with the teeth
+// of a comb. Its "spacing" input is the distance between teeth in Hz,
+// "phase" offsets them, as a fraction of the spacing, and "width" is
+// the fraction of the spacing that each tooth passes.
+func NewComb() ugen.UGen {
+	return &transform{
+		apply: func(cfg ugen.SampleConfig, fs *Frames, f *Frame) {
+			spacing := control(cfg, "spacing", f, 500)
+			phase := control(cfg, "phase", f, 0)
+			width := control(cfg, "width", f, 0.5)
+			if !isFinite(spacing) || !isFinite(phase) || !isFinite(width) || spacing <= 0 {
+				return
+			}
+			sampleRate := float64(cfg.SampleRateHz)
+			for k := range f.Bins {
+				x := fs.BinFreq(k, sampleRate)/spacing + phase
+				if x-math.Floor(x) >= width {
+					f.Bins[k] = 0
+				}
+			}
+		},
+	}
+}
+
+// NewBinShift returns a transform that moves each partial to a new
+// frequency, multiplied by its "stretch" input and then offset by its
+// "shift" input, in Hz. Partials moved outside the spectrum are
+// dropped.
+//
+// Each peak in the spectrum is moved with the bins around it, down to
+// the quietest bins between it and its neighbors, so that partials
+// keep their shape, and the phases of the moved bins are rotated by an
+// amount that advances with the frequency each peak was moved by
+// (Laroche and Dolson's phase-locked vocoder).
+func NewBinShift() ugen.UGen {
+	var (
+		mags, phases, lastPhases []float64
+		// rotations holds the phase rotation of the peaks of the
+		// last frame, by the bins they were moved to.
+		rotations, nextRotations []float64
+		peaks                    []int
+		scratch                  []complex128
+	)
+	return &transform{
+		apply: func(cfg ugen.SampleConfig, fs *Frames, f *Frame) {
+			stretch := control(cfg, "stretch", f, 1)
+			shift := control(cfg, "shift", f, 0)
+			if !isFinite(stretch) || !isFinite(shift) {
+				return
+			}
+			// frequencies are in bins from here on.
+			shift /= fs.BinFreq(1, float64(cfg.SampleRateHz))
+
+			n := len(f.Bins)
+			if len(mags) != n {
+				mags = make([]float64, n)
+				phases = make([]float64, n)
+				lastPhases = make([]float64, n)
+				rotations = make([]float64, n)
+				nextRotations = make([]float64, n)
+				peaks = make([]int, 0, n)
+				scratch = make([]complex128, n)
+			}
+			peaks = peaks[:0]
+			for k, c := range f.Bins {
+				mags[k], phases[k] = cmplx.Polar(c)
+			}
+			for k, mag := range mags {
+				if mag > 0 && (k == 0 || mag > mags[k-1]) && (k == n-1 || mag >= mags[k+1]) {
+					peaks = append(peaks, k)
+				}
+			}
+
+			// the phase advance over a hop of a sinusoid at the
+			// center of bin 1.
+			binAdvance := 2 * math.Pi * float64(fs.Hop) / float64(fs.Size)
+			clear(scratch)
+			clear(nextRotations)
+			start := 0
+			for i, p := range peaks {
+				// the peak's region ends at the quietest bin before
+				// the next peak.
+				end := n
+				if i+1 < len(peaks) {
+					end = p + 1
+					for k := p + 1; k < peaks[i+1]; k++ {
+						if mags[k] < mags[end] {
+							end = k
+						}
+					}
+				}
+				first := start
+				start = end
+
+				// the peak's frequency, from the advance of its
+				// phase since the last frame.
+				dev := math.Remainder(phases[p]-lastPhases[p]-float64(p)*binAdvance, 2*math.Pi)
+				freq := float64(p) + dev/binAdvance
+				delta := freq*stretch + shift - freq
+				if math.Abs(delta) >= float64(n) {
+					continue
+				}
+				d := int(math.Round(delta))
+				if p+d < 0 || p+d >= n {
+					continue
+				}
+				rot := math.Remainder(rotations[p+d]+delta*binAdvance, 2*math.Pi)
+				nextRotations[p+d] = rot
+				for k := first; k < end; k++ {
+					if j := k + d; j >= 0 && j < n {
+						scratch[j] += cmplx.Rect(mags[k], phases[k]+rot)
+					}
+				}
+			}
+			copy(lastPhases, phases)
+			rotations, nextRotations = nextRotations, rotations
+			copy(f.Bins, scratch)
+		},
+	}
+}
+
+// NewMagGate returns a transform that zeroes the bins quieter than its
+// "threshold" input, the amplitude of a sinusoid that would give the
+// bin its magnitude.
+func NewMagGate() ugen.UGen {
+	return &transform{
+		apply: func(cfg ugen.SampleConfig, fs *Frames, f *Frame) {
+			threshold := control(cfg, "threshold", f, 0)
+			for k, c := range f.Bins {
+				if fs.Amplitude(c) < threshold {
+					f.Bins[k] = 0
+				}
+			}
+		},
+	}
+}
+
+// NewCross returns a transform for cross-synthesis: it gives the
+// frames of its "in" input the magnitudes of the frames of its "with"
+// input, keeping the phases of "in". Its "amount" input, from 0 to 1,
+// crossfades between the magnitudes of "in" and those of "with". The
+// frames of "in" and "with" must be the same size; if they aren't,
+// "in" is passed through unchanged.
+func NewCross() ugen.UGen {
+	var (
+		mags []float64
+		// next is the index of the next frame of "with" in the
+		// current block.
+		next int
+	)
+	// update takes the magnitudes of the frames of "with" that ended
+	// at or before offset.
+	update := func(with *Frames, offset int) {
+		for ; next < len(with.Frames) && with.Frames[next].Offset <= offset; next++ {
+			mags = resize(mags, len(with.Frames[next].Bins))
+			for k, c := range with.Frames[next].Bins {
+				mags[k] = cmplx.Abs(c)
+			}
+		}
+	}
+	return &transform{
+		apply: func(cfg ugen.SampleConfig, fs *Frames, f *Frame) {
+			with, _ := cfg.InputData["with"].(*Frames)
+			if with == nil || with.Size != fs.Size {
+				return
+			}
+			update(with, f.Offset)
+			amount := control(cfg, "amount", f, 1)
+			if len(mags) != len(f.Bins) || !isFinite(amount) {
+				return
+			}
+			amount = math.Max(0, math.Min(1, amount))
+			for k, c := range f.Bins {
+				mag, phase := cmplx.Polar(c)
+				f.Bins[k] = cmplx.Rect(mag+amount*(mags[k]-mag), phase)
+			}
+		},
+		finish: func(cfg ugen.SampleConfig) {
+			// frames of "with" after the last frame of "in" are
+			// used for the first frames of the next block.
+			if with, _ := cfg.InputData["with"].(*Frames); with != nil {
+				update(with, math.MaxInt)
+			}
+			next = 0
+		},
+	}
+}
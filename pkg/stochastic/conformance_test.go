@@ -0,0 +1,50 @@
+package stochastic
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+)
+
+func TestConformance(t *testing.T) {
+	ugentest.Run(t,
+		ugentest.Spec{
+			Name:   "noise",
+			New:    func() ugen.UGen { return NewNoise(ugen.WithSeed(1)) },
+			Inputs: map[string]ugentest.Signal{"w": ugentest.Const(0)},
+		},
+		ugentest.Spec{
+			Name:   "noise-sample-and-hold",
+			New:    func() ugen.UGen { return NewNoise(ugen.WithSeed(1)) },
+			Inputs: map[string]ugentest.Signal{"w": ugentest.Const(1000)},
+		},
+		ugentest.Spec{
+			Name:   "noise-quad",
+			New:    func() ugen.UGen { return NewNoiseQuad(ugen.WithSeed(1)) },
+			Inputs: map[string]ugentest.Signal{"w": ugentest.Const(500)},
+		},
+		ugentest.Spec{
+			Name: "pink-noise",
+			New:  func() ugen.UGen { return NewPinkNoise(ugen.WithSeed(1)) },
+			// the slowest octaves of pink noise wander far from zero
+			// over a second, so compare the level about the mean.
+			Measure: func(a *audiotest.Audio) float64 {
+				rms, dc := a.RMS(), a.DC()
+				return math.Sqrt(rms*rms - dc*dc)
+			},
+		},
+		ugentest.Spec{
+			Name: "rrand",
+			New:  func() ugen.UGen { return NewRRand(rand.New(rand.NewSource(1))) },
+			Inputs: map[string]ugentest.Signal{
+				"trig": ugentest.Gate(10),
+				"min":  ugentest.Const(1),
+				"max":  ugentest.Const(2),
+			},
+		},
+	)
+}
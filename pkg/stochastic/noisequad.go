@@ -36,7 +36,7 @@ func NewNoiseQuad(opts ...ugen.Option) ugen.UGen {
 		i := 0
 		for remain > 0 {
 			freq := defaultFreq
-			if len(ws) > 0 {
+			if len(ws) > 0 && !math.IsNaN(ws[i]) && !math.IsInf(ws[i], 0) {
 				freq = math.Max(ws[i], 0.001)
 			}
 
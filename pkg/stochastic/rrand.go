@@ -11,10 +11,13 @@ func NewRRand(rnd *rand.Rand) ugen.UGen {
 	var val float64
 	inited := false
 	lastTrig := 0.0
+	trigInput := ugen.Input{Name: "trig"}
+	minInput := ugen.Input{Name: "min"}
+	maxInput := ugen.Input{Name: "max", Default: 1}
 	return ugen.UGenFunc(func(ctx context.Context, cfg ugen.SampleConfig, out []float64) {
-		trigs := cfg.InputSamples["trig"]
-		mins := cfg.InputSamples["min"]
-		maxs := cfg.InputSamples["max"]
+		trigs := trigInput.Samples(cfg, len(out))
+		mins := minInput.Samples(cfg, len(out))
+		maxs := maxInput.Samples(cfg, len(out))
 
 		if !inited {
 			val = mins[0] + rnd.Float64()*(maxs[0]-mins[0])
@@ -25,6 +28,7 @@ func NewRRand(rnd *rand.Rand) ugen.UGen {
 				val = mins[i] + rnd.Float64()*(maxs[i]-mins[i])
 			}
 			out[i] = val
+			lastTrig = trigs[i]
 		}
 	})
 }
@@ -6,8 +6,9 @@ import (
 )
 
 func NewAbs() UGen {
+	inInput := Input{Name: "in"}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
+		in := inInput.Samples(cfg, len(out))
 		// index the last element of in to lift the bounds check
 		_ = in[len(out)-1]
 		for i := range out {
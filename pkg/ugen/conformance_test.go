@@ -0,0 +1,160 @@
+package ugen_test
+
+import (
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+	"github.com/jfhamlin/muscrat/pkg/ugen/ugentest"
+)
+
+func TestConformance(t *testing.T) {
+	sine := ugentest.Sine(440)
+	ugentest.Run(t,
+		ugentest.Spec{
+			Name:   "abs",
+			New:    ugen.NewAbs,
+			Inputs: map[string]ugentest.Signal{"in": sine},
+		},
+		ugentest.Spec{
+			Name: "constant",
+			New:  func() ugen.UGen { return ugen.NewConstant(0.5) },
+		},
+		ugentest.Spec{
+			Name:   "copy-sign",
+			New:    ugen.NewCopySign,
+			Inputs: map[string]ugentest.Signal{"in": sine, "sign": ugentest.Sine(110)},
+		},
+		ugentest.Spec{
+			Name:   "exp",
+			New:    ugen.NewExp,
+			Inputs: map[string]ugentest.Signal{"in": sine},
+		},
+		ugentest.Spec{
+			Name: "fma",
+			New:  ugen.NewFMA,
+			Inputs: map[string]ugentest.Signal{
+				"in":  sine,
+				"mul": ugentest.Const(0.5),
+				"add": ugentest.Const(0.1),
+			},
+		},
+		ugentest.Spec{
+			Name:   "fma-static",
+			New:    func() ugen.UGen { return ugen.NewFMAStatic(0.5, 0.1) },
+			Inputs: map[string]ugentest.Signal{"in": sine},
+		},
+		ugentest.Spec{
+			Name:   "freq-ratio",
+			New:    func() ugen.UGen { return ugen.NewFreqRatio("semitones") },
+			Inputs: map[string]ugentest.Signal{"in": ugentest.Const(7)},
+		},
+		ugentest.Spec{
+			Name:        "hydra",
+			New:         func() ugen.UGen { return ugen.NewHydra(nil, []string{"x"}) },
+			Inputs:      map[string]ugentest.Signal{"x": sine},
+			AllowAllocs: true,
+		},
+		ugentest.Spec{
+			Name: "impulse",
+			New:  func() ugen.UGen { return ugen.NewImpulse() },
+			Inputs: map[string]ugentest.Signal{
+				"w":      ugentest.Const(100),
+				"iphase": ugentest.Const(0),
+				"sync":   ugentest.Const(0),
+			},
+			Measure: ugentest.Count,
+		},
+		ugentest.Spec{
+			Name: "knob",
+			New:  func() ugen.UGen { return ugen.NewKnob("knob", 0.5, 0, 1, 0.01, "") },
+		},
+		ugentest.Spec{
+			Name:   "latch",
+			New:    ugen.NewLatch,
+			Inputs: map[string]ugentest.Signal{"in": sine, "trigger": ugentest.Gate(10)},
+		},
+		ugentest.Spec{
+			Name:   "leak-dc",
+			New:    ugen.NewLeakDC,
+			Inputs: map[string]ugentest.Signal{"in": sine, "coef": ugentest.Const(0.995)},
+		},
+		ugentest.Spec{
+			Name: "lin-exp",
+			New:  ugen.NewLinExp,
+			Inputs: map[string]ugentest.Signal{
+				"in":    sine,
+				"srclo": ugentest.Const(-1),
+				"srchi": ugentest.Const(1),
+				"dstlo": ugentest.Const(100),
+				"dsthi": ugentest.Const(1000),
+			},
+		},
+		ugentest.Spec{
+			Name:   "log2",
+			New:    ugen.NewLog2,
+			Inputs: map[string]ugentest.Signal{"in": ugentest.Const(440)},
+		},
+		ugentest.Spec{
+			Name:   "max",
+			New:    ugen.NewMax,
+			Inputs: map[string]ugentest.Signal{"0": sine, "1": ugentest.Sine(110)},
+		},
+		ugentest.Spec{
+			Name:   "midi-freq",
+			New:    ugen.NewMIDIFreq,
+			Inputs: map[string]ugentest.Signal{"in": ugentest.Const(69)},
+		},
+		ugentest.Spec{
+			Name:   "min",
+			New:    ugen.NewMin,
+			Inputs: map[string]ugentest.Signal{"0": sine, "1": ugentest.Sine(110)},
+		},
+		ugentest.Spec{
+			Name:   "moving-average",
+			New:    func() ugen.UGen { return ugen.NewMovingAverage(0.1) },
+			Inputs: map[string]ugentest.Signal{"in": ugentest.Gate(5), "dur": ugentest.Const(0.05)},
+		},
+		ugentest.Spec{
+			Name:   "product",
+			New:    ugen.NewProduct,
+			Inputs: map[string]ugentest.Signal{"$0": sine, "$1": ugentest.Const(0.5)},
+		},
+		ugentest.Spec{
+			Name:   "pow",
+			New:    ugen.NewPow,
+			Inputs: map[string]ugentest.Signal{"base": sine, "exp": ugentest.Const(2)},
+		},
+		ugentest.Spec{
+			Name:    "pulse-div",
+			New:     func() ugen.UGen { return ugen.NewPulseDiv(0) },
+			Inputs:  map[string]ugentest.Signal{"trigger": ugentest.Gate(100), "div": ugentest.Const(4)},
+			Measure: ugentest.Count,
+		},
+		ugentest.Spec{
+			Name:   "quotient",
+			New:    ugen.NewQuotient,
+			Inputs: map[string]ugentest.Signal{"$0": sine, "$1": ugentest.Const(2)},
+		},
+		ugentest.Spec{
+			Name:        "scope",
+			New:         func() ugen.UGen { return ugen.NewScope("scope", 0) },
+			Inputs:      map[string]ugentest.Signal{"in": sine},
+			AllowAllocs: true,
+		},
+		ugentest.Spec{
+			Name:   "sine",
+			New:    ugen.NewSine,
+			Inputs: map[string]ugentest.Signal{"in": sine},
+		},
+		ugentest.Spec{
+			Name:   "sum",
+			New:    ugen.NewSum,
+			Inputs: map[string]ugentest.Signal{"$0": sine, "$1": ugentest.Const(0.5)},
+		},
+		ugentest.Spec{
+			Name:   "tanh",
+			New:    ugen.NewTanh,
+			Inputs: map[string]ugentest.Signal{"in": sine},
+		},
+	)
+}
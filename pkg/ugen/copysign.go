@@ -6,9 +6,11 @@ import (
 )
 
 func NewCopySign() UGen {
+	inInput := Input{Name: "in"}
+	signInput := Input{Name: "sign", Default: 1}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		sign := cfg.InputSamples["sign"]
+		in := inInput.Samples(cfg, len(out))
+		sign := signInput.Samples(cfg, len(out))
 		// index the last element of in and sign to lift the bounds check
 		_ = in[len(out)-1]
 		_ = sign[len(out)-1]
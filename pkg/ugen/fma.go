@@ -5,10 +5,13 @@ import "context"
 // NewFMA creates a new ugen for a fused multiply + add operation with
 // dynamic multiplicand and summand.
 func NewFMA() UGen {
+	inInput := Input{Name: "in"}
+	mulInput := Input{Name: "mul", Default: 1}
+	addInput := Input{Name: "add"}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		mul := cfg.InputSamples["mul"]
-		add := cfg.InputSamples["add"]
+		in := inInput.Samples(cfg, len(out))
+		mul := mulInput.Samples(cfg, len(out))
+		add := addInput.Samples(cfg, len(out))
 		// index the last element of in, mul, and add to lift the bounds check
 		_ = in[len(out)-1]
 		_ = mul[len(out)-1]
@@ -22,8 +25,9 @@ func NewFMA() UGen {
 // NewFMAStatic creates a new ugen for a fused multiply + add
 // operation with static multiplicand and summand.
 func NewFMAStatic(mul, add float64) UGen {
+	inInput := Input{Name: "in"}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
+		in := inInput.Samples(cfg, len(out))
 		// index the last element of in to lift the bounds check
 		_ = in[len(out)-1]
 		for i := range out {
@@ -10,6 +10,10 @@ type (
 
 		// Input samples that can be used to generate the output samples.
 		InputSamples map[string][]float64
+
+		// InputData holds the data of inputs connected to a
+		// DataSource, such as the spectra from spectral ugens.
+		InputData map[string]any
 	}
 
 	// UGen is an abstract interface for generating samples.
@@ -47,3 +51,13 @@ type Starter interface {
 type Stopper interface {
 	Stop(ctx context.Context) error
 }
+
+// DataSource is an interface for sample generators that pass data
+// other than samples to the generators connected to them. Data is
+// called once, when the generator is connected, and must return the
+// same value every time; its contents may be updated by each call to
+// Gen, and are read by connected generators through
+// SampleConfig.InputData.
+type DataSource interface {
+	Data() any
+}
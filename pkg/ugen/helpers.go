@@ -8,23 +8,29 @@ import (
 
 // CollectIndexedInputs collects all inputs of the form "$<int>".
 func CollectIndexedInputs(cfg SampleConfig) [][]float64 {
-	res := make([][]float64, 0, len(cfg.InputSamples))
+	return AppendIndexedInputs(make([][]float64, 0, len(cfg.InputSamples)), cfg)
+}
+
+// AppendIndexedInputs appends all inputs of the form "$<int>" to dst,
+// in order of their indices, and returns the extended slice. As with
+// CollectIndexedInputs, missing indices are nil. Ugens can pass
+// dst[:0] of the previous result to avoid allocating on every call.
+func AppendIndexedInputs(dst [][]float64, cfg SampleConfig) [][]float64 {
+	base := len(dst)
 	for k, v := range cfg.InputSamples {
 		if !strings.HasPrefix(k, "$") {
 			continue
 		}
 		idx, err := strconv.Atoi(k[1:])
-		if err != nil {
+		if err != nil || idx < 0 {
 			continue
 		}
-		if idx >= len(res) {
-			for i := len(res); i <= idx; i++ {
-				res = append(res, nil)
-			}
+		for base+idx >= len(dst) {
+			dst = append(dst, nil)
 		}
-		res[idx] = v
+		dst[base+idx] = v
 	}
-	return res
+	return dst
 }
 
 func ZapGremlins(x float64) float64 {
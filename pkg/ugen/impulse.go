@@ -15,13 +15,13 @@ func NewImpulse(opts ...Option) UGen {
 	initialized := false
 	lastSync := 1.0
 
+	wInput := Input{Name: "w", Default: 440}
+	syncInput := Input{Name: "sync"}
+
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		ws := cfg.InputSamples["w"]
+		ws := wInput.Samples(cfg, len(out))
 		iphases := cfg.InputSamples["iphase"]
-		syncs := cfg.InputSamples["sync"]
-		if len(syncs) == 0 {
-			syncs = Zeros
-		}
+		syncs := syncInput.Samples(cfg, len(out))
 
 		_ = ws[len(out)-1]
 		_ = syncs[len(out)-1]
@@ -38,14 +38,14 @@ func NewImpulse(opts ...Option) UGen {
 			if phase == 0 {
 				phase = 1 // emit a sample on the first iteration
 			}
-			phaseIncrement = freq / float64(cfg.SampleRateHz)
+			phaseIncrement = impulseIncrement(freq, cfg.SampleRateHz)
 			initialized = true
 		}
 
 		for i := range out {
 			if ws[i] != freq {
 				freq = math.Max(ws[i], 0)
-				phaseIncrement = freq / float64(cfg.SampleRateHz)
+				phaseIncrement = impulseIncrement(freq, cfg.SampleRateHz)
 			}
 			if len(iphases) > 0 && iphases[i] != phaseOffset {
 				correction := iphases[i] - phaseOffset
@@ -53,6 +53,8 @@ func NewImpulse(opts ...Option) UGen {
 				phase = math.Mod(phase+correction, 1)
 				if phase < 0 {
 					phase = 1 + phase
+				} else if math.IsNaN(phase) {
+					phase = 0
 				}
 			}
 			phase += phaseIncrement
@@ -71,3 +73,14 @@ func NewImpulse(opts ...Option) UGen {
 		}
 	})
 }
+
+// impulseIncrement returns the phase increment per sample for
+// impulses at freq Hz. It's at most 1, an impulse every sample, and 0
+// for a NaN frequency, so the phase stays finite.
+func impulseIncrement(freq float64, sampleRateHz int) float64 {
+	inc := freq / float64(sampleRateHz)
+	if !(inc > 0) {
+		return 0
+	}
+	return math.Min(inc, 1)
+}
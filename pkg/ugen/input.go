@@ -0,0 +1,32 @@
+package ugen
+
+type (
+	// Input reads an input port that may not be connected, standing
+	// in a default value for its samples if it isn't. Ugens should
+	// read their inputs through an Input rather than indexing
+	// SampleConfig.InputSamples directly, since a missing port would
+	// otherwise panic on the audio thread.
+	Input struct {
+		Name    string
+		Default float64
+
+		defaults []float64
+	}
+)
+
+// Samples returns the samples of the input for a block of n samples,
+// or n copies of the default if the input isn't connected. The
+// defaults are only allocated when a block is larger than any before
+// it.
+func (in *Input) Samples(cfg SampleConfig, n int) []float64 {
+	if s := cfg.InputSamples[in.Name]; len(s) >= n {
+		return s[:n]
+	}
+	if len(in.defaults) < n {
+		in.defaults = make([]float64, n)
+		for i := range in.defaults {
+			in.defaults[i] = in.Default
+		}
+	}
+	return in.defaults[:n]
+}
@@ -38,6 +38,11 @@ const (
 	// value changes.
 	KnobValueChangeEvent = "knob-value-change"
 
+	// KnobValueSetEvent is the event that is sent when a knob's value
+	// is changed remotely, e.g. over OSC, so that user interfaces can
+	// reflect the new value.
+	KnobValueSetEvent = "knob-value-set"
+
 	// KnobsChangedEvent is the event that is sent when the list of
 	// knobs changes.
 	KnobsChangedEvent = "knobs-changed"
@@ -81,7 +86,21 @@ func NewKnob(name string, def, min, max, step float64, group string) *Knob {
 	return k
 }
 
+// Value returns the knob's current value.
+func (k *Knob) Value() float64 {
+	return math.Float64frombits(k.valueBits.Load())
+}
+
+// Start registers the knob and subscribes to changes to its value.
+// Starting a started knob does nothing.
 func (k *Knob) Start(ctx context.Context) error {
+	knobLock.RLock()
+	started := k.unsubscribe != nil
+	knobLock.RUnlock()
+	if started {
+		return nil
+	}
+
 	unsubscribe := pubsub.Subscribe(KnobValueChangeEvent, func(event string, data any) {
 		update := data.(KnobUpdate)
 		if update.ID != k.ID {
@@ -101,15 +120,18 @@ func (k *Knob) Start(ctx context.Context) error {
 	return nil
 }
 
+// Stop unregisters the knob. Stopping a stopped knob does nothing.
 func (k *Knob) Stop(ctx context.Context) error {
 	knobLock.Lock()
 	delete(knobs, k.ID)
 	unsubscribe := k.unsubscribe
+	k.unsubscribe = nil
 	knobLock.Unlock()
 
-	if unsubscribe != nil {
-		unsubscribe()
+	if unsubscribe == nil {
+		return nil
 	}
+	unsubscribe()
 	pubsub.Publish(KnobsChangedEvent, nil)
 
 	return nil
@@ -5,9 +5,11 @@ import "context"
 func NewLatch() UGen {
 	lastTrig := 0.0
 	val := 0.0
+	inInput := Input{Name: "in"}
+	trigInput := Input{Name: "trigger"}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		trig := cfg.InputSamples["trigger"]
+		in := inInput.Samples(cfg, len(out))
+		trig := trigInput.Samples(cfg, len(out))
 		// index the last element of in to lift the bounds check
 		_ = in[len(out)-1]
 		_ = trig[len(out)-1]
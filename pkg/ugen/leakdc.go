@@ -1,9 +1,6 @@
 package ugen
 
-import (
-	"context"
-	"math"
-)
+import "context"
 
 // NewLeakDC creates a DC blocking filter (high-pass filter).
 // The coef parameter controls the filter coefficient (0.995 is a good default).
@@ -15,9 +12,12 @@ func NewLeakDC() UGen {
 		initialized bool
 	)
 
+	inInput := Input{Name: "in"}
+	coefInput := Input{Name: "coef", Default: 0.995}
+
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		coef := cfg.InputSamples["coef"]
+		in := inInput.Samples(cfg, len(out))
+		coef := coefInput.Samples(cfg, len(out))
 
 		// index the last elements to lift the bounds check
 		_ = in[len(out)-1]
@@ -39,10 +39,9 @@ func NewLeakDC() UGen {
 			// Store current input for next iteration
 			x1 = x0
 			
-			// Remove denormal numbers (equivalent to zapgremlins)
-			if math.Abs(y1) < 1e-15 {
-				y1 = 0
-			}
+			// Remove denormal numbers, and recover from NaN or
+			// infinite input
+			y1 = ZapGremlins(y1)
 			
 			out[i] = y1
 		}
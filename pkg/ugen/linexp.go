@@ -6,12 +6,17 @@ import (
 )
 
 func NewLinExp() UGen {
+	inInput := Input{Name: "in"}
+	srcloInput := Input{Name: "srclo"}
+	srchiInput := Input{Name: "srchi", Default: 1}
+	dstloInput := Input{Name: "dstlo", Default: 1}
+	dsthiInput := Input{Name: "dsthi", Default: 2}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		srclo := cfg.InputSamples["srclo"]
-		srchi := cfg.InputSamples["srchi"]
-		dstlo := cfg.InputSamples["dstlo"]
-		dsthi := cfg.InputSamples["dsthi"]
+		in := inInput.Samples(cfg, len(out))
+		srclo := srcloInput.Samples(cfg, len(out))
+		srchi := srchiInput.Samples(cfg, len(out))
+		dstlo := dstloInput.Samples(cfg, len(out))
+		dsthi := dsthiInput.Samples(cfg, len(out))
 
 		_ = in[len(out)-1]
 		_ = srclo[len(out)-1]
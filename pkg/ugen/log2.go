@@ -6,8 +6,9 @@ import (
 )
 
 func NewLog2() UGen {
+	inInput := Input{Name: "in"}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
+		in := inInput.Samples(cfg, len(out))
 		_ = in[len(out)-1]
 		for i := range out {
 			out[i] = math.Log2(in[i])
@@ -6,8 +6,9 @@ import (
 )
 
 func NewMIDIFreq() UGen {
+	inInput := Input{Name: "in", Default: 69}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
+		in := inInput.Samples(cfg, len(out))
 		for i := range out {
 			out[i] = 440 * math.Pow(2, (in[i]-69)/12)
 		}
@@ -20,9 +20,12 @@ func NewMovingAverage(maxDurSecs float64) UGen {
 		head, tail int
 	)
 
+	inInput := Input{Name: "in"}
+	durInput := Input{Name: "dur", Default: 0.001}
+
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		in := cfg.InputSamples["in"]
-		dur := cfg.InputSamples["dur"]
+		in := inInput.Samples(cfg, len(out))
+		dur := durInput.Samples(cfg, len(out))
 
 		// index the last elements to lift the bounds check
 		_ = in[len(out)-1]
@@ -65,8 +68,13 @@ func NewMovingAverage(maxDurSecs float64) UGen {
 				curSize--
 			}
 
-			sum += in[i]
-			buf[head] = in[i]
+			x := in[i]
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				// keep the running sum finite.
+				x = 0
+			}
+			sum += x
+			buf[head] = x
 			curSize++
 
 			head++
@@ -6,9 +6,11 @@ import (
 )
 
 func NewPow() UGen {
+	baseInput := Input{Name: "base"}
+	expInput := Input{Name: "exp", Default: 1}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		base := cfg.InputSamples["base"]
-		exp := cfg.InputSamples["exp"]
+		base := baseInput.Samples(cfg, len(out))
+		exp := expInput.Samples(cfg, len(out))
 		for i := range out {
 			if base[i] < 0 {
 				out[i] = -math.Pow(-base[i], exp[i])
@@ -8,9 +8,11 @@ import (
 func NewPulseDiv(start float64) UGen {
 	count := int(math.Floor(start + 0.5))
 	lastTrig := 0.0
+	trigInput := Input{Name: "trigger"}
+	divInput := Input{Name: "div", Default: 2}
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		trig := cfg.InputSamples["trigger"]
-		div := cfg.InputSamples["div"]
+		trig := trigInput.Samples(cfg, len(out))
+		div := divInput.Samples(cfg, len(out))
 
 		_ = trig[len(out)-1]
 		_ = div[len(out)-1]
@@ -3,14 +3,19 @@ package ugen
 import "context"
 
 func NewQuotient() UGen {
+	var xs [][]float64
 	return UGenFunc(func(ctx context.Context, cfg SampleConfig, out []float64) {
-		xs := CollectIndexedInputs(cfg)
-		if len(xs) == 0 {
+		xs = AppendIndexedInputs(xs[:0], cfg)
+		if len(xs) == 0 || len(xs[0]) < len(out) {
 			return
 		}
-		for i := range out {
-			out[i] = xs[0][i]
-			for _, x := range xs[1:] {
+		copy(out, xs[0])
+		for _, x := range xs[1:] {
+			if len(x) < len(out) {
+				// a missing divisor divides by one.
+				continue
+			}
+			for i := range out {
 				out[i] /= x[i]
 			}
 		}
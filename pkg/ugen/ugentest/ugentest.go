@@ -0,0 +1,463 @@
+// Package ugentest is a conformance suite for ugens. Every ugen
+// constructor should pass it, since a ugen that panics takes down the
+// audio thread, and one that allocates or misbehaves at an unusual
+// block size or sample rate glitches it:
+//
+//	func TestConformance(t *testing.T) {
+//		ugentest.Run(t, ugentest.Spec{
+//			Name: "lpf",
+//			New:  func() ugen.UGen { return NewLPF() },
+//			Inputs: map[string]ugentest.Signal{
+//				"in":   ugentest.Sine(440),
+//				"freq": ugentest.Const(1000),
+//			},
+//		})
+//	}
+//
+// Each ugen is checked, as a subtest, for:
+//
+//   - missing inputs: Gen must not panic when any of its inputs, or
+//     all of them, aren't connected.
+//   - NaN and infinite inputs: Gen must not panic when an input is NaN
+//     or infinite, and soon after the input is finite again, so must
+//     the output be, measuring about the same as if the input had
+//     never been NaN or infinite.
+//   - block sizes: the output must not depend on the number of samples
+//     generated per call.
+//   - allocations: Gen must not allocate once it's warmed up, whether
+//     its inputs are all connected or any of them, or all of them,
+//     aren't.
+//   - sample rates: the output at rates from 22.05 kHz to 192 kHz must
+//     be finite and measure about the same as at 44.1 kHz.
+//   - start and stop: Start and Stop, if implemented, must succeed
+//     when called twice.
+package ugentest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/jfhamlin/muscrat/pkg/audiotest"
+	"github.com/jfhamlin/muscrat/pkg/conf"
+	"github.com/jfhamlin/muscrat/pkg/ugen"
+)
+
+var (
+	// SampleRates are the sample rates at which ugens are checked.
+	SampleRates = []int{22050, 44100, 48000, 96000, 192000}
+
+	// BlockSizes are the odd block sizes at which ugens are checked,
+	// in addition to conf.BufferSize.
+	BlockSizes = []int{1, 7, 61}
+)
+
+const (
+	// referenceRate is the sample rate to which output at other rates
+	// is compared.
+	referenceRate = 44100
+
+	// renderSeconds is the duration of each render.
+	renderSeconds = 1
+
+	// badInputStart is the time in seconds at which a NaN or infinite
+	// input is applied, for one block.
+	badInputStart = 0.1
+
+	// recoverySeconds is the time allowed after a NaN or infinite
+	// input for the output to become finite again.
+	recoverySeconds = 0.25
+
+	// blockTolerance is the largest difference allowed between the
+	// outputs at different block sizes.
+	blockTolerance = 1e-9
+
+	// rateTolerance is the largest difference allowed between the
+	// measures of the output at different sample rates, relative to
+	// the measure at referenceRate.
+	rateTolerance = 0.1
+
+	// minMeasure is the measure below which output is considered
+	// silent when comparing measures.
+	minMeasure = 1e-3
+)
+
+type (
+	// Spec describes a ugen to check.
+	Spec struct {
+		// Name is the name of the ugen's subtest.
+		Name string
+
+		// New returns a new instance of the ugen. It's called after
+		// conf.SampleRate is set to the rate being checked, and must
+		// return ugens that produce the same output for the same
+		// input, so stochastic ugens should be seeded.
+		New func() ugen.UGen
+
+		// Inputs holds a signal for each port the ugen reads, typical
+		// of how it's used.
+		Inputs map[string]Signal
+
+		// Measure summarizes the output for comparison across sample
+		// rates, and with the output after NaN or infinite inputs. It
+		// defaults to the RMS level, which suits most
+		// ugens, but not, for example, ones that emit single-sample
+		// impulses.
+		Measure func(*audiotest.Audio) float64
+
+		// AllowAllocs exempts the ugen from the allocation check. It's
+		// only for ugens whose purpose is to publish their input to
+		// other goroutines.
+		AllowAllocs bool
+
+		// FixedRate exempts the ugen from comparing its output across
+		// sample rates, though the output must still be finite at
+		// each. It's only for ugens that wrap code tuned for a single
+		// sample rate.
+		FixedRate bool
+	}
+
+	// Signal is an input signal, as a function of time in seconds.
+	Signal func(t float64) float64
+)
+
+// Const returns a constant signal.
+func Const(x float64) Signal {
+	return func(float64) float64 { return x }
+}
+
+// Sine returns a unit sine wave at freq Hz.
+func Sine(freq float64) Signal {
+	return func(t float64) float64 { return math.Sin(2 * math.Pi * freq * t) }
+}
+
+// Gate returns a signal that is 1 for the first half of each period
+// at freq Hz and 0 for the second, for trigger and gate inputs.
+func Gate(freq float64) Signal {
+	return func(t float64) float64 {
+		if _, frac := math.Modf(t * freq); frac < 0.5 {
+			return 1
+		}
+		return 0
+	}
+}
+
+// Count returns a measure of the number of non-zero samples per
+// second, for ugens that emit impulses.
+func Count(a *audiotest.Audio) float64 {
+	var n int
+	for _, ch := range a.Channels {
+		for _, x := range ch {
+			if x != 0 {
+				n++
+			}
+		}
+	}
+	return float64(n) / a.Duration()
+}
+
+// Run checks each ugen in a subtest named after it.
+func Run(t *testing.T, specs ...Spec) {
+	t.Helper()
+	for _, spec := range specs {
+		t.Run(spec.Name, func(t *testing.T) {
+			t.Run("missing-inputs", spec.checkMissingInputs)
+			t.Run("nonfinite-inputs", spec.checkNonfiniteInputs)
+			t.Run("block-sizes", spec.checkBlockSizes)
+			t.Run("allocs", spec.checkAllocs)
+			t.Run("sample-rates", spec.checkSampleRates)
+			t.Run("start-stop", spec.checkStartStop)
+		})
+	}
+}
+
+func (s Spec) ports() []string {
+	ports := make([]string, 0, len(s.Inputs))
+	for port := range s.Inputs {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	return ports
+}
+
+func (s Spec) checkMissingInputs(t *testing.T) {
+	for _, port := range s.ports() {
+		inputs := maps.Clone(s.Inputs)
+		delete(inputs, port)
+		if _, err := s.render(t.Context(), referenceRate, conf.BufferSize, inputs); err != nil {
+			t.Errorf("without input %q: %v", port, err)
+		}
+	}
+	if _, err := s.render(t.Context(), referenceRate, conf.BufferSize, nil); err != nil {
+		t.Errorf("without inputs: %v", err)
+	}
+}
+
+func (s Spec) checkNonfiniteInputs(t *testing.T) {
+	blockSeconds := float64(conf.BufferSize) / referenceRate
+	recovered := int((badInputStart + blockSeconds + recoverySeconds) * referenceRate)
+
+	clean, err := s.render(t.Context(), referenceRate, conf.BufferSize, s.Inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := s.measure(referenceRate, clean[recovered:])
+
+	for _, port := range s.ports() {
+		for _, bad := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			inputs := maps.Clone(s.Inputs)
+			sig := s.Inputs[port]
+			inputs[port] = func(t float64) float64 {
+				if t >= badInputStart && t < badInputStart+blockSeconds {
+					return bad
+				}
+				return sig(t)
+			}
+			out, err := s.render(t.Context(), referenceRate, conf.BufferSize, inputs)
+			if err != nil {
+				t.Errorf("with input %q of %v: %v", port, bad, err)
+				continue
+			}
+			if i := firstNonfinite(out[recovered:]); i >= 0 {
+				t.Errorf("with input %q of %v: output %v at %.3fs, %gs after the input was finite again",
+					port, bad, out[recovered+i], float64(recovered+i)/referenceRate, recoverySeconds)
+			} else if got := s.measure(referenceRate, out[recovered:]); !near(got, want, rateTolerance*math.Max(math.Abs(want), minMeasure)) {
+				t.Errorf("with input %q of %v: output measures %v %gs after the input was finite again, want about %v",
+					port, bad, got, recoverySeconds, want)
+			}
+		}
+	}
+}
+
+func (s Spec) checkBlockSizes(t *testing.T) {
+	want, err := s.render(t.Context(), referenceRate, conf.BufferSize, s.Inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range BlockSizes {
+		got, err := s.render(t.Context(), referenceRate, size, s.Inputs)
+		if err != nil {
+			t.Errorf("with blocks of %d: %v", size, err)
+			continue
+		}
+		for i := range want {
+			if !near(got[i], want[i], blockTolerance) {
+				t.Errorf("with blocks of %d: sample %d is %v, want %v as with blocks of %d",
+					size, i, got[i], want[i], conf.BufferSize)
+				break
+			}
+		}
+	}
+}
+
+func (s Spec) checkAllocs(t *testing.T) {
+	if s.AllowAllocs {
+		t.Skip("ugen allows allocations")
+	}
+	defer setSampleRate(referenceRate)()
+
+	if n := s.allocs(t, s.Inputs); n > 0 {
+		t.Errorf("got %v allocations per call to Gen, want 0", n)
+	}
+	for _, port := range s.ports() {
+		inputs := maps.Clone(s.Inputs)
+		delete(inputs, port)
+		if n := s.allocs(t, inputs); n > 0 {
+			t.Errorf("without input %q: got %v allocations per call to Gen, want 0", port, n)
+		}
+	}
+	if n := s.allocs(t, nil); n > 0 {
+		t.Errorf("without inputs: got %v allocations per call to Gen, want 0", n)
+	}
+}
+
+// allocs returns the average number of allocations per call to Gen of
+// a new, warmed up instance of the ugen with the given inputs.
+func (s Spec) allocs(t *testing.T, inputs map[string]Signal) float64 {
+	t.Helper()
+	ctx := t.Context()
+	u := s.New()
+	if st, ok := u.(ugen.Starter); ok {
+		if err := st.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st, ok := u.(ugen.Stopper); ok {
+		defer st.Stop(ctx)
+	}
+
+	// warm up for a second of blocks, so that buffers sized on the
+	// first call, or grown as the ugen runs, are allocated.
+	cfg := ugen.SampleConfig{
+		SampleRateHz: referenceRate,
+		InputSamples: make(map[string][]float64, len(inputs)),
+	}
+	out := make([]float64, conf.BufferSize)
+	var start int
+	gen := func() {
+		for port, sig := range inputs {
+			in := cfg.InputSamples[port]
+			if in == nil {
+				in = make([]float64, len(out))
+				cfg.InputSamples[port] = in
+			}
+			for i := range in {
+				in[i] = sig(float64(start+i) / referenceRate)
+			}
+		}
+		u.Gen(ctx, cfg, out)
+		start += len(out)
+	}
+	for start < referenceRate {
+		gen()
+	}
+	return testing.AllocsPerRun(100, gen)
+}
+
+func (s Spec) checkSampleRates(t *testing.T) {
+	measures := make(map[int]float64)
+	for _, rate := range append([]int{referenceRate}, SampleRates...) {
+		if _, ok := measures[rate]; ok {
+			continue
+		}
+		out, err := s.render(t.Context(), rate, conf.BufferSize, s.Inputs)
+		if err != nil {
+			t.Errorf("at %d Hz: %v", rate, err)
+			continue
+		}
+		if i := firstNonfinite(out); i >= 0 {
+			t.Errorf("at %d Hz: output %v at sample %d", rate, out[i], i)
+			continue
+		}
+		measures[rate] = s.measure(rate, out)
+	}
+
+	want, ok := measures[referenceRate]
+	if !ok || s.FixedRate {
+		return
+	}
+	for _, rate := range SampleRates {
+		got, ok := measures[rate]
+		if !ok || rate == referenceRate {
+			continue
+		}
+		if !near(got, want, rateTolerance*math.Max(math.Abs(want), minMeasure)) {
+			t.Errorf("at %d Hz: output measures %v, want about %v as at %d Hz", rate, got, want, referenceRate)
+		}
+	}
+}
+
+func (s Spec) checkStartStop(t *testing.T) {
+	defer setSampleRate(referenceRate)()
+
+	ctx := t.Context()
+	u := s.New()
+	starter, isStarter := u.(ugen.Starter)
+	stopper, isStopper := u.(ugen.Stopper)
+	if !isStarter && !isStopper {
+		t.Skip("ugen implements neither Start nor Stop")
+	}
+
+	err := catch(func() {
+		if isStarter {
+			for i := 0; i < 2; i++ {
+				if err := starter.Start(ctx); err != nil {
+					t.Errorf("start %d: %v", i+1, err)
+				}
+			}
+		}
+		u.Gen(ctx, ugen.SampleConfig{SampleRateHz: referenceRate}, make([]float64, conf.BufferSize))
+		if isStopper {
+			for i := 0; i < 2; i++ {
+				if err := stopper.Stop(ctx); err != nil {
+					t.Errorf("stop %d: %v", i+1, err)
+				}
+			}
+		}
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func (s Spec) measure(rate int, out []float64) float64 {
+	a := &audiotest.Audio{SampleRate: rate, Channels: [][]float64{out}}
+	if s.Measure == nil {
+		return a.RMS()
+	}
+	return s.Measure(a)
+}
+
+// render renders renderSeconds of a new instance of the ugen at the
+// given sample rate and block size, returning an error if it panics.
+func (s Spec) render(ctx context.Context, rate, blockSize int, inputs map[string]Signal) (out []float64, err error) {
+	defer setSampleRate(rate)()
+
+	numSamples := int(renderSeconds * float64(rate))
+	samples := make(map[string][]float64, len(inputs))
+	for port, sig := range inputs {
+		in := make([]float64, numSamples)
+		for i := range in {
+			in[i] = sig(float64(i) / float64(rate))
+		}
+		samples[port] = in
+	}
+
+	out = make([]float64, numSamples)
+	err = catch(func() {
+		u := s.New()
+		if st, ok := u.(ugen.Starter); ok {
+			if err := st.Start(ctx); err != nil {
+				panic(err)
+			}
+		}
+		if st, ok := u.(ugen.Stopper); ok {
+			defer st.Stop(ctx)
+		}
+
+		cfg := ugen.SampleConfig{
+			SampleRateHz: rate,
+			InputSamples: make(map[string][]float64, len(samples)),
+		}
+		for start := 0; start < numSamples; start += blockSize {
+			end := min(start+blockSize, numSamples)
+			for port, in := range samples {
+				cfg.InputSamples[port] = in[start:end]
+			}
+			u.Gen(ctx, cfg, out[start:end])
+		}
+	})
+	return out, err
+}
+
+// setSampleRate sets conf.SampleRate, which many ugens read when
+// they're constructed, and returns a function that restores it.
+func setSampleRate(rate int) func() {
+	prev := conf.SampleRate
+	conf.SampleRate = rate
+	return func() { conf.SampleRate = prev }
+}
+
+func catch(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	f()
+	return nil
+}
+
+func firstNonfinite(xs []float64) int {
+	return slices.IndexFunc(xs, func(x float64) bool {
+		return math.IsNaN(x) || math.IsInf(x, 0)
+	})
+}
+
+func near(a, b, tol float64) bool {
+	return a == b || math.Abs(a-b) <= tol || math.IsNaN(a) && math.IsNaN(b)
+}
@@ -0,0 +1,202 @@
+package wavtabs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+const (
+	// FrameSize is the number of samples per frame of wavetable
+	// files that don't say otherwise. Most wavetable synths use it.
+	FrameSize = 2048
+
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+)
+
+type (
+	loadKey struct {
+		filename  string
+		frameSize int
+	}
+)
+
+var (
+	loaded   = make(map[loadKey]*Stack)
+	loadedMu sync.Mutex
+
+	// clmFrameSize matches the frame size in the "clm " chunk that
+	// Serum and others write to wavetable files, e.g. "<!>2048 ...".
+	clmFrameSize = regexp.MustCompile(`^<!>(\d+)`)
+)
+
+// Load loads a stack from a WAV file. If frameSize is zero, it's
+// taken from the file's "clm " chunk if it has one; otherwise, a file
+// whose length is a multiple of FrameSize is split into frames of
+// that size, and any other file is a single frame. Channels are
+// mixed. Frames aren't resampled, whatever the sample rate of the
+// file. Stacks are cached, so loading the same file again gives the
+// same stack.
+func Load(filename string, frameSize int) (*Stack, error) {
+	key := loadKey{filename, frameSize}
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	if s, ok := loaded[key]; ok {
+		return s, nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := ReadWAV(f, frameSize)
+	if err != nil {
+		return nil, fmt.Errorf("wavtabs: %s: %w", filename, err)
+	}
+	loaded[key] = s
+	return s, nil
+}
+
+// ReadWAV reads a stack from WAV data, as Load does.
+func ReadWAV(r io.Reader, frameSize int) (*Stack, error) {
+	samples, clmSize, err := readWAV(r)
+	if err != nil {
+		return nil, err
+	}
+	if frameSize <= 0 {
+		switch {
+		case clmSize > 0:
+			frameSize = clmSize
+		case len(samples) > FrameSize && len(samples)%FrameSize == 0:
+			frameSize = FrameSize
+		default:
+			frameSize = len(samples)
+		}
+	}
+	if frameSize < 2 || len(samples) < frameSize {
+		return nil, fmt.Errorf("%d samples can't be split into frames of %d", len(samples), frameSize)
+	}
+
+	frames := make([][]float64, len(samples)/frameSize)
+	for i := range frames {
+		frames[i] = samples[i*frameSize : (i+1)*frameSize]
+	}
+	return NewStack(frames)
+}
+
+// readWAV returns the samples of WAV data, with channels mixed, and
+// the frame size given by its "clm " chunk, or zero if it has none.
+func readWAV(r io.Reader) (samples []float64, clmSize int, err error) {
+	var hdr [12]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, 0, fmt.Errorf("reading WAV header: %w", err)
+	}
+	if string(hdr[:4]) != "RIFF" || string(hdr[8:]) != "WAVE" {
+		return nil, 0, errors.New("not a WAV file")
+	}
+
+	var (
+		format, channels, bits int
+		data                   []byte
+	)
+	for data == nil {
+		var chunkHdr [8]byte
+		if _, err := io.ReadFull(r, chunkHdr[:]); err != nil {
+			return nil, 0, errors.New("no data chunk")
+		}
+		id := string(chunkHdr[:4])
+		size := binary.LittleEndian.Uint32(chunkHdr[4:])
+		// the size of a data chunk may be wrong in files that were
+		// written as a stream, so it's read to the end of the file
+		// if it's short.
+		body, err := io.ReadAll(io.LimitReader(r, int64(size)))
+		if err != nil {
+			return nil, 0, fmt.Errorf("reading %q chunk: %w", id, err)
+		}
+		if id != "data" && len(body) < int(size) {
+			return nil, 0, fmt.Errorf("short %q chunk", id)
+		}
+		if size%2 == 1 {
+			// chunks are padded to even sizes
+			io.CopyN(io.Discard, r, 1)
+		}
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, 0, errors.New("short fmt chunk")
+			}
+			format = int(binary.LittleEndian.Uint16(body[0:]))
+			channels = int(binary.LittleEndian.Uint16(body[2:]))
+			bits = int(binary.LittleEndian.Uint16(body[14:]))
+			if format == wavFormatExtensible && len(body) >= 26 {
+				// the format is the first two bytes of the
+				// subformat GUID.
+				format = int(binary.LittleEndian.Uint16(body[24:]))
+			}
+		case "clm ":
+			if m := clmFrameSize.FindSubmatch(body); m != nil {
+				clmSize, _ = strconv.Atoi(string(m[1]))
+			}
+		case "data":
+			data = body
+		}
+	}
+	if channels == 0 {
+		return nil, 0, errors.New("no fmt chunk before the data chunk")
+	}
+
+	decode, err := sampleDecoder(format, bits)
+	if err != nil {
+		return nil, 0, err
+	}
+	width := bits / 8
+	numFrames := len(data) / (width * channels)
+	samples = make([]float64, numFrames)
+	rd := bytes.NewReader(data)
+	buf := make([]byte, width)
+	for i := range samples {
+		var sum float64
+		for range channels {
+			rd.Read(buf)
+			if x := decode(buf); !math.IsNaN(x) && !math.IsInf(x, 0) {
+				sum += x
+			}
+		}
+		samples[i] = sum / float64(channels)
+	}
+	return samples, clmSize, nil
+}
+
+// sampleDecoder returns a function that decodes a little-endian
+// sample of the given format and bit depth to [-1, 1].
+func sampleDecoder(format, bits int) (func([]byte) float64, error) {
+	switch {
+	case format == wavFormatPCM && bits == 8:
+		// 8-bit samples are unsigned
+		return func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }, nil
+	case format == wavFormatPCM && bits == 16:
+		return func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }, nil
+	case format == wavFormatPCM && bits == 24:
+		return func(b []byte) float64 {
+			return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / (1 << 23)
+		}, nil
+	case format == wavFormatPCM && bits == 32:
+		return func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }, nil
+	case format == wavFormatFloat && bits == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }, nil
+	case format == wavFormatFloat && bits == 64:
+		return func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }, nil
+	}
+	return nil, fmt.Errorf("unsupported WAV format %d with %d bits per sample", format, bits)
+}
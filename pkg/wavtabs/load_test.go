@@ -0,0 +1,162 @@
+package wavtabs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// wavBytes returns a WAV file of the given format holding samples,
+// with an optional "clm " chunk.
+func wavBytes(format, bits, channels int, clm string, data []byte) []byte {
+	var chunks bytes.Buffer
+	writeChunk := func(id string, body []byte) {
+		chunks.WriteString(id)
+		binary.Write(&chunks, binary.LittleEndian, uint32(len(body)))
+		chunks.Write(body)
+		if len(body)%2 == 1 {
+			chunks.WriteByte(0)
+		}
+	}
+
+	var fmtChunk bytes.Buffer
+	for _, v := range []uint16{uint16(format), uint16(channels)} {
+		binary.Write(&fmtChunk, binary.LittleEndian, v)
+	}
+	binary.Write(&fmtChunk, binary.LittleEndian, uint32(44100))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint32(44100*channels*bits/8))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(channels*bits/8))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(bits))
+	writeChunk("fmt ", fmtChunk.Bytes())
+	if clm != "" {
+		writeChunk("clm ", []byte(clm))
+	}
+	writeChunk("data", data)
+
+	var wav bytes.Buffer
+	wav.WriteString("RIFF")
+	binary.Write(&wav, binary.LittleEndian, uint32(4+chunks.Len()))
+	wav.WriteString("WAVE")
+	wav.Write(chunks.Bytes())
+	return wav.Bytes()
+}
+
+func pcm16(samples []float64) []byte {
+	var b bytes.Buffer
+	for _, x := range samples {
+		binary.Write(&b, binary.LittleEndian, int16(x*(1<<15-1)))
+	}
+	return b.Bytes()
+}
+
+func float32s(samples []float64) []byte {
+	var b bytes.Buffer
+	for _, x := range samples {
+		binary.Write(&b, binary.LittleEndian, float32(x))
+	}
+	return b.Bytes()
+}
+
+func ramp(n int) []float64 {
+	samples := make([]float64, n)
+	for i := range samples {
+		samples[i] = float64(i%256)/256 - 0.5
+	}
+	return samples
+}
+
+func TestReadWAV(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		wav        []byte
+		frameSize  int
+		wantFrames int
+		wantRes    int
+	}{
+		{
+			name:       "single cycle",
+			wav:        wavBytes(wavFormatPCM, 16, 1, "", pcm16(ramp(600))),
+			wantFrames: 1,
+			wantRes:    600,
+		},
+		{
+			name:       "2048 per frame",
+			wav:        wavBytes(wavFormatFloat, 32, 1, "", float32s(ramp(3*FrameSize))),
+			wantFrames: 3,
+			wantRes:    FrameSize,
+		},
+		{
+			name:       "clm chunk",
+			wav:        wavBytes(wavFormatFloat, 32, 1, "<!>256 10000000 wavetable", float32s(ramp(4*256))),
+			wantFrames: 4,
+			wantRes:    256,
+		},
+		{
+			name:       "frame size",
+			wav:        wavBytes(wavFormatPCM, 16, 2, "", pcm16(ramp(2*512))),
+			frameSize:  128,
+			wantFrames: 4,
+			wantRes:    128,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ReadWAV(bytes.NewReader(tc.wav), tc.frameSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Len() != tc.wantFrames {
+				t.Fatalf("got %d frames, want %d", s.Len(), tc.wantFrames)
+			}
+			if res := s.Frame(0).Resolution(); res != tc.wantRes {
+				t.Fatalf("got %d samples per frame, want %d", res, tc.wantRes)
+			}
+		})
+	}
+}
+
+func TestReadWAVSamples(t *testing.T) {
+	want := []float64{0, 0.5, -0.5, 0.25}
+	for _, tc := range []struct {
+		name string
+		wav  []byte
+	}{
+		{"pcm16", wavBytes(wavFormatPCM, 16, 1, "", pcm16(want))},
+		{"float32", wavBytes(wavFormatFloat, 32, 1, "", float32s(want))},
+		// stereo, with the same samples in both channels
+		{"stereo", wavBytes(wavFormatFloat, 32, 2, "", float32s([]float64{0, 0, 0.5, 0.5, -0.5, -0.5, 0.25, 0.25}))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ReadWAV(bytes.NewReader(tc.wav), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Frame(0).tbl
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-4 {
+					t.Errorf("sample %d: got %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadWAVErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		wav       []byte
+		frameSize int
+	}{
+		{"empty", nil, 0},
+		{"not a WAV", []byte("RIFF\x04\x00\x00\x00AIFF"), 0},
+		{"no data", wavBytes(wavFormatPCM, 16, 1, "", nil)[:36], 0},
+		{"unsupported format", wavBytes(2, 4, 1, "", []byte{1, 2, 3, 4}), 0},
+		{"short for frame size", wavBytes(wavFormatPCM, 16, 1, "", pcm16(ramp(100))), 128},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReadWAV(bytes.NewReader(tc.wav), tc.frameSize); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package wavtabs
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+type (
+	// Stack is a sequence of single-cycle wavetables, or frames, to
+	// be scanned across. All frames have the same resolution.
+	Stack struct {
+		frames []*Table
+	}
+)
+
+// NewStack returns a stack of the given frames, each a single cycle
+// of a waveform. Frames must be non-empty and of the same length.
+func NewStack(frames [][]float64) (*Stack, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("wavtabs: no frames")
+	}
+	res := len(frames[0])
+	if res < 2 {
+		return nil, fmt.Errorf("wavtabs: frames must have at least 2 samples, got %d", res)
+	}
+	s := &Stack{frames: make([]*Table, len(frames))}
+	for i, f := range frames {
+		if len(f) != res {
+			return nil, fmt.Errorf("wavtabs: frame %d has %d samples, want %d", i, len(f), res)
+		}
+		s.frames[i] = New(f)
+	}
+	return s, nil
+}
+
+var (
+	harmonicStacks   = make(map[string]*Stack)
+	harmonicStacksMu sync.Mutex
+)
+
+// NewHarmonicStack returns a stack with a frame for each list of
+// harmonic amplitudes, the first of which is the amplitude of the
+// fundamental. Each harmonic is a sine in phase with the
+// fundamental. Stacks are memoized, so the same harmonics always
+// give the same stack.
+func NewHarmonicStack(frames [][]float64) (*Stack, error) {
+	key := fmt.Sprint(frames)
+	harmonicStacksMu.Lock()
+	defer harmonicStacksMu.Unlock()
+	if s, ok := harmonicStacks[key]; ok {
+		return s, nil
+	}
+
+	points := make([][]float64, len(frames))
+	for i, amps := range frames {
+		if len(amps) >= DefaultResolution/2 {
+			return nil, fmt.Errorf("wavtabs: frame %d has %d harmonics, want fewer than %d", i, len(amps), DefaultResolution/2)
+		}
+		pts := make([]float64, DefaultResolution)
+		for h, amp := range amps {
+			if amp == 0 {
+				continue
+			}
+			for j := range pts {
+				pts[j] += amp * math.Sin(2*math.Pi*float64((h+1)*j)/DefaultResolution)
+			}
+		}
+		points[i] = pts
+	}
+	s, err := NewStack(points)
+	if err != nil {
+		return nil, err
+	}
+	harmonicStacks[key] = s
+	return s, nil
+}
+
+// Len returns the number of frames in the stack.
+func (s *Stack) Len() int {
+	return len(s.frames)
+}
+
+// Frame returns the i-th frame.
+func (s *Stack) Frame(i int) *Table {
+	return s.frames[i]
+}
+
+// HermiteBL returns the value at phase x of the waveform at position
+// pos, in [0, 1], across the frames, band-limited for a waveform
+// played at freq cycles per second. Positions between frames
+// interpolate linearly between them.
+func (s *Stack) HermiteBL(freq, pos, x float64) float64 {
+	if len(s.frames) == 1 {
+		return s.frames[0].HermiteBL(freq, x)
+	}
+	pos = math.Max(0, math.Min(1, pos)) * float64(len(s.frames)-1)
+	i := int(pos)
+	if i == len(s.frames)-1 {
+		return s.frames[i].HermiteBL(freq, x)
+	}
+	frac := pos - float64(i)
+	v0 := s.frames[i].HermiteBL(freq, x)
+	if frac == 0 {
+		return v0
+	}
+	v1 := s.frames[i+1].HermiteBL(freq, x)
+	return v0 + frac*(v1-v0)
+}
@@ -0,0 +1,73 @@
+package wavtabs
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHarmonicStack(t *testing.T) {
+	s, err := NewHarmonicStack([][]float64{{1}, {0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 2 {
+		t.Fatalf("got %d frames, want 2", s.Len())
+	}
+	if again, _ := NewHarmonicStack([][]float64{{1}, {0, 1}}); again != s {
+		t.Error("got a new stack for the same harmonics")
+	}
+
+	const freq = 110
+	for _, x := range []float64{0, 0.1, 0.25, 0.6, 0.9} {
+		sin1, sin2 := math.Sin(2*math.Pi*x), math.Sin(4*math.Pi*x)
+		for _, tc := range []struct {
+			pos, want float64
+		}{
+			{0, sin1},
+			{1, sin2},
+			{0.25, 0.75*sin1 + 0.25*sin2},
+			{-1, sin1},
+			{2, sin2},
+		} {
+			if got := s.HermiteBL(freq, tc.pos, x); math.Abs(got-tc.want) > 1e-3 {
+				t.Errorf("at pos %v, phase %v: got %v, want %v", tc.pos, x, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestHarmonicStackBandLimited(t *testing.T) {
+	// a frame with only a 100th harmonic, which is far above the
+	// Nyquist frequency for a fundamental of 2kHz.
+	amps := make([]float64, 100)
+	amps[99] = 1
+	s, err := NewHarmonicStack([][]float64{amps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sampled at the peaks of the harmonic
+	var peakLow, peakHigh float64
+	for i := range 100 {
+		x := (float64(i) + 0.25) / 100
+		peakLow = math.Max(peakLow, math.Abs(s.HermiteBL(20, 0, x)))
+		peakHigh = math.Max(peakHigh, math.Abs(s.HermiteBL(2000, 0, x)))
+	}
+	if peakLow < 0.5 {
+		t.Errorf("got peak %v at 20Hz, want the harmonic", peakLow)
+	}
+	if peakHigh > 1e-6 {
+		t.Errorf("got peak %v at 2kHz, want the harmonic filtered out", peakHigh)
+	}
+}
+
+func TestNewStackErrors(t *testing.T) {
+	for _, frames := range [][][]float64{
+		nil,
+		{{1}},
+		{{1, 2, 3}, {1, 2}},
+	} {
+		if _, err := NewStack(frames); err == nil {
+			t.Errorf("NewStack(%v): expected an error", frames)
+		}
+	}
+}